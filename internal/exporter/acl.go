@@ -0,0 +1,157 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const aclFileName = "acl.json"
+
+// ACLReport is the access control snapshot written to acl.json
+type ACLReport struct {
+	CapturedAt time.Time `json:"captured_at"`
+	// Skipped explains why the ACL could not be read, e.g. a NOPERM error
+	// when the export user lacks ACL rights; Rules and Users are then empty
+	Skipped string `json:"skipped,omitempty"`
+	// Rules are the ACL LIST lines, which ACL SETUSER or an ACL file accept
+	Rules []string  `json:"rules,omitempty"`
+	Users []ACLUser `json:"users,omitempty"`
+}
+
+// ACLUser holds the ACL GETUSER reply for one user. Properties vary by
+// server version and include flags, password hashes, commands and keys.
+type ACLUser struct {
+	Name       string                 `json:"name"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// captureACL reads ACL LIST and ACL GETUSER for every user. Errors do not
+// fail the export; they are recorded in the report as the reason it was skipped.
+func (re *RedisExporter) captureACL() *ACLReport {
+	report := &ACLReport{CapturedAt: time.Now().UTC()}
+
+	rules, users, err := re.readACL()
+	if err != nil {
+		fmt.Printf("Warning: ACL export skipped: %v\n", err)
+		report.Skipped = err.Error()
+		return report
+	}
+
+	report.Rules = rules
+	report.Users = users
+	re.verbosity.infof("Captured ACL rules for %d users\n", len(users))
+	return report
+}
+
+func (re *RedisExporter) readACL() ([]string, []ACLUser, error) {
+	rules, err := re.client.Do(re.ctx, "ACL", "LIST").StringSlice()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list ACL users: %w", err)
+	}
+
+	// Each rule starts "user <name>"
+	names := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		fields := strings.Fields(rule)
+		if len(fields) < 2 || fields[0] != "user" {
+			return nil, nil, fmt.Errorf("unexpected ACL LIST entry: %q", rule)
+		}
+		names = append(names, fields[1])
+	}
+
+	pipe := re.client.Pipeline()
+	cmds := make([]*redis.Cmd, len(names))
+	for i, name := range names {
+		cmds[i] = pipe.Do(re.ctx, "ACL", "GETUSER", name)
+	}
+	ctx, cancel := re.batchContext(re.ctx)
+	defer cancel()
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, nil, fmt.Errorf("failed to read ACL users: %w", err)
+	}
+
+	users := make([]ACLUser, 0, len(names))
+	for i, name := range names {
+		reply, err := cmds[i].Result()
+		if err == redis.Nil {
+			// Deleted between ACL LIST and ACL GETUSER
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read ACL user %s: %w", name, err)
+		}
+		properties, err := aclProperties(reply)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read ACL user %s: %w", name, err)
+		}
+		users = append(users, ACLUser{Name: name, Properties: properties})
+	}
+	return rules, users, nil
+}
+
+// aclProperties turns an ACL GETUSER reply into a map. RESP2 replies
+// alternate names and values; RESP3 replies are maps.
+func aclProperties(reply interface{}) (map[string]interface{}, error) {
+	switch reply := reply.(type) {
+	case []interface{}:
+		properties := make(map[string]interface{}, len(reply)/2)
+		for i := 0; i+1 < len(reply); i += 2 {
+			if name, ok := reply[i].(string); ok {
+				properties[name] = aclValue(reply[i+1])
+			}
+		}
+		return properties, nil
+	case map[interface{}]interface{}:
+		properties := make(map[string]interface{}, len(reply))
+		for name, value := range reply {
+			if name, ok := name.(string); ok {
+				properties[name] = aclValue(value)
+			}
+		}
+		return properties, nil
+	default:
+		return nil, fmt.Errorf("unexpected ACL GETUSER reply type %T", reply)
+	}
+}
+
+// aclValue converts nested RESP3 maps, such as selectors, to maps JSON can encode
+func aclValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for name, v := range value {
+			converted[fmt.Sprint(name)] = aclValue(v)
+		}
+		return converted
+	case []interface{}:
+		for i, v := range value {
+			value[i] = aclValue(v)
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+// SetACL records the ACL snapshot written to acl.json on Close
+func (fm *FileManager) SetACL(report *ACLReport) {
+	fm.acl = report
+}
+
+// writeACLFile writes acl.json, readable only by its owner as it holds
+// password hashes
+func (fm *FileManager) writeACLFile() error {
+	data, err := json.MarshalIndent(fm.acl, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode ACL: %w", err)
+	}
+	if err := writeFileAtomically(filepath.Join(fm.config.OutputDir, aclFileName), append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write ACL file: %w", err)
+	}
+	return nil
+}
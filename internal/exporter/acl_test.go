@@ -0,0 +1,176 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2/server"
+)
+
+// readACLReport decodes the acl.json written to dir
+func readACLReport(t *testing.T, dir string) ACLReport {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dir, aclFileName))
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", aclFileName, err)
+	}
+	var report ACLReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func TestIncludeACL(t *testing.T) {
+	// RESP3 servers reply to ACL GETUSER with a map instead of an array
+	for _, version := range []int{2, 3} {
+		t.Run(fmt.Sprintf("RESP%d", version), func(t *testing.T) {
+			exp, mr := newTestExporter(t, RedisExporterOptions{IncludeACL: true, ChecksumManifest: true, ProtocolVersion: version})
+			defer func() {
+				_ = exp.Close()
+			}()
+			if err := mr.Set("key", "value"); err != nil {
+				t.Fatal(err)
+			}
+
+			// miniredis has no ACL command; newTestExporter already captured the
+			// ACL, so capture again once it is served
+			rules := []string{
+				"user default on nopass ~* &* +@all",
+				"user reader on #5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8 ~cache:* resetchannels -@all +get",
+			}
+			err := mr.Server().Register("ACL", func(c *server.Peer, cmd string, args []string) {
+				switch strings.ToUpper(args[0]) {
+				case "LIST":
+					c.WriteLen(len(rules))
+					for _, rule := range rules {
+						c.WriteBulk(rule)
+					}
+				case "GETUSER":
+					if c.Resp3 {
+						c.WriteMapLen(3)
+						c.WriteBulk("flags")
+						c.WriteSetLen(1)
+						c.WriteBulk("on")
+						c.WriteBulk("keys")
+						c.WriteBulk("~" + args[1] + ":*")
+						c.WriteBulk("selectors")
+						c.WriteLen(1)
+						c.WriteMapLen(1)
+						c.WriteBulk("keys")
+						c.WriteBulk("~tmp:*")
+						return
+					}
+					c.WriteLen(6)
+					c.WriteBulk("flags")
+					c.WriteLen(1)
+					c.WriteBulk("on")
+					c.WriteBulk("keys")
+					c.WriteBulk("~" + args[1] + ":*")
+					c.WriteBulk("selectors")
+					c.WriteLen(1)
+					c.WriteLen(2)
+					c.WriteBulk("keys")
+					c.WriteBulk("~tmp:*")
+				}
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			exp.fileManager.SetACL(exp.captureACL())
+
+			outputDir := exp.fileManager.config.OutputDir
+			if err := exp.ExportKeysOnly(); err != nil {
+				t.Fatalf("ExportKeysOnly failed: %v", err)
+			}
+
+			report := readACLReport(t, outputDir)
+			if report.Skipped != "" {
+				t.Fatalf("Expected ACL to be captured, skipped: %s", report.Skipped)
+			}
+			if strings.Join(report.Rules, "\n") != strings.Join(rules, "\n") {
+				t.Errorf("Expected rules %v, got %v", rules, report.Rules)
+			}
+			if len(report.Users) != 2 || report.Users[0].Name != "default" || report.Users[1].Name != "reader" {
+				t.Fatalf("Expected users default and reader, got %+v", report.Users)
+			}
+			if keys := report.Users[1].Properties["keys"]; keys != "~reader:*" {
+				t.Errorf("Expected keys property ~reader:*, got %v", keys)
+			}
+			if flags, ok := report.Users[1].Properties["flags"].([]interface{}); !ok || len(flags) != 1 || flags[0] != "on" {
+				t.Errorf("Expected flags [on], got %v", report.Users[1].Properties["flags"])
+			}
+			if selectors := fmt.Sprint(report.Users[1].Properties["selectors"]); !strings.Contains(selectors, "~tmp:*") {
+				t.Errorf("Expected a ~tmp:* selector, got %s", selectors)
+			}
+
+			// Password hashes are kept from other users, and the file is checksummed
+			info, err := os.Stat(filepath.Join(outputDir, aclFileName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if perm := info.Mode().Perm(); perm != 0600 {
+				t.Errorf("Expected acl.json mode 0600, got %o", perm)
+			}
+			manifest, err := os.ReadFile(filepath.Join(outputDir, checksumManifestFileName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(manifest), "  "+aclFileName+"\n") {
+				t.Errorf("Expected %s in the checksum manifest, got:\n%s", aclFileName, manifest)
+			}
+		})
+	}
+}
+
+func TestIncludeACLSkipped(t *testing.T) {
+	tests := []struct {
+		name     string
+		register bool
+		reason   string
+	}{
+		{"unknown command", false, "unknown command"},
+		{"no permission", true, "NOPERM"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp, mr := newTestExporter(t, RedisExporterOptions{IncludeACL: true})
+			defer func() {
+				_ = exp.Close()
+			}()
+			if err := mr.Set("key", "value"); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.register {
+				err := mr.Server().Register("ACL", func(c *server.Peer, cmd string, args []string) {
+					c.WriteError("NOPERM User export has no permissions to run the 'acl|list' command")
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				exp.fileManager.SetACL(exp.captureACL())
+			}
+
+			// The export itself is unaffected
+			outputDir := exp.fileManager.config.OutputDir
+			if err := exp.ExportKeysOnly(); err != nil {
+				t.Fatalf("ExportKeysOnly failed: %v", err)
+			}
+
+			report := readACLReport(t, outputDir)
+			if !strings.Contains(report.Skipped, tt.reason) {
+				t.Errorf("Expected skipped reason containing %q, got %q", tt.reason, report.Skipped)
+			}
+			if len(report.Rules) != 0 || len(report.Users) != 0 {
+				t.Errorf("Expected no rules or users, got %v and %v", report.Rules, report.Users)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package exporter
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PartitionScheme selects how records are laid out into partition directories
+type PartitionScheme string
+
+const (
+	// PartitionByTime nests partitions under year=/month=/day=/hour= of the export
+	PartitionByTime PartitionScheme = "time"
+	// PartitionByAge groups keys into age= buckets derived from OBJECT IDLETIME
+	PartitionByAge PartitionScheme = "age"
+	// PartitionByTemplate lays partitions out by rendering PartitionTemplate per record
+	PartitionByTemplate PartitionScheme = "template"
+)
+
+// ageUnknownBucket holds keys whose idle time could not be read, e.g. when
+// maxmemory-policy is an LFU policy and OBJECT IDLETIME is unavailable
+const ageUnknownBucket = "age=unknown"
+
+// ageBuckets are the upper bounds of each age partition, oldest last
+var ageBuckets = []struct {
+	name  string
+	limit time.Duration
+}{
+	{"age=0-1d", 24 * time.Hour},
+	{"age=1-7d", 7 * 24 * time.Hour},
+	{"age=7-30d", 30 * 24 * time.Hour},
+	{"age=30-90d", 90 * 24 * time.Hour},
+}
+
+// ageBucketOverflow holds keys idle for longer than the last bucket
+const ageBucketOverflow = "age=90d+"
+
+// ageBucket maps a key's idle time in seconds to its age partition
+func ageBucket(idleSeconds int64) string {
+	if idleSeconds < 0 {
+		return ageUnknownBucket
+	}
+
+	idle := time.Duration(idleSeconds) * time.Second
+	for _, bucket := range ageBuckets {
+		if idle < bucket.limit {
+			return bucket.name
+		}
+	}
+	return ageBucketOverflow
+}
+
+// keyIdleSeconds pipelines OBJECT IDLETIME for a batch of keys. It returns nil
+// unless partitioning by age or writing idle_seconds; keys whose idle time
+// can't be read map to -1. It must run before the keys' values are read, as
+// reads reset the idle clock.
+func (re *RedisExporter) keyIdleSeconds(keys []string) map[string]int64 {
+	if re.fileManager.config.PartitionBy != PartitionByAge && !re.fileManager.config.IncludeIdleTime {
+		return nil
+	}
+
+	idle := make(map[string]int64, len(keys))
+	if re.idleTimeUnsupported {
+		for _, key := range keys {
+			idle[key] = -1
+		}
+		return idle
+	}
+
+	pipe := re.client.Pipeline()
+	cmds := make(map[string]*redis.DurationCmd, len(keys))
+	for _, key := range keys {
+		cmds[key] = pipe.ObjectIdleTime(re.ctx, key)
+	}
+
+	// Failures are expected under LFU eviction policies and fall into the unknown bucket
+	ctx, cancel := re.batchContext(re.ctx)
+	defer cancel()
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		log.Printf("OBJECT IDLETIME pipeline error: %v", err)
+	}
+
+	for _, key := range keys {
+		duration, err := cmds[key].Result()
+		if isUnknownCommandError(err) && !re.idleTimeUnsupported {
+			re.idleTimeUnsupported = true
+			fmt.Printf("Warning: OBJECT IDLETIME is not supported by the server; remaining keys land in the %s partition\n", ageUnknownBucket)
+		}
+		if err != nil {
+			idle[key] = -1
+			continue
+		}
+		idle[key] = int64(duration.Seconds())
+	}
+
+	return idle
+}
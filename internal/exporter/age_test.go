@@ -0,0 +1,124 @@
+package exporter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAgeBucket(t *testing.T) {
+	tests := []struct {
+		idleSeconds int64
+		expected    string
+	}{
+		{-1, "age=unknown"},
+		{0, "age=0-1d"},
+		{86399, "age=0-1d"},
+		{86400, "age=1-7d"},
+		{8 * 86400, "age=7-30d"},
+		{45 * 86400, "age=30-90d"},
+		{365 * 86400, "age=90d+"},
+	}
+
+	for _, tt := range tests {
+		if got := ageBucket(tt.idleSeconds); got != tt.expected {
+			t.Errorf("ageBucket(%d) = %s, expected %s", tt.idleSeconds, got, tt.expected)
+		}
+	}
+}
+
+func TestPartitionByAge(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{PartitionBy: "age"})
+
+	start := time.Now()
+	mr.SetTime(start)
+	mr.Set("cold:1", "value")
+	mr.Set("cold:2", "value")
+
+	mr.SetTime(start.Add(10 * 24 * time.Hour))
+	mr.Set("hot:1", "value")
+
+	outputDir := exp.fileManager.config.OutputDir
+	if err := exp.ExportKeysOnly(); err != nil {
+		t.Fatalf("ExportKeysOnly failed: %v", err)
+	}
+
+	buckets := make(map[string]int)
+	for _, file := range findDataFiles(t, outputDir, ".csv") {
+		rel, err := filepath.Rel(outputDir, filepath.Dir(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buckets[rel] += countCSVRecords(t, file)
+	}
+
+	expected := map[string]int{"age=0-1d": 1, "age=7-30d": 2}
+	if len(buckets) != len(expected) {
+		names := make([]string, 0, len(buckets))
+		for name := range buckets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		t.Fatalf("Expected buckets %v, got %v", expected, names)
+	}
+	for bucket, count := range expected {
+		if buckets[bucket] != count {
+			t.Errorf("Expected %d records in %s, got %d", count, bucket, buckets[bucket])
+		}
+	}
+}
+
+// countCSVRecords returns the number of data rows in a CSV file
+func countCSVRecords(t *testing.T, path string) int {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := 0
+	for _, b := range data {
+		if b == '\n' {
+			lines++
+		}
+	}
+	return lines - 1
+}
+
+func TestIncludeIdleTime(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{IncludeIdleTime: true})
+
+	start := time.Now()
+	mr.SetTime(start)
+	mr.Set("cold", "value")
+	mr.SetTime(start.Add(100 * 24 * time.Hour))
+	mr.Set("hot", "value")
+
+	if err := exp.ExportKeysOnly(); err != nil {
+		t.Fatalf("ExportKeysOnly failed: %v", err)
+	}
+
+	rows := readCSVRows(t, exp.fileManager.config.OutputDir)
+	if len(rows) == 0 || rows[0][3] != "idle_seconds" {
+		t.Fatalf("Expected idle_seconds after value, got headers %v", rows)
+	}
+	idle := make(map[string]string)
+	for _, row := range rows[1:] {
+		idle[row[0]] = row[3]
+	}
+	if want := fmt.Sprint(100 * 24 * 60 * 60); idle["cold"] != want || idle["hot"] != "0" {
+		t.Errorf("Expected cold=%s hot=0, got %v", want, idle)
+	}
+}
+
+func TestIdleTimeRequiresKeysOnly(t *testing.T) {
+	exp, _ := newTestExporter(t, RedisExporterOptions{IncludeIdleTime: true})
+	if err := exp.ExportByPattern("*"); err == nil || !strings.Contains(err.Error(), "INCLUDE_IDLE_TIME") {
+		t.Errorf("Expected a full export with INCLUDE_IDLE_TIME to fail, got %v", err)
+	}
+}
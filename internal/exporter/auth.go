@@ -0,0 +1,144 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9/auth"
+)
+
+const (
+	// authFetchTimeout bounds a single credentials request to the provider
+	authFetchTimeout = 30 * time.Second
+	// authRetryInterval is the wait before retrying a failed refresh
+	authRetryInterval = 10 * time.Second
+	// minAuthRefresh keeps very short-lived credentials from spinning
+	minAuthRefresh = time.Second
+)
+
+// AuthProvider supplies credentials that expire, such as Entra ID tokens for
+// Azure Cache for Redis. Credentials returns the user, the password or token,
+// and when it stops being valid; a zero expiry never refreshes.
+type AuthProvider interface {
+	Credentials(ctx context.Context) (username, password string, expiresAt time.Time, err error)
+}
+
+// rotatingCredentials adapts an AuthProvider to go-redis streaming
+// credentials. Every connection subscribes; one timer fetches new credentials
+// at three quarters of their lifetime and re-authenticates all of them.
+type rotatingCredentials struct {
+	provider AuthProvider
+
+	mu        sync.Mutex
+	current   auth.Credentials
+	listeners map[int]auth.CredentialsListener
+	nextID    int
+	timer     *time.Timer
+	stopped   bool
+}
+
+func newRotatingCredentials(provider AuthProvider) *rotatingCredentials {
+	return &rotatingCredentials{
+		provider:  provider,
+		listeners: make(map[int]auth.CredentialsListener),
+	}
+}
+
+// Subscribe returns the current credentials, fetching the first ones, and
+// registers listener for the rotations that follow
+func (r *rotatingCredentials) Subscribe(listener auth.CredentialsListener) (auth.Credentials, auth.UnsubscribeFunc, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.current == nil {
+		creds, expiresAt, err := r.fetch()
+		if err != nil {
+			return nil, nil, err
+		}
+		r.current = creds
+		r.schedule(expiresAt)
+	}
+
+	id := r.nextID
+	r.nextID++
+	r.listeners[id] = listener
+	unsubscribe := func() error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.listeners, id)
+		return nil
+	}
+	return r.current, unsubscribe, nil
+}
+
+// fetch asks the provider for credentials
+func (r *rotatingCredentials) fetch() (auth.Credentials, time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), authFetchTimeout)
+	defer cancel()
+
+	username, password, expiresAt, err := r.provider.Credentials(ctx)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to get Redis credentials: %w", err)
+	}
+	return auth.NewBasicCredentials(username, password), expiresAt, nil
+}
+
+// schedule arms the refresh for credentials expiring at expiresAt. The
+// caller holds mu.
+func (r *rotatingCredentials) schedule(expiresAt time.Time) {
+	if expiresAt.IsZero() || r.stopped {
+		return
+	}
+	wait := time.Until(expiresAt) * 3 / 4
+	if wait < minAuthRefresh {
+		wait = minAuthRefresh
+	}
+	r.timer = time.AfterFunc(wait, r.refresh)
+}
+
+// refresh fetches new credentials and hands them to every connection, which
+// re-authenticates with AUTH. A failure is retried while the old
+// credentials, which live connections keep using, are still valid.
+func (r *rotatingCredentials) refresh() {
+	creds, expiresAt, err := r.fetch()
+
+	r.mu.Lock()
+	if r.stopped {
+		r.mu.Unlock()
+		return
+	}
+	if err != nil {
+		log.Printf("Error refreshing Redis credentials, retrying in %s: %v", authRetryInterval, err)
+		r.timer = time.AfterFunc(authRetryInterval, r.refresh)
+		r.mu.Unlock()
+		return
+	}
+	r.current = creds
+	r.schedule(expiresAt)
+	listeners := make([]auth.CredentialsListener, 0, len(r.listeners))
+	for _, listener := range r.listeners {
+		listeners = append(listeners, listener)
+	}
+	r.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener.OnNext(creds)
+	}
+}
+
+// stop cancels further refreshes
+func (r *rotatingCredentials) stop() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stopped = true
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+}
@@ -0,0 +1,145 @@
+package exporter
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9/auth"
+)
+
+// sequenceAuth returns password1, password2, ... with a short lifetime
+type sequenceAuth struct {
+	mu       sync.Mutex
+	calls    int
+	lifetime time.Duration
+}
+
+func (s *sequenceAuth) Credentials(ctx context.Context) (string, string, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	return "exporter", fmt.Sprintf("password%d", s.calls), time.Now().Add(s.lifetime), nil
+}
+
+// credentialsRecorder is a connection's listener for rotated credentials
+type credentialsRecorder struct {
+	next chan auth.Credentials
+}
+
+func (c *credentialsRecorder) OnNext(credentials auth.Credentials) { c.next <- credentials }
+func (c *credentialsRecorder) OnError(err error)                   {}
+
+func TestRotatingCredentials(t *testing.T) {
+	provider := &sequenceAuth{lifetime: time.Second}
+	rotating := newRotatingCredentials(provider)
+	defer rotating.stop()
+
+	first := &credentialsRecorder{next: make(chan auth.Credentials, 1)}
+	creds, _, err := rotating.Subscribe(first)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if user, password := creds.BasicAuth(); user != "exporter" || password != "password1" {
+		t.Errorf("Expected exporter/password1, got %s/%s", user, password)
+	}
+
+	// Later connections share the credentials instead of fetching their own
+	second := &credentialsRecorder{next: make(chan auth.Credentials, 1)}
+	if creds, _, _ := rotating.Subscribe(second); creds.RawCredentials() != "exporter:password1" {
+		t.Errorf("Expected the second connection to reuse password1, got %s", creds.RawCredentials())
+	}
+
+	for _, listener := range []*credentialsRecorder{first, second} {
+		select {
+		case next := <-listener.next:
+			if _, password := next.BasicAuth(); password != "password2" {
+				t.Errorf("Expected rotation to password2, got %s", password)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected credentials to rotate before they expired")
+		}
+	}
+}
+
+func TestRotatingCredentialsStop(t *testing.T) {
+	provider := &sequenceAuth{lifetime: time.Second}
+	rotating := newRotatingCredentials(provider)
+
+	listener := &credentialsRecorder{next: make(chan auth.Credentials, 1)}
+	if _, _, err := rotating.Subscribe(listener); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	rotating.stop()
+
+	select {
+	case <-listener.next:
+		t.Error("Expected no rotation after stop")
+	case <-time.After(2 * time.Second):
+	}
+
+	// A nil provider, as when no AuthProvider is set, stops without panicking
+	var none *rotatingCredentials
+	none.stop()
+}
+
+func TestAuthProviderExport(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.RequireUserAuth("exporter", "password1")
+	mr.Set("greeting", "hello")
+
+	provider := &sequenceAuth{lifetime: time.Second}
+	exp, err := NewRedisExporter(RedisExporterOptions{
+		RedisURL:     "redis://" + mr.Addr() + "/0",
+		OutputDir:    t.TempDir(),
+		OutputFormat: "csv",
+		AuthProvider: provider,
+	})
+	if err != nil {
+		t.Fatalf("NewRedisExporter failed: %v", err)
+	}
+
+	// Revoke the first password once the second is in use
+	mr.RequireUserAuth("exporter", "password2")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		provider.mu.Lock()
+		calls := provider.calls
+		provider.mu.Unlock()
+		if calls >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the credentials to rotate")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("Expected the export to re-authenticate, got %v", err)
+	}
+}
+
+func TestTokenObjectID(t *testing.T) {
+	jwt := func(payload string) string {
+		return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+	}
+
+	oid, err := tokenObjectID(jwt(`{"aud":"https://redis.azure.com","oid":"5a6b7c8d"}`))
+	if err != nil {
+		t.Fatalf("tokenObjectID failed: %v", err)
+	}
+	if oid != "5a6b7c8d" {
+		t.Errorf("Expected oid 5a6b7c8d, got %s", oid)
+	}
+
+	for _, token := range []string{"not-a-jwt", jwt(`{"aud":"https://redis.azure.com"}`), jwt(`not json`)} {
+		if _, err := tokenObjectID(token); err == nil {
+			t.Errorf("Expected %q to be rejected", token)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package exporter
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// avroMagic starts every Avro object container file
+var avroMagic = []byte{'O', 'b', 'j', 1}
+
+// avroEncoder appends values in Avro binary encoding
+type avroEncoder struct {
+	buf bytes.Buffer
+}
+
+// writeLong writes a zig-zag varint, which Avro uses for both int and long
+func (e *avroEncoder) writeLong(v int64) {
+	u := uint64((v << 1) ^ (v >> 63))
+	for u >= 0x80 {
+		e.buf.WriteByte(byte(u) | 0x80)
+		u >>= 7
+	}
+	e.buf.WriteByte(byte(u))
+}
+
+func (e *avroEncoder) writeString(s string) {
+	e.writeLong(int64(len(s)))
+	e.buf.WriteString(s)
+}
+
+// writeOptionalLong writes a ["null", "long"] union holding v
+func (e *avroEncoder) writeOptionalLong(v int64) {
+	e.writeLong(1)
+	e.writeLong(v)
+}
+
+// writeAvroFile writes records, each already encoded against schema, as an
+// uncompressed Avro object container file with a single data block
+func writeAvroFile(path, schema string, meta map[string]string, records [][]byte) (int64, error) {
+	var header avroEncoder
+	header.buf.Write(avroMagic)
+
+	// File metadata is a map<bytes>; the schema and codec are mandatory
+	header.writeLong(int64(len(meta) + 2))
+	header.writeString("avro.schema")
+	header.writeString(schema)
+	header.writeString("avro.codec")
+	header.writeString("null")
+	keys := make([]string, 0, len(meta))
+	for key := range meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		header.writeString(key)
+		header.writeString(meta[key])
+	}
+	header.writeLong(0)
+
+	sync := make([]byte, 16)
+	if _, err := rand.Read(sync); err != nil {
+		return 0, fmt.Errorf("failed to generate Avro sync marker: %w", err)
+	}
+	header.buf.Write(sync)
+
+	var block bytes.Buffer
+	for _, record := range records {
+		block.Write(record)
+	}
+	header.writeLong(int64(len(records)))
+	header.writeLong(int64(block.Len()))
+	header.buf.Write(block.Bytes())
+	header.buf.Write(sync)
+
+	if err := os.WriteFile(path, header.buf.Bytes(), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write Avro file: %w", err)
+	}
+	return int64(header.buf.Len()), nil
+}
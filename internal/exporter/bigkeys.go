@@ -0,0 +1,352 @@
+package exporter
+
+import (
+	"container/heap"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// BigKeysByMemory ranks keys by MEMORY USAGE
+	BigKeysByMemory = "memory"
+	// BigKeysByCardinality ranks collections by element count and strings by STRLEN
+	BigKeysByCardinality = "cardinality"
+
+	defaultBigKeysTop = 10
+)
+
+// bigKey is a key measured for the bigkeys report
+type bigKey struct {
+	key     string
+	keyType string
+	// size is the ranking metric, bytes or elements
+	size int64
+	// bytes is the MEMORY USAGE, -1 when not read
+	bytes int64
+	// elements is the cardinality, or STRLEN for strings, -1 when not read
+	elements int64
+}
+
+// bigKeyHeap is a min-heap of keys by size, so the smallest of the current
+// top N is the one replaced
+type bigKeyHeap []bigKey
+
+func (h bigKeyHeap) Len() int { return len(h) }
+func (h bigKeyHeap) Less(i, j int) bool {
+	if h[i].size != h[j].size {
+		return h[i].size < h[j].size
+	}
+	return h[i].key > h[j].key
+}
+func (h bigKeyHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *bigKeyHeap) Push(x interface{}) { *h = append(*h, x.(bigKey)) }
+func (h *bigKeyHeap) Pop() interface{} {
+	old := *h
+	k := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return k
+}
+
+// bigKeyRanking keeps the top N keys of each type
+type bigKeyRanking struct {
+	top   int
+	types map[string]*bigKeyHeap
+}
+
+func newBigKeyRanking(top int) *bigKeyRanking {
+	if top <= 0 {
+		top = defaultBigKeysTop
+	}
+	return &bigKeyRanking{top: top, types: make(map[string]*bigKeyHeap)}
+}
+
+// Add ranks a key against the others of its type
+func (r *bigKeyRanking) Add(k bigKey) {
+	h, ok := r.types[k.keyType]
+	if !ok {
+		h = &bigKeyHeap{}
+		r.types[k.keyType] = h
+	}
+
+	if h.Len() < r.top {
+		heap.Push(h, k)
+		return
+	}
+	if smallest := (*h)[0]; k.size > smallest.size || (k.size == smallest.size && k.key < smallest.key) {
+		(*h)[0] = k
+		heap.Fix(h, 0)
+	}
+}
+
+// Ranked returns the top keys of each type, largest first
+func (r *bigKeyRanking) Ranked() map[string][]bigKey {
+	ranked := make(map[string][]bigKey, len(r.types))
+	for keyType, h := range r.types {
+		keys := append([]bigKey(nil), *h...)
+		sort.Slice(keys, func(i, j int) bool { return bigKeyHeap(keys).Less(j, i) })
+		ranked[keyType] = keys
+	}
+	return ranked
+}
+
+// ExportBigKeys scans the keyspace and writes the largest keys of each type,
+// ranked, as records whose value holds rank, bytes and elements
+func (re *RedisExporter) ExportBigKeys(pattern string) (err error) {
+	defer re.closeExport(&err)
+
+	if err := re.requireLiveServer("bigkeys"); err != nil {
+		return err
+	}
+	if err := re.requireSingleSource("bigkeys"); err != nil {
+		return err
+	}
+	if err := re.requireValueColumn(); err != nil {
+		return err
+	}
+
+	ranking := newBigKeyRanking(re.bigKeysTop)
+	count := 0
+
+	re.verbosity.infof("Finding the %d biggest keys of each type by %s with pattern: %s\n", ranking.top, re.bigKeysBy, pattern)
+
+	err = re.scanBatches(pattern, func(keys []string) error {
+		for _, k := range re.measureBigKeys(keys) {
+			ranking.Add(k)
+		}
+
+		if (count+len(keys))/re.flushInterval > count/re.flushInterval {
+			re.verbosity.infof("Analyzed %d keys...\n", count+len(keys))
+		}
+		count += len(keys)
+		return nil
+	})
+	if err != nil {
+		re.fileManager.SetMetadata(pattern, int64(count))
+		re.fileManager.MarkPartial(err)
+		return err
+	}
+
+	ranked := ranking.Ranked()
+	re.fillBigKeyElements(ranked)
+	written, err := re.writeBigKeys(ranked)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Big keys analysis completed! Total keys analyzed: %d, ranked: %d\n", count, written)
+	return nil
+}
+
+// measureBigKeys pipelines TYPE with MEMORY USAGE, or with the element count
+// when ranking by cardinality or the server lacks MEMORY USAGE. Keys deleted
+// since SCAN and failed lookups are left out.
+func (re *RedisExporter) measureBigKeys(keys []string) []bigKey {
+	types := re.bigKeyTypes(keys)
+	if len(types) == 0 {
+		return nil
+	}
+
+	if re.bigKeysBy == BigKeysByCardinality || re.memoryUsageUnsupported {
+		elements := re.keyElements(types)
+		measured := make([]bigKey, 0, len(elements))
+		for key, n := range elements {
+			measured = append(measured, bigKey{key: key, keyType: types[key], size: n, bytes: -1, elements: n})
+		}
+		return measured
+	}
+
+	pipe := re.client.Pipeline()
+	usages := make(map[string]*redis.IntCmd, len(types))
+	for key := range types {
+		usages[key] = pipe.MemoryUsage(re.ctx, key)
+	}
+	ctx, cancel := re.batchContext(re.ctx)
+	defer cancel()
+	// Per-command errors are checked below
+	_, _ = pipe.Exec(ctx)
+
+	measured := make([]bigKey, 0, len(types))
+	for key, cmd := range usages {
+		bytes, err := cmd.Result()
+		if isUnknownCommandError(err) {
+			re.memoryUsageUnsupported = true
+			fmt.Printf("Warning: MEMORY USAGE is not supported by the server; ranking keys by cardinality instead\n")
+			return re.measureBigKeys(keys)
+		}
+		if err != nil {
+			if err != redis.Nil {
+				log.Printf("Error getting memory usage for key %s: %v", key, err)
+			}
+			continue
+		}
+		measured = append(measured, bigKey{key: key, keyType: types[key], size: bytes, bytes: bytes, elements: -1})
+	}
+	return measured
+}
+
+// bigKeyTypes resolves the types of a batch of keys through the types already
+// known and a pipelined TYPE for the rest
+func (re *RedisExporter) bigKeyTypes(keys []string) map[string]string {
+	types := make(map[string]string, len(keys))
+	if re.assumeType != "" {
+		for _, key := range keys {
+			types[key] = re.assumeType
+		}
+		return types
+	}
+
+	pipe := re.client.Pipeline()
+	cmds := make(map[string]*redis.StatusCmd, len(keys))
+	for _, key := range keys {
+		if keyType, ok := re.knownType(key); ok {
+			types[key] = keyType
+		} else {
+			cmds[key] = pipe.Type(re.ctx, key)
+		}
+	}
+	if len(cmds) == 0 {
+		return types
+	}
+
+	ctx, cancel := re.batchContext(re.ctx)
+	defer cancel()
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Pipeline error: %v", err)
+		return types
+	}
+
+	for key, cmd := range cmds {
+		keyType, err := cmd.Result()
+		if err != nil {
+			log.Printf("Error getting type for key %s: %v", key, err)
+			continue
+		}
+		// Deleted since SCAN
+		if keyType == "none" {
+			continue
+		}
+		re.cacheType(key, keyType)
+		types[key] = keyType
+	}
+	return types
+}
+
+// keyElements pipelines the element count of collections and STRLEN of
+// strings. Keys of other types, e.g. module types, count as 0.
+func (re *RedisExporter) keyElements(types map[string]string) map[string]int64 {
+	pipe := re.client.Pipeline()
+	cmds := make(map[string]*redis.IntCmd, len(types))
+	for key, keyType := range types {
+		if keyType == "string" {
+			cmds[key] = pipe.StrLen(re.ctx, key)
+		} else if cmd := re.cardinalityCmd(pipe, key, keyType); cmd != nil {
+			cmds[key] = cmd
+		}
+	}
+
+	elements := make(map[string]int64, len(types))
+	for key := range types {
+		elements[key] = 0
+	}
+	if len(cmds) == 0 {
+		return elements
+	}
+
+	ctx, cancel := re.batchContext(re.ctx)
+	defer cancel()
+	// Per-command errors, e.g. a key recreated with another type, are checked below
+	_, _ = pipe.Exec(ctx)
+
+	for key, cmd := range cmds {
+		n, err := cmd.Result()
+		if err != nil {
+			log.Printf("Error getting element count for key %s: %v", key, err)
+			delete(elements, key)
+			continue
+		}
+		elements[key] = n
+	}
+	return elements
+}
+
+// fillBigKeyElements reads the element counts of keys ranked by memory, so
+// the report shows both
+func (re *RedisExporter) fillBigKeyElements(ranked map[string][]bigKey) {
+	types := make(map[string]string)
+	for keyType, keys := range ranked {
+		for _, k := range keys {
+			if k.elements < 0 {
+				types[k.key] = keyType
+			}
+		}
+	}
+	if len(types) == 0 {
+		return
+	}
+
+	elements := re.keyElements(types)
+	for _, keys := range ranked {
+		for i := range keys {
+			if n, ok := elements[keys[i].key]; ok && keys[i].elements < 0 {
+				keys[i].elements = n
+			}
+		}
+	}
+}
+
+// writeBigKeys writes one record per ranked key, types in name order, and
+// prints the biggest key of each type
+func (re *RedisExporter) writeBigKeys(ranked map[string][]bigKey) (int, error) {
+	keyTypes := make([]string, 0, len(ranked))
+	for keyType := range ranked {
+		keyTypes = append(keyTypes, keyType)
+	}
+	sort.Strings(keyTypes)
+
+	written := 0
+	timestamp := re.exportedAt()
+	for _, keyType := range keyTypes {
+		for i, k := range ranked[keyType] {
+			record := &RedisRecord{
+				Key:   k.key,
+				Type:  keyType,
+				Value: formatBigKey(i+1, k),
+
+				TTLSeconds: ttlNoExpiry,
+				ExportedAt: timestamp,
+				Slot:       keySlot(k.key),
+
+				IdleSeconds: -1,
+				Cardinality: noCardinality,
+			}
+			if err := re.fileManager.WriteRecord(record); err != nil {
+				return written, fmt.Errorf("failed to write big key %s: %w", k.key, err)
+			}
+			written++
+		}
+
+		biggest := ranked[keyType][0]
+		if biggest.bytes >= 0 {
+			fmt.Printf("Biggest %s: %q with %d bytes\n", keyType, biggest.key, biggest.bytes)
+		} else {
+			fmt.Printf("Biggest %s: %q with %d elements\n", keyType, biggest.key, biggest.elements)
+		}
+	}
+	return written, nil
+}
+
+// formatBigKey renders "rank=N,bytes=B,elements=E", leaving out what was not read
+func formatBigKey(rank int, k bigKey) string {
+	parts := []string{fmt.Sprintf("rank=%d", rank)}
+	if k.bytes >= 0 {
+		parts = append(parts, fmt.Sprintf("bytes=%d", k.bytes))
+	}
+	if k.elements >= 0 {
+		parts = append(parts, fmt.Sprintf("elements=%d", k.elements))
+	}
+	return strings.Join(parts, ",")
+}
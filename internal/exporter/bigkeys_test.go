@@ -0,0 +1,91 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestBigKeyRanking(t *testing.T) {
+	ranking := newBigKeyRanking(2)
+	for i, size := range []int64{5, 1, 9, 3, 9} {
+		ranking.Add(bigKey{key: fmt.Sprintf("k%d", i), keyType: "set", size: size})
+	}
+	ranking.Add(bigKey{key: "only", keyType: "hash", size: 1})
+
+	ranked := ranking.Ranked()
+	var got []string
+	for _, k := range ranked["set"] {
+		got = append(got, k.key)
+	}
+	// Ties rank by key name
+	if strings.Join(got, ",") != "k2,k4" {
+		t.Errorf("Expected k2,k4, got %v", got)
+	}
+	if len(ranked["hash"]) != 1 {
+		t.Errorf("Expected 1 hash, got %d", len(ranked["hash"]))
+	}
+}
+
+func TestExportBigKeysByCardinality(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{BigKeysTop: 2, BigKeysBy: BigKeysByCardinality})
+	for i := 1; i <= 5; i++ {
+		for j := 0; j < i; j++ {
+			mr.SAdd(fmt.Sprintf("set:%d", i), fmt.Sprint(j))
+		}
+	}
+	mr.Set("short", "ab")
+	mr.Set("long", "abcdef")
+
+	if err := exp.ExportBigKeys("*"); err != nil {
+		t.Fatalf("ExportBigKeys failed: %v", err)
+	}
+
+	records := make(map[string]string)
+	for _, row := range readCSVRows(t, exp.fileManager.config.OutputDir)[1:] {
+		records[row[0]+" "+row[1]] = row[2]
+	}
+	expected := map[string]string{
+		"set:5 set":    "rank=1,elements=5",
+		"set:4 set":    "rank=2,elements=4",
+		"long string":  "rank=1,elements=6",
+		"short string": "rank=2,elements=2",
+	}
+	if len(records) != len(expected) {
+		t.Errorf("Expected %d records, got %v", len(expected), records)
+	}
+	for name, value := range expected {
+		if records[name] != value {
+			t.Errorf("Expected %s = %q, got %q", name, value, records[name])
+		}
+	}
+}
+
+func TestExportBigKeysByMemory(t *testing.T) {
+	exp, _ := newTestExporter(t, RedisExporterOptions{BigKeysTop: 1})
+	seedCollections(t, exp)
+
+	if err := exp.ExportBigKeys("*"); err != nil {
+		t.Fatalf("ExportBigKeys failed: %v", err)
+	}
+
+	rows := readCSVRows(t, exp.fileManager.config.OutputDir)[1:]
+	if len(rows) != 6 {
+		t.Fatalf("Expected one key of each of 6 types, got %d", len(rows))
+	}
+	for _, row := range rows {
+		if !strings.HasPrefix(row[2], "rank=1,bytes=") || !strings.Contains(row[2], ",elements=") {
+			t.Errorf("Expected rank, bytes and elements for %s, got %q", row[0], row[2])
+		}
+	}
+}
+
+func TestBigKeysByValidation(t *testing.T) {
+	mr := miniredis.RunT(t)
+	_, err := NewRedisExporter(RedisExporterOptions{RedisURL: "redis://" + mr.Addr(), OutputDir: t.TempDir(), BigKeysBy: "size"})
+	if err == nil || !strings.Contains(err.Error(), "BIGKEYS_BY") {
+		t.Errorf("Expected an unsupported BIGKEYS_BY to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package exporter
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/bits"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// isBitmapKey reports whether a string key should also be exported as a bitmap
+func (re *RedisExporter) isBitmapKey(key string) bool {
+	return re.bitmapKeys != "" && matchGlob(re.bitmapKeys, key)
+}
+
+// exportBitmap writes a "bitmap" record with the population count, the first
+// set and clear bit positions and any configured GETBIT samples, then the
+// content of val in chunks when enabled
+func (re *RedisExporter) exportBitmap(key, val string, slot int, idleSeconds int64, timestamp string) error {
+	pipe := re.client.Pipeline()
+	bitCount := pipe.BitCount(re.ctx, key, nil)
+	firstSet := pipe.BitPos(re.ctx, key, 1)
+	firstClear := pipe.BitPos(re.ctx, key, 0)
+
+	samples := make([]*redis.IntCmd, len(re.bitmapSampleOffsets))
+	for i, offset := range re.bitmapSampleOffsets {
+		samples[i] = pipe.GetBit(re.ctx, key, offset)
+	}
+
+	if _, err := pipe.Exec(re.ctx); err != nil {
+		return fmt.Errorf("failed to read bitmap %s: %w", key, err)
+	}
+
+	var value strings.Builder
+	fmt.Fprintf(&value, "bitcount=%d,first_set=%d,first_clear=%d",
+		bitCount.Val(), firstSet.Val(), firstClear.Val())
+	for i, offset := range re.bitmapSampleOffsets {
+		fmt.Fprintf(&value, ",bit_%d=%d", offset, samples[i].Val())
+	}
+
+	record := &RedisRecord{
+		Key:        key,
+		Type:       "bitmap",
+		Value:      value.String(),
+		TTLSeconds: ttlNoExpiry,
+		ExportedAt: timestamp,
+		Slot:       slot,
+
+		IdleSeconds: idleSeconds,
+	}
+	if err := re.fileManager.WriteRecord(record); err != nil {
+		return err
+	}
+
+	if re.bitmapChunkBytes > 0 {
+		return re.exportBitmapChunks(key, val, slot, idleSeconds, timestamp)
+	}
+	return nil
+}
+
+// exportBitmapChunks writes a "bitmap_chunk" record per bitmapChunkBytes of
+// val with at least one bit set: the offset of its first bit, its population
+// count and its bytes in hex. The value was already read with GET, so the
+// chunks cost no further round trips.
+func (re *RedisExporter) exportBitmapChunks(key, val string, slot int, idleSeconds int64, timestamp string) error {
+	for start := 0; start < len(val); start += re.bitmapChunkBytes {
+		chunk := val[start:min(start+re.bitmapChunkBytes, len(val))]
+
+		count := 0
+		for i := 0; i < len(chunk); i++ {
+			count += bits.OnesCount8(chunk[i])
+		}
+		// Sparse presence bitmaps are mostly zeros
+		if count == 0 {
+			continue
+		}
+
+		record := &RedisRecord{
+			Key:        fmt.Sprintf("%s:chunk:%d", key, start/re.bitmapChunkBytes),
+			Type:       "bitmap_chunk",
+			Value:      fmt.Sprintf("offset=%d,bitcount=%d,data=%s", start*8, count, hex.EncodeToString([]byte(chunk))),
+			TTLSeconds: ttlNoExpiry,
+			ExportedAt: timestamp,
+			Slot:       slot,
+
+			IdleSeconds: idleSeconds,
+			ParentKey:   key,
+		}
+		if err := re.fileManager.WriteRecord(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
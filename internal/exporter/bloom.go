@@ -0,0 +1,47 @@
+package exporter
+
+import "fmt"
+
+// TYPE replies of RedisBloom filter keys
+const (
+	bloomFilterType  = "MBbloom--"
+	cuckooFilterType = "MBbloomCF"
+)
+
+// exportFilterInfo writes a bloom_info or cuckoo_info record with the
+// BF.INFO or CF.INFO statistics of a RedisBloom filter key; the filter bits
+// themselves are not exported. It returns the filter's size in bytes.
+func (re *RedisExporter) exportFilterInfo(key, keyType string, slot int, idleSeconds int64, timestamp string) (int64, error) {
+	record := &RedisRecord{
+		Key:        key,
+		TTLSeconds: ttlNoExpiry,
+		ExportedAt: timestamp,
+		Slot:       slot,
+
+		IdleSeconds: idleSeconds,
+	}
+
+	var size int64
+	if keyType == bloomFilterType {
+		info, err := re.client.BFInfo(re.ctx, key).Result()
+		if err != nil {
+			return 0, fmt.Errorf("failed to read bloom filter info: %w", err)
+		}
+		record.Type = "bloom_info"
+		record.Value = fmt.Sprintf("capacity=%d,size=%d,filters=%d,items=%d,expansion_rate=%d",
+			info.Capacity, info.Size, info.Filters, info.ItemsInserted, info.ExpansionRate)
+		size = info.Size
+	} else {
+		info, err := re.client.CFInfo(re.ctx, key).Result()
+		if err != nil {
+			return 0, fmt.Errorf("failed to read cuckoo filter info: %w", err)
+		}
+		record.Type = "cuckoo_info"
+		record.Value = fmt.Sprintf("size=%d,buckets=%d,filters=%d,items=%d,deleted=%d,bucket_size=%d,expansion_rate=%d,max_iterations=%d",
+			info.Size, info.NumBuckets, info.NumFilters, info.NumItemsInserted, info.NumItemsDeleted,
+			info.BucketSize, info.ExpansionRate, info.MaxIteration)
+		size = info.Size
+	}
+
+	return size, re.fileManager.WriteRecord(record)
+}
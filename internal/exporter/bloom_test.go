@@ -0,0 +1,62 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// bloomHook answers TYPE, BF.INFO and CF.INFO for filter keys the way a
+// server with RedisBloom loaded would
+type bloomHook struct {
+	passHook
+	types map[string]string
+}
+
+func (h bloomHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		switch c := cmd.(type) {
+		case *redis.StatusCmd:
+			if cmd.Name() == "type" && h.types[cmd.Args()[1].(string)] != "" {
+				c.SetVal(h.types[cmd.Args()[1].(string)])
+				return nil
+			}
+		case *redis.BFInfoCmd:
+			c.SetVal(redis.BFInfo{Capacity: 1000, Size: 1432, Filters: 1, ItemsInserted: 42, ExpansionRate: 2})
+			return nil
+		case *redis.CFInfoCmd:
+			c.SetVal(redis.CFInfo{Size: 1080, NumBuckets: 512, NumFilters: 1, NumItemsInserted: 7, NumItemsDeleted: 1, BucketSize: 2, ExpansionRate: 1, MaxIteration: 20})
+			return nil
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func TestExportFilterInfo(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{})
+	mr.Set("seen:bf", "")
+	mr.Set("seen:cf", "")
+	exp.client.AddHook(bloomHook{types: map[string]string{"seen:bf": bloomFilterType, "seen:cf": cuckooFilterType}})
+
+	if err := exp.ExportByPattern("seen:*"); err != nil {
+		t.Fatalf("ExportByPattern failed: %v", err)
+	}
+
+	records := make(map[string]string)
+	for _, row := range readCSVRows(t, exp.fileManager.config.OutputDir)[1:] {
+		records[row[0]+" "+row[1]] = row[2]
+	}
+
+	expected := map[string]string{
+		"seen:bf bloom_info":          "capacity=1000,size=1432,filters=1,items=42,expansion_rate=2",
+		"seen:bf " + bloomFilterType:  "size=1432",
+		"seen:cf cuckoo_info":         "size=1080,buckets=512,filters=1,items=7,deleted=1,bucket_size=2,expansion_rate=1,max_iterations=20",
+		"seen:cf " + cuckooFilterType: "size=1080",
+	}
+	for name, value := range expected {
+		if records[name] != value {
+			t.Errorf("Expected %s = %q, got %q", name, value, records[name])
+		}
+	}
+}
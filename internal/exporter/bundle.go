@@ -0,0 +1,225 @@
+package exporter
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Bundle selects the archive the finished export is packed into
+type Bundle string
+
+const (
+	BundleNone  Bundle = ""
+	BundleTarGz Bundle = "tar.gz"
+	BundleZip   Bundle = "zip"
+)
+
+// bundleFileName is the archive's name in OutputDir, without its extension
+const bundleFileName = "export"
+
+// validateBundle rejects unknown archive formats and bundling a FIFO stream
+func validateBundle(config StorageConfig) error {
+	switch config.Bundle {
+	case BundleNone:
+		if config.BundleDeleteFiles {
+			return errors.New("deleting bundled files requires a bundle format")
+		}
+		return nil
+	case BundleTarGz, BundleZip:
+	default:
+		return fmt.Errorf("unsupported bundle format %q (supported: %s, %s)", config.Bundle, BundleTarGz, BundleZip)
+	}
+
+	if config.Stream {
+		return errors.New("FIFO output does not support a bundle")
+	}
+	return nil
+}
+
+// bundleArchiver adds files to an archive as they are read
+type bundleArchiver interface {
+	add(name string, info fs.FileInfo, file io.Reader) error
+	Close() error
+}
+
+type tarGzArchiver struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func newTarGzArchiver(out io.Writer) *tarGzArchiver {
+	gz := gzip.NewWriter(out)
+	return &tarGzArchiver{gz: gz, tw: tar.NewWriter(gz)}
+}
+
+func (a *tarGzArchiver) add(name string, info fs.FileInfo, file io.Reader) error {
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if err := a.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(a.tw, file)
+	return err
+}
+
+func (a *tarGzArchiver) Close() error {
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	return a.gz.Close()
+}
+
+type zipArchiver struct {
+	zw *zip.Writer
+}
+
+func (a *zipArchiver) add(name string, info fs.FileInfo, file io.Reader) error {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	// Parquet pages and gzipped CSV are already compressed
+	header.Method = zip.Deflate
+	if strings.HasSuffix(name, ".parquet") || strings.HasSuffix(name, ".gz") {
+		header.Method = zip.Store
+	}
+
+	w, err := a.zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, file)
+	return err
+}
+
+func (a *zipArchiver) Close() error {
+	return a.zw.Close()
+}
+
+// bundlePath returns where the archive is written
+func (fm *FileManager) bundlePath() string {
+	return filepath.Join(fm.config.OutputDir, bundleFileName+"."+string(fm.config.Bundle))
+}
+
+// writeBundle packs every file under OutputDir, metadata and checksum
+// manifest included, into a single archive. Files are streamed into it one at
+// a time; with BundleDeleteFiles each is deleted once archived, so the export
+// never needs twice its size on disk.
+func (fm *FileManager) writeBundle() error {
+	path := fm.bundlePath()
+	tmpPath := path + ".tmp"
+
+	var names []string
+	err := filepath.WalkDir(fm.config.OutputDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || p == path || p == tmpPath {
+			return err
+		}
+		names = append(names, fm.relativePath(p))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list files to bundle: %w", err)
+	}
+	sort.Strings(names)
+
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+
+	var archiver bundleArchiver
+	if fm.config.Bundle == BundleZip {
+		archiver = &zipArchiver{zw: zip.NewWriter(out)}
+	} else {
+		archiver = newTarGzArchiver(out)
+	}
+
+	deleted := 0
+	fail := func(err error) error {
+		_ = out.Close()
+		// Deleted files only survive in the incomplete archive, so it is kept
+		if deleted == 0 {
+			_ = os.Remove(tmpPath)
+			return fmt.Errorf("failed to write bundle: %w", err)
+		}
+		return fmt.Errorf("failed to write bundle, %d bundled files are only in %s: %w", deleted, tmpPath, err)
+	}
+
+	for _, name := range names {
+		if err := fm.bundleFile(archiver, name); err != nil {
+			return fail(err)
+		}
+		if fm.config.BundleDeleteFiles {
+			if err := os.Remove(filepath.Join(fm.config.OutputDir, filepath.FromSlash(name))); err != nil {
+				return fail(err)
+			}
+			deleted++
+		}
+	}
+
+	if err := archiver.Close(); err != nil {
+		return fail(err)
+	}
+	if err := out.Close(); err != nil {
+		deleted++ // never remove an archive that may hold the only copy
+		return fail(err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to finish bundle: %w", err)
+	}
+
+	if fm.config.BundleDeleteFiles {
+		removeEmptyDirs(fm.config.OutputDir)
+	}
+	return nil
+}
+
+// bundleFile streams one file, named relative to OutputDir, into the archive
+func (fm *FileManager) bundleFile(archiver bundleArchiver, name string) error {
+	file, err := os.Open(filepath.Join(fm.config.OutputDir, filepath.FromSlash(name)))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if err := archiver.add(name, info, file); err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	return nil
+}
+
+// removeEmptyDirs removes the partition directories left empty under root,
+// deepest first, keeping root itself
+func removeEmptyDirs(root string) {
+	var dirs []string
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() && path != root {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+
+	// Removing a non-empty directory fails and leaves it in place
+	for i := len(dirs) - 1; i >= 0; i-- {
+		_ = os.Remove(dirs[i])
+	}
+}
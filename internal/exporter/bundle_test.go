@@ -0,0 +1,185 @@
+package exporter
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// bundleEntries lists the files in an export archive with their contents
+func bundleEntries(t *testing.T, path string, bundle Bundle) map[string]string {
+	t.Helper()
+
+	entries := make(map[string]string)
+	if bundle == BundleZip {
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			t.Fatalf("Failed to open zip: %v", err)
+		}
+		defer func() {
+			_ = zr.Close()
+		}()
+		for _, f := range zr.File {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := io.ReadAll(rc)
+			_ = rc.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			entries[f.Name] = string(data)
+		}
+		return entries
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Failed to open gzip: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatalf("Failed to read tar: %v", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[header.Name] = string(data)
+	}
+}
+
+func TestBundle(t *testing.T) {
+	for _, bundle := range []Bundle{BundleTarGz, BundleZip} {
+		for _, deleteFiles := range []bool{false, true} {
+			name := string(bundle)
+			if deleteFiles {
+				name += "+delete"
+			}
+			t.Run(name, func(t *testing.T) {
+				tempDir := t.TempDir()
+				config := StorageConfig{
+					OutputDir:         tempDir,
+					Format:            FormatCSV,
+					MaxRecords:        2,
+					ChecksumManifest:  true,
+					Bundle:            bundle,
+					BundleDeleteFiles: deleteFiles,
+				}
+				if err := validateStorageConfig(config); err != nil {
+					t.Fatal(err)
+				}
+
+				fm := NewFileManager(config)
+				for _, key := range []string{"a", "b", "c"} {
+					record := &RedisRecord{Key: key, Type: "string", Value: "value", TTLSeconds: -1, ExportedAt: "2024-01-15T14:30:00Z"}
+					if err := fm.WriteRecord(record); err != nil {
+						t.Fatalf("Failed to write record: %v", err)
+					}
+				}
+				if err := fm.Close(); err != nil {
+					t.Fatalf("Failed to close file manager: %v", err)
+				}
+				// A second Close leaves the bundle alone
+				if err := fm.Close(); err != nil {
+					t.Fatalf("Failed to close file manager again: %v", err)
+				}
+
+				archivePath := filepath.Join(tempDir, "export."+string(bundle))
+				entries := bundleEntries(t, archivePath, bundle)
+
+				// Data files, metadata and the manifest are all bundled
+				expected := []string{metadataFileName, loadSQLFileName, checksumManifestFileName}
+				for _, partition := range fm.metadata.Partitions {
+					expected = append(expected, partition.Path)
+				}
+				sort.Strings(expected)
+				names := make([]string, 0, len(entries))
+				for name := range entries {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				if strings.Join(names, ",") != strings.Join(expected, ",") {
+					t.Fatalf("Expected entries %v, got %v", expected, names)
+				}
+				if len(fm.metadata.Partitions) != 2 {
+					t.Fatalf("Expected 2 partitions, got %d", len(fm.metadata.Partitions))
+				}
+				for _, partition := range fm.metadata.Partitions {
+					if !strings.HasPrefix(entries[partition.Path], "key,type,value") {
+						t.Errorf("Expected CSV content in %s, got %q", partition.Path, entries[partition.Path])
+					}
+				}
+				if !strings.Contains(entries[checksumManifestFileName], metadataFileName) {
+					t.Errorf("Expected the bundled manifest to cover the metadata, got %q", entries[checksumManifestFileName])
+				}
+
+				// Only the archive is left when loose files are deleted
+				var loose []string
+				err := filepath.WalkDir(tempDir, func(path string, d os.DirEntry, err error) error {
+					if err == nil && path != tempDir && path != archivePath {
+						loose = append(loose, path)
+					}
+					return err
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if deleteFiles && len(loose) != 0 {
+					t.Errorf("Expected only the archive to remain, found %v", loose)
+				}
+				if !deleteFiles && len(findDataFiles(t, tempDir, ".csv")) != 2 {
+					t.Errorf("Expected loose CSV files to be kept, found %v", loose)
+				}
+			})
+		}
+	}
+}
+
+func TestValidateBundle(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  StorageConfig
+		wantErr string
+	}{
+		{"none", StorageConfig{Format: FormatCSV}, ""},
+		{"zip", StorageConfig{Format: FormatParquet, Bundle: BundleZip, BundleDeleteFiles: true}, ""},
+		{"unknown", StorageConfig{Format: FormatCSV, Bundle: "rar"}, "unsupported bundle format"},
+		{"delete without bundle", StorageConfig{Format: FormatCSV, BundleDeleteFiles: true}, "requires a bundle format"},
+		{"fifo", StorageConfig{Format: FormatCSV, Stream: true, Bundle: BundleTarGz}, "FIFO"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStorageConfig(tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
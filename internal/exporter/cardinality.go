@@ -0,0 +1,61 @@
+package exporter
+
+import (
+	"log"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// noCardinality marks records of types without elements, written as NULL
+const noCardinality int64 = -1
+
+// cardinalityCmd queues the command counting the elements of a collection,
+// or returns nil for types without elements
+func (re *RedisExporter) cardinalityCmd(pipe redis.Pipeliner, key, keyType string) *redis.IntCmd {
+	switch keyType {
+	case "set":
+		return pipe.SCard(re.ctx, key)
+	case "zset":
+		return pipe.ZCard(re.ctx, key)
+	case "hash":
+		return pipe.HLen(re.ctx, key)
+	case "list":
+		return pipe.LLen(re.ctx, key)
+	case "stream":
+		return pipe.XLen(re.ctx, key)
+	default:
+		return nil
+	}
+}
+
+// keyCardinalities pipelines SCARD, ZCARD, HLEN, LLEN or XLEN for the
+// collections among types. Other types and failed lookups are left out, so
+// their cardinality is written as NULL.
+func (re *RedisExporter) keyCardinalities(types map[string]string) map[string]int64 {
+	pipe := re.client.Pipeline()
+	cmds := make(map[string]*redis.IntCmd, len(types))
+	for key, keyType := range types {
+		if cmd := re.cardinalityCmd(pipe, key, keyType); cmd != nil {
+			cmds[key] = cmd
+		}
+	}
+	if len(cmds) == 0 {
+		return nil
+	}
+
+	ctx, cancel := re.batchContext(re.ctx)
+	defer cancel()
+	// Per-command errors, e.g. a key recreated with another type, are checked below
+	_, _ = pipe.Exec(ctx)
+
+	cardinalities := make(map[string]int64, len(cmds))
+	for key, cmd := range cmds {
+		n, err := cmd.Result()
+		if err != nil {
+			log.Printf("Error getting cardinality for key %s: %v", key, err)
+			continue
+		}
+		cardinalities[key] = n
+	}
+	return cardinalities
+}
@@ -0,0 +1,131 @@
+package exporter
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// seedCollections writes one key of every type with a known element count
+func seedCollections(t *testing.T, exp *RedisExporter) {
+	t.Helper()
+
+	ctx := exp.ctx
+	client := exp.client
+	steps := []error{
+		client.Set(ctx, "greeting", "hello", 0).Err(),
+		client.SAdd(ctx, "tags", "a", "b", "c").Err(),
+		client.ZAdd(ctx, "scores", redis.Z{Score: 1, Member: "amy"}, redis.Z{Score: 2, Member: "bob"}).Err(),
+		client.HSet(ctx, "user:1", "name", "alice", "email", "a@example.com").Err(),
+		client.RPush(ctx, "queue", "1", "2", "3", "4").Err(),
+		client.XAdd(ctx, &redis.XAddArgs{Stream: "events", Values: map[string]interface{}{"action": "login"}}).Err(),
+	}
+	for _, err := range steps {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestKeysOnlyCardinality(t *testing.T) {
+	exp, _ := newTestExporter(t, RedisExporterOptions{IncludeCardinality: true})
+	seedCollections(t, exp)
+
+	if err := exp.ExportKeysOnly(); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	rows := readCSVRows(t, exp.fileManager.config.OutputDir)
+	if len(rows) == 0 || rows[0][3] != "cardinality" {
+		t.Fatalf("Expected cardinality after value, got headers %v", rows)
+	}
+	got := make(map[string]string)
+	for _, row := range rows[1:] {
+		got[row[0]] = row[3]
+	}
+	expected := map[string]string{
+		"greeting": "",
+		"tags":     "3",
+		"scores":   "2",
+		"user:1":   "2",
+		"queue":    "4",
+		"events":   "1",
+	}
+	for key, want := range expected {
+		if got[key] != want {
+			t.Errorf("Key %s: expected cardinality %q, got %q", key, want, got[key])
+		}
+	}
+}
+
+func TestKeysOnlyCardinalityParquet(t *testing.T) {
+	exp, _ := newTestExporter(t, RedisExporterOptions{OutputFormat: "parquet", IncludeCardinality: true})
+	seedCollections(t, exp)
+
+	if err := exp.ExportKeysOnly(); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// The biggest collection is a one-line query on a BIGINT column
+	source := fmt.Sprintf("read_parquet('%s')", filepath.Join(exp.fileManager.config.OutputDir, "**", "*.parquet"))
+	var key, columnType string
+	query := fmt.Sprintf("SELECT key, typeof(cardinality) FROM %s ORDER BY cardinality DESC NULLS LAST LIMIT 1", source)
+	if err := db.QueryRow(query).Scan(&key, &columnType); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if key != "queue" || columnType != "BIGINT" {
+		t.Errorf("Expected queue with a BIGINT cardinality, got %s (%s)", key, columnType)
+	}
+
+	var nulls int
+	if err := db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE cardinality IS NULL", source)).Scan(&nulls); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if nulls != 1 {
+		t.Errorf("Expected only the string to have a NULL cardinality, got %d", nulls)
+	}
+}
+
+func TestRDBCardinality(t *testing.T) {
+	rdbFile := filepath.Join(t.TempDir(), "dump.rdb")
+	if err := os.WriteFile(rdbFile, testRDB(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	exp, _ := newTestExporter(t, RedisExporterOptions{RDBFile: rdbFile, IncludeCardinality: true})
+	if err := exp.ExportKeysOnly(); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, row := range readCSVRows(t, exp.fileManager.config.OutputDir) {
+		got[row[0]] = row[3]
+	}
+	expected := map[string]string{"greeting": "", "queue": "3", "ids": "2", "session": "2", "scores": "2"}
+	for key, want := range expected {
+		if got[key] != want {
+			t.Errorf("Key %s: expected cardinality %q, got %q", key, want, got[key])
+		}
+	}
+}
+
+func TestCardinalityRequiresKeysOnly(t *testing.T) {
+	exp, _ := newTestExporter(t, RedisExporterOptions{IncludeCardinality: true})
+	err := exp.ExportByPattern("*")
+	if err == nil || !strings.Contains(err.Error(), "INCLUDE_CARDINALITY") {
+		t.Errorf("Expected a full export with INCLUDE_CARDINALITY to fail, got %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package exporter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const checksumManifestFileName = "SHA256SUMS"
+
+// hashSum returns the hex digest of h, or "" when no hash was kept
+func hashSum(h hash.Hash) string {
+	if h == nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hashFile returns the hex SHA-256 of the file at path
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s for hashing: %w", path, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hashSum(h), nil
+}
+
+// checksumSidecars returns the files next to the data that the manifest
+// covers, relative to the output directory
+func (fm *FileManager) checksumSidecars() ([]string, error) {
+	sidecars := []string{metadataFileName}
+	if fm.queryable() {
+		sidecars = append(sidecars, loadSQLFileName)
+	}
+	if fm.acl != nil {
+		sidecars = append(sidecars, aclFileName)
+	}
+	if fm.config.ParquetSummaryFiles {
+		sidecars = append(sidecars, parquetCommonFileName, parquetSummaryFileName)
+	}
+
+	if fm.config.IcebergMetadata {
+		root := filepath.Join(fm.config.OutputDir, icebergMetadataDir)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			sidecars = append(sidecars, fm.relativePath(path))
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Iceberg metadata: %w", err)
+		}
+	}
+	return sidecars, nil
+}
+
+// writeChecksumManifest writes SHA256SUMS in the format sha256sum -c reads,
+// covering every data file and the metadata written next to them. Data
+// files use the digests taken as they were written; the small sidecar files
+// are hashed here.
+func (fm *FileManager) writeChecksumManifest() error {
+	sums := make(map[string]string, len(fm.metadata.Partitions))
+	for _, partition := range fm.metadata.Partitions {
+		if partition.SHA256 == "" {
+			return fmt.Errorf("no checksum recorded for %s", partition.Path)
+		}
+		sums[partition.Path] = partition.SHA256
+	}
+
+	sidecars, err := fm.checksumSidecars()
+	if err != nil {
+		return err
+	}
+	for _, path := range sidecars {
+		sum, err := hashFile(filepath.Join(fm.config.OutputDir, filepath.FromSlash(path)))
+		if err != nil {
+			return err
+		}
+		sums[path] = sum
+	}
+
+	paths := make([]string, 0, len(sums))
+	for path := range sums {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	for _, path := range paths {
+		fmt.Fprintf(&b, "%s  %s\n", sums[path], path)
+	}
+
+	if err := os.WriteFile(filepath.Join(fm.config.OutputDir, checksumManifestFileName), []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write checksum manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package exporter
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChecksumManifest(t *testing.T) {
+	configs := []StorageConfig{
+		{Format: FormatCSV, Compression: CompressionGzip},
+		{Format: FormatParquet, ParquetSummaryFiles: true},
+	}
+
+	for _, config := range configs {
+		t.Run(string(config.Format), func(t *testing.T) {
+			config.OutputDir = t.TempDir()
+			config.MaxRecords = 2
+			config.ChecksumManifest = true
+
+			fm := NewFileManager(config)
+			for i := 0; i < 5; i++ {
+				record := &RedisRecord{
+					Key:        "key:" + string(rune('a'+i)),
+					Type:       "string",
+					Value:      "value",
+					TTLSeconds: -1,
+					ExportedAt: "2024-01-15T14:30:00Z",
+				}
+				if err := fm.WriteRecord(record); err != nil {
+					t.Fatalf("Failed to write record: %v", err)
+				}
+			}
+			if err := fm.Close(); err != nil {
+				t.Fatalf("Failed to close file manager: %v", err)
+			}
+
+			manifest, err := os.ReadFile(filepath.Join(config.OutputDir, checksumManifestFileName))
+			if err != nil {
+				t.Fatalf("Failed to read manifest: %v", err)
+			}
+
+			listed := make(map[string]string)
+			for _, line := range strings.Split(strings.TrimSpace(string(manifest)), "\n") {
+				sum, path, ok := strings.Cut(line, "  ")
+				if !ok || len(sum) != 64 {
+					t.Fatalf("Malformed manifest line: %q", line)
+				}
+				listed[path] = sum
+			}
+
+			expected := []string{"export_metadata.json", loadSQLFileName}
+			if config.ParquetSummaryFiles {
+				expected = append(expected, parquetSummaryFileName, parquetCommonFileName)
+			}
+			if len(fm.metadata.Partitions) != 3 {
+				t.Fatalf("Expected 3 partitions, got %d", len(fm.metadata.Partitions))
+			}
+			for _, partition := range fm.metadata.Partitions {
+				expected = append(expected, partition.Path)
+				if listed[partition.Path] != partition.SHA256 {
+					t.Errorf("Manifest and metadata disagree for %s", partition.Path)
+				}
+			}
+			if len(listed) != len(expected) {
+				t.Errorf("Expected %d manifest entries, got %d", len(expected), len(listed))
+			}
+
+			// The digests taken while writing must match the files on disk
+			for _, path := range expected {
+				sum, err := hashFile(filepath.Join(config.OutputDir, filepath.FromSlash(path)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if listed[path] != sum {
+					t.Errorf("Checksum mismatch for %s: manifest %s, file %s", path, listed[path], sum)
+				}
+			}
+
+			if _, err := exec.LookPath("sha256sum"); err == nil {
+				cmd := exec.Command("sha256sum", "-c", "--quiet", checksumManifestFileName)
+				cmd.Dir = config.OutputDir
+				if output, err := cmd.CombinedOutput(); err != nil {
+					t.Errorf("sha256sum -c failed: %v\n%s", err, output)
+				}
+			}
+		})
+	}
+}
+
+func TestChecksumManifestRejectsFIFO(t *testing.T) {
+	err := validateStorageConfig(StorageConfig{Format: FormatCSV, Stream: true, ChecksumManifest: true})
+	if err == nil || !strings.Contains(err.Error(), "checksum manifest") {
+		t.Errorf("Expected checksum manifest to be rejected for FIFO output, got %v", err)
+	}
+}
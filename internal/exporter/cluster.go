@@ -0,0 +1,156 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// clusterOptions builds cluster client options from seed node URLs. Every
+// other setting, including credentials and TLS, is taken from opt, which is
+// parsed from the first URL.
+func clusterOptions(opt *redis.Options, urls []string) (*redis.ClusterOptions, error) {
+	if opt.DB != 0 {
+		return nil, fmt.Errorf("cluster URLs must select database 0, got %d", opt.DB)
+	}
+
+	addrs := make([]string, 0, len(urls))
+	for _, url := range urls {
+		seed, err := redis.ParseURL(url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Redis cluster URL: %w", err)
+		}
+		addrs = append(addrs, seed.Addr)
+	}
+
+	return &redis.ClusterOptions{
+		Addrs:        addrs,
+		Username:     opt.Username,
+		Password:     opt.Password,
+		TLSConfig:    opt.TLSConfig,
+		OnConnect:    opt.OnConnect,
+		PoolSize:     opt.PoolSize,
+		MinIdleConns: opt.MinIdleConns,
+		MaxRetries:   opt.MaxRetries,
+		DialTimeout:  opt.DialTimeout,
+		ReadTimeout:  opt.ReadTimeout,
+		WriteTimeout: opt.WriteTimeout,
+
+		Protocol:              opt.Protocol,
+		ContextTimeoutEnabled: opt.ContextTimeoutEnabled,
+
+		CredentialsProviderContext: opt.CredentialsProviderContext,
+		ConnMaxLifetime:            opt.ConnMaxLifetime,
+
+		StreamingCredentialsProvider: opt.StreamingCredentialsProvider,
+		Dialer:                       opt.Dialer,
+	}, nil
+}
+
+// scanNodes returns the clients SCAN must visit: the client itself, or every
+// master of a cluster ordered by address. With READ_FROM_REPLICA a replica
+// of each master is visited instead.
+func (re *RedisExporter) scanNodes(ctx context.Context) ([]redis.Cmdable, error) {
+	cluster, ok := re.client.(*redis.ClusterClient)
+	if !ok {
+		return []redis.Cmdable{re.client}, nil
+	}
+
+	var keep map[string]bool
+	each := cluster.ForEachMaster
+	if re.readFromReplica {
+		slots, err := cluster.ClusterSlots(ctx).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cluster slots: %w", err)
+		}
+		var alone []string
+		keep, alone = replicaScanAddrs(slots)
+		if len(alone) > 0 {
+			fmt.Printf("Warning: masters %s have no replica and will be scanned directly\n", strings.Join(alone, ", "))
+		}
+		each = cluster.ForEachShard
+	}
+
+	var mu sync.Mutex
+	var clients []*redis.Client
+	err := each(ctx, func(ctx context.Context, node *redis.Client) error {
+		if keep != nil && !keep[node.Options().Addr] {
+			return nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		clients = append(clients, node)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cluster nodes: %w", err)
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].Options().Addr < clients[j].Options().Addr
+	})
+
+	nodes := make([]redis.Cmdable, len(clients))
+	for i, client := range clients {
+		nodes[i] = client
+	}
+	return nodes, nil
+}
+
+// replicaScanAddrs picks the first replica CLUSTER SLOTS lists for every
+// master, keeping the masters that have none, which are also returned
+func replicaScanAddrs(slots []redis.ClusterSlot) (map[string]bool, []string) {
+	picked := make(map[string]string)
+	for _, slot := range slots {
+		if len(slot.Nodes) == 0 {
+			continue
+		}
+		master := slot.Nodes[0].Addr
+		if _, ok := picked[master]; ok {
+			continue
+		}
+		picked[master] = master
+		if len(slot.Nodes) > 1 {
+			picked[master] = slot.Nodes[1].Addr
+		}
+	}
+
+	addrs := make(map[string]bool, len(picked))
+	var alone []string
+	for master, addr := range picked {
+		addrs[addr] = true
+		if addr == master {
+			alone = append(alone, master)
+		}
+	}
+	sort.Strings(alone)
+	return addrs, alone
+}
+
+// dbSize returns DBSIZE, summed over every master of a cluster
+func dbSize(ctx context.Context, client redis.UniversalClient) (int64, error) {
+	cluster, ok := client.(*redis.ClusterClient)
+	if !ok {
+		return client.DBSize(ctx).Result()
+	}
+
+	var total atomic.Int64
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+		n, err := master.DBSize(ctx).Result()
+		total.Add(n)
+		return err
+	})
+	return total.Load(), err
+}
+
+// requireSingleNode rejects commands that rely on per-node server state
+func (re *RedisExporter) requireSingleNode(command string) error {
+	if _, ok := re.client.(*redis.ClusterClient); ok {
+		return fmt.Errorf("%s is not supported with REDIS_CLUSTER_URLS", command)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Compression selects how output files are compressed
+type Compression string
+
+const (
+	// CompressionDefault uses the format's default: none for CSV, snappy for Parquet
+	CompressionDefault Compression = ""
+	CompressionNone    Compression = "none"
+	CompressionSnappy  Compression = "snappy"
+	CompressionGzip    Compression = "gzip"
+	CompressionZstd    Compression = "zstd"
+)
+
+// supportedCompressions lists the compressions each format can write. CSV is
+// wrapped in a gzip stream; Parquet compresses its pages with the codec.
+var supportedCompressions = map[OutputFormat][]Compression{
+	FormatCSV:     {CompressionNone, CompressionGzip},
+	FormatParquet: {CompressionNone, CompressionSnappy, CompressionGzip, CompressionZstd},
+	FormatProto:   {CompressionNone, CompressionGzip},
+}
+
+// validateStorageConfig rejects format and compression combinations the
+// writers cannot produce, before any output is created
+func validateStorageConfig(config StorageConfig) error {
+	supported, ok := supportedCompressions[config.Format]
+	if !ok {
+		return fmt.Errorf("unsupported output format: %s (supported: %s, %s, %s)", config.Format, FormatCSV, FormatParquet, FormatProto)
+	}
+
+	if config.Compression != CompressionDefault && !compressionIn(config.Compression, supported) {
+		return fmt.Errorf("unsupported compression %q for %s format (supported: %s)",
+			config.Compression, config.Format, joinCompressions(supported))
+	}
+
+	if config.Stream {
+		if config.Format != FormatCSV {
+			return fmt.Errorf("FIFO output requires csv format, got: %s", config.Format)
+		}
+		if config.Compression != CompressionDefault && config.Compression != CompressionNone {
+			return fmt.Errorf("FIFO output does not support compression, got: %s", config.Compression)
+		}
+		if config.ChecksumManifest {
+			return errors.New("FIFO output does not support a checksum manifest")
+		}
+	}
+
+	if config.MaterializePartitionCols && (config.Stream || config.PartitionBy == PartitionByAge || config.PartitionBy == PartitionByTemplate) {
+		return errors.New("materialized partition columns require time partitioning")
+	}
+
+	if err := validatePartitionTemplate(config); err != nil {
+		return err
+	}
+
+	if err := validateBundle(config); err != nil {
+		return err
+	}
+
+	if err := validateCSVDialect(config); err != nil {
+		return err
+	}
+
+	if config.IcebergMetadata && config.Format != FormatParquet {
+		return fmt.Errorf("iceberg metadata requires parquet format, got: %s", config.Format)
+	}
+
+	if config.ParquetSummaryFiles && config.Format != FormatParquet {
+		return fmt.Errorf("parquet summary files require parquet format, got: %s", config.Format)
+	}
+
+	return nil
+}
+
+func compressionIn(c Compression, list []Compression) bool {
+	for _, candidate := range list {
+		if c == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+func joinCompressions(list []Compression) string {
+	names := make([]string, len(list))
+	for i, c := range list {
+		names[i] = string(c)
+	}
+	return strings.Join(names, ", ")
+}
+
+// fileExtension returns the extension of data files, e.g. csv.gz
+func (fm *FileManager) fileExtension() string {
+	ext := string(fm.config.Format)
+	if fm.config.Format == FormatProto {
+		ext = "pb"
+	}
+	if fm.config.Format != FormatParquet && fm.config.Compression == CompressionGzip {
+		ext += ".gz"
+	}
+	return ext
+}
+
+// parquetCodec returns the DuckDB COMPRESSION value for Parquet output
+func (fm *FileManager) parquetCodec() string {
+	switch fm.config.Compression {
+	case CompressionNone:
+		return "uncompressed"
+	case CompressionDefault:
+		return string(CompressionSnappy)
+	default:
+		return string(fm.config.Compression)
+	}
+}
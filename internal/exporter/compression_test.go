@@ -0,0 +1,226 @@
+package exporter
+
+import (
+	"compress/gzip"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateStorageConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  StorageConfig
+		wantErr string
+	}{
+		{name: "csv default", config: StorageConfig{Format: FormatCSV}},
+		{name: "csv gzip", config: StorageConfig{Format: FormatCSV, Compression: CompressionGzip}},
+		{name: "parquet zstd", config: StorageConfig{Format: FormatParquet, Compression: CompressionZstd}},
+		{name: "parquet none", config: StorageConfig{Format: FormatParquet, Compression: CompressionNone}},
+		{name: "fifo csv", config: StorageConfig{Format: FormatCSV, Stream: true}},
+		{name: "proto gzip", config: StorageConfig{Format: FormatProto, Compression: CompressionGzip}},
+		{
+			name:    "unknown format",
+			config:  StorageConfig{Format: OutputFormat("avro")},
+			wantErr: "unsupported output format: avro (supported: csv, parquet, proto)",
+		},
+		{
+			name:    "csv snappy",
+			config:  StorageConfig{Format: FormatCSV, Compression: CompressionSnappy},
+			wantErr: `unsupported compression "snappy" for csv format (supported: none, gzip)`,
+		},
+		{
+			name:    "csv zstd",
+			config:  StorageConfig{Format: FormatCSV, Compression: CompressionZstd},
+			wantErr: `unsupported compression "zstd" for csv format (supported: none, gzip)`,
+		},
+		{
+			name:    "parquet unknown codec",
+			config:  StorageConfig{Format: FormatParquet, Compression: Compression("lz4")},
+			wantErr: `unsupported compression "lz4" for parquet format (supported: none, snappy, gzip, zstd)`,
+		},
+		{
+			name:    "fifo parquet",
+			config:  StorageConfig{Format: FormatParquet, Stream: true},
+			wantErr: "FIFO output requires csv format, got: parquet",
+		},
+		{
+			name:    "fifo gzip",
+			config:  StorageConfig{Format: FormatCSV, Compression: CompressionGzip, Stream: true},
+			wantErr: "FIFO output does not support compression, got: gzip",
+		},
+		{
+			name:    "materialized columns by age",
+			config:  StorageConfig{Format: FormatParquet, PartitionBy: PartitionByAge, MaterializePartitionCols: true},
+			wantErr: "materialized partition columns require time partitioning",
+		},
+		{
+			name:    "iceberg csv",
+			config:  StorageConfig{Format: FormatCSV, IcebergMetadata: true},
+			wantErr: "iceberg metadata requires parquet format, got: csv",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStorageConfig(tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected valid config, got: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Expected error %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCompressedOutput(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      OutputFormat
+		compression Compression
+		extension   string
+	}{
+		{name: "csv gzip", format: FormatCSV, compression: CompressionGzip, extension: ".csv.gz"},
+		{name: "parquet zstd", format: FormatParquet, compression: CompressionZstd, extension: ".parquet"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "redis_dumper_compression_test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				if err := os.RemoveAll(tempDir); err != nil {
+					t.Logf("Warning: failed to remove temp dir: %v", err)
+				}
+			}()
+
+			fm := NewFileManager(StorageConfig{
+				OutputDir:   tempDir,
+				Format:      tt.format,
+				Compression: tt.compression,
+				MaxRecords:  100,
+			})
+
+			for i := 0; i < 3; i++ {
+				record := &RedisRecord{
+					Key:        fmt.Sprintf("test:key%d", i),
+					Type:       "string",
+					Value:      "value",
+					TTLSeconds: -1,
+					ExportedAt: "2024-01-15T14:30:00Z",
+				}
+				if err := fm.WriteRecord(record); err != nil {
+					t.Fatalf("Failed to write record: %v", err)
+				}
+			}
+			if err := fm.Close(); err != nil {
+				t.Fatalf("Failed to close file manager: %v", err)
+			}
+
+			files, err := filepath.Glob(filepath.Join(tempDir, "year=*", "month=*", "day=*", "hour=*", "*"))
+			if err != nil || len(files) != 1 {
+				t.Fatalf("Expected 1 data file, got %v (%v)", files, err)
+			}
+			if !strings.HasSuffix(files[0], tt.extension) {
+				t.Errorf("Expected %s file, got %s", tt.extension, files[0])
+			}
+
+			switch tt.format {
+			case FormatCSV:
+				file, err := os.Open(files[0])
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer func() {
+					_ = file.Close()
+				}()
+				gz, err := gzip.NewReader(file)
+				if err != nil {
+					t.Fatalf("Expected gzip stream: %v", err)
+				}
+				rows, err := csv.NewReader(gz).ReadAll()
+				if err != nil {
+					t.Fatalf("Failed to read CSV: %v", err)
+				}
+				if len(rows) != 4 {
+					t.Errorf("Expected header and 3 rows, got %d", len(rows))
+				}
+			case FormatParquet:
+				db, err := sql.Open("duckdb", "")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer func() {
+					_ = db.Close()
+				}()
+				var codec string
+				query := fmt.Sprintf("SELECT DISTINCT compression FROM parquet_metadata('%s')", files[0])
+				if err := db.QueryRow(query).Scan(&codec); err != nil {
+					t.Fatalf("Failed to read Parquet metadata: %v", err)
+				}
+				if !strings.EqualFold(codec, "zstd") {
+					t.Errorf("Expected ZSTD pages, got %s", codec)
+				}
+			}
+		})
+	}
+}
+
+func TestGzipFlush(t *testing.T) {
+	tempDir := t.TempDir()
+	fm := NewFileManager(StorageConfig{
+		OutputDir:   tempDir,
+		Format:      FormatCSV,
+		Compression: CompressionGzip,
+		MaxRecords:  100,
+	})
+	defer func() {
+		if err := fm.Close(); err != nil {
+			t.Errorf("Failed to close file manager: %v", err)
+		}
+	}()
+
+	for i := 0; i < 3; i++ {
+		record := &RedisRecord{Key: fmt.Sprintf("test:key%d", i), Type: "string", Value: "value", TTLSeconds: -1}
+		if err := fm.WriteRecord(record); err != nil {
+			t.Fatalf("Failed to write record: %v", err)
+		}
+	}
+	fm.FlushAll()
+
+	files, err := filepath.Glob(filepath.Join(tempDir, "year=*", "month=*", "day=*", "hour=*", "*"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Expected 1 data file, got %v (%v)", files, err)
+	}
+	file, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Expected gzip stream after FlushAll: %v", err)
+	}
+
+	// The rows decompress before the stream has its trailer
+	data, err := io.ReadAll(gz)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected an unfinished stream, got %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 4 {
+		t.Errorf("Expected header and 3 rows after FlushAll, got %q", data)
+	}
+}
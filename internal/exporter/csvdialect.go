@@ -0,0 +1,157 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	defaultCSVDelimiter = ','
+	defaultCSVQuote     = '"'
+)
+
+// rowWriter is the subset of csv.Writer the CSV output uses
+type rowWriter interface {
+	Write(record []string) error
+	WriteAll(records [][]string) error
+	Flush()
+	Error() error
+}
+
+// parseCSVRune parses a CSV_DELIMITER or CSV_QUOTE value. Empty selects the
+// default, and "tab" or a literal \t select a tab for TSV output.
+func parseCSVRune(name, value string) (rune, error) {
+	switch value {
+	case "":
+		return 0, nil
+	case "tab", `\t`:
+		return '\t', nil
+	}
+
+	r, size := utf8.DecodeRuneInString(value)
+	if r == utf8.RuneError || size != len(value) {
+		return 0, fmt.Errorf("%s must be a single character, got: %q", name, value)
+	}
+	return r, nil
+}
+
+// csvDelimiter returns the configured field delimiter
+func (config StorageConfig) csvDelimiter() rune {
+	if config.CSVDelimiter == 0 {
+		return defaultCSVDelimiter
+	}
+	return config.CSVDelimiter
+}
+
+// csvQuote returns the configured quote character
+func (config StorageConfig) csvQuote() rune {
+	if config.CSVQuote == 0 {
+		return defaultCSVQuote
+	}
+	return config.CSVQuote
+}
+
+// validateCSVDialect rejects delimiters and quotes a reader could not tell
+// apart from the record structure
+func validateCSVDialect(config StorageConfig) error {
+	delimiter, quote := config.csvDelimiter(), config.csvQuote()
+	if config.Format != FormatCSV && (delimiter != defaultCSVDelimiter || quote != defaultCSVQuote || config.OmitCSVHeader) {
+		return fmt.Errorf("CSV dialect options require csv format, got: %s", config.Format)
+	}
+
+	for name, r := range map[string]rune{"delimiter": delimiter, "quote": quote} {
+		if r == '\r' || r == '\n' || r == utf8.RuneError || !utf8.ValidRune(r) {
+			return fmt.Errorf("invalid CSV %s: %q", name, r)
+		}
+	}
+	if delimiter == quote {
+		return fmt.Errorf("CSV delimiter and quote must differ, both are %q", delimiter)
+	}
+	return nil
+}
+
+// newRowWriter returns a CSV writer for the configured dialect. encoding/csv
+// only quotes with '"', so other quote characters use quotedCSVWriter.
+func (fm *FileManager) newRowWriter(out io.Writer) rowWriter {
+	if quote := fm.config.csvQuote(); quote != defaultCSVQuote {
+		return &quotedCSVWriter{
+			w:         bufio.NewWriter(out),
+			delimiter: fm.config.csvDelimiter(),
+			quote:     quote,
+		}
+	}
+
+	w := csv.NewWriter(out)
+	w.Comma = fm.config.csvDelimiter()
+	return w
+}
+
+// quotedCSVWriter follows encoding/csv's quoting rules with a custom quote
+// character, which is doubled inside quoted fields
+type quotedCSVWriter struct {
+	w         *bufio.Writer
+	delimiter rune
+	quote     rune
+	err       error
+}
+
+func (q *quotedCSVWriter) Write(record []string) error {
+	if q.err != nil {
+		return q.err
+	}
+
+	quote := string(q.quote)
+	for i, field := range record {
+		if i > 0 {
+			q.w.WriteRune(q.delimiter)
+		}
+		if !q.needsQuotes(field) {
+			q.w.WriteString(field)
+			continue
+		}
+		q.w.WriteString(quote)
+		q.w.WriteString(strings.ReplaceAll(field, quote, quote+quote))
+		q.w.WriteString(quote)
+	}
+	_, q.err = q.w.WriteString("\n")
+	return q.err
+}
+
+func (q *quotedCSVWriter) WriteAll(records [][]string) error {
+	for _, record := range records {
+		if err := q.Write(record); err != nil {
+			return err
+		}
+	}
+	q.Flush()
+	return q.err
+}
+
+func (q *quotedCSVWriter) Flush() {
+	if err := q.w.Flush(); err != nil && q.err == nil {
+		q.err = err
+	}
+}
+
+func (q *quotedCSVWriter) Error() error {
+	return q.err
+}
+
+// needsQuotes mirrors csv.Writer: fields holding the delimiter, the quote,
+// a line break or a leading space are quoted, as is the Postgres marker \.
+func (q *quotedCSVWriter) needsQuotes(field string) bool {
+	if field == "" {
+		return false
+	}
+	if field == `\.` || strings.ContainsRune(field, q.delimiter) || strings.ContainsRune(field, q.quote) ||
+		strings.ContainsAny(field, "\r\n") {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(field)
+	return unicode.IsSpace(r)
+}
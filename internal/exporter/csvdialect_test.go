@@ -0,0 +1,165 @@
+package exporter
+
+import (
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseCSVRune(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    rune
+		wantErr bool
+	}{
+		{"", 0, false},
+		{";", ';', false},
+		{"tab", '\t', false},
+		{`\t`, '\t', false},
+		{"|", '|', false},
+		{"§", '§', false},
+		{";;", 0, true},
+		{"\xff", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseCSVRune("CSV_DELIMITER", tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCSVRune(%q): unexpected error %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseCSVRune(%q): expected %q, got %q", tt.value, tt.want, got)
+		}
+	}
+}
+
+func TestValidateCSVDialect(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  StorageConfig
+		wantErr string
+	}{
+		{"defaults", StorageConfig{Format: FormatCSV}, ""},
+		{"tsv", StorageConfig{Format: FormatCSV, CSVDelimiter: '\t', OmitCSVHeader: true}, ""},
+		{"explicit defaults on parquet", StorageConfig{Format: FormatParquet, CSVDelimiter: ',', CSVQuote: '"'}, ""},
+		{"newline delimiter", StorageConfig{Format: FormatCSV, CSVDelimiter: '\n'}, "invalid CSV delimiter"},
+		{"carriage return quote", StorageConfig{Format: FormatCSV, CSVQuote: '\r'}, "invalid CSV quote"},
+		{"delimiter is quote", StorageConfig{Format: FormatCSV, CSVDelimiter: '"'}, "must differ"},
+		{"quote is delimiter", StorageConfig{Format: FormatCSV, CSVQuote: ','}, "must differ"},
+		{"parquet delimiter", StorageConfig{Format: FormatParquet, CSVDelimiter: ';'}, "require csv format"},
+		{"parquet headerless", StorageConfig{Format: FormatParquet, OmitCSVHeader: true}, "require csv format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStorageConfig(tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCSVDialect(t *testing.T) {
+	values := []string{"plain", "tab\there", "semi;colon", "it's", `say "hi"`, "multi\nline", " padded"}
+
+	tests := []struct {
+		name      string
+		delimiter rune
+		quote     rune
+		firstLine string
+	}{
+		{"tsv", '\t', 0, "k0\tstring\tplain\t-1"},
+		{"semicolon with single quotes", ';', '\'', "k0;string;plain;-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			fm := NewFileManager(StorageConfig{
+				OutputDir:       tempDir,
+				Format:          FormatCSV,
+				MaxRecords:      1000,
+				OmitPartitionID: true,
+				CSVDelimiter:    tt.delimiter,
+				CSVQuote:        tt.quote,
+				OmitCSVHeader:   true,
+			})
+			if err := validateStorageConfig(fm.config); err != nil {
+				t.Fatal(err)
+			}
+
+			for i, value := range values {
+				record := &RedisRecord{
+					Key:        "k" + string(rune('0'+i)),
+					Type:       "string",
+					Value:      value,
+					TTLSeconds: -1,
+					ExportedAt: "2024-01-15T14:30:00Z",
+				}
+				if err := fm.WriteRecord(record); err != nil {
+					t.Fatalf("Failed to write record: %v", err)
+				}
+			}
+			if err := fm.Close(); err != nil {
+				t.Fatalf("Failed to close file manager: %v", err)
+			}
+
+			files := findDataFiles(t, tempDir, ".csv")
+			if len(files) != 1 {
+				t.Fatalf("Expected 1 CSV file, got %d", len(files))
+			}
+			data, err := os.ReadFile(files[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			// No header: the file starts with the first record
+			if !strings.HasPrefix(string(data), tt.firstLine) {
+				t.Errorf("Expected file to start with %q, got %q", tt.firstLine, strings.SplitN(string(data), "\n", 2)[0])
+			}
+
+			// DuckDB reads the values back with the options from load.sql
+			db, err := sql.Open("duckdb", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				_ = db.Close()
+			}()
+
+			rows, err := db.Query("SELECT key, value FROM " + fm.QuerySource() + " ORDER BY key")
+			if err != nil {
+				t.Fatalf("Failed to query %s: %v", fm.QuerySource(), err)
+			}
+			defer func() {
+				_ = rows.Close()
+			}()
+
+			i := 0
+			for rows.Next() {
+				var key, value string
+				if err := rows.Scan(&key, &value); err != nil {
+					t.Fatal(err)
+				}
+				if i < len(values) && value != values[i] {
+					t.Errorf("Record %s: expected value %q, got %q", key, values[i], value)
+				}
+				i++
+			}
+			if err := rows.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if i != len(values) {
+				t.Errorf("Expected %d records, got %d", len(values), i)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package exporter
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+)
+
+// dialFunc opens a connection to a Redis address, as redis.Options.Dialer
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// dialTLS negotiates TLS over connections from dial. go-redis only does this
+// for its own dialer, so an SSH tunnel would otherwise carry plaintext.
+func dialTLS(dial dialFunc, config *tls.Config) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+}
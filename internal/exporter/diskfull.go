@@ -0,0 +1,64 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// ErrOutputFull marks errors caused by OUTPUT_DIR running out of space. Once
+// it occurs no further records are accepted, and Close finishes what it can.
+var ErrOutputFull = errors.New("output directory is out of space")
+
+// isNoSpaceError reports whether err is ENOSPC. DuckDB errors only carry the
+// message, so the strerror text is matched as well.
+func isNoSpaceError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, syscall.ENOSPC) || strings.Contains(strings.ToLower(err.Error()), "no space left on device")
+}
+
+// checkSpace passes err through, except that the first out-of-space error
+// stops the FileManager and every one is returned as ErrOutputFull
+func (fm *FileManager) checkSpace(err error) error {
+	if !isNoSpaceError(err) {
+		return err
+	}
+	if fm.outOfSpace == nil {
+		fm.outOfSpace = fmt.Errorf("%w: %v", ErrOutputFull, err)
+	}
+	return fm.outOfSpace
+}
+
+// OutOfSpace returns the out-of-space error that stopped the FileManager, if any
+func (fm *FileManager) OutOfSpace() error {
+	return fm.outOfSpace
+}
+
+// discardCSVFile removes a CSV file whose writes failed, freeing its space,
+// so an incomplete file is never listed as a partition
+func (fm *FileManager) discardCSVFile(w *partitionWriter, cause error) error {
+	name := w.csvFile.Name()
+	_ = w.csvFile.Close()
+	w.csvFile = nil
+	w.csvWriter = nil
+	w.gzipWriter = nil
+	w.csvBuffer = nil
+
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("Warning: failed to remove incomplete file %s: %v\n", name, err)
+	}
+	fm.dropRecords(w.recordCount)
+	w.recordCount = 0
+	return fmt.Errorf("incomplete CSV file %s removed: %w", fm.relativePath(name), cause)
+}
+
+// dropRecords counts records lost because their file could not be finished
+func (fm *FileManager) dropRecords(n int64) {
+	fm.copyMu.Lock()
+	fm.metadata.DroppedRecords += n
+	fm.copyMu.Unlock()
+}
@@ -0,0 +1,125 @@
+package exporter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// noSpaceWriter fails every write like a full disk
+type noSpaceWriter struct{}
+
+func (noSpaceWriter) Write([]byte) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: "redis_data_part_0002.csv", Err: syscall.ENOSPC}
+}
+
+func TestIsNoSpaceError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("connection refused"), false},
+		{fmt.Errorf("failed to write: %w", syscall.ENOSPC), true},
+		{errors.New("IO Error: Could not write file \"x.parquet\": No space left on device"), true},
+	}
+
+	for _, tt := range tests {
+		if got := isNoSpaceError(tt.err); got != tt.want {
+			t.Errorf("isNoSpaceError(%v): expected %v, got %v", tt.err, tt.want, got)
+		}
+	}
+}
+
+func TestOutputFullCSV(t *testing.T) {
+	tempDir := t.TempDir()
+	fm := NewFileManager(StorageConfig{
+		OutputDir:  tempDir,
+		Format:     FormatCSV,
+		MaxRecords: 2,
+	})
+
+	write := func(value string) error {
+		return fm.WriteRecord(&RedisRecord{
+			Key:        "key",
+			Type:       "string",
+			Value:      value,
+			TTLSeconds: -1,
+			ExportedAt: "2024-01-15T14:30:00Z",
+		})
+	}
+
+	// Two records fill the first file, the third opens a second one
+	for i := 0; i < 3; i++ {
+		if err := write("value"); err != nil {
+			t.Fatalf("Failed to write record %d: %v", i, err)
+		}
+	}
+
+	// The disk fills up under the second file
+	for _, w := range fm.writers {
+		w.csvWriter = fm.newRowWriter(noSpaceWriter{})
+	}
+	err := write(strings.Repeat("x", 8192))
+	if !errors.Is(err, ErrOutputFull) {
+		t.Fatalf("Expected ErrOutputFull, got %v", err)
+	}
+
+	// No more records are accepted
+	if err := write("value"); !errors.Is(err, ErrOutputFull) {
+		t.Errorf("Expected later writes to be refused, got %v", err)
+	}
+
+	if err := fm.Close(); !errors.Is(err, ErrOutputFull) {
+		t.Errorf("Expected Close to report ErrOutputFull, got %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, "export_metadata.json"))
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	var metadata ExportMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		t.Fatalf("Metadata is not valid JSON: %v", err)
+	}
+	if metadata.Status != exportStatusPartial || !strings.Contains(metadata.Error, "out of space") {
+		t.Errorf("Expected partial status with an out of space error, got %s: %s", metadata.Status, metadata.Error)
+	}
+	if len(metadata.Partitions) != 1 || metadata.Partitions[0].RecordCount != 2 {
+		t.Errorf("Expected only the finished partition, got %+v", metadata.Partitions)
+	}
+	if metadata.DroppedRecords != 1 {
+		t.Errorf("Expected 1 dropped record, got %d", metadata.DroppedRecords)
+	}
+
+	// The incomplete file is removed and the finished one parses
+	if files := findDataFiles(t, tempDir, ".csv"); len(files) != 1 {
+		t.Errorf("Expected 1 CSV file on disk, got %v", files)
+	}
+	if rows := readCSVRows(t, tempDir); len(rows) != 3 {
+		t.Errorf("Expected a header and 2 rows, got %d rows", len(rows))
+	}
+}
+
+func TestOutputFullStopsExport(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{})
+	for i := 0; i < 5; i++ {
+		if err := mr.Set(fmt.Sprintf("key:%d", i), "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exp.fileManager.outOfSpace = fmt.Errorf("%w: write: no space left on device", ErrOutputFull)
+	err := exp.ExportByPattern("*")
+	if !errors.Is(err, ErrOutputFull) {
+		t.Fatalf("Expected ErrOutputFull, got %v", err)
+	}
+	if exp.fileManager.metadata.Status != exportStatusPartial {
+		t.Errorf("Expected partial status, got %s", exp.fileManager.metadata.Status)
+	}
+}
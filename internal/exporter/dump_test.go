@@ -0,0 +1,83 @@
+package exporter
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// dumpHook answers DUMP, which miniredis lacks, from a map; other keys reply
+// nil as if deleted
+type dumpHook struct {
+	passHook
+	payloads map[string]string
+}
+
+func (h dumpHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		rest := cmds[:0:0]
+		for _, cmd := range cmds {
+			if cmd.Name() != "dump" {
+				rest = append(rest, cmd)
+				continue
+			}
+			if payload, ok := h.payloads[cmd.Args()[1].(string)]; ok {
+				cmd.(*redis.StringCmd).SetVal(payload)
+			} else {
+				cmd.SetErr(redis.Nil)
+			}
+		}
+		if len(rest) == 0 {
+			return nil
+		}
+		return next(ctx, rest)
+	}
+}
+
+func TestExportDump(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{})
+	seedCollections(t, exp)
+	mr.SetTTL("greeting", 90*time.Second)
+	exp.client.AddHook(dumpHook{payloads: map[string]string{
+		"greeting": "\x00\x05hello\x0b\x00",
+		"tags":     "\x02\x03abc\x0b\x00",
+	}})
+
+	if err := exp.ExportDump("*"); err != nil {
+		t.Fatalf("ExportDump failed: %v", err)
+	}
+
+	rows := readCSVRows(t, exp.fileManager.config.OutputDir)[1:]
+	// Keys without a payload are left out
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 dumped keys, got %v", rows)
+	}
+	got := make(map[string][]string)
+	for _, row := range rows {
+		got[row[0]] = row
+	}
+
+	greeting := got["greeting"]
+	if greeting == nil || greeting[1] != "string" || greeting[3] != "90" {
+		t.Fatalf("Expected greeting as a string with a 90s TTL, got %v", greeting)
+	}
+	payload, err := base64.StdEncoding.DecodeString(greeting[2])
+	if err != nil || string(payload) != "\x00\x05hello\x0b\x00" {
+		t.Errorf("Expected the DUMP payload of greeting, got %q (%v)", payload, err)
+	}
+	if tags := got["tags"]; tags == nil || tags[1] != "set" || tags[3] != "-1" {
+		t.Errorf("Expected tags as a set without expiry, got %v", tags)
+	}
+}
+
+func TestExportDumpRequiresValueColumn(t *testing.T) {
+	exp, _ := newTestExporter(t, RedisExporterOptions{DropValueColumn: true})
+	err := exp.ExportDump("*")
+	if err == nil || !strings.Contains(err.Error(), "DROP_VALUE_COLUMN") {
+		t.Errorf("Expected dump with DROP_VALUE_COLUMN to fail, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package exporter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+const (
+	// elastiCacheTokenLifetime is how long ElastiCache accepts an IAM token
+	elastiCacheTokenLifetime = 15 * time.Minute
+	// elastiCacheTokenRefresh reuses a token for new connections this long,
+	// leaving a margin before it expires
+	elastiCacheTokenRefresh = 10 * time.Minute
+	// elastiCacheConnLifetime recycles pooled connections before ElastiCache
+	// closes IAM-authenticated connections at 12 hours
+	elastiCacheConnLifetime = 11 * time.Hour
+)
+
+// emptyPayloadHash is the SHA-256 of the empty body of a presigned GET
+var emptyPayloadHash = func() string {
+	sum := sha256.Sum256(nil)
+	return hex.EncodeToString(sum[:])
+}()
+
+// elastiCacheTokens generates ElastiCache IAM auth tokens, SigV4 presigned
+// connect requests, for the user userID of cacheName
+type elastiCacheTokens struct {
+	cacheName  string
+	userID     string
+	serverless bool
+	region     string
+	creds      aws.CredentialsProvider
+	signer     *v4.Signer
+	now        func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	createdAt time.Time
+}
+
+// newElastiCacheTokens resolves AWS credentials and the region the way the
+// AWS CLI does: environment, shared config and profile, then instance roles
+func newElastiCacheTokens(ctx context.Context, cacheName, userID string, serverless bool) (*elastiCacheTokens, error) {
+	if userID == "" {
+		return nil, errors.New("ELASTICACHE_IAM_USER is required with ELASTICACHE_IAM_CACHE_NAME")
+	}
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, errors.New("ElastiCache IAM authentication needs an AWS region, e.g. AWS_REGION")
+	}
+
+	return &elastiCacheTokens{
+		cacheName:  cacheName,
+		userID:     userID,
+		serverless: serverless,
+		region:     cfg.Region,
+		creds:      cfg.Credentials,
+		signer:     v4.NewSigner(),
+		now:        time.Now,
+	}, nil
+}
+
+// credentials returns the user and a token for a new connection, generating
+// a fresh token once the current one is due for refresh. Connections stay
+// authenticated after their token expires, so only new ones need it.
+func (e *elastiCacheTokens) credentials(ctx context.Context) (string, string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	if e.token == "" || now.Sub(e.createdAt) >= elastiCacheTokenRefresh {
+		token, err := e.generate(ctx, now)
+		if err != nil {
+			return "", "", err
+		}
+		e.token = token
+		e.createdAt = now
+	}
+	return e.userID, e.token, nil
+}
+
+// generate presigns GET http://<cache>/?Action=connect&User=<user> for the
+// elasticache service; the token is the signed URL without its scheme
+func (e *elastiCacheTokens) generate(ctx context.Context, now time.Time) (string, error) {
+	query := url.Values{
+		"Action":        {"connect"},
+		"User":          {e.userID},
+		"X-Amz-Expires": {fmt.Sprint(int(elastiCacheTokenLifetime.Seconds()))},
+	}
+	if e.serverless {
+		query.Set("ResourceType", "ServerlessCache")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+e.cacheName+"/?"+query.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build ElastiCache auth request: %w", err)
+	}
+
+	creds, err := e.creds.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	signed, _, err := e.signer.PresignHTTP(ctx, creds, req, emptyPayloadHash, "elasticache", e.region, now)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign ElastiCache auth token: %w", err)
+	}
+	return strings.TrimPrefix(signed, "http://"), nil
+}
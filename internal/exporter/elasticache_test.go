@@ -0,0 +1,108 @@
+package exporter
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// testElastiCacheTokens signs with static credentials at a controllable time
+func testElastiCacheTokens(now *time.Time) *elastiCacheTokens {
+	return &elastiCacheTokens{
+		cacheName: "my-group",
+		userID:    "exporter",
+		region:    "us-east-1",
+		creds:     aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", "")),
+		signer:    v4.NewSigner(),
+		now:       func() time.Time { return *now },
+	}
+}
+
+func TestElastiCacheToken(t *testing.T) {
+	now := time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC)
+	tokens := testElastiCacheTokens(&now)
+
+	user, token, err := tokens.credentials(context.Background())
+	if err != nil {
+		t.Fatalf("credentials failed: %v", err)
+	}
+	if user != "exporter" {
+		t.Errorf("Expected user exporter, got %s", user)
+	}
+	if !strings.HasPrefix(token, "my-group/?") {
+		t.Fatalf("Expected a token for my-group without a scheme, got %s", token)
+	}
+
+	query, err := url.ParseQuery(strings.TrimPrefix(token, "my-group/?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"Action":           "connect",
+		"User":             "exporter",
+		"X-Amz-Algorithm":  "AWS4-HMAC-SHA256",
+		"X-Amz-Credential": "AKIDEXAMPLE/20240115/us-east-1/elasticache/aws4_request",
+		"X-Amz-Date":       "20240115T143000Z",
+		"X-Amz-Expires":    "900",
+	}
+	for name, want := range expected {
+		if got := query.Get(name); got != want {
+			t.Errorf("Expected %s=%s, got %q", name, want, got)
+		}
+	}
+	if query.Get("X-Amz-Signature") == "" || query.Has("ResourceType") {
+		t.Errorf("Expected a signed replication group token, got %s", token)
+	}
+
+	// New connections reuse the token until it is due for refresh
+	now = now.Add(elastiCacheTokenRefresh - time.Second)
+	if _, again, _ := tokens.credentials(context.Background()); again != token {
+		t.Error("Expected the token to be reused before the refresh interval")
+	}
+	now = now.Add(time.Second)
+	if _, fresh, _ := tokens.credentials(context.Background()); fresh == token {
+		t.Error("Expected a new token once the refresh interval passed")
+	}
+}
+
+func TestElastiCacheServerlessToken(t *testing.T) {
+	now := time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC)
+	tokens := testElastiCacheTokens(&now)
+	tokens.serverless = true
+
+	_, token, err := tokens.credentials(context.Background())
+	if err != nil {
+		t.Fatalf("credentials failed: %v", err)
+	}
+	if !strings.Contains(token, "ResourceType=ServerlessCache") {
+		t.Errorf("Expected a serverless token, got %s", token)
+	}
+}
+
+func TestElastiCacheIAMOptions(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "us-east-1")
+
+	opts := RedisExporterOptions{
+		RedisURL:                "redis://localhost:6379/0",
+		OutputDir:               t.TempDir(),
+		ElastiCacheIAMCacheName: "my-group",
+		ElastiCacheIAMUser:      "exporter",
+	}
+	if _, err := NewRedisExporter(opts); err == nil || !strings.Contains(err.Error(), "ENABLE_TLS") {
+		t.Errorf("Expected IAM authentication without TLS to be rejected, got %v", err)
+	}
+
+	opts.EnableTLS = true
+	opts.ElastiCacheIAMUser = ""
+	if _, err := NewRedisExporter(opts); err == nil || !strings.Contains(err.Error(), "ELASTICACHE_IAM_USER") {
+		t.Errorf("Expected a missing user to be rejected, got %v", err)
+	}
+}
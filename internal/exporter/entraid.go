@@ -0,0 +1,76 @@
+package exporter
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// entraIDRedisScope is the token scope of Azure Cache for Redis in every cloud
+const entraIDRedisScope = "https://redis.azure.com/.default"
+
+// EntraIDAuth authenticates to Azure Cache for Redis with Entra ID access
+// tokens. The user is the object ID of the principal the token was issued
+// to, read from the token unless set.
+type EntraIDAuth struct {
+	credential azcore.TokenCredential
+	user       string
+}
+
+// NewEntraIDAuth returns an AuthProvider using DefaultAzureCredential:
+// environment variables, workload identity, managed identity or the Azure CLI
+func NewEntraIDAuth(user string) (*EntraIDAuth, error) {
+	credential, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure credential: %w", err)
+	}
+	return &EntraIDAuth{credential: credential, user: user}, nil
+}
+
+func (a *EntraIDAuth) Credentials(ctx context.Context) (string, string, time.Time, error) {
+	token, err := a.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{entraIDRedisScope}})
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("failed to get Entra ID token: %w", err)
+	}
+
+	user := a.user
+	if user == "" {
+		user, err = tokenObjectID(token.Token)
+		if err != nil {
+			return "", "", time.Time{}, err
+		}
+	}
+	return user, token.Token, token.ExpiresOn, nil
+}
+
+// tokenObjectID reads the oid claim of a JWT access token without verifying
+// it; Redis does that when the token is presented
+func tokenObjectID(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("Entra ID token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("failed to decode Entra ID token: %w", err)
+	}
+
+	var claims struct {
+		OID string `json:"oid"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", fmt.Errorf("failed to decode Entra ID token claims: %w", err)
+	}
+	if claims.OID == "" {
+		return "", errors.New("Entra ID token has no oid claim; set AZURE_ENTRA_USER")
+	}
+	return claims.OID, nil
+}
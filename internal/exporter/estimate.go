@@ -0,0 +1,142 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+const defaultEstimateSample = 1000
+
+// errSampleComplete stops the estimate scan once enough keys were sampled
+var errSampleComplete = errors.New("estimate sample complete")
+
+// ExportEstimate extrapolates a full export from a timed sample
+type ExportEstimate struct {
+	SampledKeys    int64
+	SampleDuration time.Duration
+	SampleBytes    int64
+	// TotalKeys is DBSIZE, which counts every key whatever the pattern
+	TotalKeys         int64
+	KeysPerSecond     float64
+	EstimatedDuration time.Duration
+	EstimatedBytes    int64
+}
+
+// extrapolateEstimate scales the sample to TotalKeys
+func extrapolateEstimate(e *ExportEstimate) {
+	if e.SampledKeys == 0 {
+		return
+	}
+
+	if seconds := e.SampleDuration.Seconds(); seconds > 0 {
+		e.KeysPerSecond = float64(e.SampledKeys) / seconds
+	}
+
+	scale := float64(e.TotalKeys) / float64(e.SampledKeys)
+	e.EstimatedDuration = time.Duration(float64(e.SampleDuration) * scale)
+	e.EstimatedBytes = int64(float64(e.SampleBytes) * scale)
+}
+
+// Estimate exports up to estimateSample keys matching pattern into a
+// throwaway directory, timing the fetch, write and final COPY, and
+// extrapolates the duration and output size of a full export from DBSIZE.
+// Nothing is written to the output directory.
+func (re *RedisExporter) Estimate(pattern string) (*ExportEstimate, error) {
+	defer func() {
+		_ = re.client.Close()
+	}()
+
+	if err := re.requireLiveServer("estimate"); err != nil {
+		return nil, err
+	}
+	if err := re.requireSingleSource("estimate"); err != nil {
+		return nil, err
+	}
+	if err := re.requireValueColumn(); err != nil {
+		return nil, err
+	}
+
+	sample := re.estimateSample
+	if sample <= 0 {
+		sample = defaultEstimateSample
+	}
+
+	tempDir, err := os.MkdirTemp("", "redis_dumper_estimate")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create estimate directory: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			fmt.Printf("Warning: failed to remove estimate directory: %v\n", err)
+		}
+	}()
+
+	// Write the sample with the real configuration, minus FIFO streaming
+	config := re.fileManager.config
+	config.OutputDir = tempDir
+	config.Stream = false
+	output := re.fileManager
+	re.fileManager = NewFileManager(config)
+	defer func() {
+		re.fileManager = output
+	}()
+
+	totalKeys, err := dbSize(re.ctx, re.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DBSIZE: %w", err)
+	}
+
+	re.verbosity.infof("Sampling up to %d keys matching %s...\n", sample, pattern)
+
+	estimate := &ExportEstimate{TotalKeys: totalKeys}
+	startedAt := time.Now()
+
+	err = re.scanBatches(pattern, func(keys []string) error {
+		idle := re.keyIdleSeconds(keys)
+		for _, key := range keys {
+			if err := re.exportKey(key, idle[key]); err != nil {
+				log.Printf("Error exporting key %s: %v", key, err)
+				continue
+			}
+			estimate.SampledKeys++
+			if estimate.SampledKeys >= int64(sample) {
+				return errSampleComplete
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errSampleComplete) {
+		return nil, err
+	}
+
+	// Closing rotates the partitions, so the sample includes the final COPY
+	if err := re.fileManager.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish estimate sample: %w", err)
+	}
+	estimate.SampleDuration = time.Since(startedAt)
+	for _, partition := range re.fileManager.metadata.Partitions {
+		estimate.SampleBytes += partition.FileSizeBytes
+	}
+
+	// An exhausted scan means the sample was the whole export
+	if !errors.Is(err, errSampleComplete) {
+		estimate.TotalKeys = estimate.SampledKeys
+	}
+	extrapolateEstimate(estimate)
+
+	fmt.Printf("Sampled %d keys in %s (%.0f keys/sec, %d bytes of %s)\n",
+		estimate.SampledKeys, estimate.SampleDuration.Round(time.Millisecond),
+		estimate.KeysPerSecond, estimate.SampleBytes, config.Format)
+	fmt.Printf("Keys to export: %d\n", estimate.TotalKeys)
+	fmt.Printf("Estimated export time: %s\n", estimate.EstimatedDuration.Round(time.Second))
+	fmt.Printf("Estimated output size: %d bytes (%.1f MiB)\n",
+		estimate.EstimatedBytes, float64(estimate.EstimatedBytes)/(1<<20))
+	if pattern != "*" && errors.Is(err, errSampleComplete) {
+		fmt.Println("Note: DBSIZE counts every key, so estimates for a pattern are an upper bound")
+	}
+
+	return estimate, nil
+}
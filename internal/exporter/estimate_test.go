@@ -0,0 +1,60 @@
+package exporter
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestExtrapolateEstimate(t *testing.T) {
+	estimate := &ExportEstimate{
+		SampledKeys:    100,
+		SampleDuration: 2 * time.Second,
+		SampleBytes:    5000,
+		TotalKeys:      10000,
+	}
+	extrapolateEstimate(estimate)
+
+	if estimate.KeysPerSecond != 50 {
+		t.Errorf("Expected 50 keys/sec, got %f", estimate.KeysPerSecond)
+	}
+	if estimate.EstimatedDuration != 200*time.Second {
+		t.Errorf("Expected 200s, got %s", estimate.EstimatedDuration)
+	}
+	if estimate.EstimatedBytes != 500000 {
+		t.Errorf("Expected 500000 bytes, got %d", estimate.EstimatedBytes)
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{EstimateSample: 10, BatchSize: 4})
+
+	for i := 0; i < 50; i++ {
+		if err := mr.Set(fmt.Sprintf("key:%02d", i), "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outputDir := exp.fileManager.config.OutputDir
+	estimate, err := exp.Estimate("*")
+	if err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+
+	if estimate.SampledKeys != 10 || estimate.TotalKeys != 50 {
+		t.Errorf("Expected 10 of 50 keys sampled, got %d of %d", estimate.SampledKeys, estimate.TotalKeys)
+	}
+	if estimate.SampleBytes == 0 || estimate.EstimatedBytes != estimate.SampleBytes*5 {
+		t.Errorf("Expected output size scaled 5x from %d, got %d", estimate.SampleBytes, estimate.EstimatedBytes)
+	}
+
+	// The sample never reaches the real output directory
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected empty output directory, got %d entries", len(entries))
+	}
+}
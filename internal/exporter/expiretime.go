@@ -0,0 +1,47 @@
+package exporter
+
+import (
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyExpiryTimes pipelines PEXPIRETIME (Redis 7+) for a batch of keys when
+// writing expires_at. Keys without an expiry, deleted since SCAN or whose
+// lookup failed are left out, so their expiry is derived from the TTL.
+func (re *RedisExporter) keyExpiryTimes(keys []string) map[string]int64 {
+	if !re.fileManager.config.IncludeExpiresAt || re.expireTimeUnsupported {
+		return nil
+	}
+
+	pipe := re.client.Pipeline()
+	cmds := make(map[string]*redis.DurationCmd, len(keys))
+	for _, key := range keys {
+		cmds[key] = pipe.PExpireTime(re.ctx, key)
+	}
+
+	ctx, cancel := re.batchContext(re.ctx)
+	defer cancel()
+	// Per-command errors are checked below
+	_, _ = pipe.Exec(ctx)
+
+	expiries := make(map[string]int64, len(keys))
+	for key, cmd := range cmds {
+		expireAt, err := cmd.Result()
+		if isUnknownCommandError(err) {
+			re.expireTimeUnsupported = true
+			fmt.Printf("Warning: PEXPIRETIME is not supported by the server; expires_at is computed from the TTL instead\n")
+			return nil
+		}
+		if err != nil {
+			re.verbosity.debugf("PEXPIRETIME failed for %s: %v\n", key, err)
+			continue
+		}
+		// go-redis passes -1 (no expiry) and -2 (deleted) through unscaled,
+		// so they round to 0 milliseconds
+		if ms := expireAt.Milliseconds(); ms > 0 {
+			expiries[key] = ms
+		}
+	}
+	return expiries
+}
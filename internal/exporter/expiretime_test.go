@@ -0,0 +1,87 @@
+package exporter
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// expiresAtByKey exports keys only and returns the header and the expires_at
+// value of each key
+func expiresAtByKey(t *testing.T, exp *RedisExporter) ([]string, map[string]string) {
+	t.Helper()
+
+	if err := exp.ExportKeysOnly(); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	rows := readCSVRows(t, exp.fileManager.config.OutputDir)
+	if len(rows) == 0 {
+		t.Fatal("Expected a header row")
+	}
+	got := make(map[string]string)
+	for _, row := range rows[1:] {
+		got[row[0]] = row[4]
+	}
+	return rows[0], got
+}
+
+// checkExpiresAt fails unless value is an RFC 3339 time within a few seconds
+// of want
+func checkExpiresAt(t *testing.T, value string, want time.Time) {
+	t.Helper()
+
+	expiresAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("Expected an RFC 3339 expires_at, got %q: %v", value, err)
+	}
+	if d := expiresAt.Sub(want); d < -5*time.Second || d > 5*time.Second {
+		t.Errorf("Expected expires_at near %s, got %s", want, expiresAt)
+	}
+}
+
+func TestIncludeExpiresAt(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{IncludeExpiresAt: true})
+	mr.Set("session", "abc")
+	mr.SetTTL("session", time.Hour)
+	mr.Set("config", "x")
+
+	header, got := expiresAtByKey(t, exp)
+	if header[3] != "ttl_seconds" || header[4] != "expires_at" {
+		t.Fatalf("Expected expires_at after ttl_seconds, got headers %v", header)
+	}
+	checkExpiresAt(t, got["session"], time.Now().Add(time.Hour))
+	// Keys without an expiry are NULL
+	if got["config"] != "" {
+		t.Errorf("Expected an empty expires_at for config, got %q", got["config"])
+	}
+}
+
+func TestExpiresAtWithoutPExpireTime(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{IncludeExpiresAt: true})
+	mr.Set("session", "abc")
+	mr.SetTTL("session", 10*time.Minute)
+	calls := &atomic.Int64{}
+	exp.client.AddHook(unknownCommandHook{name: "pexpiretime", calls: calls})
+
+	_, got := expiresAtByKey(t, exp)
+	if calls.Load() == 0 {
+		t.Fatal("Expected PEXPIRETIME to be tried")
+	}
+	// Computed from the TTL instead
+	checkExpiresAt(t, got["session"], time.Now().Add(10*time.Minute))
+}
+
+func TestExpiresAtRequiresTTL(t *testing.T) {
+	mr := miniredis.RunT(t)
+	_, err := NewRedisExporter(RedisExporterOptions{
+		RedisURL:         "redis://" + mr.Addr(),
+		OutputDir:        t.TempDir(),
+		IncludeExpiresAt: true,
+		SkipTTL:          true,
+	})
+	if err == nil {
+		t.Error("Expected INCLUDE_EXPIRES_AT with SKIP_TTL to be rejected")
+	}
+}
@@ -1,8 +1,21 @@
 package exporter
 
+import (
+	"context"
+	"io"
+)
+
 type Exporter interface {
 	ExportKeysOnly() error
 	ExportKeysOnlyByPattern(pattern string) error
 	ExportByPattern(pattern string) error
+	ExportNamespaces(pattern string) error
+	ExportBigKeys(pattern string) error
+	ExportSample(pattern string) error
+	ExportDump(pattern string) error
+	ExportKeyList(pattern string, out io.Writer) (int64, error)
+	Watch(ctx context.Context, pattern string) error
+	Poll(ctx context.Context, pattern string) error
+	Estimate(pattern string) (*ExportEstimate, error)
 	Close() error
 }
@@ -0,0 +1,71 @@
+//go:build unix
+
+package exporter
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestFIFOOutput(t *testing.T) {
+	fifoPath := filepath.Join(t.TempDir(), "export.fifo")
+	if err := syscall.Mkfifo(fifoPath, 0600); err != nil {
+		t.Skipf("mkfifo unsupported: %v", err)
+	}
+
+	// Read the stream concurrently - opening the FIFO blocks until both ends exist
+	rowsCh := make(chan [][]string, 1)
+	errCh := make(chan error, 1)
+	go func() {
+		file, err := os.Open(fifoPath)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+
+		rows, err := csv.NewReader(file).ReadAll()
+		if err != nil {
+			errCh <- err
+			return
+		}
+		rowsCh <- rows
+	}()
+
+	exp, mr := newTestExporter(t, RedisExporterOptions{OutputDir: fifoPath, MaxRecordsPerFile: 1})
+	if !exp.fileManager.config.Stream {
+		t.Fatal("Expected FIFO output dir to enable streaming")
+	}
+
+	mr.Set("key:1", "a")
+	mr.Set("key:2", "b")
+	mr.Set("key:3", "c")
+
+	if err := exp.ExportKeysOnly(); err != nil {
+		t.Fatalf("ExportKeysOnly failed: %v", err)
+	}
+
+	var rows [][]string
+	select {
+	case rows = <-rowsCh:
+	case err := <-errCh:
+		t.Fatalf("Failed to read FIFO: %v", err)
+	}
+
+	// One header and every record in a single stream despite MaxRecordsPerFile
+	if len(rows) != 4 {
+		t.Fatalf("Expected header and 3 records, got %d rows", len(rows))
+	}
+	if rows[0][0] != "key" {
+		t.Errorf("Expected header row first, got %v", rows[0])
+	}
+
+	if len(exp.fileManager.metadata.Partitions) != 0 {
+		t.Errorf("Expected no partition metadata, got %d", len(exp.fileManager.metadata.Partitions))
+	}
+}
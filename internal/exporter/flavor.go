@@ -0,0 +1,103 @@
+package exporter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Server flavors told apart by INFO server. Forks report a redis_version for
+// client compatibility that says little about what they implement.
+const (
+	FlavorRedis     = "redis"
+	FlavorValkey    = "valkey"
+	FlavorKeyDB     = "keydb"
+	FlavorDragonfly = "dragonfly"
+)
+
+// detectFlavor returns the server flavor and, for forks, their own version
+func detectFlavor(fields map[string]string) (string, string) {
+	switch {
+	case fields["dragonfly_version"] != "":
+		return FlavorDragonfly, strings.TrimPrefix(fields["dragonfly_version"], "df-")
+	case fields["valkey_version"] != "" || fields["server_name"] == FlavorValkey:
+		return FlavorValkey, fields["valkey_version"]
+	case strings.Contains(fields["executable"], "keydb"):
+		return FlavorKeyDB, ""
+	default:
+		return FlavorRedis, ""
+	}
+}
+
+// serverSupport lists the optional commands a server flavor implements
+type serverSupport struct {
+	httl           bool
+	objectIdleTime bool
+}
+
+// supportFor returns what the server in info implements. HTTL arrived in
+// Redis 7.4 and Valkey 9.0; Dragonfly has neither HTTL nor OBJECT IDLETIME.
+// Anything not ruled out here is still detected when the server rejects it.
+func supportFor(info *ServerInfo) serverSupport {
+	support := serverSupport{httl: true, objectIdleTime: true}
+	if info == nil {
+		return support
+	}
+
+	switch info.Flavor {
+	case FlavorDragonfly:
+		support.httl = false
+		support.objectIdleTime = false
+	case FlavorValkey:
+		support.httl = versionAtLeast(info.FlavorVersion, 9, 0)
+	default:
+		support.httl = versionAtLeast(info.RedisVersion, 7, 4)
+	}
+	return support
+}
+
+// versionAtLeast compares the major and minor parts of a dotted version. An
+// unparseable version is assumed to be recent.
+func versionAtLeast(version string, major, minor int) bool {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return true
+	}
+	gotMajor, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return true
+	}
+	gotMinor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return true
+	}
+	return gotMajor > major || (gotMajor == major && gotMinor >= minor)
+}
+
+// applyServerSupport turns off the optional commands server lacks, so they
+// are never sent rather than failing part-way through the export
+func (re *RedisExporter) applyServerSupport(server *ServerInfo) {
+	re.httlUnsupported = false
+	re.idleTimeUnsupported = false
+	re.memoryUsageUnsupported = false
+
+	support := supportFor(server)
+	if !support.httl {
+		re.httlUnsupported = true
+		re.verbosity.debugf("HTTL not supported by %s %s, exporting hash fields without TTLs\n", server.Flavor, serverVersion(server))
+	}
+	if !support.objectIdleTime {
+		re.idleTimeUnsupported = true
+		if re.fileManager.config.PartitionBy == PartitionByAge {
+			fmt.Printf("Warning: %s does not support OBJECT IDLETIME; every key lands in the %s partition\n", server.Flavor, ageUnknownBucket)
+		}
+	}
+}
+
+// serverVersion is the flavor's own version, or the Redis version
+func serverVersion(server *ServerInfo) string {
+	if server.FlavorVersion != "" {
+		return server.FlavorVersion
+	}
+	return server.RedisVersion
+}
@@ -0,0 +1,127 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestDetectFlavor(t *testing.T) {
+	tests := []struct {
+		name          string
+		info          string
+		flavor        string
+		flavorVersion string
+	}{
+		{"redis", "redis_version:7.2.4\r\nexecutable:/usr/local/bin/redis-server\r\n", FlavorRedis, ""},
+		{"valkey", "redis_version:7.2.4\r\nserver_name:valkey\r\nvalkey_version:8.1.1\r\n", FlavorValkey, "8.1.1"},
+		{"keydb", "redis_version:6.3.4\r\nexecutable:/usr/local/bin/keydb-server\r\n", FlavorKeyDB, ""},
+		{"dragonfly", "redis_version:7.2.0\r\ndragonfly_version:df-v1.27.1\r\n", FlavorDragonfly, "v1.27.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := parseServerInfo(tt.info)
+			if server.Flavor != tt.flavor || server.FlavorVersion != tt.flavorVersion {
+				t.Errorf("Expected %s %q, got %s %q", tt.flavor, tt.flavorVersion, server.Flavor, server.FlavorVersion)
+			}
+		})
+	}
+}
+
+func TestSupportFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		server   *ServerInfo
+		expected serverSupport
+	}{
+		{"unknown", nil, serverSupport{httl: true, objectIdleTime: true}},
+		{"redis 7.4", &ServerInfo{Flavor: FlavorRedis, RedisVersion: "7.4.1"}, serverSupport{httl: true, objectIdleTime: true}},
+		{"redis 7.2", &ServerInfo{Flavor: FlavorRedis, RedisVersion: "7.2.4"}, serverSupport{httl: false, objectIdleTime: true}},
+		{"valkey 8", &ServerInfo{Flavor: FlavorValkey, RedisVersion: "7.2.4", FlavorVersion: "8.1.1"}, serverSupport{httl: false, objectIdleTime: true}},
+		{"valkey 9", &ServerInfo{Flavor: FlavorValkey, RedisVersion: "7.2.4", FlavorVersion: "9.0.0"}, serverSupport{httl: true, objectIdleTime: true}},
+		{"keydb", &ServerInfo{Flavor: FlavorKeyDB, RedisVersion: "6.3.4"}, serverSupport{httl: false, objectIdleTime: true}},
+		{"dragonfly", &ServerInfo{Flavor: FlavorDragonfly, RedisVersion: "7.2.0", FlavorVersion: "v1.27.1"}, serverSupport{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := supportFor(tt.server); got != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// unknownCommandHook rejects commands named name as a fork lacking them would
+type unknownCommandHook struct {
+	passHook
+	name  string
+	calls *atomic.Int64
+}
+
+func (h unknownCommandHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		rejected := false
+		for _, cmd := range cmds {
+			if cmd.Name() == h.name {
+				h.calls.Add(1)
+				cmd.SetErr(serverError("ERR Unknown subcommand or wrong number of arguments for '" + h.name + "'"))
+				rejected = true
+			}
+		}
+		if rejected {
+			return nil
+		}
+		return next(ctx, cmds)
+	}
+}
+
+func TestUnsupportedIdleTime(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{PartitionBy: "age", BatchSize: 2})
+	for i := 0; i < 6; i++ {
+		mr.Set(fmt.Sprintf("key:%d", i), "value")
+	}
+	calls := &atomic.Int64{}
+	exp.client.AddHook(unknownCommandHook{name: "object", calls: calls})
+
+	outputDir := exp.fileManager.config.OutputDir
+	if err := exp.ExportKeysOnly(); err != nil {
+		t.Fatalf("ExportKeysOnly failed: %v", err)
+	}
+
+	// The first rejected batch stops OBJECT IDLETIME for the rest of the export
+	if !exp.idleTimeUnsupported || calls.Load() > 2 {
+		t.Errorf("Expected OBJECT IDLETIME to be given up after one batch, sent %d", calls.Load())
+	}
+	for _, file := range findDataFiles(t, outputDir, ".csv") {
+		if dir := filepath.Base(filepath.Dir(file)); dir != ageUnknownBucket {
+			t.Errorf("Expected every key in %s, got %s", ageUnknownBucket, dir)
+		}
+	}
+}
+
+func TestUnsupportedMemoryUsage(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{MinSizeBytes: 1024, BatchSize: 2})
+	for i := 0; i < 6; i++ {
+		mr.Set(fmt.Sprintf("key:%d", i), "value")
+	}
+	calls := &atomic.Int64{}
+	exp.client.AddHook(unknownCommandHook{name: "memory", calls: calls})
+
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	// Without sizes nothing is filtered out
+	if rows := readCSVRows(t, exp.fileManager.config.OutputDir); len(rows)-1 != 6 {
+		t.Errorf("Expected all 6 keys to be kept, got %d", len(rows)-1)
+	}
+	if !exp.memoryUsageUnsupported || calls.Load() > 2 {
+		t.Errorf("Expected MEMORY USAGE to be given up after one batch, sent %d", calls.Load())
+	}
+}
@@ -0,0 +1,83 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseHashFields cleans a HASH_FIELDS list, dropping blanks and duplicates
+func parseHashFields(fields []string) []string {
+	var parsed []string
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" || seen[field] {
+			continue
+		}
+		seen[field] = true
+		parsed = append(parsed, field)
+	}
+	return parsed
+}
+
+// hashFieldSelected reports whether HASH_FIELDS is unset or names field
+func (re *RedisExporter) hashFieldSelected(field string) bool {
+	if len(re.hashFields) == 0 {
+		return true
+	}
+	for _, selected := range re.hashFields {
+		if selected == field {
+			return true
+		}
+	}
+	return false
+}
+
+// exportHashFields exports only the HASH_FIELDS of a hash with one HMGET
+// instead of HSCAN. Fields the hash lacks are left out, and the returned
+// size counts the selected fields only.
+func (re *RedisExporter) exportHashFields(key string, slot int, idleSeconds int64, timestamp string) (int64, error) {
+	values, err := re.client.HMGet(re.ctx, key, re.hashFields...).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	fields := make([]string, 0, len(values))
+	present := make([]string, 0, len(values))
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		fields = append(fields, re.hashFields[i])
+		present = append(present, fmt.Sprint(value))
+	}
+
+	fieldTTLs, err := re.hashFieldTTLs(key, fields)
+	if err != nil {
+		return 0, err
+	}
+
+	totalSize := int64(0)
+	for i, field := range fields {
+		ttlSeconds := ttlNoExpiry
+		if fieldTTLs != nil {
+			ttlSeconds = fieldTTLs[i]
+		}
+		record := &RedisRecord{
+			Key:        fmt.Sprintf("%s:field:%s", key, field),
+			Type:       "hash_field",
+			Value:      present[i],
+			TTLSeconds: ttlSeconds,
+			ExportedAt: timestamp,
+			Slot:       slot,
+
+			IdleSeconds: idleSeconds,
+			ParentKey:   key,
+		}
+		if err := re.fileManager.WriteRecord(record); err != nil {
+			return 0, err
+		}
+		totalSize += int64(len(field) + len(present[i]))
+	}
+	return totalSize, nil
+}
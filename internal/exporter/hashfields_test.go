@@ -0,0 +1,46 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseHashFields(t *testing.T) {
+	got := parseHashFields([]string{" email", "", "created_at", "email"})
+	if strings.Join(got, ",") != "email,created_at" {
+		t.Errorf("Expected email,created_at, got %v", got)
+	}
+}
+
+func TestExportHashFields(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{HashFields: []string{"email", "created_at"}})
+	mr.HSet("user:1", "name", "alice", "email", "a@example.com", "created_at", "2024-01-01")
+	mr.HSet("user:2", "name", "bob", "email", "b@example.com")
+	mr.HSet("user:3", "name", "carol")
+
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	values := make(map[string]string)
+	for _, row := range readCSVRows(t, exp.fileManager.config.OutputDir)[1:] {
+		values[row[0]] = row[2]
+	}
+	expected := map[string]string{
+		"user:1:field:email":      "a@example.com",
+		"user:1:field:created_at": "2024-01-01",
+		"user:2:field:email":      "b@example.com",
+		// Sizes count the selected fields only
+		"user:1": "size=38",
+		"user:2": "size=18",
+		"user:3": "size=0",
+	}
+	if len(values) != len(expected) {
+		t.Errorf("Expected %d records, got %v", len(expected), values)
+	}
+	for key, want := range expected {
+		if values[key] != want {
+			t.Errorf("Expected %s = %q, got %q", key, want, values[key])
+		}
+	}
+}
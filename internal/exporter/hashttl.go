@@ -0,0 +1,52 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// hashFieldTTLs returns the TTL in seconds of each field via HTTL (Redis
+// 7.4+), -1 for fields without one and -2 for fields deleted since HSCAN. On servers without HTTL it returns nil
+// and stops asking for the rest of the export. SkipTTL never asks.
+func (re *RedisExporter) hashFieldTTLs(key string, fields []string) ([]int64, error) {
+	if re.skipTTL || re.httlUnsupported || len(fields) == 0 {
+		return nil, nil
+	}
+
+	args := make([]interface{}, 0, len(fields)+4)
+	args = append(args, "HTTL", key, "FIELDS", len(fields))
+	for _, field := range fields {
+		args = append(args, field)
+	}
+
+	ttls, err := re.client.Do(re.ctx, args...).Int64Slice()
+	if err != nil {
+		if isUnknownCommandError(err) {
+			re.httlUnsupported = true
+			re.verbosity.debugf("HTTL not supported, exporting hash fields without TTLs\n")
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get field TTLs for key %s: %w", key, err)
+	}
+	if len(ttls) != len(fields) {
+		return nil, fmt.Errorf("HTTL returned %d TTLs for %d fields of key %s", len(ttls), len(fields), key)
+	}
+
+	// -2 means the field vanished since HSCAN and is written as such
+	for _, value := range ttls {
+		if _, err := ParseTTL(value); err != nil {
+			return nil, fmt.Errorf("failed to get field TTLs for key %s: %w", key, err)
+		}
+	}
+	return ttls, nil
+}
+
+// isUnknownCommandError reports whether Redis rejected a command or
+// subcommand it does not implement
+func isUnknownCommandError(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "unknown command") || strings.Contains(message, "unknown subcommand")
+}
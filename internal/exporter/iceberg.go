@@ -0,0 +1,315 @@
+package exporter
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	icebergMetadataDir = "metadata"
+	// icebergBlockSize fills the v1 block_size_in_bytes field, which readers ignore
+	icebergBlockSize = 64 * 1024 * 1024
+)
+
+// icebergManifestEntrySchema is the Iceberg v1 manifest entry for an
+// unpartitioned table, annotated with the field IDs readers resolve by
+const icebergManifestEntrySchema = `{"type":"record","name":"manifest_entry","fields":[` +
+	`{"name":"status","type":"int","field-id":0},` +
+	`{"name":"snapshot_id","type":"long","field-id":1},` +
+	`{"name":"data_file","type":{"type":"record","name":"r2","fields":[` +
+	`{"name":"file_path","type":"string","field-id":100},` +
+	`{"name":"file_format","type":"string","field-id":101},` +
+	`{"name":"partition","type":{"type":"record","name":"r102","fields":[]},"field-id":102},` +
+	`{"name":"record_count","type":"long","field-id":103},` +
+	`{"name":"file_size_in_bytes","type":"long","field-id":104},` +
+	`{"name":"block_size_in_bytes","type":"long","field-id":105}` +
+	`]},"field-id":2}]}`
+
+// icebergManifestFileSchema is the Iceberg v1 manifest list entry
+const icebergManifestFileSchema = `{"type":"record","name":"manifest_file","fields":[` +
+	`{"name":"manifest_path","type":"string","field-id":500},` +
+	`{"name":"manifest_length","type":"long","field-id":501},` +
+	`{"name":"partition_spec_id","type":"int","field-id":502},` +
+	`{"name":"added_snapshot_id","type":["null","long"],"default":null,"field-id":503},` +
+	`{"name":"added_data_files_count","type":["null","int"],"default":null,"field-id":504},` +
+	`{"name":"existing_data_files_count","type":["null","int"],"default":null,"field-id":505},` +
+	`{"name":"deleted_data_files_count","type":["null","int"],"default":null,"field-id":506},` +
+	`{"name":"added_rows_count","type":["null","long"],"default":null,"field-id":512},` +
+	`{"name":"existing_rows_count","type":["null","long"],"default":null,"field-id":513},` +
+	`{"name":"deleted_rows_count","type":["null","long"],"default":null,"field-id":514}]}`
+
+// IcebergField is a column of the Iceberg table schema
+type IcebergField struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Required bool   `json:"required"`
+	Type     string `json:"type"`
+}
+
+// IcebergSchema is the Iceberg table schema derived from the export columns
+type IcebergSchema struct {
+	Type     string         `json:"type"`
+	SchemaID int            `json:"schema-id"`
+	Fields   []IcebergField `json:"fields"`
+}
+
+// icebergNameMapping is an entry of the schema.name-mapping.default property.
+// DuckDB writes Parquet without field IDs, so readers resolve columns by name.
+type icebergNameMapping struct {
+	FieldID int      `json:"field-id"`
+	Names   []string `json:"names"`
+}
+
+type icebergPartitionSpec struct {
+	SpecID int        `json:"spec-id"`
+	Fields []struct{} `json:"fields"`
+}
+
+type icebergSortOrder struct {
+	OrderID int        `json:"order-id"`
+	Fields  []struct{} `json:"fields"`
+}
+
+type icebergSnapshot struct {
+	SnapshotID   int64             `json:"snapshot-id"`
+	TimestampMs  int64             `json:"timestamp-ms"`
+	Summary      map[string]string `json:"summary"`
+	ManifestList string            `json:"manifest-list"`
+	SchemaID     int               `json:"schema-id"`
+}
+
+type icebergSnapshotLogEntry struct {
+	TimestampMs int64 `json:"timestamp-ms"`
+	SnapshotID  int64 `json:"snapshot-id"`
+}
+
+// IcebergTableMetadata is the v1 table metadata file
+type IcebergTableMetadata struct {
+	FormatVersion      int                       `json:"format-version"`
+	TableUUID          string                    `json:"table-uuid"`
+	Location           string                    `json:"location"`
+	LastUpdatedMs      int64                     `json:"last-updated-ms"`
+	LastColumnID       int                       `json:"last-column-id"`
+	Schema             IcebergSchema             `json:"schema"`
+	Schemas            []IcebergSchema           `json:"schemas"`
+	CurrentSchemaID    int                       `json:"current-schema-id"`
+	PartitionSpec      []struct{}                `json:"partition-spec"`
+	PartitionSpecs     []icebergPartitionSpec    `json:"partition-specs"`
+	DefaultSpecID      int                       `json:"default-spec-id"`
+	LastPartitionID    int                       `json:"last-partition-id"`
+	Properties         map[string]string         `json:"properties"`
+	CurrentSnapshotID  int64                     `json:"current-snapshot-id"`
+	Snapshots          []icebergSnapshot         `json:"snapshots"`
+	SnapshotLog        []icebergSnapshotLogEntry `json:"snapshot-log"`
+	MetadataLog        []struct{}                `json:"metadata-log"`
+	SortOrders         []icebergSortOrder        `json:"sort-orders"`
+	DefaultSortOrderID int                       `json:"default-sort-order-id"`
+}
+
+// icebergSchema maps the active export columns onto Iceberg types
+func (fm *FileManager) icebergSchema() IcebergSchema {
+	cols := fm.columns()
+	fields := make([]IcebergField, len(cols))
+	for i, col := range cols {
+		fieldType := "string"
+		switch col.SQLType {
+		case "BIGINT":
+			fieldType = "long"
+		case "INTEGER":
+			fieldType = "int"
+		case "BOOLEAN":
+			fieldType = "boolean"
+		}
+		fields[i] = IcebergField{ID: i + 1, Name: col.Name, Type: fieldType}
+	}
+	return IcebergSchema{Type: "struct", SchemaID: 0, Fields: fields}
+}
+
+// nameMapping encodes the schema.name-mapping.default property for schema
+func (schema IcebergSchema) nameMapping() (string, error) {
+	mapping := make([]icebergNameMapping, len(schema.Fields))
+	for i, field := range schema.Fields {
+		mapping[i] = icebergNameMapping{FieldID: field.ID, Names: []string{field.Name}}
+	}
+	data, err := json.Marshal(mapping)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode Iceberg name mapping: %w", err)
+	}
+	return string(data), nil
+}
+
+// tableLocation is where readers will find the table: the published QueryURI
+// when set, otherwise the absolute output directory
+func (fm *FileManager) tableLocation() (string, error) {
+	if fm.config.QueryURI != "" {
+		return strings.TrimRight(fm.config.QueryURI, "/"), nil
+	}
+
+	abs, err := filepath.Abs(fm.config.OutputDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve output directory: %w", err)
+	}
+	return filepath.ToSlash(abs), nil
+}
+
+// writeIcebergMetadata registers the Parquet partitions written so far as a
+// single append snapshot of an unpartitioned Iceberg v1 table. Hive directory
+// values are not columns in the data files, so they cannot back a partition spec.
+func (fm *FileManager) writeIcebergMetadata() error {
+	location, err := fm.tableLocation()
+	if err != nil {
+		return err
+	}
+
+	metadataDir := filepath.Join(fm.config.OutputDir, icebergMetadataDir)
+	if err := os.MkdirAll(metadataDir, 0755); err != nil {
+		return fmt.Errorf("failed to create Iceberg metadata directory: %w", err)
+	}
+
+	snapshotID, err := randomPositiveInt64()
+	if err != nil {
+		return err
+	}
+	tableUUID, err := randomUUID()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UnixMilli()
+
+	schema := fm.icebergSchema()
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return fmt.Errorf("failed to encode Iceberg schema: %w", err)
+	}
+
+	// Manifest listing every data file as added in this snapshot
+	entries := make([][]byte, 0, len(fm.metadata.Partitions))
+	var totalRecords int64
+	for _, partition := range fm.metadata.Partitions {
+		var e avroEncoder
+		e.writeLong(1) // status: ADDED
+		e.writeLong(snapshotID)
+		e.writeString(location + "/" + filepath.ToSlash(partition.Path))
+		e.writeString("PARQUET")
+		e.writeLong(partition.RecordCount)
+		e.writeLong(partition.FileSizeBytes)
+		e.writeLong(icebergBlockSize)
+		entries = append(entries, e.buf.Bytes())
+		totalRecords += partition.RecordCount
+	}
+
+	manifestName := fmt.Sprintf("%s-m0.avro", tableUUID)
+	manifestLength, err := writeAvroFile(filepath.Join(metadataDir, manifestName), icebergManifestEntrySchema,
+		map[string]string{
+			"schema":            string(schemaJSON),
+			"schema-id":         "0",
+			"partition-spec":    "[]",
+			"partition-spec-id": "0",
+			"format-version":    "1",
+		}, entries)
+	if err != nil {
+		return err
+	}
+
+	// Manifest list pointing at the single manifest
+	var manifestFile avroEncoder
+	manifestFile.writeString(location + "/" + icebergMetadataDir + "/" + manifestName)
+	manifestFile.writeLong(manifestLength)
+	manifestFile.writeLong(0) // partition_spec_id
+	manifestFile.writeOptionalLong(snapshotID)
+	manifestFile.writeOptionalLong(int64(len(entries)))
+	manifestFile.writeOptionalLong(0)
+	manifestFile.writeOptionalLong(0)
+	manifestFile.writeOptionalLong(totalRecords)
+	manifestFile.writeOptionalLong(0)
+	manifestFile.writeOptionalLong(0)
+
+	manifestListName := fmt.Sprintf("snap-%d-1-%s.avro", snapshotID, tableUUID)
+	if _, err := writeAvroFile(filepath.Join(metadataDir, manifestListName), icebergManifestFileSchema,
+		map[string]string{
+			"snapshot-id":    fmt.Sprint(snapshotID),
+			"format-version": "1",
+		}, [][]byte{manifestFile.buf.Bytes()}); err != nil {
+		return err
+	}
+
+	nameMapping, err := schema.nameMapping()
+	if err != nil {
+		return err
+	}
+
+	table := IcebergTableMetadata{
+		FormatVersion:   1,
+		TableUUID:       tableUUID,
+		Location:        location,
+		LastUpdatedMs:   now,
+		LastColumnID:    len(schema.Fields),
+		Schema:          schema,
+		Schemas:         []IcebergSchema{schema},
+		CurrentSchemaID: 0,
+		PartitionSpec:   []struct{}{},
+		PartitionSpecs:  []icebergPartitionSpec{{SpecID: 0, Fields: []struct{}{}}},
+		DefaultSpecID:   0,
+		LastPartitionID: 999,
+		Properties: map[string]string{
+			"redis-dumper.export-id":      fm.metadata.ExportID,
+			"schema.name-mapping.default": nameMapping,
+		},
+		CurrentSnapshotID: snapshotID,
+		Snapshots: []icebergSnapshot{{
+			SnapshotID:  snapshotID,
+			TimestampMs: now,
+			Summary: map[string]string{
+				"operation":        "append",
+				"added-data-files": fmt.Sprint(len(entries)),
+				"added-records":    fmt.Sprint(totalRecords),
+			},
+			ManifestList: location + "/" + icebergMetadataDir + "/" + manifestListName,
+			SchemaID:     0,
+		}},
+		SnapshotLog:        []icebergSnapshotLogEntry{{TimestampMs: now, SnapshotID: snapshotID}},
+		MetadataLog:        []struct{}{},
+		SortOrders:         []icebergSortOrder{{OrderID: 0, Fields: []struct{}{}}},
+		DefaultSortOrderID: 0,
+	}
+
+	data, err := json.MarshalIndent(table, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode Iceberg table metadata: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(metadataDir, "v1.metadata.json"), data, 0644); err != nil {
+		return fmt.Errorf("failed to write Iceberg table metadata: %w", err)
+	}
+
+	// Lets Hadoop-style catalogs find the current metadata version
+	if err := os.WriteFile(filepath.Join(metadataDir, "version-hint.text"), []byte("1"), 0644); err != nil {
+		return fmt.Errorf("failed to write Iceberg version hint: %w", err)
+	}
+
+	return nil
+}
+
+func randomPositiveInt64() (int64, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, fmt.Errorf("failed to generate snapshot ID: %w", err)
+	}
+	return int64(binary.BigEndian.Uint64(b[:]) >> 1), nil
+}
+
+// randomUUID returns a version 4 UUID
+func randomUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate table UUID: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
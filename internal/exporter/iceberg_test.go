@@ -0,0 +1,177 @@
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// avroTestReader decodes the subset of Avro the Iceberg metadata uses
+type avroTestReader struct {
+	r *bufio.Reader
+	t *testing.T
+}
+
+func (a *avroTestReader) long() int64 {
+	u, err := binary.ReadUvarint(a.r)
+	if err != nil {
+		a.t.Fatalf("Failed to read Avro long: %v", err)
+	}
+	return int64(u>>1) ^ -int64(u&1)
+}
+
+func (a *avroTestReader) str() string {
+	b := make([]byte, a.long())
+	if _, err := a.r.Read(b); err != nil && len(b) > 0 {
+		a.t.Fatalf("Failed to read Avro string: %v", err)
+	}
+	return string(b)
+}
+
+// readAvroFile returns the file metadata and a reader positioned at the first record
+func readAvroFile(t *testing.T, path string) (map[string]string, *avroTestReader, int64) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, avroMagic) {
+		t.Fatalf("%s is not an Avro container file", path)
+	}
+
+	a := &avroTestReader{r: bufio.NewReader(bytes.NewReader(data[len(avroMagic):])), t: t}
+	meta := make(map[string]string)
+	for n := a.long(); n != 0; n = a.long() {
+		for i := int64(0); i < n; i++ {
+			key := a.str()
+			meta[key] = a.str()
+		}
+	}
+	if _, err := a.r.Discard(16); err != nil {
+		t.Fatal(err)
+	}
+
+	count := a.long()
+	a.long() // block size
+	return meta, a, count
+}
+
+func TestIcebergMetadata(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "redis_dumper_iceberg_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Logf("Warning: failed to remove temp dir: %v", err)
+		}
+	}()
+
+	fm := NewFileManager(StorageConfig{
+		OutputDir:       tempDir,
+		Format:          FormatParquet,
+		MaxRecords:      2,
+		IcebergMetadata: true,
+		QueryURI:        "s3://bucket/redis/",
+	})
+
+	for i := 0; i < 3; i++ {
+		record := &RedisRecord{
+			Key:        fmt.Sprintf("test:key%d", i),
+			Type:       "string",
+			Value:      "value",
+			TTLSeconds: -1,
+			ExportedAt: "2024-01-15T14:30:00Z",
+		}
+		if err := fm.WriteRecord(record); err != nil {
+			t.Fatalf("Failed to write record: %v", err)
+		}
+	}
+	if err := fm.Close(); err != nil {
+		t.Fatalf("Failed to close file manager: %v", err)
+	}
+
+	metadataDir := filepath.Join(tempDir, icebergMetadataDir)
+	data, err := os.ReadFile(filepath.Join(metadataDir, "v1.metadata.json"))
+	if err != nil {
+		t.Fatalf("Failed to read table metadata: %v", err)
+	}
+
+	var table IcebergTableMetadata
+	if err := json.Unmarshal(data, &table); err != nil {
+		t.Fatalf("Failed to decode table metadata: %v", err)
+	}
+	if table.Location != "s3://bucket/redis" {
+		t.Errorf("Expected location s3://bucket/redis, got %s", table.Location)
+	}
+	if len(table.Snapshots) != 1 || table.Snapshots[0].SnapshotID != table.CurrentSnapshotID {
+		t.Fatalf("Expected one current snapshot, got %+v", table.Snapshots)
+	}
+	if table.Schema.Fields[0].Name != "key" || table.Schema.Fields[3].Type != "long" {
+		t.Errorf("Unexpected schema: %+v", table.Schema.Fields)
+	}
+
+	// DuckDB writes no field IDs, so columns resolve through the name mapping
+	var mapping []icebergNameMapping
+	if err := json.Unmarshal([]byte(table.Properties["schema.name-mapping.default"]), &mapping); err != nil {
+		t.Fatalf("Failed to decode name mapping: %v", err)
+	}
+	if len(mapping) != len(table.Schema.Fields) {
+		t.Fatalf("Expected a mapping for each of %d fields, got %+v", len(table.Schema.Fields), mapping)
+	}
+	for i, field := range table.Schema.Fields {
+		if mapping[i].FieldID != field.ID || len(mapping[i].Names) != 1 || mapping[i].Names[0] != field.Name {
+			t.Errorf("Expected field %d mapped from %s, got %+v", field.ID, field.Name, mapping[i])
+		}
+	}
+
+	// Manifest list points at the manifest and counts every record
+	listName := strings.TrimPrefix(table.Snapshots[0].ManifestList, "s3://bucket/redis/metadata/")
+	meta, list, count := readAvroFile(t, filepath.Join(metadataDir, listName))
+	if meta["avro.schema"] != icebergManifestFileSchema || count != 1 {
+		t.Fatalf("Unexpected manifest list header: %v, %d records", meta, count)
+	}
+	manifestPath := list.str()
+	list.long() // manifest_length
+	list.long() // partition_spec_id
+	if list.long() != 1 || list.long() != table.CurrentSnapshotID {
+		t.Error("Expected manifest added by the current snapshot")
+	}
+	list.long()
+	if files := list.long(); files != 2 {
+		t.Errorf("Expected 2 added files, got %d", files)
+	}
+
+	// Manifest lists each Parquet partition
+	_, manifest, count := readAvroFile(t, filepath.Join(metadataDir, filepath.Base(manifestPath)))
+	if count != 2 {
+		t.Fatalf("Expected 2 manifest entries, got %d", count)
+	}
+	var records int64
+	for i := int64(0); i < count; i++ {
+		if status := manifest.long(); status != 1 {
+			t.Errorf("Expected ADDED status, got %d", status)
+		}
+		manifest.long() // snapshot_id
+		path := manifest.str()
+		if !strings.HasPrefix(path, "s3://bucket/redis/year=") || !strings.HasSuffix(path, ".parquet") {
+			t.Errorf("Unexpected data file path: %s", path)
+		}
+		if format := manifest.str(); format != "PARQUET" {
+			t.Errorf("Expected PARQUET, got %s", format)
+		}
+		records += manifest.long()
+		manifest.long() // file_size_in_bytes
+		manifest.long() // block_size_in_bytes
+	}
+	if records != 3 {
+		t.Errorf("Expected 3 records across data files, got %d", records)
+	}
+}
@@ -0,0 +1,74 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// loadIgnorePatterns reads glob patterns from a .gitignore-style file: one
+// pattern per line, with blank lines and lines starting with '#' skipped
+func loadIgnorePatterns(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ignore file: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Printf("Warning: failed to close ignore file: %v\n", err)
+		}
+	}()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ignore file: %w", err)
+	}
+
+	return patterns, nil
+}
+
+// parseExcludePatterns trims an EXCLUDE_PATTERNS list, dropping blanks
+func parseExcludePatterns(patterns []string) []string {
+	var parsed []string
+	for _, pattern := range patterns {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			parsed = append(parsed, pattern)
+		}
+	}
+	return parsed
+}
+
+// isIgnored reports whether key matches any ignore pattern
+func (re *RedisExporter) isIgnored(key string) bool {
+	for _, pattern := range re.ignorePatterns {
+		if matchGlob(pattern, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterIgnored drops ignored keys from a scanned batch in place and returns
+// the remaining keys and the number dropped
+func (re *RedisExporter) filterIgnored(keys []string) ([]string, int) {
+	if len(re.ignorePatterns) == 0 {
+		return keys, 0
+	}
+
+	kept := keys[:0]
+	for _, key := range keys {
+		if !re.isIgnored(key) {
+			kept = append(kept, key)
+		}
+	}
+	return kept, len(keys) - len(kept)
+}
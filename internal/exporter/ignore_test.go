@@ -0,0 +1,81 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadIgnorePatterns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ignore")
+	content := "# secrets\nsecret:*\n\n  token:*  \n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	patterns, err := loadIgnorePatterns(path)
+	if err != nil {
+		t.Fatalf("loadIgnorePatterns failed: %v", err)
+	}
+
+	if strings.Join(patterns, ",") != "secret:*,token:*" {
+		t.Errorf("Expected [secret:* token:*], got %v", patterns)
+	}
+
+	if _, err := loadIgnorePatterns(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected error for missing ignore file")
+	}
+}
+
+func TestIgnoreFileSkipsKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ignore")
+	if err := os.WriteFile(path, []byte("secret:*\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	exp, mr := newTestExporter(t, RedisExporterOptions{IgnoreFile: path})
+
+	mr.Set("user:1", "alice")
+	mr.Set("secret:1", "hunter2")
+	mr.Set("secret:2", "hunter3")
+
+	outputDir := exp.fileManager.config.OutputDir
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("ExportByPattern failed: %v", err)
+	}
+
+	for _, row := range readCSVRows(t, outputDir)[1:] {
+		if strings.HasPrefix(row[0], "secret:") {
+			t.Errorf("Expected ignored key to be skipped, got %s", row[0])
+		}
+	}
+
+	if exp.fileManager.metadata.IgnoredKeys != 2 {
+		t.Errorf("Expected 2 ignored keys, got %d", exp.fileManager.metadata.IgnoredKeys)
+	}
+}
+
+func TestExcludePatterns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ignore")
+	if err := os.WriteFile(path, []byte("secret:*\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	exp, mr := newTestExporter(t, RedisExporterOptions{IgnoreFile: path, ExcludePatterns: []string{"session:*", " cache:* ", ""}})
+	for _, key := range []string{"user:1", "secret:1", "session:1", "session:2", "cache:1"} {
+		mr.Set(key, "value")
+	}
+
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("ExportByPattern failed: %v", err)
+	}
+
+	rows := readCSVRows(t, exp.fileManager.config.OutputDir)[1:]
+	if len(rows) != 1 || rows[0][0] != "user:1" {
+		t.Errorf("Expected only user:1, got %v", rows)
+	}
+	if exp.fileManager.metadata.IgnoredKeys != 4 {
+		t.Errorf("Expected 4 ignored keys, got %d", exp.fileManager.metadata.IgnoredKeys)
+	}
+}
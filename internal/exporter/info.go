@@ -0,0 +1,64 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// parseInfo converts the output of an INFO command into a key/value map
+func parseInfo(info string) map[string]string {
+	fields := make(map[string]string)
+
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		fields[name] = value
+	}
+
+	return fields
+}
+
+// ServerInfo identifies the server instance an export was read from. RunID
+// changes whenever the server restarts.
+type ServerInfo struct {
+	RedisVersion string `json:"redis_version"`
+	RunID        string `json:"run_id"`
+	OS           string `json:"os,omitempty"`
+	// Flavor is redis, valkey, keydb or dragonfly, and FlavorVersion the
+	// fork's own version
+	Flavor        string `json:"flavor,omitempty"`
+	FlavorVersion string `json:"flavor_version,omitempty"`
+}
+
+// parseServerInfo extracts the provenance fields from INFO server output
+func parseServerInfo(info string) *ServerInfo {
+	fields := parseInfo(info)
+	flavor, flavorVersion := detectFlavor(fields)
+	return &ServerInfo{
+		RedisVersion:  fields["redis_version"],
+		RunID:         fields["run_id"],
+		OS:            fields["os"],
+		Flavor:        flavor,
+		FlavorVersion: flavorVersion,
+	}
+}
+
+// captureServerInfo reads INFO server of client for the export metadata
+func (re *RedisExporter) captureServerInfo(client redis.UniversalClient) (*ServerInfo, error) {
+	info, err := client.Info(re.ctx, "server").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read server info: %w", err)
+	}
+	return parseServerInfo(info), nil
+}
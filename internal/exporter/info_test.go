@@ -0,0 +1,59 @@
+package exporter
+
+import "testing"
+
+func TestParseInfo(t *testing.T) {
+	info := "# Replication\r\n" +
+		"role:master\r\n" +
+		"connected_slaves:2\r\n" +
+		"slave0:ip=10.0.0.2,port=6379,state=online,offset=1234,lag=0\r\n" +
+		"master_repl_offset:1234\r\n" +
+		"\r\n"
+
+	fields := parseInfo(info)
+
+	tests := map[string]string{
+		"role":               "master",
+		"connected_slaves":   "2",
+		"slave0":             "ip=10.0.0.2,port=6379,state=online,offset=1234,lag=0",
+		"master_repl_offset": "1234",
+	}
+
+	for name, expected := range tests {
+		if fields[name] != expected {
+			t.Errorf("Expected %s=%s, got %s", name, expected, fields[name])
+		}
+	}
+
+	if _, ok := fields["# Replication"]; ok {
+		t.Error("Section headers should not be parsed as fields")
+	}
+}
+
+func TestParseServerInfo(t *testing.T) {
+	info := "# Server\r\n" +
+		"redis_version:7.2.4\r\n" +
+		"redis_mode:standalone\r\n" +
+		"os:Linux 6.1.0 x86_64\r\n" +
+		"run_id:4d3b0e2a8f6c1d9e7b5a3c1f0e2d4b6a8c0e1f3d\r\n" +
+		"\r\n"
+
+	server := parseServerInfo(info)
+	expected := ServerInfo{
+		RedisVersion: "7.2.4",
+		RunID:        "4d3b0e2a8f6c1d9e7b5a3c1f0e2d4b6a8c0e1f3d",
+		OS:           "Linux 6.1.0 x86_64",
+		Flavor:       FlavorRedis,
+	}
+	if *server != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *server)
+	}
+}
+
+func TestServerInfoUnavailable(t *testing.T) {
+	// miniredis only implements INFO clients, like a server restricting INFO
+	exp, _ := newTestExporter(t, RedisExporterOptions{})
+	if exp.fileManager.metadata.Server != nil {
+		t.Errorf("Expected no server info, got %+v", exp.fileManager.metadata.Server)
+	}
+}
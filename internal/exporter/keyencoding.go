@@ -0,0 +1,72 @@
+package exporter
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// KeyEncoding selects how keys that are not valid UTF-8 are written
+type KeyEncoding string
+
+const (
+	// KeyEncodingRaw writes key bytes unchanged. Parquet rejects invalid UTF-8,
+	// so such keys fail to export.
+	KeyEncodingRaw KeyEncoding = "raw"
+	// KeyEncodingBase64 writes invalid UTF-8 keys as "base64:" and standard base64
+	KeyEncodingBase64 KeyEncoding = "base64"
+	// KeyEncodingHex writes invalid UTF-8 keys as "hex:" and lowercase hex
+	KeyEncodingHex KeyEncoding = "hex"
+)
+
+// parseKeyEncoding validates a KEY_ENCODING value, defaulting to raw
+func parseKeyEncoding(value string) (KeyEncoding, error) {
+	switch KeyEncoding(value) {
+	case "", KeyEncodingRaw:
+		return KeyEncodingRaw, nil
+	case KeyEncodingBase64, KeyEncodingHex:
+		return KeyEncoding(value), nil
+	default:
+		return "", fmt.Errorf("unsupported key encoding: %s (supported: %s, %s, %s)",
+			value, KeyEncodingRaw, KeyEncodingBase64, KeyEncodingHex)
+	}
+}
+
+// encodeKey returns key as written to the key and parent_key columns. Valid
+// UTF-8 is written as-is, whatever the encoding; the prefix marks the rest.
+func encodeKey(encoding KeyEncoding, key string) string {
+	if utf8.ValidString(key) {
+		return key
+	}
+
+	switch encoding {
+	case KeyEncodingBase64:
+		return "base64:" + base64.StdEncoding.EncodeToString([]byte(key))
+	case KeyEncodingHex:
+		return "hex:" + hex.EncodeToString([]byte(key))
+	default:
+		return key
+	}
+}
+
+// decodeKey reverses encodeKey, so key lists written by keylist can be read
+// back. Only prefixed names that decode to invalid UTF-8 were encoded; any
+// other name is the key itself.
+func decodeKey(encoding KeyEncoding, name string) string {
+	var decoded []byte
+	var err error
+	switch {
+	case encoding == KeyEncodingBase64 && strings.HasPrefix(name, "base64:"):
+		decoded, err = base64.StdEncoding.DecodeString(strings.TrimPrefix(name, "base64:"))
+	case encoding == KeyEncodingHex && strings.HasPrefix(name, "hex:"):
+		decoded, err = hex.DecodeString(strings.TrimPrefix(name, "hex:"))
+	default:
+		return name
+	}
+	if err != nil || utf8.Valid(decoded) {
+		return name
+	}
+	return string(decoded)
+}
@@ -0,0 +1,160 @@
+package exporter
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// awkwardKeys are binary-safe Redis keys that are hard to represent in text
+var awkwardKeys = []string{
+	"../escape/attempt",
+	"multi\nline",
+	"bad\xff\xfeutf8",
+}
+
+func TestEncodeKey(t *testing.T) {
+	tests := []struct {
+		encoding KeyEncoding
+		key      string
+		expected string
+	}{
+		{KeyEncodingBase64, "plain:key", "plain:key"},
+		{KeyEncodingBase64, "multi\nline", "multi\nline"},
+		{KeyEncodingBase64, "bad\xff", "base64:YmFk/w=="},
+		{KeyEncodingHex, "bad\xff", "hex:626164ff"},
+		{KeyEncodingRaw, "bad\xff", "bad\xff"},
+		{KeyEncodingHex, "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := encodeKey(tt.encoding, tt.key); got != tt.expected {
+			t.Errorf("encodeKey(%s, %q) = %q, expected %q", tt.encoding, tt.key, got, tt.expected)
+		}
+	}
+
+	if _, err := parseKeyEncoding("rot13"); err == nil {
+		t.Error("Expected an unsupported key encoding to be rejected")
+	}
+}
+
+func TestAwkwardKeysCSV(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{KeyEncoding: "hex", IncludeParentKey: true})
+
+	for _, key := range awkwardKeys {
+		if err := mr.Set(key, "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := mr.SAdd("set\xff", "member"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	// CSV quoting must keep the newline inside a single field
+	rows := make(map[string][]string)
+	for _, row := range readCSVRows(t, exp.fileManager.config.OutputDir)[1:] {
+		rows[row[0]] = row
+	}
+	for _, key := range []string{"../escape/attempt", "multi\nline", "hex:626164fffe75746638", "hex:736574ff"} {
+		if _, ok := rows[key]; !ok {
+			t.Errorf("Expected a row for %q, got keys %v", key, rowKeys(rows))
+		}
+	}
+
+	member, ok := rows["hex:736574ff3a6d656d6265723a6d656d626572"]
+	if !ok || member[len(member)-1] != "hex:736574ff" {
+		t.Errorf("Expected the set member row to carry the encoded parent key, got %v", member)
+	}
+
+	assertNoKeyPaths(t, exp.fileManager.config.OutputDir)
+}
+
+func TestAwkwardKeysParquet(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{OutputFormat: "parquet", KeyEncoding: "base64"})
+
+	for _, key := range awkwardKeys {
+		if err := mr.Set(key, "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	files := findDataFiles(t, exp.fileManager.config.OutputDir, ".parquet")
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 Parquet file, got %d", len(files))
+	}
+
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	rows, err := db.Query(fmt.Sprintf("SELECT key FROM read_parquet('%s') ORDER BY key", files[0]))
+	if err != nil {
+		t.Fatalf("Failed to query Parquet file: %v", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+
+	expected := []string{"../escape/attempt", "base64:YmFk//51dGY4", "multi\nline"}
+	sort.Strings(expected)
+	if fmt.Sprint(keys) != fmt.Sprint(expected) {
+		t.Errorf("Expected keys %q, got %q", expected, keys)
+	}
+
+	assertNoKeyPaths(t, exp.fileManager.config.OutputDir)
+}
+
+// assertNoKeyPaths fails if any file or directory under dir is named after a key
+func assertNoKeyPaths(t *testing.T, dir string) {
+	t.Helper()
+
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape")); err == nil {
+		t.Error("Expected no directory outside the output directory")
+	}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := info.Name()
+		if name == "escape" || name == "attempt" || name == "line" {
+			t.Errorf("Unexpected key-derived path %s", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func rowKeys(rows map[string][]string) []string {
+	keys := make([]string, 0, len(rows))
+	for key := range rows {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
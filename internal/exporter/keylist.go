@@ -0,0 +1,66 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// KeyListFileName is where the keylist command writes by default, in OutputDir
+const KeyListFileName = "keys.txt"
+
+// ExportKeyList writes the name of every key matching pattern to out, one per
+// line, and returns how many were written. Nothing but SCAN is sent, and no
+// records, partitions or metadata are written. Ignore rules and KeyEncoding
+// apply; keys containing a line break cannot be listed and are skipped.
+func (re *RedisExporter) ExportKeyList(pattern string, out io.Writer) (count int64, err error) {
+	defer func() {
+		_ = re.client.Close()
+	}()
+
+	if err := re.requireLiveServer("keylist"); err != nil {
+		return 0, err
+	}
+	if err := re.requireSingleSource("keylist"); err != nil {
+		return 0, err
+	}
+
+	re.verbosity.infof("Listing keys with pattern: %s\n", pattern)
+
+	w := bufio.NewWriter(out)
+	skipped := 0
+	err = re.scanBatches(pattern, func(keys []string) error {
+		for _, key := range keys {
+			name := encodeKey(re.fileManager.config.KeyEncoding, key)
+			if strings.ContainsAny(name, "\r\n") {
+				skipped++
+				continue
+			}
+			if _, err := w.WriteString(name); err != nil {
+				return fmt.Errorf("failed to write key list: %w", err)
+			}
+			if err := w.WriteByte('\n'); err != nil {
+				return fmt.Errorf("failed to write key list: %w", err)
+			}
+			count++
+
+			if count%int64(re.flushInterval*100) == 0 {
+				re.verbosity.infof("Listed %d keys...\n", count)
+			}
+		}
+		return nil
+	})
+	if flushErr := w.Flush(); err == nil && flushErr != nil {
+		err = fmt.Errorf("failed to write key list: %w", flushErr)
+	}
+	if err != nil {
+		return count, err
+	}
+
+	if skipped > 0 {
+		fmt.Printf("Warning: skipped %d keys containing line breaks\n", skipped)
+	}
+	re.verbosity.infof("Key list completed! Total keys listed: %d\n", count)
+	return count, nil
+}
@@ -0,0 +1,50 @@
+package exporter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestExportKeyList(t *testing.T) {
+	ignoreFile := filepath.Join(t.TempDir(), "ignore")
+	if err := os.WriteFile(ignoreFile, []byte("user:*:token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	exp, mr := newTestExporter(t, RedisExporterOptions{IgnoreFile: ignoreFile, KeyEncoding: "hex"})
+	for _, key := range []string{"user:1", "user:2", "user:1:token", "order:1", "user:multi\nline", "user:\xff"} {
+		if err := mr.Set(key, "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mr.HSet("user:3", "name", "carol")
+
+	var out bytes.Buffer
+	count, err := exp.ExportKeyList("user:*", &out)
+	if err != nil {
+		t.Fatalf("ExportKeyList failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	sort.Strings(lines)
+	expected := []string{"hex:757365723aff", "user:1", "user:2", "user:3"}
+	if strings.Join(lines, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected keys %v, got %v", expected, lines)
+	}
+	if count != int64(len(expected)) {
+		t.Errorf("Expected count %d, got %d", len(expected), count)
+	}
+
+	// The record machinery is bypassed entirely
+	entries, err := os.ReadDir(exp.fileManager.config.OutputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no files in the output directory, got %d", len(entries))
+	}
+}
@@ -0,0 +1,43 @@
+package exporter
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// compileKeyRegex compiles an optional RE2 key filter named by option
+func compileKeyRegex(option, expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", option, err)
+	}
+	return re, nil
+}
+
+// keyRegexSelected reports whether key matches KEY_REGEX, when set, and not
+// KEY_EXCLUDE_REGEX
+func (re *RedisExporter) keyRegexSelected(key string) bool {
+	if re.keyRegex != nil && !re.keyRegex.MatchString(key) {
+		return false
+	}
+	return re.keyExcludeRegex == nil || !re.keyExcludeRegex.MatchString(key)
+}
+
+// filterKeyRegex drops keys not selected by the key regexes from a scanned
+// batch in place and returns the remaining keys and the number dropped
+func (re *RedisExporter) filterKeyRegex(keys []string) ([]string, int) {
+	if re.keyRegex == nil && re.keyExcludeRegex == nil {
+		return keys, 0
+	}
+
+	kept := keys[:0]
+	for _, key := range keys {
+		if re.keyRegexSelected(key) {
+			kept = append(kept, key)
+		}
+	}
+	return kept, len(keys) - len(kept)
+}
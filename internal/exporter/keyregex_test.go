@@ -0,0 +1,37 @@
+package exporter
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestKeyRegex(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{KeyRegex: `^user:[0-9]+(:profile)?$`, KeyExcludeRegex: `^user:0`})
+	for _, key := range []string{"user:1", "user:1:profile", "user:1:cache", "user:abc", "user:007", "session:1"} {
+		mr.Set(key, "value")
+	}
+
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("ExportByPattern failed: %v", err)
+	}
+
+	var keys []string
+	for _, row := range readCSVRows(t, exp.fileManager.config.OutputDir)[1:] {
+		keys = append(keys, row[0])
+	}
+	sort.Strings(keys)
+	if got := strings.Join(keys, ","); got != "user:1,user:1:profile" {
+		t.Errorf("Expected user:1,user:1:profile, got %s", got)
+	}
+}
+
+func TestKeyRegexInvalid(t *testing.T) {
+	mr := miniredis.RunT(t)
+	_, err := NewRedisExporter(RedisExporterOptions{RedisURL: "redis://" + mr.Addr(), OutputDir: t.TempDir(), KeyExcludeRegex: "user:("})
+	if err == nil || !strings.Contains(err.Error(), "KEY_EXCLUDE_REGEX") {
+		t.Errorf("Expected an invalid KEY_EXCLUDE_REGEX to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package exporter
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keysFileReader hands out the keys listed in KEYS_FILE, one per line, in
+// place of SCAN
+type keysFileReader struct {
+	file     *os.File
+	reader   *bufio.Reader
+	encoding KeyEncoding
+	// lines counts the lines read, reported as the scan cursor
+	lines int64
+	// listed and missing count the matching keys read and those that no
+	// longer exist
+	listed  int64
+	missing int64
+}
+
+func openKeysFile(path string, encoding KeyEncoding) (*keysFileReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open keys file: %w", err)
+	}
+	return &keysFileReader{file: file, reader: bufio.NewReader(file), encoding: encoding}, nil
+}
+
+func (r *keysFileReader) Close() error {
+	return r.file.Close()
+}
+
+// next reads up to count lines and returns the keys among them that match
+// pattern, with the lines read so far as the cursor, or 0 at the end of the
+// file like a finished SCAN. Blank lines are skipped and names are decoded
+// with KEY_ENCODING, so keylist output can be read back.
+func (r *keysFileReader) next(pattern string, count int64) ([]string, uint64, error) {
+	var keys []string
+	for read := int64(0); read < count; read++ {
+		line, err := r.reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, 0, fmt.Errorf("failed to read keys file: %w", err)
+		}
+		if line != "" {
+			r.lines++
+		}
+
+		name := strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if name != "" {
+			if key := decodeKey(r.encoding, name); matchGlob(pattern, key) {
+				keys = append(keys, key)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return keys, 0, nil
+		}
+	}
+	return keys, uint64(r.lines), nil
+}
+
+// keysFileStep reads the next batch of KEYS_FILE and drops the keys that no
+// longer exist with a pipelined EXISTS
+func (re *RedisExporter) keysFileStep(ctx context.Context, r *keysFileReader, pattern string, count int64) ([]string, uint64, error) {
+	keys, next, err := r.next(pattern, count)
+	if err != nil || len(keys) == 0 {
+		return keys, next, err
+	}
+
+	pipe := re.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Exists(ctx, key)
+	}
+	batchCtx, cancel := re.batchContext(ctx)
+	defer cancel()
+	if _, err := pipe.Exec(batchCtx); err != nil {
+		return nil, 0, fmt.Errorf("failed to check keys file keys: %w", err)
+	}
+
+	kept := keys[:0]
+	for i, key := range keys {
+		if cmds[i].Val() > 0 {
+			kept = append(kept, key)
+		}
+	}
+	r.listed += int64(len(keys))
+	r.missing += int64(len(keys) - len(kept))
+	return kept, next, nil
+}
@@ -0,0 +1,59 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestKeysFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.txt")
+	content := "user:1\r\n\nuser:2\nuser:gone\nsession:1\nhex:ff00\nuser:3"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	exp, mr := newTestExporter(t, RedisExporterOptions{KeysFile: path, BatchSize: 2, KeyEncoding: "hex"})
+	for _, key := range []string{"user:1", "user:2", "user:3", "user:unlisted", "session:1", "\xff\x00"} {
+		mr.Set(key, "value")
+	}
+
+	if err := exp.ExportByPattern("user:*"); err != nil {
+		t.Fatalf("ExportByPattern failed: %v", err)
+	}
+
+	var keys []string
+	for _, row := range readCSVRows(t, exp.fileManager.config.OutputDir)[1:] {
+		keys = append(keys, row[0])
+	}
+	sort.Strings(keys)
+	if got := strings.Join(keys, ","); got != "user:1,user:2,user:3" {
+		t.Errorf("Expected the listed user keys, got %s", got)
+	}
+}
+
+func TestDecodeKey(t *testing.T) {
+	tests := []struct {
+		encoding KeyEncoding
+		name     string
+		expected string
+	}{
+		{KeyEncodingHex, "hex:ff00", "\xff\x00"},
+		{KeyEncodingBase64, "base64:/wA=", "\xff\x00"},
+		// Valid UTF-8 was never encoded, so the name is the key
+		{KeyEncodingHex, "hex:6869", "hex:6869"},
+		{KeyEncodingRaw, "hex:ff00", "hex:ff00"},
+		{KeyEncodingHex, "hex:zz", "hex:zz"},
+	}
+
+	for _, tt := range tests {
+		if got := decodeKey(tt.encoding, tt.name); got != tt.expected {
+			t.Errorf("decodeKey(%s, %q) = %q, expected %q", tt.encoding, tt.name, got, tt.expected)
+		}
+		if got := decodeKey(tt.encoding, encodeKey(tt.encoding, tt.expected)); got != tt.expected {
+			t.Errorf("Expected %q to round-trip with %s, got %q", tt.expected, tt.encoding, got)
+		}
+	}
+}
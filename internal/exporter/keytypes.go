@@ -0,0 +1,112 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// filterableTypes are the TYPE replies KEY_TYPES accepts
+var filterableTypes = map[string]bool{
+	"string": true, "list": true, "set": true, "zset": true, "hash": true, "stream": true,
+	bloomFilterType: true, cuckooFilterType: true,
+}
+
+// parseKeyTypes validates a KEY_TYPES list, dropping blanks and duplicates
+func parseKeyTypes(types []string) ([]string, error) {
+	var parsed []string
+	seen := make(map[string]bool, len(types))
+	for _, keyType := range types {
+		keyType = strings.TrimSpace(keyType)
+		if keyType == "" || seen[keyType] {
+			continue
+		}
+		if !filterableTypes[keyType] {
+			return nil, fmt.Errorf("unsupported KEY_TYPES type: %s", keyType)
+		}
+		seen[keyType] = true
+		parsed = append(parsed, keyType)
+	}
+	return parsed, nil
+}
+
+// scanTypeOption returns the type SCAN filters on itself, set only when
+// KEY_TYPES names a single type and keys are scanned rather than listed
+func (re *RedisExporter) scanTypeOption() string {
+	if len(re.keyTypes) == 1 && re.keysFile == "" {
+		return re.keyTypes[0]
+	}
+	return ""
+}
+
+// filterKeyTypes drops keys not of KEY_TYPES from a scanned batch in place,
+// pipelining TYPE on the scanned node, and returns the remaining keys, their
+// types and the number dropped. A single type is left to SCAN's TYPE option,
+// and the types are then nil.
+func (re *RedisExporter) filterKeyTypes(ctx context.Context, node redis.Cmdable, keys []string) ([]string, map[string]string, int, error) {
+	if len(re.keyTypes) == 0 || re.scanTypeOption() != "" {
+		return keys, nil, 0, nil
+	}
+
+	pipe := node.Pipeline()
+	cmds := make([]*redis.StatusCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Type(ctx, key)
+	}
+
+	batchCtx, cancel := re.batchContext(ctx)
+	defer cancel()
+	if _, err := pipe.Exec(batchCtx); err != nil && err != redis.Nil {
+		return nil, nil, 0, fmt.Errorf("failed to type keys for KEY_TYPES: %w", err)
+	}
+
+	kept := keys[:0]
+	types := make(map[string]string, len(keys))
+	dropped := 0
+	for i, key := range keys {
+		keyType, err := cmds[i].Result()
+		if err != nil {
+			log.Printf("Error getting type for key %s: %v", key, err)
+			dropped++
+			continue
+		}
+		if !re.keyTypeSelected(keyType) {
+			dropped++
+			continue
+		}
+		kept = append(kept, key)
+		types[key] = keyType
+	}
+	return kept, types, dropped, nil
+}
+
+// knownType returns the type of key when the scanner already typed it for
+// KEY_TYPES or the type cache holds it, so TYPE is not sent again
+func (re *RedisExporter) knownType(key string) (string, bool) {
+	if keyType, ok := re.scannedTypes[key]; ok {
+		return keyType, true
+	}
+	return re.typeCache.get(key)
+}
+
+// forgetType drops a known type found to be stale
+func (re *RedisExporter) forgetType(key string) {
+	delete(re.scannedTypes, key)
+	re.typeCache.remove(key)
+}
+
+// keyTypeSelected reports whether KEY_TYPES is unset or names keyType
+func (re *RedisExporter) keyTypeSelected(keyType string) bool {
+	if len(re.keyTypes) == 0 {
+		return true
+	}
+	for _, selected := range re.keyTypes {
+		if selected == keyType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestKeyTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		keyTypes []string
+		expected string
+	}{
+		{"scan type", []string{"hash"}, "user:1"},
+		{"pipelined type", []string{"hash", " zset", "hash"}, "scores,user:1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp, _ := newTestExporter(t, RedisExporterOptions{KeyTypes: tt.keyTypes})
+			seedCollections(t, exp)
+
+			if err := exp.ExportKeysOnly(); err != nil {
+				t.Fatalf("ExportKeysOnly failed: %v", err)
+			}
+
+			var keys []string
+			for _, row := range readCSVRows(t, exp.fileManager.config.OutputDir)[1:] {
+				keys = append(keys, row[0])
+			}
+			sort.Strings(keys)
+			if got := strings.Join(keys, ","); got != tt.expected {
+				t.Errorf("Expected keys %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// typeCountHook counts TYPE commands, which the scanner and the export send
+// from different goroutines
+type typeCountHook struct {
+	passHook
+	calls *atomic.Int64
+}
+
+func (h typeCountHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() == "type" {
+			h.calls.Add(1)
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h typeCountHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			if cmd.Name() == "type" {
+				h.calls.Add(1)
+			}
+		}
+		return next(ctx, cmds)
+	}
+}
+
+func TestKeyTypesTypedOnce(t *testing.T) {
+	// The export reuses the types the pipelined filter resolved
+	for _, keysOnly := range []bool{true, false} {
+		t.Run(fmt.Sprintf("keysOnly=%t", keysOnly), func(t *testing.T) {
+			exp, _ := newTestExporter(t, RedisExporterOptions{KeyTypes: []string{"hash", "zset"}})
+			seedCollections(t, exp)
+			var calls atomic.Int64
+			exp.client.AddHook(typeCountHook{calls: &calls})
+
+			export := func() error { return exp.ExportByPattern("*") }
+			if keysOnly {
+				export = exp.ExportKeysOnly
+			}
+			if err := export(); err != nil {
+				t.Fatalf("Export failed: %v", err)
+			}
+
+			// One TYPE for each of the six seeded keys, none for the two exported
+			if n := calls.Load(); n != 6 {
+				t.Errorf("Expected 6 TYPE commands, got %d", n)
+			}
+		})
+	}
+}
+
+func TestKeyTypesValidation(t *testing.T) {
+	mr := miniredis.RunT(t)
+	for _, opts := range []RedisExporterOptions{
+		{KeyTypes: []string{"hashes"}},
+		{KeyTypes: []string{"hash"}, AssumeType: "hash"},
+	} {
+		opts.RedisURL = "redis://" + mr.Addr()
+		opts.OutputDir = t.TempDir()
+		if _, err := NewRedisExporter(opts); err == nil {
+			t.Errorf("Expected KEY_TYPES=%v with ASSUME_TYPE=%q to be rejected", opts.KeyTypes, opts.AssumeType)
+		}
+	}
+}
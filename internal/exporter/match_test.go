@@ -0,0 +1,39 @@
+package exporter
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		key      string
+		expected bool
+	}{
+		{pattern: "*", key: "anything", expected: true},
+		{pattern: "*", key: "", expected: true},
+		{pattern: "user:*", key: "user:1", expected: true},
+		{pattern: "user:*", key: "session:1", expected: false},
+		{pattern: "user:*:profile", key: "user:1:profile", expected: true},
+		{pattern: "user:*:profile", key: "user:1:settings", expected: false},
+		{pattern: "h?llo", key: "hello", expected: true},
+		{pattern: "h?llo", key: "hllo", expected: false},
+		{pattern: "h[ae]llo", key: "hallo", expected: true},
+		{pattern: "h[ae]llo", key: "hillo", expected: false},
+		{pattern: "h[^e]llo", key: "hallo", expected: true},
+		{pattern: "h[^e]llo", key: "hello", expected: false},
+		{pattern: "h[a-c]llo", key: "hbllo", expected: true},
+		{pattern: "h[a-c]llo", key: "hdllo", expected: false},
+		{pattern: `h\*llo`, key: "h*llo", expected: true},
+		{pattern: `h\*llo`, key: "hello", expected: false},
+		{pattern: "flags:**", key: "flags:a/b", expected: true},
+		{pattern: "exact", key: "exact", expected: true},
+		{pattern: "exact", key: "exactly", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"_"+tt.key, func(t *testing.T) {
+			if actual := matchGlob(tt.pattern, tt.key); actual != tt.expected {
+				t.Errorf("matchGlob(%q, %q) = %v, expected %v", tt.pattern, tt.key, actual, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	namespaceSeparator    = ":"
+	namespaceOverflow     = "*"
+	namespacesFileName    = "namespaces.json"
+	defaultNamespaceDepth = 3
+	defaultNamespaceWidth = 1000
+)
+
+// NamespaceNode aggregates the keys and bytes that live under a key prefix
+type NamespaceNode struct {
+	Prefix   string           `json:"prefix"`
+	Keys     int64            `json:"keys"`
+	Bytes    int64            `json:"bytes"`
+	Children []*NamespaceNode `json:"children,omitempty"`
+
+	children map[string]*NamespaceNode
+}
+
+// NamespaceReport is the rollup written to namespaces.json
+type NamespaceReport struct {
+	Pattern     string         `json:"pattern"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	MaxDepth    int            `json:"max_depth"`
+	Root        *NamespaceNode `json:"root"`
+}
+
+// namespaceTree builds a prefix tree of key counts and sizes split on ':'
+type namespaceTree struct {
+	root     *NamespaceNode
+	maxDepth int
+	maxWidth int
+}
+
+func newNamespaceTree(maxDepth, maxWidth int) *namespaceTree {
+	if maxDepth <= 0 {
+		maxDepth = defaultNamespaceDepth
+	}
+	if maxWidth <= 0 {
+		maxWidth = defaultNamespaceWidth
+	}
+
+	return &namespaceTree{
+		root:     &NamespaceNode{Prefix: "", children: make(map[string]*NamespaceNode)},
+		maxDepth: maxDepth,
+		maxWidth: maxWidth,
+	}
+}
+
+// Add accounts a key and its size against every prefix segment it lives under.
+// The final segment is the key's own name so it never becomes a namespace.
+func (t *namespaceTree) Add(key string, bytes int64) {
+	node := t.root
+	node.Keys++
+	node.Bytes += bytes
+
+	segments := strings.Split(key, namespaceSeparator)
+	for depth := 0; depth < len(segments)-1 && depth < t.maxDepth; depth++ {
+		segment := segments[depth]
+
+		child, ok := node.children[segment]
+		if !ok {
+			// Collapse high-cardinality segments (e.g. user IDs) into one overflow node
+			if len(node.children) >= t.maxWidth {
+				segment = namespaceOverflow
+				child, ok = node.children[segment]
+			}
+			if !ok {
+				child = &NamespaceNode{
+					Prefix:   node.Prefix + segment + namespaceSeparator,
+					children: make(map[string]*NamespaceNode),
+				}
+				node.children[segment] = child
+			}
+		}
+
+		child.Keys++
+		child.Bytes += bytes
+		node = child
+	}
+}
+
+// Root returns the tree with children ordered by key count, largest first
+func (t *namespaceTree) Root() *NamespaceNode {
+	finalizeNamespaceNode(t.root)
+	return t.root
+}
+
+func finalizeNamespaceNode(node *NamespaceNode) {
+	node.Children = make([]*NamespaceNode, 0, len(node.children))
+	for _, child := range node.children {
+		finalizeNamespaceNode(child)
+		node.Children = append(node.Children, child)
+	}
+
+	sort.Slice(node.Children, func(i, j int) bool {
+		if node.Children[i].Keys != node.Children[j].Keys {
+			return node.Children[i].Keys > node.Children[j].Keys
+		}
+		return node.Children[i].Prefix < node.Children[j].Prefix
+	})
+}
+
+// ExportNamespaces scans key metadata and writes a prefix rollup to namespaces.json
+func (re *RedisExporter) ExportNamespaces(pattern string) error {
+	defer func() {
+		_ = re.Close()
+	}()
+
+	if err := re.requireLiveServer("namespaces"); err != nil {
+		return err
+	}
+
+	count := 0
+
+	tree := newNamespaceTree(re.namespaceDepth, re.namespaceWidth)
+
+	re.verbosity.infof("Starting keyspace namespace analysis with pattern: %s\n", pattern)
+
+	err := re.scanBatches(pattern, func(keys []string) error {
+		if re.memoryUsageUnsupported {
+			for _, key := range keys {
+				tree.Add(key, 0)
+			}
+			count += len(keys)
+			return nil
+		}
+
+		// Pipeline MEMORY USAGE so sizes come back in a single round trip
+		pipe := re.client.Pipeline()
+		usages := make(map[string]*redis.IntCmd, len(keys))
+		for _, key := range keys {
+			usages[key] = pipe.MemoryUsage(re.ctx, key)
+		}
+
+		// Individual MEMORY USAGE failures are tolerated and counted as zero bytes
+		ctx, cancel := re.batchContext(re.ctx)
+		defer cancel()
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			log.Printf("Pipeline error: %v", err)
+		}
+
+		for _, key := range keys {
+			bytes, err := usages[key].Result()
+			if isUnknownCommandError(err) && !re.memoryUsageUnsupported {
+				re.memoryUsageUnsupported = true
+				fmt.Printf("Warning: MEMORY USAGE is not supported by the server; remaining keys count as 0 bytes\n")
+			}
+			if err != nil {
+				bytes = 0
+			}
+			tree.Add(key, bytes)
+			count++
+
+			if count%re.flushInterval == 0 {
+				re.verbosity.infof("Analyzed %d keys...\n", count)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		re.fileManager.SetMetadata(pattern, int64(count))
+		re.fileManager.MarkPartial(err)
+		return err
+	}
+
+	report := NamespaceReport{
+		Pattern:     pattern,
+		GeneratedAt: time.Now().UTC(),
+		MaxDepth:    tree.maxDepth,
+		Root:        tree.Root(),
+	}
+
+	reportPath := filepath.Join(re.fileManager.config.OutputDir, namespacesFileName)
+	if err := writeNamespaceReport(reportPath, &report); err != nil {
+		return err
+	}
+
+	fmt.Printf("Namespace analysis completed! Total keys analyzed: %d\n", count)
+	fmt.Printf("Namespace rollup written to %s\n", reportPath)
+	return nil
+}
+
+func writeNamespaceReport(path string, report *NamespaceReport) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create namespaces file: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Printf("Warning: failed to close namespaces file: %v\n", err)
+		}
+	}()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("failed to write namespaces: %w", err)
+	}
+
+	return nil
+}
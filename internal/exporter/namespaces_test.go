@@ -0,0 +1,63 @@
+package exporter
+
+import "testing"
+
+func TestNamespaceTree(t *testing.T) {
+	tree := newNamespaceTree(3, 1000)
+
+	tree.Add("user:1", 10)
+	tree.Add("user:2", 10)
+	tree.Add("user:session:a", 5)
+	tree.Add("user:session:b", 5)
+	tree.Add("config", 1)
+
+	root := tree.Root()
+
+	if root.Keys != 5 || root.Bytes != 31 {
+		t.Fatalf("Expected root keys=5 bytes=31, got keys=%d bytes=%d", root.Keys, root.Bytes)
+	}
+
+	if len(root.Children) != 1 {
+		t.Fatalf("Expected 1 top-level namespace, got %d", len(root.Children))
+	}
+
+	user := root.Children[0]
+	if user.Prefix != "user:" || user.Keys != 4 || user.Bytes != 30 {
+		t.Errorf("Expected user: with keys=4 bytes=30, got %s keys=%d bytes=%d", user.Prefix, user.Keys, user.Bytes)
+	}
+
+	if len(user.Children) != 1 {
+		t.Fatalf("Expected 1 child under user:, got %d", len(user.Children))
+	}
+
+	session := user.Children[0]
+	if session.Prefix != "user:session:" || session.Keys != 2 || session.Bytes != 10 {
+		t.Errorf("Expected user:session: with keys=2 bytes=10, got %s keys=%d bytes=%d", session.Prefix, session.Keys, session.Bytes)
+	}
+}
+
+func TestNamespaceTreeLimits(t *testing.T) {
+	tree := newNamespaceTree(1, 2)
+
+	tree.Add("a:x:1", 1)
+	tree.Add("b:x:1", 1)
+	tree.Add("c:x:1", 1)
+	tree.Add("d:x:1", 1)
+
+	root := tree.Root()
+
+	if len(root.Children) != 3 {
+		t.Fatalf("Expected 2 namespaces plus overflow, got %d", len(root.Children))
+	}
+
+	for _, child := range root.Children {
+		if len(child.Children) != 0 {
+			t.Errorf("Expected depth limit to stop at %s, got %d children", child.Prefix, len(child.Children))
+		}
+	}
+
+	overflow := root.Children[0]
+	if overflow.Prefix != "*:" || overflow.Keys != 2 {
+		t.Errorf("Expected overflow *: with keys=2, got %s keys=%d", overflow.Prefix, overflow.Keys)
+	}
+}
@@ -0,0 +1,32 @@
+package exporter
+
+import "github.com/redis/go-redis/v9"
+
+// keyEncodings pipelines OBJECT ENCODING for a batch of keys. Keys deleted
+// since SCAN and failed lookups are left out, so their encoding is written
+// as NULL.
+func (re *RedisExporter) keyEncodings(keys []string) map[string]string {
+	pipe := re.client.Pipeline()
+	cmds := make(map[string]*redis.StringCmd, len(keys))
+	for _, key := range keys {
+		cmds[key] = pipe.ObjectEncoding(re.ctx, key)
+	}
+
+	ctx, cancel := re.batchContext(re.ctx)
+	defer cancel()
+	// Per-command errors are checked below
+	_, _ = pipe.Exec(ctx)
+
+	encodings := make(map[string]string, len(keys))
+	for key, cmd := range cmds {
+		encoding, err := cmd.Result()
+		if err != nil {
+			if err != redis.Nil {
+				re.verbosity.debugf("OBJECT ENCODING failed for %s: %v\n", key, err)
+			}
+			continue
+		}
+		encodings[key] = encoding
+	}
+	return encodings
+}
@@ -0,0 +1,82 @@
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// encodingHook answers OBJECT ENCODING, which miniredis lacks, from a map;
+// other keys reply nil as if deleted
+type encodingHook struct {
+	passHook
+	encodings map[string]string
+}
+
+func (h encodingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		rest := cmds[:0:0]
+		for _, cmd := range cmds {
+			if cmd.Name() != "object" || cmd.Args()[1] != "encoding" {
+				rest = append(rest, cmd)
+				continue
+			}
+			if encoding, ok := h.encodings[cmd.Args()[2].(string)]; ok {
+				cmd.(*redis.StringCmd).SetVal(encoding)
+			} else {
+				cmd.SetErr(redis.Nil)
+			}
+		}
+		if len(rest) == 0 {
+			return nil
+		}
+		return next(ctx, rest)
+	}
+}
+
+func TestKeysOnlyEncoding(t *testing.T) {
+	exp, _ := newTestExporter(t, RedisExporterOptions{IncludeCardinality: true, IncludeEncoding: true})
+	seedCollections(t, exp)
+	exp.client.AddHook(encodingHook{encodings: map[string]string{
+		"greeting": "embstr",
+		"tags":     "listpack",
+		"scores":   "listpack",
+		"user:1":   "listpack",
+		"queue":    "quicklist",
+	}})
+
+	if err := exp.ExportKeysOnly(); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	rows := readCSVRows(t, exp.fileManager.config.OutputDir)
+	if len(rows) == 0 || rows[0][3] != "cardinality" || rows[0][4] != "encoding" {
+		t.Fatalf("Expected encoding after cardinality, got headers %v", rows)
+	}
+	got := make(map[string]string)
+	for _, row := range rows[1:] {
+		got[row[0]] = row[4]
+	}
+	expected := map[string]string{
+		"greeting": "embstr",
+		"tags":     "listpack",
+		"queue":    "quicklist",
+		// Unknown encodings are NULL
+		"events": "",
+	}
+	for key, want := range expected {
+		if got[key] != want {
+			t.Errorf("Key %s: expected encoding %q, got %q", key, want, got[key])
+		}
+	}
+}
+
+func TestEncodingRequiresKeysOnly(t *testing.T) {
+	exp, _ := newTestExporter(t, RedisExporterOptions{IncludeEncoding: true})
+	err := exp.ExportByPattern("*")
+	if err == nil || !strings.Contains(err.Error(), "INCLUDE_ENCODING") {
+		t.Errorf("Expected a full export with INCLUDE_ENCODING to fail, got %v", err)
+	}
+}
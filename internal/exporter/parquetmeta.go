@@ -0,0 +1,125 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/apache/arrow-go/v18/parquet/metadata"
+)
+
+const (
+	parquetSummaryFileName = "_metadata"
+	parquetCommonFileName  = "_common_metadata"
+)
+
+// parquetMagic opens and closes every Parquet file
+var parquetMagic = []byte("PAR1")
+
+// readParquetFooter decodes the file metadata from the footer of a Parquet file
+func readParquetFooter(path string) (*metadata.FileMetaData, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Parquet file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat Parquet file: %w", err)
+	}
+
+	// The footer ends with its 4-byte little-endian length and the magic
+	tail := make([]byte, 8)
+	if stat.Size() < int64(len(tail)+len(parquetMagic)) {
+		return nil, fmt.Errorf("%s is too small to be a Parquet file", path)
+	}
+	if _, err := file.ReadAt(tail, stat.Size()-int64(len(tail))); err != nil {
+		return nil, fmt.Errorf("failed to read Parquet footer: %w", err)
+	}
+	if !bytes.Equal(tail[4:], parquetMagic) {
+		return nil, fmt.Errorf("%s is not a Parquet file", path)
+	}
+
+	footerLen := int64(binary.LittleEndian.Uint32(tail[:4]))
+	footerStart := stat.Size() - int64(len(tail)) - footerLen
+	if footerStart < int64(len(parquetMagic)) {
+		return nil, fmt.Errorf("%s has a corrupt footer length", path)
+	}
+
+	footer := make([]byte, footerLen)
+	if _, err := file.ReadAt(footer, footerStart); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read Parquet footer: %w", err)
+	}
+
+	meta, err := metadata.NewFileMetaData(footer, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode Parquet footer of %s: %w", path, err)
+	}
+	return meta, nil
+}
+
+// writeParquetMetadataFile writes meta as a data-less Parquet file
+func writeParquetMetadataFile(path string, meta *metadata.FileMetaData) error {
+	footer, err := meta.Serialize(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to encode Parquet metadata: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.Write(parquetMagic)
+	buf.Write(footer)
+	if err := binary.Write(&buf, binary.LittleEndian, uint32(len(footer))); err != nil {
+		return err
+	}
+	buf.Write(parquetMagic)
+
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// writeParquetSummaryFiles writes the _common_metadata (schema only) and
+// _metadata (every partition's row groups, with file paths relative to the
+// output directory) summary files used by Spark, Dask and Arrow datasets
+func (fm *FileManager) writeParquetSummaryFiles() error {
+	var summary *metadata.FileMetaData
+	for _, partition := range fm.metadata.Partitions {
+		meta, err := readParquetFooter(filepath.Join(fm.config.OutputDir, filepath.FromSlash(partition.Path)))
+		if err != nil {
+			return err
+		}
+		meta.SetFilePath(partition.Path)
+
+		if summary == nil {
+			summary = meta
+			continue
+		}
+		if err := summary.AppendRowGroups(meta); err != nil {
+			return fmt.Errorf("failed to merge Parquet metadata of %s: %w", partition.Path, err)
+		}
+	}
+
+	// No partitions were written, so there is no schema to summarize
+	if summary == nil {
+		return nil
+	}
+
+	common, err := summary.Subset(nil)
+	if err != nil {
+		return fmt.Errorf("failed to build common Parquet metadata: %w", err)
+	}
+	if err := writeParquetMetadataFile(filepath.Join(fm.config.OutputDir, parquetCommonFileName), common); err != nil {
+		return err
+	}
+
+	return writeParquetMetadataFile(filepath.Join(fm.config.OutputDir, parquetSummaryFileName), summary)
+}
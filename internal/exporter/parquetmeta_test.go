@@ -0,0 +1,138 @@
+package exporter
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParquetSummaryFiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "redis_dumper_summary_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Logf("Warning: failed to remove temp dir: %v", err)
+		}
+	}()
+
+	fm := NewFileManager(StorageConfig{
+		OutputDir:           tempDir,
+		Format:              FormatParquet,
+		MaxRecords:          2,
+		ParquetSummaryFiles: true,
+	})
+
+	for i := 0; i < 5; i++ {
+		record := &RedisRecord{
+			Key:        fmt.Sprintf("test:key%d", i),
+			Type:       "string",
+			Value:      "value",
+			TTLSeconds: -1,
+			ExportedAt: "2024-01-15T14:30:00Z",
+		}
+		if err := fm.WriteRecord(record); err != nil {
+			t.Fatalf("Failed to write record: %v", err)
+		}
+	}
+	if err := fm.Close(); err != nil {
+		t.Fatalf("Failed to close file manager: %v", err)
+	}
+
+	summary, err := readParquetFooter(filepath.Join(tempDir, parquetSummaryFileName))
+	if err != nil {
+		t.Fatalf("Failed to read _metadata: %v", err)
+	}
+	if summary.NumRows != 5 || summary.NumRowGroups() != 3 {
+		t.Errorf("Expected 5 rows in 3 row groups, got %d in %d", summary.NumRows, summary.NumRowGroups())
+	}
+
+	// Every row group points at a partition file relative to the output directory
+	for i := 0; i < summary.NumRowGroups(); i++ {
+		path := summary.RowGroups[i].Columns[0].GetFilePath()
+		if path != fm.metadata.Partitions[i].Path {
+			t.Errorf("Expected row group %d in %s, got %s", i, fm.metadata.Partitions[i].Path, path)
+		}
+	}
+
+	common, err := readParquetFooter(filepath.Join(tempDir, parquetCommonFileName))
+	if err != nil {
+		t.Fatalf("Failed to read _common_metadata: %v", err)
+	}
+	if common.NumRowGroups() != 0 || common.NumColumns() != len(fm.columns()) {
+		t.Errorf("Expected schema-only metadata with %d columns, got %d row groups and %d columns",
+			len(fm.columns()), common.NumRowGroups(), common.NumColumns())
+	}
+
+	// DuckDB reads the summary files as valid Parquet
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	var groups int
+	query := fmt.Sprintf("SELECT COUNT(DISTINCT row_group_id) FROM parquet_metadata('%s')", filepath.Join(tempDir, parquetSummaryFileName))
+	if err := db.QueryRow(query).Scan(&groups); err != nil {
+		t.Fatalf("DuckDB failed to read _metadata: %v", err)
+	}
+	if groups != 3 {
+		t.Errorf("Expected DuckDB to see 3 row groups, got %d", groups)
+	}
+}
+
+func TestFailedSidecarStillWritesMetadata(t *testing.T) {
+	tests := []struct {
+		name   string
+		config StorageConfig
+		// block occupies the sidecar's path in the output directory
+		block string
+	}{
+		{"summary files", StorageConfig{ParquetSummaryFiles: true}, filepath.Join(parquetCommonFileName, "blocker")},
+		{"iceberg", StorageConfig{IcebergMetadata: true}, icebergMetadataDir},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			config := tt.config
+			config.OutputDir = tempDir
+			config.Format = FormatParquet
+			fm := NewFileManager(config)
+
+			blocker := filepath.Join(tempDir, tt.block)
+			if err := os.MkdirAll(filepath.Dir(blocker), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(blocker, nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			record := &RedisRecord{Key: "test:key", Type: "string", Value: "value", TTLSeconds: -1, ExportedAt: "2024-01-15T14:30:00Z"}
+			if err := fm.WriteRecord(record); err != nil {
+				t.Fatalf("Failed to write record: %v", err)
+			}
+			if err := fm.Close(); err == nil {
+				t.Fatal("Expected Close to report the failed sidecar")
+			}
+
+			// The data is described even though the sidecar is missing
+			data, err := os.ReadFile(filepath.Join(tempDir, metadataFileName))
+			if err != nil {
+				t.Fatalf("Expected metadata to be written, got %v", err)
+			}
+			var metadata ExportMetadata
+			if err := json.Unmarshal(data, &metadata); err != nil {
+				t.Fatal(err)
+			}
+			if metadata.Status != exportStatusPartial || len(metadata.Partitions) != 1 {
+				t.Errorf("Expected a partial export with 1 partition, got status=%q partitions=%d", metadata.Status, len(metadata.Partitions))
+			}
+		})
+	}
+}
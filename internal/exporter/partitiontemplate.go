@@ -0,0 +1,117 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// partitionTemplateFields is what a PartitionTemplate is rendered over. Keys
+// and values are left out so they never influence directory names.
+type partitionTemplateFields struct {
+	// Type is the record type, e.g. "hash" for a key or "hash_field" for an element
+	Type       string
+	Slot       int
+	TTLSeconds int64
+	// Time is when the record was written; Date, Year, Month, Day and Hour
+	// format it like the time partition directories
+	Time  time.Time
+	Date  string
+	Year  string
+	Month string
+	Day   string
+	Hour  string
+}
+
+// newPartitionTemplateFields returns the template fields of a record written at now
+func newPartitionTemplateFields(record *RedisRecord, now time.Time) partitionTemplateFields {
+	return partitionTemplateFields{
+		Type:       record.Type,
+		Slot:       record.Slot,
+		TTLSeconds: record.TTLSeconds,
+		Time:       now,
+		Date:       now.Format("2006-01-02"),
+		Year:       now.Format("2006"),
+		Month:      now.Format("01"),
+		Day:        now.Format("02"),
+		Hour:       now.Format("15"),
+	}
+}
+
+// parsePartitionTemplate parses a PartitionTemplate and renders it once over
+// a sample record, so references to unknown fields fail before any output
+func parsePartitionTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("partition").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse partition template: %w", err)
+	}
+
+	sample := &RedisRecord{Type: "string", TTLSeconds: ttlNoExpiry}
+	if _, err := renderPartitionTemplate(tmpl, newPartitionTemplateFields(sample, time.Now())); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// renderPartitionTemplate renders the partition directory for fields,
+// relative to the output directory. The result must stay inside it: empty,
+// absolute and ".." paths are rejected.
+func renderPartitionTemplate(tmpl *template.Template, fields partitionTemplateFields) (string, error) {
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, fields); err != nil {
+		return "", fmt.Errorf("failed to render partition template: %w", err)
+	}
+
+	route := rendered.String()
+	if strings.ContainsAny(route, "\\\x00") {
+		return "", fmt.Errorf("partition template rendered an invalid path: %q", route)
+	}
+	for _, segment := range strings.Split(route, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("partition template rendered a path outside the output directory: %q", route)
+		}
+	}
+
+	route = path.Clean(route)
+	if route == "." || !filepath.IsLocal(route) {
+		return "", fmt.Errorf("partition template rendered a path outside the output directory: %q", rendered.String())
+	}
+	return route, nil
+}
+
+// validatePartitionTemplate checks that a template is given exactly when
+// partitioning by template, and that it parses
+func validatePartitionTemplate(config StorageConfig) error {
+	if config.PartitionBy != PartitionByTemplate {
+		if config.PartitionTemplate != "" {
+			return fmt.Errorf("partition template requires template partitioning, got: %s", config.PartitionBy)
+		}
+		return nil
+	}
+
+	if config.PartitionTemplate == "" {
+		return errors.New("template partitioning requires a partition template")
+	}
+	if config.Stream {
+		return errors.New("FIFO output does not support a partition template")
+	}
+	_, err := parsePartitionTemplate(config.PartitionTemplate)
+	return err
+}
+
+// templateRoute renders the partition template for record, parsing it on
+// first use
+func (fm *FileManager) templateRoute(record *RedisRecord) (string, error) {
+	if fm.partitionTemplate == nil {
+		tmpl, err := parsePartitionTemplate(fm.config.PartitionTemplate)
+		if err != nil {
+			return "", err
+		}
+		fm.partitionTemplate = tmpl
+	}
+	return renderPartitionTemplate(fm.partitionTemplate, newPartitionTemplateFields(record, time.Now()))
+}
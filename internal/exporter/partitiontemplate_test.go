@@ -0,0 +1,155 @@
+package exporter
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestRenderPartitionTemplate(t *testing.T) {
+	now := time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC)
+	record := &RedisRecord{Type: "hash_field", Slot: 42, TTLSeconds: -1}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+		wantErr  string
+	}{
+		{"type and date", "type={{.Type}}/date={{.Date}}/", "type=hash_field/date=2024-01-15", ""},
+		{"time fields", "year={{.Year}}/hour={{.Hour}}/slot={{.Slot}}", "year=2024/hour=14/slot=42", ""},
+		{"time format", `month={{.Time.Format "2006-01"}}`, "month=2024-01", ""},
+		{"parent escape", "../{{.Type}}", "", "outside the output directory"},
+		{"nested escape", "a/../../{{.Type}}", "", "outside the output directory"},
+		{"inner dot dot", "a/../{{.Type}}", "", "outside the output directory"},
+		{"absolute", "/tmp/{{.Type}}", "", "outside the output directory"},
+		{"empty", "{{if false}}x{{end}}", "", "outside the output directory"},
+		{"backslash", `a\{{.Type}}`, "", "invalid path"},
+		{"unknown field", "{{.Key}}", "", "failed to render"},
+		{"syntax", "{{.Type", "", "failed to parse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := parsePartitionTemplate(tt.template)
+			var route string
+			if err == nil {
+				route, err = renderPartitionTemplate(tmpl, newPartitionTemplateFields(record, now))
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if route != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, route)
+			}
+		})
+	}
+}
+
+func TestValidatePartitionTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  StorageConfig
+		wantErr string
+	}{
+		{"template", StorageConfig{Format: FormatCSV, PartitionBy: PartitionByTemplate, PartitionTemplate: "type={{.Type}}"}, ""},
+		{"missing template", StorageConfig{Format: FormatCSV, PartitionBy: PartitionByTemplate}, "requires a partition template"},
+		{"template without scheme", StorageConfig{Format: FormatCSV, PartitionBy: PartitionByAge, PartitionTemplate: "x"}, "requires template partitioning"},
+		{"fifo", StorageConfig{Format: FormatCSV, Stream: true, PartitionBy: PartitionByTemplate, PartitionTemplate: "x"}, "FIFO"},
+		{"escape", StorageConfig{Format: FormatCSV, PartitionBy: PartitionByTemplate, PartitionTemplate: "../x"}, "outside the output directory"},
+		{"materialized columns", StorageConfig{Format: FormatCSV, PartitionBy: PartitionByTemplate, PartitionTemplate: "x", MaterializePartitionCols: true}, "require time partitioning"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStorageConfig(tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestPartitionTemplate(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{PartitionTemplate: "type={{.Type}}/date={{.Date}}/"})
+	if err := mr.Set("config", "value"); err != nil {
+		t.Fatal(err)
+	}
+	mr.HSet("user:1", "name", "alice", "age", "30")
+
+	outputDir := exp.fileManager.config.OutputDir
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("ExportByPattern failed: %v", err)
+	}
+
+	date := time.Now().Format("2006-01-02")
+	counts := make(map[string]int)
+	for _, file := range findDataFiles(t, outputDir, ".csv") {
+		rel, err := filepath.Rel(outputDir, filepath.Dir(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[filepath.ToSlash(rel)] += countCSVRecords(t, file)
+	}
+
+	expected := map[string]int{
+		"type=string/date=" + date:     1,
+		"type=hash/date=" + date:       1,
+		"type=hash_field/date=" + date: 2,
+	}
+	if len(counts) != len(expected) {
+		t.Fatalf("Expected partitions %v, got %v", expected, counts)
+	}
+	for partition, count := range expected {
+		if counts[partition] != count {
+			t.Errorf("Expected %d records in %s, got %d", count, partition, counts[partition])
+		}
+	}
+
+	// DuckDB reads the layout back with Hive partitioning
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var fields int
+	query := "SELECT COUNT(*) FROM " + exp.fileManager.QuerySource() + " WHERE type = 'hash_field'"
+	if err := db.QueryRow(query).Scan(&fields); err != nil {
+		t.Fatalf("Failed to query export: %v", err)
+	}
+	if fields != 2 {
+		t.Errorf("Expected 2 hash fields, got %d", fields)
+	}
+}
+
+func TestPartitionTemplateConflicts(t *testing.T) {
+	mr := miniredis.RunT(t)
+	_, err := NewRedisExporter(RedisExporterOptions{
+		RedisURL:          "redis://" + mr.Addr() + "/0",
+		OutputDir:         t.TempDir(),
+		PartitionBy:       "age",
+		PartitionTemplate: "type={{.Type}}",
+	})
+	if err == nil || !strings.Contains(err.Error(), "PARTITION_TEMPLATE") {
+		t.Errorf("Expected PARTITION_BY=age to be rejected with a template, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// defaultPollSeenLimit bounds the memory Poll spends remembering keys
+const defaultPollSeenLimit = 1_000_000
+
+// errPollStopped ends a pass early when Poll is cancelled
+var errPollStopped = errors.New("poll stopped")
+
+// Poll exports every key matching pattern, then rescans every PollInterval
+// and exports only the keys that were not there in the previous pass, until
+// ctx is cancelled. Open partitions are closed after each pass so its keys
+// become readable. Changes to keys that already existed and deletions are not
+// detected; use Watch where keyspace notifications are available.
+func (re *RedisExporter) Poll(ctx context.Context, pattern string) (err error) {
+	defer re.closeExport(&err)
+
+	if err := re.requireLiveServer("POLL_INTERVAL"); err != nil {
+		return err
+	}
+	if err := re.requireSingleSource("POLL_INTERVAL"); err != nil {
+		return err
+	}
+	if err := re.requireValueColumn(); err != nil {
+		return err
+	}
+	if re.pollInterval <= 0 {
+		return errors.New("POLL_INTERVAL must be positive")
+	}
+
+	re.fileManager.SetMetadata(pattern, 0)
+
+	// The first pass has nothing to compare with and exports every key
+	var previous exactKeySet
+	count := 0
+	for pass := 1; ; pass++ {
+		current, exported, err := re.pollPass(ctx, pattern, previous)
+		count += exported
+		if errors.Is(err, errPollStopped) {
+			break
+		}
+		if err != nil {
+			re.fileManager.SetMetadata(pattern, int64(count))
+			re.fileManager.MarkPartial(err)
+			return err
+		}
+		previous = current
+
+		// Close the open partitions so readers see this pass
+		if re.fileManager.config.Stream {
+			re.flushAll()
+		} else if err := re.fileManager.RotateWriter(); err != nil {
+			err = fmt.Errorf("failed to rotate partitions: %w", err)
+			re.fileManager.SetMetadata(pattern, int64(count))
+			re.fileManager.MarkPartial(err)
+			return err
+		}
+		re.verbosity.infof("Poll pass %d exported %d new keys, next pass in %s\n", pass, exported, re.pollInterval)
+
+		timer := time.NewTimer(re.pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	re.fileManager.SetMetadata(pattern, int64(count))
+	fmt.Printf("Poll stopped! Total keys exported: %d\n", count)
+	return nil
+}
+
+// pollPass scans pattern once, exporting the keys not in previous (all of
+// them when previous is nil), and returns the keys it saw, up to the seen
+// limit, with the number exported
+func (re *RedisExporter) pollPass(ctx context.Context, pattern string, previous exactKeySet) (exactKeySet, int, error) {
+	limit := re.pollSeenLimit
+	if limit <= 0 {
+		limit = defaultPollSeenLimit
+	}
+
+	current := make(exactKeySet)
+	overflow := 0
+	count := 0
+	err := re.scanBatches(pattern, func(keys []string) error {
+		// Finish the batch in hand but start no other once cancelled
+		if ctx.Err() != nil {
+			return errPollStopped
+		}
+
+		var fresh []string
+		for _, key := range keys {
+			if len(current) < limit {
+				current[key] = struct{}{}
+			} else {
+				overflow++
+			}
+			if !previous.contains(key) {
+				fresh = append(fresh, key)
+			}
+		}
+
+		fresh, small := re.filterBySize(fresh)
+		re.fileManager.AddSmallKeys(int64(small))
+
+		idle := re.keyIdleSeconds(fresh)
+		for _, key := range fresh {
+			if err := re.exportKey(key, idle[key]); err != nil {
+				if errors.Is(err, ErrOutputFull) {
+					return err
+				}
+				log.Printf("Error exporting key %s: %v", key, err)
+				continue
+			}
+			count++
+
+			if count%100 == 0 {
+				re.verbosity.infof("Exported %d new keys...\n", count)
+				re.flushAll()
+			}
+		}
+		return nil
+	})
+
+	if overflow > 0 {
+		fmt.Printf("Warning: poll seen-set is full at %d keys, %d keys will be exported again next pass\n", limit, overflow)
+	}
+	return current, count, err
+}
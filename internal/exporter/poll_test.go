@@ -0,0 +1,137 @@
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// scanHook calls onScan with the running number of SCAN commands before each is sent
+type scanHook struct {
+	commandCountHook
+	onScan func(scans int)
+}
+
+func (h scanHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() == "scan" {
+			*h.count++
+			h.onScan(*h.count)
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func TestPoll(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{PollInterval: time.Millisecond})
+	mr.Set("user:1", "alice")
+	mr.Set("user:2", "bob")
+	mr.Set("order:1", "ignored by the pattern")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Each pass is a single SCAN: add a key before the second and stop at the third
+	scans := 0
+	exp.client.AddHook(scanHook{commandCountHook: commandCountHook{count: &scans}, onScan: func(n int) {
+		switch n {
+		case 2:
+			mr.Set("user:3", "carol")
+		case 3:
+			cancel()
+		}
+	}})
+
+	if err := exp.Poll(ctx, "user:*"); err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for _, row := range readCSVRows(t, exp.fileManager.config.OutputDir) {
+		if row[0] != "key" {
+			counts[row[0]]++
+		}
+	}
+	expected := map[string]int{"user:1": 1, "user:2": 1, "user:3": 1}
+	if len(counts) != len(expected) {
+		t.Errorf("Expected %v, got %v", expected, counts)
+	}
+	for key, n := range expected {
+		if counts[key] != n {
+			t.Errorf("Expected %s exported %d time(s), got %d", key, n, counts[key])
+		}
+	}
+
+	// The passes were rotated into separate partitions
+	if partitions := len(exp.fileManager.metadata.Partitions); partitions != 2 {
+		t.Errorf("Expected 2 partitions, got %d", partitions)
+	}
+	if status := exp.fileManager.metadata.Status; status != exportStatusComplete {
+		t.Errorf("Expected a complete export after shutdown, got %s", status)
+	}
+}
+
+func TestPollCancelledBeforeFirstPass(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{PollInterval: time.Hour})
+	mr.Set("user:1", "alice")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := exp.Poll(ctx, "*"); err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if exp.fileManager.recordCount != 0 {
+		t.Errorf("Expected nothing exported, got %d records", exp.fileManager.recordCount)
+	}
+}
+
+func TestPollPassSeenLimit(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{PollInterval: time.Second, PollSeenLimit: 2})
+	defer func() {
+		_ = exp.Close()
+	}()
+	for _, key := range []string{"a", "b", "c"} {
+		mr.Set(key, "v")
+	}
+
+	seen, exported, err := exp.pollPass(context.Background(), "*", nil)
+	if err != nil {
+		t.Fatalf("First pass failed: %v", err)
+	}
+	if exported != 3 || len(seen) != 2 {
+		t.Fatalf("Expected 3 keys exported and 2 remembered, got %d and %d", exported, len(seen))
+	}
+
+	// The key that did not fit is exported again, the others are not
+	_, exported, err = exp.pollPass(context.Background(), "*", seen)
+	if err != nil {
+		t.Fatalf("Second pass failed: %v", err)
+	}
+	if exported != 1 {
+		t.Errorf("Expected 1 key exported again, got %d", exported)
+	}
+}
+
+func TestPollOptionConflicts(t *testing.T) {
+	mr := miniredis.RunT(t)
+	_, err := NewRedisExporter(RedisExporterOptions{
+		RedisURL:         "redis://" + mr.Addr() + "/0",
+		OutputDir:        t.TempDir(),
+		OutputFormat:     "csv",
+		PollInterval:     time.Second,
+		CompletedKeysLog: t.TempDir() + "/completed.log",
+	})
+	if err == nil || !strings.Contains(err.Error(), "COMPLETED_KEYS_LOG") {
+		t.Errorf("Expected POLL_INTERVAL with COMPLETED_KEYS_LOG to be rejected, got %v", err)
+	}
+
+	exp, _ := newTestExporter(t, RedisExporterOptions{})
+	if err := exp.Poll(context.Background(), "*"); err == nil || !strings.Contains(err.Error(), "POLL_INTERVAL") {
+		t.Errorf("Expected Poll without an interval to fail, got %v", err)
+	}
+}
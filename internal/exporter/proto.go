@@ -0,0 +1,145 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/cameronnewman/redis-dumper/recordpb"
+	"google.golang.org/protobuf/encoding/protodelim"
+)
+
+// protoRowWriter writes rows as varint length-delimited recordpb.RedisRecord
+// messages. It takes the same rows as the CSV writers, so rotation, gzip,
+// checksums and reproducible sorting work exactly as they do for CSV.
+type protoRowWriter struct {
+	w *bufio.Writer
+	// set assigns each column, in order, to its message field
+	set []func(msg *recordpb.RedisRecord, value string) error
+	err error
+}
+
+// newProtoRowWriter returns a writer for rows with the given column names
+func newProtoRowWriter(out io.Writer, names []string) (*protoRowWriter, error) {
+	p := &protoRowWriter{w: bufio.NewWriter(out)}
+	for _, name := range names {
+		set, ok := protoFieldSetters[name]
+		if !ok {
+			return nil, fmt.Errorf("column %s has no protobuf field", name)
+		}
+		p.set = append(p.set, set)
+	}
+	return p, nil
+}
+
+// protoFieldSetters maps each column to its recordpb.RedisRecord field.
+// Numeric columns arrive formatted like CSV and are parsed back.
+var protoFieldSetters = map[string]func(msg *recordpb.RedisRecord, value string) error{
+	"key":   func(m *recordpb.RedisRecord, v string) error { m.Key = []byte(v); return nil },
+	"type":  func(m *recordpb.RedisRecord, v string) error { m.Type = v; return nil },
+	"value": func(m *recordpb.RedisRecord, v string) error { m.Value = []byte(v); return nil },
+	"ttl_seconds": func(m *recordpb.RedisRecord, v string) (err error) {
+		m.TtlSeconds, err = strconv.ParseInt(v, 10, 64)
+		return err
+	},
+	"exported_at": func(m *recordpb.RedisRecord, v string) error { m.ExportedAt = v; return nil },
+	"partition_id": func(m *recordpb.RedisRecord, v string) error {
+		n, err := strconv.ParseInt(v, 10, 32)
+		m.PartitionId = int32(n)
+		return err
+	},
+	"slot": func(m *recordpb.RedisRecord, v string) error {
+		n, err := strconv.ParseInt(v, 10, 32)
+		m.Slot = int32(n)
+		return err
+	},
+	"year":       func(m *recordpb.RedisRecord, v string) error { m.Year = v; return nil },
+	"month":      func(m *recordpb.RedisRecord, v string) error { m.Month = v; return nil },
+	"day":        func(m *recordpb.RedisRecord, v string) error { m.Day = v; return nil },
+	"hour":       func(m *recordpb.RedisRecord, v string) error { m.Hour = v; return nil },
+	"parent_key": func(m *recordpb.RedisRecord, v string) error { m.ParentKey = []byte(v); return nil },
+	"raw_dump":   func(m *recordpb.RedisRecord, v string) error { m.RawDump = []byte(v); return nil },
+	"source":     func(m *recordpb.RedisRecord, v string) error { m.Source = v; return nil },
+	"encoding":   func(m *recordpb.RedisRecord, v string) error { m.Encoding = v; return nil },
+	"expires_at": func(m *recordpb.RedisRecord, v string) error { m.ExpiresAt = v; return nil },
+	"value_truncated": func(m *recordpb.RedisRecord, v string) (err error) {
+		m.ValueTruncated, err = strconv.ParseBool(v)
+		return err
+	},
+	"elements_truncated": func(m *recordpb.RedisRecord, v string) (err error) {
+		m.ElementsTruncated, err = strconv.ParseBool(v)
+		return err
+	},
+	// An empty full length is NULL and stays unset
+	"full_length": func(m *recordpb.RedisRecord, v string) error {
+		if v == "" {
+			return nil
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		m.FullLength = &n
+		return err
+	},
+	"size_estimate": func(m *recordpb.RedisRecord, v string) (err error) {
+		m.SizeEstimate, err = strconv.ParseInt(v, 10, 64)
+		return err
+	},
+	// An empty cardinality is NULL and stays unset
+	"cardinality": func(m *recordpb.RedisRecord, v string) error {
+		if v == "" {
+			return nil
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		m.Cardinality = &n
+		return err
+	},
+	// An empty idle time is NULL and stays unset
+	"idle_seconds": func(m *recordpb.RedisRecord, v string) error {
+		if v == "" {
+			return nil
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		m.IdleSeconds = &n
+		return err
+	},
+}
+
+func (p *protoRowWriter) Write(record []string) error {
+	if p.err != nil {
+		return p.err
+	}
+	if len(record) != len(p.set) {
+		p.err = fmt.Errorf("expected %d columns, got %d", len(p.set), len(record))
+		return p.err
+	}
+
+	msg := &recordpb.RedisRecord{}
+	for i, value := range record {
+		if err := p.set[i](msg, value); err != nil {
+			p.err = fmt.Errorf("failed to encode column %d: %w", i, err)
+			return p.err
+		}
+	}
+	_, p.err = protodelim.MarshalTo(p.w, msg)
+	return p.err
+}
+
+func (p *protoRowWriter) WriteAll(records [][]string) error {
+	for _, record := range records {
+		if err := p.Write(record); err != nil {
+			return err
+		}
+	}
+	p.Flush()
+	return p.err
+}
+
+func (p *protoRowWriter) Flush() {
+	if err := p.w.Flush(); err != nil && p.err == nil {
+		p.err = err
+	}
+}
+
+func (p *protoRowWriter) Error() error {
+	return p.err
+}
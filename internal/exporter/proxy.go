@@ -0,0 +1,133 @@
+package exporter
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// directDialer matches the dialer go-redis uses without a proxy
+var directDialer = &net.Dialer{KeepAlive: 5 * time.Minute}
+
+// proxyDialer dials through the SOCKS5 or HTTP CONNECT proxy at proxyURL,
+// except for hosts matched by noProxy, a NO_PROXY style list of hosts,
+// domains, IPs and CIDR ranges
+func proxyDialer(proxyURL, noProxy string) (dialFunc, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proxy URL: %w", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy URL has no host: %s", u.Redacted())
+	}
+
+	var dialer contextDialer
+	switch u.Scheme {
+	case "socks5", "socks5h":
+		socks, err := proxy.FromURL(u, directDialer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create SOCKS5 dialer: %w", err)
+		}
+		dialer = socks.(contextDialer)
+	case "http":
+		dialer = &httpConnectDialer{proxy: u}
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme: %s (supported: socks5, socks5h, http)", u.Scheme)
+	}
+
+	if noProxy != "" {
+		perHost := proxy.NewPerHost(dialer, directDialer)
+		perHost.AddFromString(noProxy)
+		dialer = perHost
+	}
+	return dialer.DialContext, nil
+}
+
+// contextDialer is what proxy.NewPerHost needs of the proxy dialer
+type contextDialer interface {
+	proxy.Dialer
+	proxy.ContextDialer
+}
+
+// httpConnectDialer tunnels connections through an HTTP proxy with CONNECT
+type httpConnectDialer struct {
+	proxy *url.URL
+}
+
+func (d *httpConnectDialer) Dial(network, addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, addr)
+}
+
+func (d *httpConnectDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	proxyAddr := d.proxy.Host
+	if d.proxy.Port() == "" {
+		proxyAddr = net.JoinHostPort(d.proxy.Hostname(), "80")
+	}
+	conn, err := directDialer.DialContext(ctx, network, proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to proxy %s: %w", proxyAddr, err)
+	}
+
+	// The handshake shares the dial's deadline
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	tunneled, err := d.connect(conn, addr)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return tunneled, nil
+}
+
+// connect asks the proxy on conn for a tunnel to addr
+func (d *httpConnectDialer) connect(conn net.Conn, addr string) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if user := d.proxy.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, fmt.Errorf("failed to send CONNECT to proxy: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proxy response: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("proxy refused CONNECT to %s: %s", addr, resp.Status)
+	}
+
+	// Anything the proxy sent past its response belongs to Redis
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn reads what a bufio.Reader already took from Conn first
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
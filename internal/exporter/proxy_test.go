@@ -0,0 +1,214 @@
+package exporter
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// testProxy counts the connections a test proxy relayed
+type testProxy struct {
+	addr    string
+	relayed atomic.Int64
+}
+
+// startTestProxy accepts connections and hands each to handshake, which
+// returns the target address to relay to, or "" to drop the connection
+func startTestProxy(t *testing.T, handshake func(conn net.Conn, reader *bufio.Reader) string) *testProxy {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	p := &testProxy{addr: listener.Addr().String()}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				target := handshake(conn, reader)
+				if target == "" {
+					return
+				}
+				upstream, err := net.Dial("tcp", target)
+				if err != nil {
+					return
+				}
+				defer upstream.Close()
+				p.relayed.Add(1)
+				go io.Copy(upstream, reader)
+				io.Copy(conn, upstream)
+			}()
+		}
+	}()
+	return p
+}
+
+// httpConnectHandshake accepts CONNECT from user:secret
+func httpConnectHandshake(conn net.Conn, reader *bufio.Reader) string {
+	req, err := http.ReadRequest(reader)
+	if err != nil || req.Method != http.MethodConnect {
+		return ""
+	}
+	if req.Header.Get("Proxy-Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte("user:secret")) {
+		io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+		return ""
+	}
+	io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+	return req.Host
+}
+
+// socks5Handshake accepts unauthenticated CONNECT requests
+func socks5Handshake(conn net.Conn, reader *bufio.Reader) string {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(reader, header); err != nil || header[0] != 5 {
+		return ""
+	}
+	if _, err := io.ReadFull(reader, make([]byte, header[1])); err != nil {
+		return ""
+	}
+	conn.Write([]byte{5, 0})
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(reader, request); err != nil || request[1] != 1 {
+		return ""
+	}
+	var host string
+	switch request[3] {
+	case 1:
+		ip := make([]byte, 4)
+		if _, err := io.ReadFull(reader, ip); err != nil {
+			return ""
+		}
+		host = net.IP(ip).String()
+	case 3:
+		length, err := reader.ReadByte()
+		if err != nil {
+			return ""
+		}
+		name := make([]byte, length)
+		if _, err := io.ReadFull(reader, name); err != nil {
+			return ""
+		}
+		host = string(name)
+	default:
+		return ""
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(reader, port); err != nil {
+		return ""
+	}
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port))))
+}
+
+func TestProxyExport(t *testing.T) {
+	tests := []struct {
+		name      string
+		handshake func(net.Conn, *bufio.Reader) string
+		scheme    string
+	}{
+		{"http", httpConnectHandshake, "http://user:secret@"},
+		{"socks5", socks5Handshake, "socks5://"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := startTestProxy(t, tt.handshake)
+			mr := miniredis.RunT(t)
+			mr.Set("greeting", "hello")
+
+			exp, err := NewRedisExporter(RedisExporterOptions{
+				RedisURL:     "redis://" + mr.Addr() + "/0",
+				OutputDir:    t.TempDir(),
+				OutputFormat: "csv",
+				ProxyURL:     tt.scheme + proxy.addr,
+			})
+			if err != nil {
+				t.Fatalf("NewRedisExporter failed: %v", err)
+			}
+			if err := exp.ExportByPattern("*"); err != nil {
+				t.Fatalf("Export failed: %v", err)
+			}
+			if proxy.relayed.Load() == 0 {
+				t.Error("Expected Redis connections to go through the proxy")
+			}
+		})
+	}
+}
+
+func TestProxyDialer(t *testing.T) {
+	proxy := startTestProxy(t, httpConnectHandshake)
+	mr := miniredis.RunT(t)
+
+	// NO_PROXY hosts are dialed directly
+	dial, err := proxyDialer("http://user:secret@"+proxy.addr, "localhost,127.0.0.0/8")
+	if err != nil {
+		t.Fatalf("proxyDialer failed: %v", err)
+	}
+	conn, err := dial(context.Background(), "tcp", mr.Addr())
+	if err != nil {
+		t.Fatalf("Direct dial failed: %v", err)
+	}
+	conn.Close()
+	if proxy.relayed.Load() != 0 {
+		t.Error("Expected NO_PROXY to bypass the proxy")
+	}
+
+	// Wrong credentials are refused by the proxy
+	dial, err = proxyDialer("http://user:wrong@"+proxy.addr, "")
+	if err != nil {
+		t.Fatalf("proxyDialer failed: %v", err)
+	}
+	if _, err := dial(context.Background(), "tcp", mr.Addr()); err == nil {
+		t.Error("Expected the proxy to refuse bad credentials")
+	}
+
+	for _, bad := range []string{"ftp://proxy:21", "socks5://", "::"} {
+		if _, err := proxyDialer(bad, ""); err == nil {
+			t.Errorf("Expected proxy URL %q to be rejected", bad)
+		}
+	}
+}
+
+func TestCustomDialer(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	var dials atomic.Int64
+	exp, err := NewRedisExporter(RedisExporterOptions{
+		RedisURL:     "redis://" + mr.Addr() + "/0",
+		OutputDir:    t.TempDir(),
+		OutputFormat: "csv",
+		// The custom dialer takes precedence over the proxy
+		ProxyURL: "http://127.0.0.1:1",
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dials.Add(1)
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewRedisExporter failed: %v", err)
+	}
+	if err := exp.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if dials.Load() == 0 {
+		t.Error("Expected connections to use the custom dialer")
+	}
+}
@@ -0,0 +1,116 @@
+package exporter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const loadSQLFileName = "load.sql"
+
+// queryable reports whether DuckDB can read the data files, which it cannot
+// for protobuf
+func (fm *FileManager) queryable() bool {
+	return fm.config.Format != FormatProto
+}
+
+// QueryLocation returns the glob DuckDB should read the export from: under
+// QueryURI when the output is served from object storage, otherwise locally
+func (fm *FileManager) QueryLocation() string {
+	if fm.config.QueryURI == "" {
+		return fm.GetQueryPath()
+	}
+	return fmt.Sprintf("%s/**/*.%s", strings.TrimRight(fm.config.QueryURI, "/"), fm.fileExtension())
+}
+
+// QuerySource returns the DuckDB table function reading the whole export
+func (fm *FileManager) QuerySource() string {
+	location := strings.ReplaceAll(fm.QueryLocation(), "'", "''")
+
+	// Materialized partition columns are already in the files
+	hive := "true"
+	if fm.config.MaterializePartitionCols {
+		hive = "false"
+	}
+
+	switch fm.config.Format {
+	case FormatCSV:
+		return fmt.Sprintf("read_csv('%s', %s, hive_partitioning=%s)", location, fm.csvDialectOptions(), hive)
+	default:
+		return fmt.Sprintf("read_parquet('%s', hive_partitioning=%s)", location, hive)
+	}
+}
+
+// csvDialectOptions returns the read_csv options matching the CSV dialect.
+// Headerless files are given the column names explicitly.
+func (fm *FileManager) csvDialectOptions() string {
+	sqlRune := func(r rune) string {
+		return "'" + strings.ReplaceAll(string(r), "'", "''") + "'"
+	}
+
+	options := []string{"header=true"}
+	if fm.config.OmitCSVHeader {
+		var names []string
+		for _, name := range fm.columnNames() {
+			names = append(names, "'"+name+"'")
+		}
+		options = []string{"header=false", "names=[" + strings.Join(names, ", ") + "]"}
+	}
+	if delimiter := fm.config.csvDelimiter(); delimiter != defaultCSVDelimiter {
+		options = append(options, "delim="+sqlRune(delimiter))
+	}
+	if quote := fm.config.csvQuote(); quote != defaultCSVQuote {
+		options = append(options, "quote="+sqlRune(quote), "escape="+sqlRune(quote))
+	}
+	return strings.Join(options, ", ")
+}
+
+// querySetup returns the DuckDB statements needed before reading QueryURI
+func (fm *FileManager) querySetup() []string {
+	uri := fm.config.QueryURI
+	switch {
+	case strings.HasPrefix(uri, "s3://"):
+		setup := []string{"INSTALL httpfs;", "LOAD httpfs;"}
+		if fm.config.QueryRegion != "" {
+			region := strings.ReplaceAll(fm.config.QueryRegion, "'", "''")
+			setup = append(setup, fmt.Sprintf("SET s3_region='%s';", region))
+		}
+		return append(setup, "-- Credentials: CREATE SECRET (TYPE S3, PROVIDER CREDENTIAL_CHAIN);")
+	case strings.HasPrefix(uri, "gs://"), strings.HasPrefix(uri, "gcs://"):
+		return []string{
+			"INSTALL httpfs;",
+			"LOAD httpfs;",
+			"-- Credentials: CREATE SECRET (TYPE GCS, KEY_ID '<hmac key>', SECRET '<hmac secret>');",
+		}
+	case strings.HasPrefix(uri, "az://"), strings.HasPrefix(uri, "azure://"), strings.HasPrefix(uri, "abfss://"):
+		return []string{
+			"INSTALL azure;",
+			"LOAD azure;",
+			"-- Credentials: CREATE SECRET (TYPE AZURE, PROVIDER CREDENTIAL_CHAIN, ACCOUNT_NAME '<account>');",
+		}
+	default:
+		return nil
+	}
+}
+
+// loadSQL builds a script that creates a redis_data view over the export
+func (fm *FileManager) loadSQL() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- Load %s into DuckDB: duckdb -init %s\n", fm.metadata.ExportID, loadSQLFileName)
+	for _, statement := range fm.querySetup() {
+		b.WriteString(statement)
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "CREATE OR REPLACE VIEW redis_data AS SELECT * FROM %s;\n", fm.QuerySource())
+	return b.String()
+}
+
+// writeLoadSQL writes load.sql next to export_metadata.json
+func (fm *FileManager) writeLoadSQL() error {
+	path := filepath.Join(fm.config.OutputDir, loadSQLFileName)
+	if err := os.WriteFile(path, []byte(fm.loadSQL()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", loadSQLFileName, err)
+	}
+	return nil
+}
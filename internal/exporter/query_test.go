@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuerySource(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   StorageConfig
+		expected string
+	}{
+		{
+			name:     "local parquet",
+			config:   StorageConfig{OutputDir: "/tmp/out", Format: FormatParquet},
+			expected: "read_parquet('/tmp/out/**/*.parquet', hive_partitioning=true)",
+		},
+		{
+			name:     "s3 parquet",
+			config:   StorageConfig{OutputDir: "/tmp/out", Format: FormatParquet, QueryURI: "s3://bucket/prefix/"},
+			expected: "read_parquet('s3://bucket/prefix/**/*.parquet', hive_partitioning=true)",
+		},
+		{
+			name:     "gcs csv",
+			config:   StorageConfig{OutputDir: "/tmp/out", Format: FormatCSV, QueryURI: "gs://bucket/prefix"},
+			expected: "read_csv('gs://bucket/prefix/**/*.csv', header=true, hive_partitioning=true)",
+		},
+		{
+			name: "headerless tsv",
+			config: StorageConfig{OutputDir: "/tmp/out", Format: FormatCSV, OmitPartitionID: true,
+				CSVDelimiter: '\t', CSVQuote: '\'', OmitCSVHeader: true},
+			expected: "read_csv('/tmp/out/**/*.csv', header=false, names=['key', 'type', 'value', 'ttl_seconds', 'exported_at'], " +
+				"delim='\t', quote='''', escape='''', hive_partitioning=true)",
+		},
+		{
+			name:     "materialized partition columns",
+			config:   StorageConfig{OutputDir: "/tmp/out", Format: FormatParquet, MaterializePartitionCols: true},
+			expected: "read_parquet('/tmp/out/**/*.parquet', hive_partitioning=false)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := NewFileManager(tt.config)
+			if got := fm.QuerySource(); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLoadSQL(t *testing.T) {
+	fm := NewFileManager(StorageConfig{
+		OutputDir:   "/tmp/out",
+		Format:      FormatParquet,
+		QueryURI:    "s3://bucket/prefix",
+		QueryRegion: "eu-west-1",
+	})
+
+	script := fm.loadSQL()
+	for _, expected := range []string{
+		"LOAD httpfs;",
+		"SET s3_region='eu-west-1';",
+		"CREATE OR REPLACE VIEW redis_data AS SELECT * FROM read_parquet('s3://bucket/prefix/**/*.parquet', hive_partitioning=true);",
+	} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("Expected load.sql to contain %q, got:\n%s", expected, script)
+		}
+	}
+
+	// A quote cannot end the region literal early
+	quoted := NewFileManager(StorageConfig{
+		OutputDir:   "/tmp/out",
+		Format:      FormatParquet,
+		QueryURI:    "s3://bucket/prefix",
+		QueryRegion: "eu'; DROP TABLE x; --",
+	})
+	if expected := "SET s3_region='eu''; DROP TABLE x; --';"; !strings.Contains(quoted.loadSQL(), expected) {
+		t.Errorf("Expected load.sql to contain %q, got:\n%s", expected, quoted.loadSQL())
+	}
+
+	// Local exports need no extensions
+	local := NewFileManager(StorageConfig{OutputDir: "/tmp/out", Format: FormatParquet})
+	if strings.Contains(local.loadSQL(), "httpfs") {
+		t.Error("Expected no httpfs setup for a local export")
+	}
+}
@@ -0,0 +1,108 @@
+package exporter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// rateLimiter is a token bucket shared by every connection of an export. It
+// refills at rate tokens per second up to a second's worth, and each command
+// sent takes a token, so SCAN calls, pipelines and per-key reads together stay
+// under the limit.
+type rateLimiter struct {
+	rate float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns nil, which never waits, when opsPerSecond is not positive
+func newRateLimiter(opsPerSecond int) *rateLimiter {
+	if opsPerSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{rate: float64(opsPerSecond), tokens: float64(opsPerSecond), last: time.Now()}
+}
+
+// reserve takes n tokens and returns how long to wait before they are
+// available. A pipeline larger than the bucket runs into debt that later
+// commands wait out.
+func (l *rateLimiter) reserve(n int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// wait blocks until n commands may be sent, or until ctx is done
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	delay := l.reserve(n)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DialHook leaves connecting unlimited; only commands take tokens
+func (l *rateLimiter) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (l *rateLimiter) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := l.wait(ctx, 1); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (l *rateLimiter) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := l.wait(ctx, len(cmds)); err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		return next(ctx, cmds)
+	}
+}
+
+// limit applies l to every command of client. Commands of a cluster are
+// sent by its node clients, which also carry the SCAN of each master.
+func (l *rateLimiter) limit(client redis.UniversalClient) {
+	if l == nil {
+		return
+	}
+	if cluster, ok := client.(*redis.ClusterClient); ok {
+		cluster.OnNewNode(func(node *redis.Client) {
+			node.AddHook(l)
+		})
+		return
+	}
+	client.AddHook(l)
+}
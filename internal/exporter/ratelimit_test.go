@@ -0,0 +1,92 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	limiter := newRateLimiter(10)
+
+	// A second's worth of commands is available at once
+	if delay := limiter.reserve(10); delay != 0 {
+		t.Errorf("Expected the burst to pass, waited %s", delay)
+	}
+	// Then they are spaced out at the rate
+	if delay := limiter.reserve(1); delay < 90*time.Millisecond || delay > 110*time.Millisecond {
+		t.Errorf("Expected about 100ms, got %s", delay)
+	}
+	// A large pipeline is waited out by what follows it
+	if delay := limiter.reserve(5); delay < 550*time.Millisecond {
+		t.Errorf("Expected about 600ms, got %s", delay)
+	}
+
+	if newRateLimiter(0) != nil {
+		t.Error("Expected no limiter without a rate")
+	}
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	limiter := newRateLimiter(1)
+	limiter.reserve(10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.wait(ctx, 1); err != context.Canceled {
+		t.Errorf("Expected the wait to end with the context, got %v", err)
+	}
+}
+
+// opsCountHook counts every command sent, pipelined or not
+type opsCountHook struct {
+	passHook
+	count *atomic.Int64
+}
+
+func (h opsCountHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.count.Add(1)
+		return next(ctx, cmd)
+	}
+}
+
+func (h opsCountHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		h.count.Add(int64(len(cmds)))
+		return next(ctx, cmds)
+	}
+}
+
+func TestRateLimitedExport(t *testing.T) {
+	const opsPerSecond = 100
+	exp, mr := newTestExporter(t, RedisExporterOptions{BatchSize: 5, MaxOpsPerSecond: opsPerSecond})
+	for i := 0; i < 50; i++ {
+		mr.Set(fmt.Sprintf("key:%02d", i), "value")
+	}
+	ops := &atomic.Int64{}
+	exp.client.AddHook(opsCountHook{count: ops})
+
+	startedAt := time.Now()
+	if err := exp.ExportByPattern("key:*"); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	elapsed := time.Since(startedAt)
+
+	// The bucket starts full, so only commands beyond the burst are paced
+	sent := ops.Load()
+	if sent <= opsPerSecond {
+		t.Fatalf("Expected the export to send more than %d commands, sent %d", opsPerSecond, sent)
+	}
+	minimum := time.Duration(float64(sent-opsPerSecond) / opsPerSecond * 0.9 * float64(time.Second))
+	if elapsed < minimum {
+		t.Errorf("Expected %d commands to take at least %s, took %s", sent, minimum, elapsed)
+	}
+	if rows := readCSVRows(t, exp.fileManager.config.OutputDir); len(rows)-1 != 50 {
+		t.Errorf("Expected 50 keys, got %d", len(rows)-1)
+	}
+}
@@ -0,0 +1,1403 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// RDB opcodes that precede a key or carry file-level data
+const (
+	rdbOpSlotInfo    = 0xF4
+	rdbOpFunction2   = 0xF5
+	rdbOpFunctionPre = 0xF6
+	rdbOpModuleAux   = 0xF7
+	rdbOpIdle        = 0xF8
+	rdbOpFreq        = 0xF9
+	rdbOpAux         = 0xFA
+	rdbOpResizeDB    = 0xFB
+	rdbOpExpireMs    = 0xFC
+	rdbOpExpire      = 0xFD
+	rdbOpSelectDB    = 0xFE
+	rdbOpEOF         = 0xFF
+)
+
+// RDB value types
+const (
+	rdbTypeString              = 0
+	rdbTypeList                = 1
+	rdbTypeSet                 = 2
+	rdbTypeZSet                = 3
+	rdbTypeHash                = 4
+	rdbTypeZSet2               = 5
+	rdbTypeModulePreGA         = 6
+	rdbTypeModule2             = 7
+	rdbTypeHashZipmap          = 9
+	rdbTypeListZiplist         = 10
+	rdbTypeSetIntset           = 11
+	rdbTypeZSetZiplist         = 12
+	rdbTypeHashZiplist         = 13
+	rdbTypeListQuicklist       = 14
+	rdbTypeStreamListpacks     = 15
+	rdbTypeHashListpack        = 16
+	rdbTypeZSetListpack        = 17
+	rdbTypeListQuicklist2      = 18
+	rdbTypeStreamListpacks2    = 19
+	rdbTypeSetListpack         = 20
+	rdbTypeStreamListpacks3    = 21
+	rdbTypeHashMetadataPreGA   = 22
+	rdbTypeHashListpackExPreGA = 23
+	rdbTypeHashMetadata        = 24
+	rdbTypeHashListpackEx      = 25
+)
+
+// Special string encodings flagged by a length whose top bits are 11
+const (
+	rdbEncInt8  = 0
+	rdbEncInt16 = 1
+	rdbEncInt32 = 2
+	rdbEncLZF   = 3
+)
+
+// Module value opcodes, used to skip module data without the module
+const (
+	rdbModuleOpEOF    = 0
+	rdbModuleOpSInt   = 1
+	rdbModuleOpUInt   = 2
+	rdbModuleOpFloat  = 3
+	rdbModuleOpDouble = 4
+	rdbModuleOpString = 5
+)
+
+// rdbMaxVersion is the newest RDB version the parser understands
+const rdbMaxVersion = 12
+
+// rdbCRCTable is the CRC-64/Jones table Redis checksums RDB files with
+var rdbCRCTable = crc64.MakeTable(0x95AC9329AC4BC9B5)
+
+// rdbKey is one key decoded from an RDB file. Only the fields for its type are set.
+type rdbKey struct {
+	DB   int
+	Key  string
+	Type string
+	// ExpireAt is the key's expiry in Unix milliseconds, 0 when it has none
+	ExpireAt int64
+	// IdleSeconds is the LRU idle time saved with the key, -1 when absent
+	IdleSeconds int64
+
+	Value    string
+	Elements []string
+	Fields   []rdbField
+	Members  []rdbMember
+	Stream   *rdbStream
+}
+
+type rdbField struct {
+	Name  string
+	Value string
+	// ExpireAt is the field's expiry in Unix milliseconds, 0 when it has none
+	ExpireAt int64
+}
+
+type rdbMember struct {
+	Member string
+	Score  float64
+}
+
+// rdbStream holds a stream's live entries and consumer groups
+type rdbStream struct {
+	Length  int64
+	Entries []rdbStreamEntry
+	Groups  []rdbStreamGroup
+	// V1 is set for files written before Redis 7, which lack entries_read
+	V1 bool
+}
+
+type rdbStreamID struct {
+	Ms  uint64
+	Seq uint64
+}
+
+func (id rdbStreamID) String() string {
+	return fmt.Sprintf("%d-%d", id.Ms, id.Seq)
+}
+
+func (id rdbStreamID) after(other rdbStreamID) bool {
+	return id.Ms > other.Ms || (id.Ms == other.Ms && id.Seq > other.Seq)
+}
+
+type rdbStreamEntry struct {
+	ID     rdbStreamID
+	Fields []rdbField
+}
+
+type rdbStreamGroup struct {
+	Name   string
+	LastID rdbStreamID
+	// EntriesRead is -1 when Redis did not know it or the file predates it
+	EntriesRead int64
+	Pending     int64
+	Consumers   []rdbStreamConsumer
+}
+
+type rdbStreamConsumer struct {
+	Name    string
+	Pending int64
+	// SeenTime and ActiveTime are Unix milliseconds; ActiveTime is -1 when
+	// the consumer never read successfully
+	SeenTime   int64
+	ActiveTime int64
+}
+
+// rdbSummary describes an RDB file once it has been read to the end
+type rdbSummary struct {
+	Version int
+	Aux     map[string]string
+	// Skipped counts keys of types the exporter cannot represent, e.g. "module"
+	Skipped map[string]int64
+}
+
+// rdbReader decodes RDB primitives while checksumming every byte read
+type rdbReader struct {
+	r   *bufio.Reader
+	crc uint64
+}
+
+// rdbReadChunk bounds how far a buffer grows ahead of the bytes read into it
+const rdbReadChunk = 1 << 20
+
+// read returns the next n bytes. Lengths come from the file, so a corrupt one
+// must not allocate more than the input holds: buffers grow a chunk at a time
+// as the bytes arrive.
+func (r *rdbReader) read(n int) ([]byte, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid RDB length %d", n)
+	}
+	buf := make([]byte, 0, min(n, rdbReadChunk))
+	for len(buf) < n {
+		start := len(buf)
+		end := start + min(n-start, rdbReadChunk)
+		buf = slices.Grow(buf, end-start)[:end]
+		if _, err := io.ReadFull(r.r, buf[start:]); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	}
+	r.crc = ^crc64.Update(^r.crc, rdbCRCTable, buf)
+	return buf, nil
+}
+
+func (r *rdbReader) readByte() (byte, error) {
+	buf, err := r.read(1)
+	if err != nil {
+		return 0, err
+	}
+	return buf[0], nil
+}
+
+// readLength decodes a length, reporting whether it is a special string encoding
+func (r *rdbReader) readLength() (uint64, bool, error) {
+	first, err := r.readByte()
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch first >> 6 {
+	case 0:
+		return uint64(first & 0x3F), false, nil
+	case 1:
+		next, err := r.readByte()
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(first&0x3F)<<8 | uint64(next), false, nil
+	case 2:
+		switch first {
+		case 0x80:
+			buf, err := r.read(4)
+			if err != nil {
+				return 0, false, err
+			}
+			return uint64(binary.BigEndian.Uint32(buf)), false, nil
+		case 0x81:
+			buf, err := r.read(8)
+			if err != nil {
+				return 0, false, err
+			}
+			return binary.BigEndian.Uint64(buf), false, nil
+		default:
+			return 0, false, fmt.Errorf("invalid RDB length encoding 0x%02x", first)
+		}
+	default:
+		return uint64(first & 0x3F), true, nil
+	}
+}
+
+func (r *rdbReader) readLen() (uint64, error) {
+	length, encoded, err := r.readLength()
+	if err != nil {
+		return 0, err
+	}
+	if encoded {
+		return 0, errors.New("unexpected encoded string where a length was expected")
+	}
+	return length, nil
+}
+
+// readString decodes a string, which may be stored as an integer or LZF compressed
+func (r *rdbReader) readString() (string, error) {
+	length, encoded, err := r.readLength()
+	if err != nil {
+		return "", err
+	}
+
+	if !encoded {
+		buf, err := r.read(int(length))
+		if err != nil {
+			return "", err
+		}
+		return string(buf), nil
+	}
+
+	switch length {
+	case rdbEncInt8:
+		b, err := r.readByte()
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(int64(int8(b)), 10), nil
+	case rdbEncInt16:
+		buf, err := r.read(2)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(int64(int16(binary.LittleEndian.Uint16(buf))), 10), nil
+	case rdbEncInt32:
+		buf, err := r.read(4)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(int64(int32(binary.LittleEndian.Uint32(buf))), 10), nil
+	case rdbEncLZF:
+		compressedLen, err := r.readLen()
+		if err != nil {
+			return "", err
+		}
+		rawLen, err := r.readLen()
+		if err != nil {
+			return "", err
+		}
+		compressed, err := r.read(int(compressedLen))
+		if err != nil {
+			return "", err
+		}
+		raw, err := lzfDecompress(compressed, int(rawLen))
+		if err != nil {
+			return "", err
+		}
+		return string(raw), nil
+	default:
+		return "", fmt.Errorf("unknown RDB string encoding %d", length)
+	}
+}
+
+func (r *rdbReader) readUint64LE() (uint64, error) {
+	buf, err := r.read(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(buf), nil
+}
+
+// readStringDouble decodes the ASCII score of the original zset encoding
+func (r *rdbReader) readStringDouble() (float64, error) {
+	length, err := r.readByte()
+	if err != nil {
+		return 0, err
+	}
+
+	switch length {
+	case 253:
+		return math.NaN(), nil
+	case 254:
+		return math.Inf(1), nil
+	case 255:
+		return math.Inf(-1), nil
+	}
+
+	buf, err := r.read(int(length))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(string(buf), 64)
+}
+
+// parseRDB reads an RDB file and calls handle for every key, in file order,
+// with the auxiliary fields read so far. Module values are skipped and
+// counted in the summary.
+func parseRDB(input io.Reader, handle func(key *rdbKey, aux map[string]string) error) (*rdbSummary, error) {
+	r := &rdbReader{r: bufio.NewReaderSize(input, 1<<20)}
+
+	header, err := r.read(9)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RDB header: %w", err)
+	}
+	if string(header[:5]) != "REDIS" {
+		return nil, errors.New("not an RDB file: missing REDIS header")
+	}
+	version, err := strconv.Atoi(string(header[5:]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RDB version %q", header[5:])
+	}
+	if version < 1 || version > rdbMaxVersion {
+		return nil, fmt.Errorf("unsupported RDB version %d (supported: 1-%d)", version, rdbMaxVersion)
+	}
+
+	summary := &rdbSummary{
+		Version: version,
+		Aux:     make(map[string]string),
+		Skipped: make(map[string]int64),
+	}
+
+	db := 0
+	expireAt := int64(0)
+	idle := int64(-1)
+
+	for {
+		opcode, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case rdbOpEOF:
+			if version >= 5 {
+				expected := r.crc
+				checksum, err := r.readUint64LE()
+				if err != nil {
+					return nil, fmt.Errorf("failed to read RDB checksum: %w", err)
+				}
+				// A zero checksum means rdbchecksum was disabled
+				if checksum != 0 && checksum != expected {
+					return nil, fmt.Errorf("RDB checksum mismatch: file has %016x, computed %016x", checksum, expected)
+				}
+			}
+			return summary, nil
+
+		case rdbOpSelectDB:
+			n, err := r.readLen()
+			if err != nil {
+				return nil, err
+			}
+			db = int(n)
+
+		case rdbOpResizeDB:
+			if _, err := r.readLen(); err != nil {
+				return nil, err
+			}
+			if _, err := r.readLen(); err != nil {
+				return nil, err
+			}
+
+		case rdbOpSlotInfo:
+			for i := 0; i < 3; i++ {
+				if _, err := r.readLen(); err != nil {
+					return nil, err
+				}
+			}
+
+		case rdbOpAux:
+			name, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+			value, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+			summary.Aux[name] = value
+
+		case rdbOpFunction2:
+			// Function libraries are not keys
+			if _, err := r.readString(); err != nil {
+				return nil, err
+			}
+
+		case rdbOpFunctionPre:
+			return nil, errors.New("RDB contains pre-release function data, which is not supported")
+
+		case rdbOpModuleAux:
+			if _, err := r.readLen(); err != nil {
+				return nil, err
+			}
+			if _, err := r.readLen(); err != nil {
+				return nil, err
+			}
+			if err := r.skipModuleValue(); err != nil {
+				return nil, err
+			}
+
+		case rdbOpExpireMs:
+			ms, err := r.readUint64LE()
+			if err != nil {
+				return nil, err
+			}
+			expireAt = int64(ms)
+
+		case rdbOpExpire:
+			buf, err := r.read(4)
+			if err != nil {
+				return nil, err
+			}
+			expireAt = int64(binary.LittleEndian.Uint32(buf)) * 1000
+
+		case rdbOpIdle:
+			n, err := r.readLen()
+			if err != nil {
+				return nil, err
+			}
+			idle = int64(n)
+
+		case rdbOpFreq:
+			if _, err := r.readByte(); err != nil {
+				return nil, err
+			}
+
+		default:
+			key, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+
+			record := &rdbKey{DB: db, Key: key, ExpireAt: expireAt, IdleSeconds: idle}
+			expireAt, idle = 0, -1
+
+			skipped, err := r.readValue(opcode, record)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read key %s: %w", key, err)
+			}
+			if skipped != "" {
+				summary.Skipped[skipped]++
+				continue
+			}
+			if err := handle(record, summary.Aux); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// readValue decodes a value of valueType into key. For types the exporter
+// cannot represent it consumes the value and returns the type name instead.
+func (r *rdbReader) readValue(valueType byte, key *rdbKey) (string, error) {
+	switch valueType {
+	case rdbTypeString:
+		key.Type = "string"
+		value, err := r.readString()
+		key.Value = value
+		return "", err
+
+	case rdbTypeList, rdbTypeSet:
+		key.Type = "list"
+		if valueType == rdbTypeSet {
+			key.Type = "set"
+		}
+		n, err := r.readLen()
+		if err != nil {
+			return "", err
+		}
+		for i := uint64(0); i < n; i++ {
+			element, err := r.readString()
+			if err != nil {
+				return "", err
+			}
+			key.Elements = append(key.Elements, element)
+		}
+		return "", nil
+
+	case rdbTypeZSet, rdbTypeZSet2:
+		key.Type = "zset"
+		n, err := r.readLen()
+		if err != nil {
+			return "", err
+		}
+		for i := uint64(0); i < n; i++ {
+			member, err := r.readString()
+			if err != nil {
+				return "", err
+			}
+			var score float64
+			if valueType == rdbTypeZSet2 {
+				bits, err := r.readUint64LE()
+				if err != nil {
+					return "", err
+				}
+				score = math.Float64frombits(bits)
+			} else if score, err = r.readStringDouble(); err != nil {
+				return "", err
+			}
+			key.Members = append(key.Members, rdbMember{Member: member, Score: score})
+		}
+		return "", nil
+
+	case rdbTypeHash:
+		key.Type = "hash"
+		n, err := r.readLen()
+		if err != nil {
+			return "", err
+		}
+		for i := uint64(0); i < n; i++ {
+			field, err := r.readString()
+			if err != nil {
+				return "", err
+			}
+			value, err := r.readString()
+			if err != nil {
+				return "", err
+			}
+			key.Fields = append(key.Fields, rdbField{Name: field, Value: value})
+		}
+		return "", nil
+
+	case rdbTypeHashMetadata, rdbTypeHashMetadataPreGA:
+		key.Type = "hash"
+		// Field expiries are stored relative to the smallest one, plus one
+		minExpire := uint64(0)
+		if valueType == rdbTypeHashMetadata {
+			var err error
+			if minExpire, err = r.readUint64LE(); err != nil {
+				return "", err
+			}
+		}
+		n, err := r.readLen()
+		if err != nil {
+			return "", err
+		}
+		for i := uint64(0); i < n; i++ {
+			expire, err := r.readLen()
+			if err != nil {
+				return "", err
+			}
+			if expire != 0 && valueType == rdbTypeHashMetadata {
+				expire += minExpire - 1
+			}
+			field, err := r.readString()
+			if err != nil {
+				return "", err
+			}
+			value, err := r.readString()
+			if err != nil {
+				return "", err
+			}
+			key.Fields = append(key.Fields, rdbField{Name: field, Value: value, ExpireAt: int64(expire)})
+		}
+		return "", nil
+
+	case rdbTypeHashZipmap:
+		key.Type = "hash"
+		blob, err := r.readString()
+		if err != nil {
+			return "", err
+		}
+		pairs, err := decodeZipmap([]byte(blob))
+		if err != nil {
+			return "", err
+		}
+		key.Fields = pairsToFields(pairs)
+		return "", nil
+
+	case rdbTypeListZiplist:
+		key.Type = "list"
+		blob, err := r.readString()
+		if err != nil {
+			return "", err
+		}
+		key.Elements, err = decodeZiplist([]byte(blob))
+		return "", err
+
+	case rdbTypeSetIntset:
+		key.Type = "set"
+		blob, err := r.readString()
+		if err != nil {
+			return "", err
+		}
+		key.Elements, err = decodeIntset([]byte(blob))
+		return "", err
+
+	case rdbTypeZSetZiplist, rdbTypeZSetListpack, rdbTypeHashZiplist, rdbTypeHashListpack:
+		blob, err := r.readString()
+		if err != nil {
+			return "", err
+		}
+		var entries []string
+		if valueType == rdbTypeZSetZiplist || valueType == rdbTypeHashZiplist {
+			entries, err = decodeZiplist([]byte(blob))
+		} else {
+			entries, err = decodeListpack([]byte(blob))
+		}
+		if err != nil {
+			return "", err
+		}
+		if len(entries)%2 != 0 {
+			return "", errors.New("odd number of entries in encoded pairs")
+		}
+
+		if valueType == rdbTypeHashZiplist || valueType == rdbTypeHashListpack {
+			key.Type = "hash"
+			key.Fields = pairsToFields(entries)
+			return "", nil
+		}
+
+		key.Type = "zset"
+		for i := 0; i < len(entries); i += 2 {
+			score, err := strconv.ParseFloat(entries[i+1], 64)
+			if err != nil {
+				return "", fmt.Errorf("invalid zset score %q: %w", entries[i+1], err)
+			}
+			key.Members = append(key.Members, rdbMember{Member: entries[i], Score: score})
+		}
+		return "", nil
+
+	case rdbTypeHashListpackEx, rdbTypeHashListpackExPreGA:
+		key.Type = "hash"
+		if valueType == rdbTypeHashListpackEx {
+			// The smallest field expiry, which the absolute expiries below repeat
+			if _, err := r.readUint64LE(); err != nil {
+				return "", err
+			}
+		}
+		blob, err := r.readString()
+		if err != nil {
+			return "", err
+		}
+		entries, err := decodeListpack([]byte(blob))
+		if err != nil {
+			return "", err
+		}
+		if len(entries)%3 != 0 {
+			return "", errors.New("hash listpack entries are not field, value, expiry triples")
+		}
+		for i := 0; i < len(entries); i += 3 {
+			expire, err := strconv.ParseInt(entries[i+2], 10, 64)
+			if err != nil {
+				return "", fmt.Errorf("invalid hash field expiry %q: %w", entries[i+2], err)
+			}
+			key.Fields = append(key.Fields, rdbField{Name: entries[i], Value: entries[i+1], ExpireAt: expire})
+		}
+		return "", nil
+
+	case rdbTypeListQuicklist, rdbTypeListQuicklist2:
+		key.Type = "list"
+		nodes, err := r.readLen()
+		if err != nil {
+			return "", err
+		}
+		for i := uint64(0); i < nodes; i++ {
+			// quicklist2 nodes are either a single plain element or a listpack
+			container := uint64(2)
+			if valueType == rdbTypeListQuicklist2 {
+				if container, err = r.readLen(); err != nil {
+					return "", err
+				}
+			}
+			blob, err := r.readString()
+			if err != nil {
+				return "", err
+			}
+
+			var elements []string
+			switch {
+			case valueType == rdbTypeListQuicklist:
+				elements, err = decodeZiplist([]byte(blob))
+			case container == 1:
+				elements = []string{blob}
+			default:
+				elements, err = decodeListpack([]byte(blob))
+			}
+			if err != nil {
+				return "", err
+			}
+			key.Elements = append(key.Elements, elements...)
+		}
+		return "", nil
+
+	case rdbTypeSetListpack:
+		key.Type = "set"
+		blob, err := r.readString()
+		if err != nil {
+			return "", err
+		}
+		key.Elements, err = decodeListpack([]byte(blob))
+		return "", err
+
+	case rdbTypeStreamListpacks, rdbTypeStreamListpacks2, rdbTypeStreamListpacks3:
+		key.Type = "stream"
+		stream, err := r.readStream(valueType)
+		key.Stream = stream
+		return "", err
+
+	case rdbTypeModule2:
+		// The module ID names the type; the value is self-describing
+		if _, err := r.readLen(); err != nil {
+			return "", err
+		}
+		return "module", r.skipModuleValue()
+
+	case rdbTypeModulePreGA:
+		return "", errors.New("pre-release module values cannot be read without the module")
+
+	default:
+		return "", fmt.Errorf("unsupported RDB value type %d", valueType)
+	}
+}
+
+// readStream decodes a stream value: its listpack nodes, metadata and
+// consumer groups
+func (r *rdbReader) readStream(valueType byte) (*rdbStream, error) {
+	stream := &rdbStream{V1: valueType == rdbTypeStreamListpacks}
+
+	// Listpacks keyed by their master entry ID
+	nodes, err := r.readLen()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < nodes; i++ {
+		master, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		if len(master) != 16 {
+			return nil, fmt.Errorf("invalid stream node ID of %d bytes", len(master))
+		}
+		blob, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		entries, err := decodeStreamListpack(rawStreamID([]byte(master)), []byte(blob))
+		if err != nil {
+			return nil, err
+		}
+		stream.Entries = append(stream.Entries, entries...)
+	}
+
+	// Length and last ID, then first ID, max deleted ID and entries added from v2
+	length, err := r.readLen()
+	if err != nil {
+		return nil, err
+	}
+	stream.Length = int64(length)
+	skip := 2
+	if !stream.V1 {
+		skip += 5
+	}
+	for i := 0; i < skip; i++ {
+		if _, err := r.readLen(); err != nil {
+			return nil, err
+		}
+	}
+
+	groups, err := r.readLen()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < groups; i++ {
+		group, err := r.readStreamGroup(valueType)
+		if err != nil {
+			return nil, err
+		}
+		stream.Groups = append(stream.Groups, group)
+	}
+	return stream, nil
+}
+
+func (r *rdbReader) readStreamGroup(valueType byte) (rdbStreamGroup, error) {
+	group := rdbStreamGroup{EntriesRead: -1}
+
+	var err error
+	if group.Name, err = r.readString(); err != nil {
+		return group, err
+	}
+	if group.LastID.Ms, err = r.readLen(); err != nil {
+		return group, err
+	}
+	if group.LastID.Seq, err = r.readLen(); err != nil {
+		return group, err
+	}
+	if valueType != rdbTypeStreamListpacks {
+		// An unknown count is saved as -1
+		n, err := r.readLen()
+		if err != nil {
+			return group, err
+		}
+		group.EntriesRead = int64(n)
+	}
+
+	// Pending entries: raw 128-bit ID, delivery time and delivery count
+	pending, err := r.readLen()
+	if err != nil {
+		return group, err
+	}
+	group.Pending = int64(pending)
+	for j := uint64(0); j < pending; j++ {
+		if _, err := r.read(16 + 8); err != nil {
+			return group, err
+		}
+		if _, err := r.readLen(); err != nil {
+			return group, err
+		}
+	}
+
+	consumers, err := r.readLen()
+	if err != nil {
+		return group, err
+	}
+	for j := uint64(0); j < consumers; j++ {
+		var consumer rdbStreamConsumer
+		if consumer.Name, err = r.readString(); err != nil {
+			return group, err
+		}
+		seen, err := r.readUint64LE()
+		if err != nil {
+			return group, err
+		}
+		consumer.SeenTime = int64(seen)
+		// Before v3 Redis loads the seen time as the active time
+		consumer.ActiveTime = consumer.SeenTime
+		if valueType == rdbTypeStreamListpacks3 {
+			active, err := r.readUint64LE()
+			if err != nil {
+				return group, err
+			}
+			consumer.ActiveTime = int64(active)
+		}
+		owned, err := r.readLen()
+		if err != nil {
+			return group, err
+		}
+		consumer.Pending = int64(owned)
+		if _, err := r.read(int(owned) * 16); err != nil {
+			return group, err
+		}
+		group.Consumers = append(group.Consumers, consumer)
+	}
+	return group, nil
+}
+
+// rawStreamID decodes a big-endian 128-bit stream ID
+func rawStreamID(b []byte) rdbStreamID {
+	return rdbStreamID{Ms: binary.BigEndian.Uint64(b), Seq: binary.BigEndian.Uint64(b[8:])}
+}
+
+// Stream listpack entry flags
+const (
+	streamItemDeleted    = 1
+	streamItemSameFields = 2
+)
+
+// decodeStreamListpack returns the live entries of one stream node. The node
+// starts with a master entry holding the entry counts and the field names
+// entries flagged as same-fields reuse; every entry stores its ID as deltas
+// from the node ID and ends with its own entry count.
+func decodeStreamListpack(master rdbStreamID, b []byte) ([]rdbStreamEntry, error) {
+	items, err := decodeListpack(b)
+	if err != nil {
+		return nil, err
+	}
+
+	pos := 0
+	next := func() (string, error) {
+		if pos >= len(items) {
+			return "", errors.New("truncated stream listpack")
+		}
+		pos++
+		return items[pos-1], nil
+	}
+	nextInt := func() (int64, error) {
+		item, err := next()
+		if err != nil {
+			return 0, err
+		}
+		n, err := strconv.ParseInt(item, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid stream listpack integer %q", item)
+		}
+		return n, nil
+	}
+	// Every counted entry or field takes at least one more item
+	nextCount := func() (int64, error) {
+		n, err := nextInt()
+		if err == nil && (n < 0 || n > int64(len(items)-pos)) {
+			err = fmt.Errorf("invalid stream listpack count %d", n)
+		}
+		return n, err
+	}
+
+	count, err := nextCount()
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := nextCount()
+	if err != nil {
+		return nil, err
+	}
+	numFields, err := nextCount()
+	if err != nil {
+		return nil, err
+	}
+	masterFields := make([]string, numFields)
+	for i := range masterFields {
+		if masterFields[i], err = next(); err != nil {
+			return nil, err
+		}
+	}
+	// The master entry ends with a zero
+	if _, err := next(); err != nil {
+		return nil, err
+	}
+
+	entries := make([]rdbStreamEntry, 0, count)
+	for i := int64(0); i < count+deleted; i++ {
+		flags, err := nextInt()
+		if err != nil {
+			return nil, err
+		}
+		msDiff, err := nextInt()
+		if err != nil {
+			return nil, err
+		}
+		seqDiff, err := nextInt()
+		if err != nil {
+			return nil, err
+		}
+		entry := rdbStreamEntry{ID: rdbStreamID{Ms: master.Ms + uint64(msDiff), Seq: master.Seq + uint64(seqDiff)}}
+
+		names := masterFields
+		if flags&streamItemSameFields == 0 {
+			n, err := nextCount()
+			if err != nil {
+				return nil, err
+			}
+			names = make([]string, n)
+		}
+		for j := range names {
+			name := names[j]
+			if flags&streamItemSameFields == 0 {
+				if name, err = next(); err != nil {
+					return nil, err
+				}
+			}
+			value, err := next()
+			if err != nil {
+				return nil, err
+			}
+			entry.Fields = append(entry.Fields, rdbField{Name: name, Value: value})
+		}
+		if _, err := next(); err != nil {
+			return nil, err
+		}
+
+		if flags&streamItemDeleted == 0 {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// skipModuleValue consumes an opcode-tagged module value up to its EOF marker
+func (r *rdbReader) skipModuleValue() error {
+	for {
+		opcode, err := r.readLen()
+		if err != nil {
+			return err
+		}
+
+		switch opcode {
+		case rdbModuleOpEOF:
+			return nil
+		case rdbModuleOpSInt, rdbModuleOpUInt:
+			_, err = r.readLen()
+		case rdbModuleOpFloat:
+			_, err = r.read(4)
+		case rdbModuleOpDouble:
+			_, err = r.read(8)
+		case rdbModuleOpString:
+			_, err = r.readString()
+		default:
+			return fmt.Errorf("unknown module value opcode %d", opcode)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func pairsToFields(pairs []string) []rdbField {
+	fields := make([]rdbField, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		fields = append(fields, rdbField{Name: pairs[i], Value: pairs[i+1]})
+	}
+	return fields
+}
+
+// decodeZiplist returns the entries of a ziplist as strings
+func decodeZiplist(b []byte) ([]string, error) {
+	if len(b) < 11 {
+		return nil, errors.New("ziplist too short")
+	}
+
+	var entries []string
+	pos := 10
+	for {
+		if pos >= len(b) {
+			return nil, errors.New("ziplist is missing its end marker")
+		}
+		if b[pos] == 0xFF {
+			return entries, nil
+		}
+
+		// Skip the previous entry length
+		if b[pos] == 0xFE {
+			pos += 5
+		} else {
+			pos++
+		}
+		if pos >= len(b) {
+			return nil, errors.New("truncated ziplist entry")
+		}
+
+		encoding := b[pos]
+		var value string
+		var width int
+		switch encoding >> 6 {
+		case 0:
+			width = 1 + int(encoding&0x3F)
+			if pos+width > len(b) {
+				return nil, errors.New("truncated ziplist entry")
+			}
+			value = string(b[pos+1 : pos+width])
+		case 1:
+			if pos+2 > len(b) {
+				return nil, errors.New("truncated ziplist entry")
+			}
+			width = 2 + (int(encoding&0x3F)<<8 | int(b[pos+1]))
+			if pos+width > len(b) {
+				return nil, errors.New("truncated ziplist entry")
+			}
+			value = string(b[pos+2 : pos+width])
+		case 2:
+			if pos+5 > len(b) {
+				return nil, errors.New("truncated ziplist entry")
+			}
+			width = 5 + int(binary.BigEndian.Uint32(b[pos+1:]))
+			if pos+width > len(b) {
+				return nil, errors.New("truncated ziplist entry")
+			}
+			value = string(b[pos+5 : pos+width])
+		default:
+			var n int64
+			var size int
+			switch encoding {
+			case 0xC0:
+				size = 2
+			case 0xD0:
+				size = 4
+			case 0xE0:
+				size = 8
+			case 0xF0:
+				size = 3
+			case 0xFE:
+				size = 1
+			default:
+				if encoding < 0xF1 || encoding > 0xFD {
+					return nil, fmt.Errorf("invalid ziplist encoding 0x%02x", encoding)
+				}
+				// Immediate 4-bit integer between 0 and 12
+				n = int64(encoding&0x0F) - 1
+			}
+			if pos+1+size > len(b) {
+				return nil, errors.New("truncated ziplist entry")
+			}
+			data := b[pos+1 : pos+1+size]
+			switch size {
+			case 1:
+				n = int64(int8(data[0]))
+			case 2:
+				n = int64(int16(binary.LittleEndian.Uint16(data)))
+			case 3:
+				n = int64(int32(uint32(data[0])<<8|uint32(data[1])<<16|uint32(data[2])<<24) >> 8)
+			case 4:
+				n = int64(int32(binary.LittleEndian.Uint32(data)))
+			case 8:
+				n = int64(binary.LittleEndian.Uint64(data))
+			}
+			width = 1 + size
+			value = strconv.FormatInt(n, 10)
+		}
+
+		entries = append(entries, value)
+		pos += width
+	}
+}
+
+// decodeListpack returns the entries of a listpack as strings
+func decodeListpack(b []byte) ([]string, error) {
+	if len(b) < 7 {
+		return nil, errors.New("listpack too short")
+	}
+
+	var entries []string
+	pos := 6
+	for {
+		if pos >= len(b) {
+			return nil, errors.New("listpack is missing its end marker")
+		}
+		encoding := b[pos]
+		if encoding == 0xFF {
+			return entries, nil
+		}
+
+		var value string
+		var width int
+		need := func(n int) error {
+			if pos+n > len(b) {
+				return errors.New("truncated listpack entry")
+			}
+			return nil
+		}
+
+		switch {
+		case encoding&0x80 == 0:
+			width = 1
+			value = strconv.Itoa(int(encoding))
+		case encoding&0xC0 == 0x80:
+			width = 1 + int(encoding&0x3F)
+			if err := need(width); err != nil {
+				return nil, err
+			}
+			value = string(b[pos+1 : pos+width])
+		case encoding&0xE0 == 0xC0:
+			if err := need(2); err != nil {
+				return nil, err
+			}
+			width = 2
+			n := int(encoding&0x1F)<<8 | int(b[pos+1])
+			if n >= 1<<12 {
+				n -= 1 << 13
+			}
+			value = strconv.Itoa(n)
+		case encoding&0xF0 == 0xE0:
+			if err := need(2); err != nil {
+				return nil, err
+			}
+			width = 2 + (int(encoding&0x0F)<<8 | int(b[pos+1]))
+			if err := need(width); err != nil {
+				return nil, err
+			}
+			value = string(b[pos+2 : pos+width])
+		case encoding == 0xF0:
+			if err := need(5); err != nil {
+				return nil, err
+			}
+			width = 5 + int(binary.LittleEndian.Uint32(b[pos+1:]))
+			if err := need(width); err != nil {
+				return nil, err
+			}
+			value = string(b[pos+5 : pos+width])
+		case encoding >= 0xF1 && encoding <= 0xF4:
+			size := map[byte]int{0xF1: 2, 0xF2: 3, 0xF3: 4, 0xF4: 8}[encoding]
+			if err := need(1 + size); err != nil {
+				return nil, err
+			}
+			data := b[pos+1 : pos+1+size]
+			var n int64
+			switch size {
+			case 2:
+				n = int64(int16(binary.LittleEndian.Uint16(data)))
+			case 3:
+				n = int64(int32(uint32(data[0])<<8|uint32(data[1])<<16|uint32(data[2])<<24) >> 8)
+			case 4:
+				n = int64(int32(binary.LittleEndian.Uint32(data)))
+			case 8:
+				n = int64(binary.LittleEndian.Uint64(data))
+			}
+			width = 1 + size
+			value = strconv.FormatInt(n, 10)
+		default:
+			return nil, fmt.Errorf("invalid listpack encoding 0x%02x", encoding)
+		}
+
+		entries = append(entries, value)
+		pos += width + listpackBacklenSize(width)
+	}
+}
+
+// listpackBacklenSize is the size of the back length that follows an entry
+func listpackBacklenSize(entryLen int) int {
+	switch {
+	case entryLen <= 127:
+		return 1
+	case entryLen < 16383:
+		return 2
+	case entryLen < 2097151:
+		return 3
+	case entryLen < 268435455:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// decodeIntset returns the members of an intset as decimal strings
+func decodeIntset(b []byte) ([]string, error) {
+	if len(b) < 8 {
+		return nil, errors.New("intset too short")
+	}
+
+	size := int(binary.LittleEndian.Uint32(b))
+	count := int(binary.LittleEndian.Uint32(b[4:]))
+	if size != 2 && size != 4 && size != 8 {
+		return nil, fmt.Errorf("invalid intset encoding %d", size)
+	}
+	if len(b) < 8+size*count {
+		return nil, errors.New("truncated intset")
+	}
+
+	members := make([]string, count)
+	for i := range members {
+		data := b[8+i*size:]
+		var n int64
+		switch size {
+		case 2:
+			n = int64(int16(binary.LittleEndian.Uint16(data)))
+		case 4:
+			n = int64(int32(binary.LittleEndian.Uint32(data)))
+		case 8:
+			n = int64(binary.LittleEndian.Uint64(data))
+		}
+		members[i] = strconv.FormatInt(n, 10)
+	}
+	return members, nil
+}
+
+// decodeZipmap returns the alternating fields and values of a zipmap
+func decodeZipmap(b []byte) ([]string, error) {
+	var pairs []string
+	pos := 1
+
+	readLen := func() (int, error) {
+		if pos >= len(b) {
+			return 0, errors.New("truncated zipmap")
+		}
+		n := int(b[pos])
+		if n < 254 {
+			pos++
+			return n, nil
+		}
+		if n == 254 && pos+5 <= len(b) {
+			n = int(binary.LittleEndian.Uint32(b[pos+1:]))
+			pos += 5
+			return n, nil
+		}
+		return 0, errors.New("invalid zipmap length")
+	}
+
+	for {
+		if pos >= len(b) {
+			return nil, errors.New("zipmap is missing its end marker")
+		}
+		if b[pos] == 0xFF {
+			return pairs, nil
+		}
+
+		n, err := readLen()
+		if err != nil {
+			return nil, err
+		}
+		if pos+n > len(b) {
+			return nil, errors.New("truncated zipmap")
+		}
+		field := string(b[pos : pos+n])
+		pos += n
+
+		n, err = readLen()
+		if err != nil {
+			return nil, err
+		}
+		if pos >= len(b) {
+			return nil, errors.New("truncated zipmap")
+		}
+		free := int(b[pos])
+		pos++
+		if pos+n+free > len(b) {
+			return nil, errors.New("truncated zipmap")
+		}
+		pairs = append(pairs, field, string(b[pos:pos+n]))
+		pos += n + free
+	}
+}
+
+// lzfMaxExpansion is the most LZF can expand its input: a three-byte back
+// reference copies 264 bytes
+const lzfMaxExpansion = 88
+
+// lzfDecompress expands LZF data into a buffer of rawLen bytes
+func lzfDecompress(in []byte, rawLen int) ([]byte, error) {
+	if rawLen < 0 || rawLen > len(in)*lzfMaxExpansion {
+		return nil, fmt.Errorf("invalid LZF length %d for %d compressed bytes", rawLen, len(in))
+	}
+	out := make([]byte, 0, rawLen)
+	for i := 0; i < len(in); {
+		ctrl := int(in[i])
+		i++
+
+		if ctrl < 32 {
+			// Literal run of ctrl+1 bytes
+			n := ctrl + 1
+			if i+n > len(in) {
+				return nil, errors.New("truncated LZF literal")
+			}
+			out = append(out, in[i:i+n]...)
+			i += n
+			continue
+		}
+
+		// Back reference
+		length := ctrl >> 5
+		if length == 7 {
+			if i >= len(in) {
+				return nil, errors.New("truncated LZF back reference")
+			}
+			length += int(in[i])
+			i++
+		}
+		if i >= len(in) {
+			return nil, errors.New("truncated LZF back reference")
+		}
+		ref := len(out) - (ctrl&0x1F)<<8 - int(in[i]) - 1
+		i++
+		if ref < 0 {
+			return nil, errors.New("invalid LZF back reference")
+		}
+		for j := 0; j < length+2; j++ {
+			out = append(out, out[ref+j])
+		}
+	}
+
+	if len(out) != rawLen {
+		return nil, fmt.Errorf("LZF data expanded to %d bytes, expected %d", len(out), rawLen)
+	}
+	return out, nil
+}
+
+// unexpectedEOF reports a file that ends before its EOF opcode as truncated
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return errors.New("RDB file is truncated")
+	}
+	return err
+}
@@ -0,0 +1,458 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// RDBSource describes the RDB file an offline export was read from
+type RDBSource struct {
+	File    string `json:"file"`
+	Version int    `json:"version"`
+	// CreatedAt is when Redis wrote the file, from its ctime field or, when
+	// absent, the file modification time. TTLs are relative to it.
+	CreatedAt time.Time `json:"created_at"`
+	// SkippedKeys counts keys of types that cannot be exported from RDB files
+	SkippedKeys map[string]int64 `json:"skipped_keys,omitempty"`
+}
+
+// validateRDBOptions rejects options that need a live server in RDB_FILE mode
+func validateRDBOptions(opts RedisExporterOptions) error {
+	if _, err := os.Stat(opts.RDBFile); err != nil {
+		return fmt.Errorf("failed to open RDB file: %w", err)
+	}
+
+	unsupported := map[string]bool{
+		"DUAL_MODE":      opts.DualMode,
+		"MIN_SIZE_BYTES": opts.MinSizeBytes > 0,
+		"BITMAP_KEYS":    opts.BitmapKeys != "",
+		"SNAPSHOT_WAIT":  opts.SnapshotWait,
+
+		"COMPLETED_KEYS_LOG": opts.CompletedKeysLog != "",
+		"TARGET_FILE_COUNT":  opts.TargetFileCount > 0,
+		"INCLUDE_ACL":        opts.IncludeACL,
+		"REDIS_CLUSTER_URLS": len(opts.RedisClusterURLs) > 0,
+
+		"SENTINEL_MASTER_NAME": opts.SentinelMasterName != "",
+		"READ_FROM_REPLICA":    opts.ReadFromReplica,
+
+		"ELASTICACHE_IAM_CACHE_NAME": opts.ElastiCacheIAMCacheName != "",
+		"SSH_HOST":                   opts.SSHHost != "",
+		"INCLUDE_ENCODING":           opts.IncludeEncoding,
+		"KEYS_FILE":                  opts.KeysFile != "",
+	}
+	for _, name := range []string{"DUAL_MODE", "MIN_SIZE_BYTES", "BITMAP_KEYS", "SNAPSHOT_WAIT", "COMPLETED_KEYS_LOG", "TARGET_FILE_COUNT", "INCLUDE_ACL", "REDIS_CLUSTER_URLS", "SENTINEL_MASTER_NAME", "READ_FROM_REPLICA", "ELASTICACHE_IAM_CACHE_NAME", "SSH_HOST", "INCLUDE_ENCODING", "KEYS_FILE"} {
+		if unsupported[name] {
+			return fmt.Errorf("%s is not supported with RDB_FILE", name)
+		}
+	}
+	return nil
+}
+
+// requireLiveServer rejects commands that cannot run against an RDB file
+func (re *RedisExporter) requireLiveServer(command string) error {
+	if re.rdbFile != "" {
+		return fmt.Errorf("%s is not supported with RDB_FILE", command)
+	}
+	return nil
+}
+
+// rdbSnapshotTime returns when the RDB file was written, in Unix milliseconds
+func rdbSnapshotTime(aux map[string]string, modTime time.Time) int64 {
+	if ctime, err := strconv.ParseInt(aux["ctime"], 10, 64); err == nil && ctime > 0 {
+		return ctime * 1000
+	}
+	return modTime.UnixMilli()
+}
+
+// rdbTTL converts an absolute expiry to the TTL Redis would have reported
+// when the file was written, rounded like the TTL command. Anything already
+// expired by then is missing.
+func rdbTTL(expireAt, snapshotMs int64) TTL {
+	if expireAt == 0 {
+		return NoExpiry()
+	}
+	if expireAt <= snapshotMs {
+		return MissingTTL()
+	}
+	return ExpiresIn((expireAt - snapshotMs + 500) / 1000)
+}
+
+// exportRDB exports keys matching pattern from the RDB file instead of the
+// live server. Only the database selected by REDIS_URL is exported, and keys
+// that had already expired when the file was written are skipped.
+func (re *RedisExporter) exportRDB(pattern string, keysOnly bool) (err error) {
+	defer re.closeExport(&err)
+
+	if !keysOnly {
+		if err := re.requireValueColumn(); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Open(re.rdbFile)
+	if err != nil {
+		return fmt.Errorf("failed to open RDB file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat RDB file: %w", err)
+	}
+
+	re.fileManager.SetMetadata(pattern, 0)
+	re.verbosity.infof("Reading RDB file %s (database %d) with pattern: %s\n", re.rdbFile, re.db, pattern)
+
+	count := 0
+	expired := 0
+	mismatched := 0
+	snapshotMs := int64(0)
+	keepIdle := re.fileManager.config.PartitionBy == PartitionByAge || re.fileManager.config.IncludeIdleTime
+
+	summary, err := parseRDB(file, func(key *rdbKey, aux map[string]string) error {
+		// AUX fields precede the first key, so ctime is known by now
+		if snapshotMs == 0 {
+			snapshotMs = rdbSnapshotTime(aux, info.ModTime())
+		}
+
+		if key.DB != re.db || !matchGlob(pattern, key.Key) {
+			return nil
+		}
+		if re.isIgnored(key.Key) {
+			re.fileManager.AddIgnoredKeys(1)
+			return nil
+		}
+		if !re.sampler.keep(key.Key) {
+			return nil
+		}
+		if key.ExpireAt != 0 && key.ExpireAt <= snapshotMs {
+			expired++
+			return nil
+		}
+		if !re.keyTypeSelected(key.Type) || !re.keyRegexSelected(key.Key) {
+			return nil
+		}
+		if re.ttlWindow != nil && !re.ttlWindow.keep(rdbTTL(key.ExpireAt, snapshotMs)) {
+			re.fileManager.AddTTLFilteredKeys(1)
+			return nil
+		}
+		if re.assumeType != "" && key.Type != re.assumeType {
+			mismatched++
+			return nil
+		}
+
+		// Idle times are only saved under an LRU policy and only used for age
+		// partitions and idle_seconds
+		idleSeconds := int64(0)
+		if keepIdle {
+			idleSeconds = key.IdleSeconds
+		}
+
+		ttlSeconds := ttlNoExpiry
+		if !re.skipTTL {
+			ttlSeconds = rdbTTL(key.ExpireAt, snapshotMs).Value()
+		}
+		if err := re.writeRDBKey(key, keysOnly, ttlSeconds, idleSeconds, snapshotMs); err != nil {
+			return fmt.Errorf("failed to export key %s: %w", key.Key, err)
+		}
+		count++
+
+		interval := 100
+		if keysOnly {
+			interval = re.flushInterval
+		}
+		if count%interval == 0 {
+			re.verbosity.infof("Exported %d keys...\n", count)
+			re.flushAll()
+			re.writeCheckpoint(count)
+		}
+		return nil
+	})
+	if err != nil {
+		// Close still rotates the open partitions, so record what was exported
+		re.fileManager.SetMetadata(pattern, int64(count))
+		re.fileManager.MarkPartial(err)
+		return fmt.Errorf("failed to read RDB file: %w", err)
+	}
+
+	if snapshotMs == 0 {
+		snapshotMs = rdbSnapshotTime(summary.Aux, info.ModTime())
+	}
+	re.fileManager.SetMetadata(pattern, int64(count))
+	re.fileManager.SetRDBSource(&RDBSource{
+		File:        re.rdbFile,
+		Version:     summary.Version,
+		CreatedAt:   time.UnixMilli(snapshotMs).UTC(),
+		SkippedKeys: summary.Skipped,
+	})
+
+	fmt.Printf("Export completed! Total keys exported from RDB file: %d\n", count)
+	if expired > 0 {
+		fmt.Printf("Skipped %d keys that had expired when the RDB file was written\n", expired)
+	}
+	if mismatched > 0 {
+		fmt.Printf("Skipped %d keys that were not of assumed type %s\n", mismatched, re.assumeType)
+	}
+	re.reportTruncatedKeys()
+	for _, keyType := range sortedKeys(summary.Skipped) {
+		fmt.Printf("Skipped %d %s keys, which cannot be exported from RDB files\n", summary.Skipped[keyType], keyType)
+	}
+	return nil
+}
+
+// writeRDBKey writes the element and key records for a decoded key, in the
+// same shapes as a live export. Keys-only exports write only the key record,
+// with the exact size in place of an estimate.
+func (re *RedisExporter) writeRDBKey(key *rdbKey, keysOnly bool, ttlSeconds, idleSeconds, snapshotMs int64) error {
+	timestamp := re.exportedAt()
+	slot := keySlot(key.Key)
+
+	write := func(suffix, recordType, value string, ttl, expireAt int64) error {
+		if keysOnly {
+			return nil
+		}
+		return re.fileManager.WriteRecord(&RedisRecord{
+			Key:        key.Key + suffix,
+			Type:       recordType,
+			Value:      value,
+			TTLSeconds: ttl,
+			ExportedAt: timestamp,
+			Slot:       slot,
+
+			IdleSeconds: idleSeconds,
+			ParentKey:   key.Key,
+			ExpiresAt:   expireAt,
+		})
+	}
+
+	size := int64(0)
+	cardinality := noCardinality
+	// Full length of a list cut short by MAX_ELEMENTS_PER_KEY
+	truncatedLength := int64(0)
+	switch key.Type {
+	case "string":
+		size = int64(len(key.Value))
+
+	case "list":
+		cardinality = int64(len(key.Elements))
+		first, last := int64(0), int64(len(key.Elements))
+		if !keysOnly {
+			var truncated bool
+			if first, last, truncated = re.listWindow(last); truncated {
+				truncatedLength = int64(len(key.Elements))
+			}
+		}
+		for i := first; i < last; i++ {
+			value := key.Elements[i]
+			if err := write(fmt.Sprintf(":index:%d", i), "list_item", value, -1, 0); err != nil {
+				return err
+			}
+			size += int64(len(value))
+		}
+
+	case "set":
+		cardinality = int64(len(key.Elements))
+		for _, member := range key.Elements {
+			if err := write(":member:"+member, "set_member", member, -1, 0); err != nil {
+				return err
+			}
+			size += int64(len(member))
+		}
+
+	case "hash":
+		cardinality = 0
+		for _, field := range key.Fields {
+			// HASH_FIELDS narrows full exports; keys-only counts every field
+			if !keysOnly && !re.hashFieldSelected(field.Name) {
+				continue
+			}
+			// Redis drops expired fields lazily, so one may still be in the file
+			fieldTTL := rdbTTL(field.ExpireAt, snapshotMs)
+			if fieldTTL.State() == TTLMissing {
+				continue
+			}
+			if re.skipTTL {
+				fieldTTL = NoExpiry()
+			}
+			if err := write(":field:"+field.Name, "hash_field", field.Value, fieldTTL.Value(), field.ExpireAt); err != nil {
+				return err
+			}
+			size += int64(len(field.Name) + len(field.Value))
+			cardinality++
+		}
+
+	case "zset":
+		cardinality = int64(len(key.Members))
+		ranked := re.zsetWithRank && int64(len(key.Members)) <= re.zsetRankMaxSize
+		if ranked {
+			sortRDBMembers(key.Members)
+		}
+		for i, member := range key.Members {
+			// ZSET_MIN_SCORE/ZSET_MAX_SCORE narrow full exports; ranks stay
+			// positions in the whole set
+			if !keysOnly && !re.zsetScores.contains(member.Score) {
+				continue
+			}
+			value := fmt.Sprintf("score=%s", formatScore(member.Score))
+			if ranked {
+				value = fmt.Sprintf("%s,rank=%d", value, i)
+			}
+			if err := write(":member:"+member.Member, "zset_member", value, -1, 0); err != nil {
+				return err
+			}
+			size += int64(len(member.Member))
+		}
+
+	case "stream":
+		cardinality = key.Stream.Length
+		var err error
+		if size, err = re.writeRDBStream(key, keysOnly, idleSeconds, snapshotMs, timestamp); err != nil {
+			return err
+		}
+
+	default:
+		return errors.New("unexpected RDB value type " + key.Type)
+	}
+
+	record := &RedisRecord{
+		Key:        key.Key,
+		Type:       key.Type,
+		Value:      fmt.Sprintf("size=%d", size),
+		TTLSeconds: ttlSeconds,
+		ExportedAt: timestamp,
+		Slot:       slot,
+
+		SizeEstimate: size,
+		SizeLabel:    true,
+		IdleSeconds:  idleSeconds,
+		Cardinality:  cardinality,
+		ExpiresAt:    key.ExpireAt,
+	}
+	if keysOnly {
+		record.Value = fmt.Sprintf("size_estimate=%d", size)
+	}
+	if truncatedLength > 0 {
+		re.markTruncated(record, truncatedLength)
+	}
+	return re.fileManager.WriteRecord(record)
+}
+
+// writeRDBStream writes the stream_info, consumer group and entry records of
+// a decoded stream in the shapes exportStream uses, returning the size of the
+// entries. Keys-only exports write nothing but still get the size.
+func (re *RedisExporter) writeRDBStream(key *rdbKey, keysOnly bool, idleSeconds, snapshotMs int64, timestamp string) (int64, error) {
+	stream := key.Stream
+	slot := keySlot(key.Key)
+	write := func(record *RedisRecord) error {
+		if keysOnly {
+			return nil
+		}
+		record.TTLSeconds = ttlNoExpiry
+		record.ExportedAt = timestamp
+		record.Slot = slot
+		record.IdleSeconds = idleSeconds
+		return re.fileManager.WriteRecord(record)
+	}
+
+	var firstID, lastID string
+	if n := len(stream.Entries); n > 0 {
+		firstID = stream.Entries[0].ID.String()
+		lastID = stream.Entries[n-1].ID.String()
+	}
+	err := write(&RedisRecord{
+		Key:   key.Key,
+		Type:  "stream_info",
+		Value: fmt.Sprintf("length=%d,first_id=%s,last_id=%s", stream.Length, firstID, lastID),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, group := range stream.Groups {
+		// The file holds every entry, so lag is counted rather than estimated
+		lag := int64(0)
+		for _, entry := range stream.Entries {
+			if entry.ID.after(group.LastID) {
+				lag++
+			}
+		}
+		groupKey := fmt.Sprintf("%s:group:%s", key.Key, group.Name)
+		err := write(&RedisRecord{
+			Key:  groupKey,
+			Type: "stream_group",
+			Value: fmt.Sprintf("consumers=%d,pending=%d,last_delivered_id=%s,entries_read=%d,lag=%d",
+				len(group.Consumers), group.Pending, group.LastID, max(group.EntriesRead, 0), lag),
+			ParentKey: key.Key,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		for _, consumer := range group.Consumers {
+			inactive := int64(-1)
+			if consumer.ActiveTime != -1 {
+				inactive = snapshotMs - consumer.ActiveTime
+			}
+			err := write(&RedisRecord{
+				Key:       fmt.Sprintf("%s:consumer:%s", groupKey, consumer.Name),
+				Type:      "stream_consumer",
+				Value:     fmt.Sprintf("pending=%d,idle_ms=%d,inactive_ms=%d", consumer.Pending, snapshotMs-consumer.SeenTime, inactive),
+				ParentKey: key.Key,
+			})
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	// STREAM_SINCE is a millisecond start ID, or "-" for the whole stream
+	since, _ := strconv.ParseUint(re.streamSince, 10, 64)
+	size := int64(0)
+	for _, entry := range stream.Entries {
+		if !keysOnly && entry.ID.Ms < since {
+			continue
+		}
+		values := make(map[string]interface{}, len(entry.Fields))
+		for _, field := range entry.Fields {
+			values[field.Name] = field.Value
+		}
+		value := streamEntryValue(values)
+		err := write(&RedisRecord{
+			Key:       fmt.Sprintf("%s:entry:%s", key.Key, entry.ID),
+			Type:      "stream_entry",
+			Value:     value,
+			ParentKey: key.Key,
+		})
+		if err != nil {
+			return 0, err
+		}
+		size += int64(len(value))
+	}
+	return size, nil
+}
+
+// sortRDBMembers orders sorted set members as ZRANGE does: by score, then member
+func sortRDBMembers(members []rdbMember) {
+	sort.SliceStable(members, func(i, j int) bool {
+		if members[i].Score != members[j].Score {
+			return members[i].Score < members[j].Score
+		}
+		return members[i].Member < members[j].Member
+	})
+}
+
+func sortedKeys(counts map[string]int64) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
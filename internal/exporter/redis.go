@@ -3,56 +3,490 @@ package exporter
 import (
 	"context"
 	"crypto/tls"
+	"encoding/base64"
+	"errors"
 	"fmt"
-	"github.com/go-redis/redis/v8"
+	"github.com/redis/go-redis/v9"
 	"log"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
+const (
+	defaultListChunkSize    = 1000
+	maxConnectRetryInterval = 30 * time.Second
+	// minTargetRecordsPerFile keeps TargetFileCount from producing tiny files
+	// for small keyspaces
+	minTargetRecordsPerFile = 1000
+)
+
+// errAssumedTypeMismatch marks keys skipped because they are not of ASSUME_TYPE
+var errAssumedTypeMismatch = errors.New("key is not of the assumed type")
+
 type RedisExporterOptions struct {
-	RedisURL          string
-	OutputDir         string
-	BatchSize         int
-	EnableTLS         bool
-	SkipTLSVerify     bool
+	RedisURL string
+	// RedisClusterURLs are seed nodes of a Redis Cluster; when set they
+	// replace RedisURL and every master is scanned
+	RedisClusterURLs []string
+	// RedisSourceURLs are independent servers, e.g. one per shard, exported
+	// one after another into the same output with a source column; when set
+	// they replace RedisURL. Settings other than the address, credentials
+	// and database are shared.
+	RedisSourceURLs []string
+	// RedisUsername and RedisPassword, when set, replace the credentials in
+	// the URLs, so secrets can be injected without templating the URL
+	RedisUsername string
+	RedisPassword string
+	// SentinelMasterName and SentinelAddrs connect through Redis Sentinel,
+	// following failovers; RedisURL then only supplies credentials, the
+	// database and TLS. SentinelPassword authenticates to the sentinels.
+	SentinelMasterName string
+	SentinelAddrs      []string
+	SentinelPassword   string
+	// ReadFromReplica reads from replicas to spare the primary: cluster
+	// replicas after READONLY, a Sentinel-resolved replica, or, for a plain
+	// RedisURL, a check that it points at one
+	ReadFromReplica bool
+	// ProtocolVersion is 2 for servers that only speak RESP2, or 3; 0 keeps
+	// RESP3, or the protocol the URL's protocol parameter selects
+	ProtocolVersion int
+	// ElastiCacheIAMCacheName authenticates as ElastiCacheIAMUser with IAM
+	// tokens for this replication group or serverless cache, generated from
+	// the default AWS credentials and refreshed for new connections
+	ElastiCacheIAMCacheName  string
+	ElastiCacheIAMUser       string
+	ElastiCacheIAMServerless bool
+	// AuthProvider supplies expiring credentials, such as Entra ID tokens;
+	// connections re-authenticate with new ones before the old expire
+	AuthProvider AuthProvider
+	// SSHHost is a bastion, "host" or "host:port", that every Redis
+	// connection is tunneled through as SSHUser with the private key file
+	// SSHKey. SSHKnownHosts verifies its host key, defaulting to
+	// ~/.ssh/known_hosts; without either the connection fails unless
+	// SSHInsecureSkipHostKey accepts any host key.
+	SSHHost                string
+	SSHUser                string
+	SSHKey                 string
+	SSHKnownHosts          string
+	SSHInsecureSkipHostKey bool
+	// Dialer opens every network connection, to Redis or the SSH host, in
+	// place of ProxyURL or a direct dial; TLS is negotiated on top of it
+	Dialer func(ctx context.Context, network, addr string) (net.Conn, error)
+	// ProxyURL is a socks5://, socks5h:// or http:// proxy for connections
+	// to hosts that NoProxy, a comma-separated NO_PROXY list, does not match
+	ProxyURL string
+	NoProxy  string
+
+	OutputDir     string
+	BatchSize     int
+	EnableTLS     bool
+	SkipTLSVerify bool
+	// TLSCAFile is a PEM file, or a directory of them, whose certificates
+	// verify the server; setting it turns verification on
+	TLSCAFile         string
 	OutputFormat      string
 	MaxRecordsPerFile int64
+	// TargetFileCount replaces MaxRecordsPerFile with DBSIZE divided by this
+	// many files, computed at startup (0 disables)
+	TargetFileCount int64
+	// Compression is none, gzip (CSV and Parquet), snappy or zstd (Parquet only)
+	Compression string
+	// SnapshotWait records the replication offset at export start
+	SnapshotWait bool
+	// SnapshotWaitReplicas issues WAIT for this many replicas (0 disables WAIT)
+	SnapshotWaitReplicas int
+	SnapshotWaitTimeout  time.Duration
+	// ListChunkSize is the initial and maximum LRANGE window for list exports
+	ListChunkSize int64
+	// ListChunkBytes shrinks the LRANGE window when a chunk exceeds this many bytes (0 disables)
+	ListChunkBytes int64
+	// NamespaceDepth limits how many prefix segments the namespaces rollup descends
+	NamespaceDepth int
+	// NamespaceWidth caps distinct children per prefix before collapsing into '*'
+	NamespaceWidth int
+	// BigKeysTop is how many keys of each type the bigkeys report ranks
+	BigKeysTop int
+	// BigKeysBy ranks big keys by "memory" (MEMORY USAGE, default) or
+	// "cardinality"
+	BigKeysBy string
+	// SampleRate is the fraction of keys the sample command exports, 0.01
+	// when 0
+	SampleRate float64
+	// SampleMode picks sampled keys by a hash of their name ("random",
+	// default) or takes every Nth scanned key ("nth")
+	SampleMode string
+	// ClientName, followed by /<export_id>, is set with CLIENT SETNAME on
+	// every connection so the exporter and its run can be identified in
+	// CLIENT LIST
+	ClientName string
+	// DualMode writes a base64 DUMP payload in a raw_dump column for every
+	// top-level key exported with full data
+	DualMode bool
+	// ConnectRetries is the number of additional PING attempts before giving up
+	ConnectRetries int
+	// ConnectRetryInterval is the initial delay between attempts, doubled after each failure
+	ConnectRetryInterval time.Duration
+	// ScanRetries is the number of times a failed SCAN call is retried at the
+	// same cursor, after ScanRetryInterval doubled after each failure, so a
+	// dropped connection or failover does not end the export
+	ScanRetries       int
+	ScanRetryInterval time.Duration
+	// Reproducible produces byte-identical partition files for unchanged data
+	Reproducible bool
+	// BitmapKeys is a glob; matching string keys also get a "bitmap" record
+	BitmapKeys string
+	// BitmapSampleOffsets are bit offsets sampled with GETBIT for bitmap keys
+	BitmapSampleOffsets []int64
+	// BitmapChunkBytes also exports the content of bitmap keys as hex chunks
+	// of this many bytes, skipping chunks without a set bit (0 disables)
+	BitmapChunkBytes int
+	// WriteQueueSize bounds how many scanned batches may wait for the writer
+	WriteQueueSize int
+	// PartitionBy is "time" (default) or "age" to bucket keys by OBJECT IDLETIME
+	PartitionBy string
+	// PartitionTemplate lays partitions out by rendering this Go template per
+	// record, e.g. "type={{.Type}}/date={{.Date}}", instead of PartitionBy
+	PartitionTemplate string
+	// BatchTimeout bounds each SCAN call and pipeline round trip (0 disables)
+	BatchTimeout time.Duration
+	// OmitPartitionID drops the partition_id column from the output schema
+	OmitPartitionID bool
+	// AssumeType skips the TYPE round trip and treats every scanned key as this type
+	AssumeType string
+	// KeyTypes limits exports to keys of these types, using SCAN's TYPE
+	// option when there is only one
+	KeyTypes []string
+	// TypeCacheSize remembers the TYPE of up to this many recently typed keys
+	// so they are not typed again (0 disables)
+	TypeCacheSize int
+	// SequentialElementScans waits for each SSCAN, HSCAN and ZSCAN step to be
+	// written before fetching the next instead of prefetching it
+	SequentialElementScans bool
+	// SkipTTL drops the TTL and HTTL lookups and writes -1 for every TTL
+	SkipTTL bool
+	// IgnoreFile lists glob patterns of keys that must never be exported
+	IgnoreFile string
+	// TTLFilter keeps only "persistent" or "expiring" keys; MinTTL and
+	// MaxTTL keep only keys expiring within that window (0 disables each)
+	TTLFilter string
+	MinTTL    time.Duration
+	MaxTTL    time.Duration
+	// KeysFile lists the keys to export, one per line, in place of SCAN
+	KeysFile string
+	// ExcludePatterns are further globs of keys to skip, e.g. "session:*",
+	// applied like IgnoreFile rules
+	ExcludePatterns []string
+	// KeyRegex and KeyExcludeRegex are RE2 expressions scanned keys must
+	// match, and must not match, on top of the MATCH pattern
+	KeyRegex        string
+	KeyExcludeRegex string
+	// AutoScanCount adapts SCAN COUNT to ScanLatencyTarget instead of using BatchSize
+	AutoScanCount     bool
+	ScanLatencyTarget time.Duration
+	// MaxOpsPerSecond caps the commands sent to Redis per second across all
+	// connections, counting each command of a pipeline (0 disables)
+	MaxOpsPerSecond int
+	// ThrottleLatency slows SCAN and batch reads down while a PING sampled
+	// every ThrottleInterval takes longer than this (0 disables)
+	ThrottleLatency  time.Duration
+	ThrottleInterval time.Duration
+	// FlushEvery flushes buffered records this often however few arrive
+	FlushEvery time.Duration
+	// IntermediateFlush rewrites the open Parquet file every this many records (0 disables)
+	IntermediateFlush int64
+	// QueryURI is where the output is published, used for query hints and load.sql
+	QueryURI    string
+	QueryRegion string
+	// ZSetWithRank exports sorted sets in score order with their true rank
+	ZSetWithRank bool
+	// ZSetRankMaxSize falls back to unranked ZSCAN for larger sorted sets
+	ZSetRankMaxSize int64
+	// ZSetMinScore and ZSetMaxScore export only sorted set members scored
+	// within them, in ZRANGEBYSCORE syntax, e.g. "(100" or "+inf"
+	ZSetMinScore string
+	ZSetMaxScore string
+	// MaxValueBytes cuts element values longer than this many bytes and adds
+	// a value_truncated column (0 for no limit). Elements are still fetched
+	// whole and cut when written; strings are sized with STRLEN instead of
+	// being read.
+	MaxValueBytes int
+	// MaxElementsPerKey exports at most this many elements of each list,
+	// taken from MaxElementsFrom ("head" or "tail"), and adds
+	// elements_truncated and full_length columns; 0 exports all
+	MaxElementsPerKey int64
+	MaxElementsFrom   string
+	// HashFields exports only these fields of each hash, read with HMGET
+	// instead of HSCAN
+	HashFields []string
+	// Verbosity gates progress output; errors and summaries always print
+	Verbosity Verbosity
+	// IncludeParentKey adds a parent_key column to element records
+	IncludeParentKey bool
+	// IncludeSlot adds a slot column with the cluster hash slot of the
+	// top-level key, computed locally
+	IncludeSlot bool
+	// KeyEncoding is raw (default), base64 or hex for keys that are not valid UTF-8
+	KeyEncoding string
+	// CSVDelimiter and CSVQuote are single characters ("tab" selects TSV),
+	// comma and double quote when empty
+	CSVDelimiter string
+	CSVQuote     string
+	// OmitCSVHeader drops the header row from CSV files
+	OmitCSVHeader bool
+	// IncludeCardinality adds a keys-only cardinality column from pipelined
+	// SCARD, ZCARD, HLEN, LLEN or XLEN
+	IncludeCardinality bool
+	// IncludeEncoding adds a keys-only encoding column from pipelined
+	// OBJECT ENCODING, e.g. listpack, intset or embstr
+	IncludeEncoding bool
+	// IncludeIdleTime adds a keys-only idle_seconds column from pipelined
+	// OBJECT IDLETIME, for finding keys untouched for months
+	IncludeIdleTime bool
+	// IncludeExpiresAt adds an expires_at column with each TTL as an absolute
+	// RFC 3339 time, from PEXPIRETIME where the server has it
+	IncludeExpiresAt bool
+	// DropValueColumn replaces the value column with a numeric size_estimate
+	// column; only keys-only exports can use it
+	DropValueColumn bool
+	// MaterializePartitionCols adds year, month, day and hour columns matching
+	// the Hive partition directories
+	MaterializePartitionCols bool
+	// EstimateSample is how many keys the estimate command exports (default 1000)
+	EstimateSample int
+	// RunTimestamp is "record" to stamp each record with its own export time
+	// (the default) or "fixed" to stamp every record with the run start time
+	RunTimestamp string
+	// MinSizeBytes skips keys whose MEMORY USAGE is below this many bytes (0 disables)
+	MinSizeBytes int64
+	// MaxConcurrentCopies runs up to this many Parquet COPYs in the background
+	// (0 copies synchronously on rotation)
+	MaxConcurrentCopies int
+	// ParquetSummaryFiles writes _metadata and _common_metadata summary files
+	ParquetSummaryFiles bool
+	// ChecksumManifest writes a SHA256SUMS file covering the export on Close
+	ChecksumManifest bool
+	// IncludeACL writes the ACL users and rules to acl.json
+	IncludeACL bool
+	// Bundle is "tar.gz" or "zip" to pack the finished export into one
+	// archive; BundleDeleteFiles removes the loose files as they are archived
+	Bundle            string
+	BundleDeleteFiles bool
+	// IcebergMetadata writes Iceberg v1 table metadata for Parquet exports
+	IcebergMetadata bool
+	// StreamSince limits stream exports to entries newer than a millisecond
+	// timestamp or a relative duration such as "-5m"
+	StreamSince string
+	// WatchRotateInterval closes open partitions this often in watch mode so
+	// changes become readable (default 1m)
+	WatchRotateInterval time.Duration
+	// PollInterval is the pause between the passes of Poll
+	PollInterval time.Duration
+	// PollSeenLimit caps how many keys of a pass Poll remembers (default
+	// 1,000,000); keys beyond it are exported again by the next pass
+	PollSeenLimit int
+	// CompletedKeysLog appends each top-level key a full-data export finishes
+	CompletedKeysLog string
+	// Resume skips the keys already in CompletedKeysLog
+	Resume bool
+	// ResumeBloomFPRate loads the log into a bloom filter with this false
+	// positive rate instead of an exact set (0 keeps the exact set)
+	ResumeBloomFPRate float64
+	// RDBFile exports from this RDB file instead of the live server; only
+	// the database selected by RedisURL is read
+	RDBFile string
 }
 
 type PartitionInfo struct {
-	PartitionID   int       `json:"partition_id"`
-	DataType      string    `json:"data_type"`
-	FileName      string    `json:"file_name"`
-	RecordCount   int64     `json:"record_count"`
-	FileSizeBytes int64     `json:"file_size_bytes"`
-	StartTime     time.Time `json:"start_time"`
-	EndTime       time.Time `json:"end_time"`
+	PartitionID   int    `json:"partition_id"`
+	DataType      string `json:"data_type"`
+	Partition     string `json:"partition,omitempty"`
+	Path          string `json:"path"`
+	FileName      string `json:"file_name"`
+	RecordCount   int64  `json:"record_count"`
+	FileSizeBytes int64  `json:"file_size_bytes"`
+	// SHA256 is the hex digest of the file, recorded with CHECKSUM_MANIFEST
+	SHA256    string    `json:"sha256,omitempty"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
 }
 
 type ExportMetadata struct {
-	ExportID   string          `json:"export_id"`
+	ExportID string `json:"export_id"`
+	// Status is "complete", or "partial" when the scan failed part way; the
+	// written partitions then hold the keys exported before Error
+	Status     string          `json:"status"`
+	Error      string          `json:"error,omitempty"`
 	Pattern    string          `json:"pattern"`
 	StartTime  time.Time       `json:"start_time"`
 	EndTime    time.Time       `json:"end_time"`
 	TotalKeys  int64           `json:"total_keys"`
 	Partitions []PartitionInfo `json:"partitions"`
+	// Server identifies the Redis instance, when INFO server could be read
+	Server *ServerInfo `json:"server,omitempty"`
+	// Sources lists every server of a multi-source export, in export order
+	Sources []SourceInfo `json:"sources,omitempty"`
+	// Replication is populated when SnapshotWait is enabled
+	Replication *ReplicationSnapshot `json:"replication,omitempty"`
+	// RDB is populated when the export was read from an RDB file
+	RDB *RDBSource `json:"rdb,omitempty"`
+	// IgnoredKeys counts scanned keys dropped by IgnoreFile patterns
+	IgnoredKeys int64 `json:"ignored_keys"`
+	// SmallKeys counts keys skipped for using less than MinSizeBytes
+	SmallKeys int64 `json:"small_keys,omitempty"`
+	// TTLFilteredKeys counts keys outside the TTL_FILTER, MIN_TTL and MAX_TTL window
+	TTLFilteredKeys int64 `json:"ttl_filtered_keys,omitempty"`
+	// TruncatedKeys counts lists cut short by MAX_ELEMENTS_PER_KEY
+	TruncatedKeys int64 `json:"truncated_keys,omitempty"`
+	// DroppedRecords counts written records lost because their file could not
+	// be finished, e.g. when OUTPUT_DIR ran out of space
+	DroppedRecords int64 `json:"dropped_records,omitempty"`
+	// ResumedKeys counts keys skipped because the completed keys log listed them
+	ResumedKeys int64 `json:"resumed_keys,omitempty"`
+	// TTLsSkipped is set when SkipTTL wrote -1 in place of every TTL
+	TTLsSkipped bool `json:"ttls_skipped,omitempty"`
+	// TypeCounts and TypeBytes profile the written records (and their data
+	// bytes) by record type, e.g. "hash" keys and "hash_field" elements
+	TypeCounts map[string]int64 `json:"type_counts"`
+	TypeBytes  map[string]int64 `json:"type_bytes"`
 }
 
 type RedisExporter struct {
-	client        *redis.Client
+	// client is a *redis.Client, or a *redis.ClusterClient for REDIS_CLUSTER_URLS
+	client        redis.UniversalClient
 	fileManager   *FileManager
 	ctx           context.Context
 	batchSize     int
 	flushInterval int
+	verbosity     Verbosity
+	// writeQueueSize bounds the batches buffered between scanner and writer
+	writeQueueSize int
+	batchTimeout   time.Duration
+
+	autoScanCount     bool
+	scanLatencyTarget time.Duration
+	// throttle pauses SCAN and batch reads while the server is slow, nil when disabled
+	throttle *throttle
+	// assumeType replaces TYPE lookups for homogeneous keyspaces when set
+	assumeType string
+	// keyTypes are the types KEY_TYPES selects, all types when empty
+	keyTypes []string
+	// scanCursor is the cursor returned by the latest SCAN, for checkpoints
+	scanCursor atomic.Uint64
+	// typeCache skips TYPE for keys typed before; nil when disabled
+	typeCache *typeCache
+	// scannedTypes are the types KEY_TYPES resolved for the batch being
+	// handled, so the export does not send TYPE again
+	scannedTypes map[string]string
+	// sequentialElementScans disables prefetching in scanElements
+	sequentialElementScans bool
+	// skipTTL writes -1 for TTLs instead of looking them up
+	skipTTL bool
+
+	ignorePatterns []string
+
+	keyRegex        *regexp.Regexp
+	keyExcludeRegex *regexp.Regexp
+
+	// keysFile replaces SCAN with the keys it lists when set
+	keysFile string
+	// ttlWindow drops scanned keys by TTL, nil when no TTL filter is set
+	ttlWindow *ttlWindow
+
+	listChunkSize  int64
+	listChunkBytes int64
+
+	zsetWithRank    bool
+	zsetRankMaxSize int64
+	// zsetScores is the ZSET_MIN_SCORE/ZSET_MAX_SCORE range, nil for all members
+	zsetScores *scoreRange
+	// hashFields are the fields HASH_FIELDS selects, all fields when empty
+	hashFields []string
+	// maxElements and maxElementsFrom truncate long lists, 0 for no limit
+	maxElements     int64
+	maxElementsFrom string
+	// truncatedLength is the full length of the list exportKeyData just cut
+	// short, 0 when it was exported whole
+	truncatedLength int64
+
+	// streamSince is the XRANGE start ID, "-" for the whole stream
+	streamSince string
+	// httlUnsupported is set once the server rejects HTTL (pre-7.4)
+	httlUnsupported bool
+	// idleTimeUnsupported and memoryUsageUnsupported are set once the server
+	// rejects OBJECT IDLETIME or MEMORY USAGE, as some forks do
+	idleTimeUnsupported    bool
+	memoryUsageUnsupported bool
+	// expireTimeUnsupported is set once the server rejects PEXPIRETIME (pre-7.0)
+	expireTimeUnsupported bool
+	// estimateSample is how many keys Estimate exports
+	estimateSample int
+	// runTimestamp is the fixed exported_at for every record, empty for per-record times
+	runTimestamp string
+	// minSizeBytes skips keys smaller than this, per MEMORY USAGE
+	minSizeBytes int64
+	// watchRotateInterval is how often Watch closes open partitions
+	watchRotateInterval time.Duration
+	// pollInterval and pollSeenLimit configure Poll
+	pollInterval  time.Duration
+	pollSeenLimit int
+	// completedKeys logs finished keys and skips those of a resumed run
+	completedKeys *completedKeysLog
+	// readFromReplica scans a replica of every cluster master
+	readFromReplica bool
+	// credentials rotates AuthProvider credentials until Close
+	credentials *rotatingCredentials
+	// tunnel carries Redis connections through SSH_HOST when set
+	tunnel *sshTunnel
+	// sources are the servers of a multi-source export, nil otherwise;
+	// client and db belong to sources[currentSource]
+	sources       []exportSource
+	currentSource int
+	// scanRetries and scanRetryInterval retry SCAN calls at the same cursor
+	scanRetries       int
+	scanRetryInterval time.Duration
+	// rdbFile replaces the live server as the source of keys when set
+	rdbFile string
+	// db is the database selected by the URL
+	db int
+
+	namespaceDepth int
+	namespaceWidth int
+
+	bigKeysTop int
+	bigKeysBy  string
+
+	// sample selects the keys ExportSample exports; sampler is set to it for
+	// that export only and filters nothing while nil
+	sample  *keySampler
+	sampler *keySampler
+
+	dualMode bool
+
+	bitmapKeys          string
+	bitmapSampleOffsets []int64
+	bitmapChunkBytes    int
 }
 
 func NewRedisExporter(opts RedisExporterOptions) (Exporter, error) {
-	// Parse Redis connection
-	opt, err := redis.ParseURL(opts.RedisURL)
+	// Parse Redis connection; a cluster takes its settings from the first seed
+	redisURL := opts.RedisURL
+	if len(opts.RedisClusterURLs) > 0 {
+		redisURL = opts.RedisClusterURLs[0]
+	}
+	if len(opts.RedisSourceURLs) > 0 {
+		redisURL = opts.RedisSourceURLs[0]
+	}
+	opt, err := redis.ParseURL(redisURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
 	}
+	overrideCredentials(opt, opts.RedisUsername, opts.RedisPassword)
 
 	// Optimize Redis client for large datasets
 	opt.PoolSize = 10
@@ -61,160 +495,788 @@ func NewRedisExporter(opts RedisExporterOptions) (Exporter, error) {
 	opt.DialTimeout = time.Second * 5
 	opt.ReadTimeout = time.Second * 30
 	opt.WriteTimeout = time.Second * 30
+	// BatchTimeout bounds round trips through their context
+	opt.ContextTimeoutEnabled = true
+
+	// ElastiCache IAM tokens replace the password from the URL
+	if opts.ElastiCacheIAMCacheName != "" {
+		if !opts.EnableTLS {
+			return nil, errors.New("ELASTICACHE_IAM_CACHE_NAME requires ENABLE_TLS or a rediss:// URL")
+		}
+		tokens, err := newElastiCacheTokens(context.Background(), opts.ElastiCacheIAMCacheName, opts.ElastiCacheIAMUser, opts.ElastiCacheIAMServerless)
+		if err != nil {
+			return nil, err
+		}
+		opt.CredentialsProviderContext = tokens.credentials
+		opt.ConnMaxLifetime = elastiCacheConnLifetime
+		opts.Verbosity.infof("ElastiCache IAM authentication as %s for %s (%s)\n", opts.ElastiCacheIAMUser, opts.ElastiCacheIAMCacheName, tokens.region)
+	}
+
+	// Rotating credentials re-authenticate live connections, so a long export
+	// outlives any one token
+	var credentials *rotatingCredentials
+	if opts.AuthProvider != nil {
+		if opts.ElastiCacheIAMCacheName != "" {
+			return nil, errors.New("ELASTICACHE_IAM_CACHE_NAME cannot be combined with an auth provider")
+		}
+		credentials = newRotatingCredentials(opts.AuthProvider)
+		opt.StreamingCredentialsProvider = credentials
+	}
+
+	switch opts.ProtocolVersion {
+	case 0:
+	case 2, 3:
+		opt.Protocol = opts.ProtocolVersion
+	default:
+		return nil, fmt.Errorf("unsupported protocol version: %d (supported: 2, 3)", opts.ProtocolVersion)
+	}
+
+	// Name every pooled connection so operators can find it, and the run it
+	// belongs to, in CLIENT LIST
+	exportID := newExportID(time.Now())
+	if opts.ClientName != "" {
+		clientName := opts.ClientName + "/" + exportID
+		opts.Verbosity.infof("Client name: %s\n", clientName)
+		opt.OnConnect = func(ctx context.Context, cn *redis.Conn) error {
+			return cn.ClientSetName(ctx, clientName).Err()
+		}
+	}
 
 	// Configure TLS if needed
+	if opts.TLSCAFile != "" && !opts.EnableTLS {
+		return nil, errors.New("TLS_CA_FILE requires ENABLE_TLS or a rediss:// URL")
+	}
 	if opts.EnableTLS {
+		// A CA bundle is only useful if certificates are verified against it
 		tlsConfig := &tls.Config{
-			InsecureSkipVerify: opts.SkipTLSVerify,
+			InsecureSkipVerify: opts.SkipTLSVerify && opts.TLSCAFile == "",
+		}
+		// Verification needs the host name rediss:// URLs would have set
+		if host, _, err := net.SplitHostPort(opt.Addr); err == nil {
+			tlsConfig.ServerName = host
+		}
+		if opts.TLSCAFile != "" {
+			tlsConfig.RootCAs, err = loadCAPool(opts.TLSCAFile)
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		// If the URL scheme is rediss://, it should already enable TLS
 		// But we can force it here too
 		opt.TLSConfig = tlsConfig
 
-		fmt.Printf("TLS enabled (InsecureSkipVerify: %v)\n", opts.SkipTLSVerify)
+		opts.Verbosity.infof("TLS enabled (InsecureSkipVerify: %v)\n", tlsConfig.InsecureSkipVerify)
 	}
 
-	client := redis.NewClient(opt)
+	// A custom dialer or a proxy carries every connection, including the one
+	// to the SSH host
+	dial := dialFunc(opts.Dialer)
+	if dial == nil && opts.ProxyURL != "" && opts.RDBFile == "" {
+		dial, err = proxyDialer(opts.ProxyURL, opts.NoProxy)
+		if err != nil {
+			return nil, err
+		}
+		if u, err := url.Parse(opts.ProxyURL); err == nil {
+			opts.Verbosity.infof("Connecting through proxy %s\n", u.Redacted())
+		}
+	}
+
+	// Redis addresses, including cluster nodes and sentinels, are resolved
+	// and dialed from the SSH host
+	var tunnel *sshTunnel
+	if opts.SSHHost != "" && opts.RDBFile == "" {
+		tunnel, err = newSSHTunnel(opts.SSHHost, opts.SSHUser, opts.SSHKey, opts.SSHKnownHosts, opts.SSHInsecureSkipHostKey, dial)
+		if err != nil {
+			return nil, err
+		}
+		dial = tunnel.dial
+		opts.Verbosity.infof("Tunneling Redis connections through SSH host %s\n", tunnel.addr)
+	}
+	if dial != nil {
+		opt.Dialer = dial
+		if opt.TLSConfig != nil {
+			opt.Dialer = dialTLS(dial, opt.TLSConfig)
+		}
+	}
+
+	sentinel := opts.SentinelMasterName != "" || len(opts.SentinelAddrs) > 0
+	if sentinel && len(opts.RedisClusterURLs) > 0 {
+		return nil, errors.New("SENTINEL_MASTER_NAME cannot be combined with REDIS_CLUSTER_URLS")
+	}
+	if err := validateSourceOptions(opts); err != nil {
+		return nil, err
+	}
 
-	// Test connection
+	var client redis.UniversalClient
+	if sentinel {
+		failoverOpt, err := failoverOptions(opt, opts.SentinelMasterName, opts.SentinelAddrs, opts.SentinelPassword)
+		if err != nil {
+			return nil, err
+		}
+		failoverOpt.ReplicaOnly = opts.ReadFromReplica
+		client = redis.NewFailoverClient(failoverOpt)
+		opts.Verbosity.infof("Sentinel mode for master %s via %d sentinels\n", opts.SentinelMasterName, len(opts.SentinelAddrs))
+	} else if len(opts.RedisClusterURLs) > 0 {
+		clusterOpt, err := clusterOptions(opt, opts.RedisClusterURLs)
+		if err != nil {
+			return nil, err
+		}
+		clusterOpt.ReadOnly = opts.ReadFromReplica
+		client = redis.NewClusterClient(clusterOpt)
+		opts.Verbosity.infof("Redis Cluster mode with %d seed nodes\n", len(clusterOpt.Addrs))
+	} else {
+		client = redis.NewClient(opt)
+	}
+
+	// One budget is shared by every connection, including further sources
+	limiter := newRateLimiter(opts.MaxOpsPerSecond)
+	limiter.limit(client)
+
+	// Test connection, retrying when Redis is still starting up. RDB file
+	// exports never connect.
 	ctx := context.Background()
-	_, err = client.Ping(ctx).Result()
-	if err != nil {
+	if opts.RDBFile != "" {
+		if err := validateRDBOptions(opts); err != nil {
+			return nil, err
+		}
+	} else if err := pingWithRetry(ctx, client, opts.ConnectRetries, opts.ConnectRetryInterval); err != nil {
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	} else if opts.ReadFromReplica && !sentinel && len(opts.RedisClusterURLs) == 0 {
+		if err := requireReplicaRole(ctx, client); err != nil {
+			return nil, err
+		}
+	}
+
+	// Further sources connect the same way as the first
+	var sources []exportSource
+	if len(opts.RedisSourceURLs) > 0 {
+		sources = append(sources, exportSource{name: sourceName(opt), client: client, db: opt.DB})
+		for _, rawURL := range opts.RedisSourceURLs[1:] {
+			sourceOpt, err := sourceOptions(opt, rawURL, dial)
+			if err != nil {
+				return nil, err
+			}
+			overrideCredentials(sourceOpt, opts.RedisUsername, opts.RedisPassword)
+			sourceClient := redis.NewClient(sourceOpt)
+			limiter.limit(sourceClient)
+			if err := pingWithRetry(ctx, sourceClient, opts.ConnectRetries, opts.ConnectRetryInterval); err != nil {
+				return nil, fmt.Errorf("failed to connect to Redis source %s: %w", sourceName(sourceOpt), err)
+			}
+			if opts.ReadFromReplica {
+				if err := requireReplicaRole(ctx, sourceClient); err != nil {
+					return nil, err
+				}
+			}
+			sources = append(sources, exportSource{name: sourceName(sourceOpt), client: sourceClient, db: sourceOpt.DB})
+		}
+		opts.Verbosity.infof("Exporting %d sources into one output\n", len(sources))
+	}
+
+	// A FIFO output receives a single CSV stream instead of partition files
+	streaming := isFIFO(opts.OutputDir)
+
+	if opts.IncludeACL && streaming {
+		return nil, errors.New("INCLUDE_ACL is not supported with FIFO output")
 	}
 
 	// Create output directory
-	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	if !streaming {
+		if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create output directory: %w", err)
+		}
 	}
 
-	// Determine output format
-	var format OutputFormat
-	switch opts.OutputFormat {
-	case "parquet":
-		format = FormatParquet
-	case "csv", "":
+	// Determine output format - unknown formats are rejected by validateStorageConfig
+	format := OutputFormat(opts.OutputFormat)
+	if format == "" {
 		format = FormatCSV
+	}
+
+	// Ignore rules are a compliance control: fail rather than export without them
+	var ignorePatterns []string
+	if opts.IgnoreFile != "" {
+		ignorePatterns, err = loadIgnorePatterns(opts.IgnoreFile)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("Ignore rules in effect: %d patterns from %s - matching keys will NOT be exported\n",
+			len(ignorePatterns), opts.IgnoreFile)
+	}
+	if excluded := parseExcludePatterns(opts.ExcludePatterns); len(excluded) > 0 {
+		ignorePatterns = append(ignorePatterns, excluded...)
+		fmt.Printf("Exclude patterns in effect: %s - matching keys will NOT be exported\n", strings.Join(excluded, ", "))
+	}
+
+	// Resolve relative cutoffs once so every stream shares the same window
+	streamSince, err := parseStreamSince(opts.StreamSince, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// A fixed run timestamp is taken once so every record shares it
+	var runTimestamp string
+	switch opts.RunTimestamp {
+	case "fixed":
+		runTimestamp = time.Now().UTC().Format(time.RFC3339)
+	case "record", "":
+	default:
+		return nil, fmt.Errorf("unsupported run timestamp mode: %s", opts.RunTimestamp)
+	}
+
+	// Only types exportKeyData understands can be assumed
+	switch opts.AssumeType {
+	case "", "string", "list", "set", "zset", "hash", "stream":
+	default:
+		return nil, fmt.Errorf("unsupported assumed type: %s", opts.AssumeType)
+	}
+
+	keyRegex, err := compileKeyRegex("KEY_REGEX", opts.KeyRegex)
+	if err != nil {
+		return nil, err
+	}
+	keyExcludeRegex, err := compileKeyRegex("KEY_EXCLUDE_REGEX", opts.KeyExcludeRegex)
+	if err != nil {
+		return nil, err
+	}
+
+	ttlWindow, err := newTTLWindow(opts.TTLFilter, opts.MinTTL, opts.MaxTTL)
+	if err != nil {
+		return nil, err
+	}
+	zsetScores, err := newScoreRange(opts.ZSetMinScore, opts.ZSetMaxScore)
+	if err != nil {
+		return nil, err
+	}
+	maxElementsFrom, err := validateTruncation(opts.MaxElementsPerKey, opts.MaxElementsFrom)
+	if err != nil {
+		return nil, err
+	}
+	if opts.MaxValueBytes < 0 {
+		return nil, fmt.Errorf("MAX_VALUE_BYTES must not be negative, got %d", opts.MaxValueBytes)
+	}
+	if opts.IncludeExpiresAt && opts.SkipTTL {
+		return nil, errors.New("INCLUDE_EXPIRES_AT cannot be combined with SKIP_TTL")
+	}
+
+	keyTypes, err := parseKeyTypes(opts.KeyTypes)
+	if err != nil {
+		return nil, err
+	}
+	assumeType := opts.AssumeType
+	if len(keyTypes) > 0 && assumeType != "" {
+		return nil, errors.New("KEY_TYPES cannot be combined with ASSUME_TYPE")
+	}
+	// SCAN TYPE already checked the type of every key it returns
+	if len(keyTypes) == 1 && opts.RDBFile == "" && opts.KeysFile == "" && keyTypes[0] != bloomFilterType && keyTypes[0] != cuckooFilterType {
+		assumeType = keyTypes[0]
+	}
+
+	keyEncoding, err := parseKeyEncoding(opts.KeyEncoding)
+	if err != nil {
+		return nil, err
+	}
+
+	csvDelimiter, err := parseCSVRune("CSV_DELIMITER", opts.CSVDelimiter)
+	if err != nil {
+		return nil, err
+	}
+	csvQuote, err := parseCSVRune("CSV_QUOTE", opts.CSVQuote)
+	if err != nil {
+		return nil, err
+	}
+
+	// Determine partition layout
+	var partitionBy PartitionScheme
+	switch opts.PartitionBy {
+	case "age":
+		partitionBy = PartitionByAge
+	case "time", "":
+		partitionBy = PartitionByTime
 	default:
-		return nil, fmt.Errorf("unsupported output format: %s", opts.OutputFormat)
+		return nil, fmt.Errorf("unsupported partition scheme: %s", opts.PartitionBy)
+	}
+	if opts.PartitionTemplate != "" {
+		if partitionBy != PartitionByTime {
+			return nil, fmt.Errorf("PARTITION_TEMPLATE cannot be combined with PARTITION_BY=%s", opts.PartitionBy)
+		}
+		partitionBy = PartitionByTemplate
+	}
+
+	bigKeysBy := opts.BigKeysBy
+	switch bigKeysBy {
+	case "":
+		bigKeysBy = BigKeysByMemory
+	case BigKeysByMemory, BigKeysByCardinality:
+	default:
+		return nil, fmt.Errorf("unsupported BIGKEYS_BY: %s", opts.BigKeysBy)
+	}
+
+	sample, err := newKeySampler(opts.SampleMode, opts.SampleRate)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create file manager
 	storageConfig := StorageConfig{
-		OutputDir:  opts.OutputDir,
-		Format:     format,
-		MaxRecords: opts.MaxRecordsPerFile,
+		OutputDir:   opts.OutputDir,
+		Format:      format,
+		Compression: Compression(opts.Compression),
+		MaxRecords:  opts.MaxRecordsPerFile,
+		ExportID:    exportID,
+
+		IncludeRawDump:     opts.DualMode,
+		Reproducible:       opts.Reproducible,
+		OmitPartitionID:    opts.OmitPartitionID,
+		IncludeParentKey:   opts.IncludeParentKey,
+		IncludeSlot:        opts.IncludeSlot,
+		IncludeSource:      len(sources) > 0,
+		KeyEncoding:        keyEncoding,
+		OmitValue:          opts.DropValueColumn,
+		IncludeCardinality: opts.IncludeCardinality,
+		IncludeEncoding:    opts.IncludeEncoding,
+		IncludeIdleTime:    opts.IncludeIdleTime,
+		IncludeExpiresAt:   opts.IncludeExpiresAt,
+		MaxValueBytes:      opts.MaxValueBytes,
+		TruncateElements:   opts.MaxElementsPerKey > 0,
+
+		CSVDelimiter:  csvDelimiter,
+		CSVQuote:      csvQuote,
+		OmitCSVHeader: opts.OmitCSVHeader,
+
+		MaterializePartitionCols: opts.MaterializePartitionCols,
+
+		IntermediateFlush: opts.IntermediateFlush,
+		FlushEvery:        opts.FlushEvery,
+		IcebergMetadata:   opts.IcebergMetadata,
+
+		ParquetSummaryFiles: opts.ParquetSummaryFiles,
+		ChecksumManifest:    opts.ChecksumManifest,
+
+		Bundle:            Bundle(opts.Bundle),
+		BundleDeleteFiles: opts.BundleDeleteFiles,
+
+		MaxConcurrentCopies: opts.MaxConcurrentCopies,
+
+		QueryURI:    opts.QueryURI,
+		QueryRegion: opts.QueryRegion,
+
+		PartitionBy:       partitionBy,
+		PartitionTemplate: opts.PartitionTemplate,
+		Stream:            streaming,
+	}
+	if err := validateStorageConfig(storageConfig); err != nil {
+		return nil, err
+	}
+
+	if opts.TargetFileCount > 0 && !streaming {
+		dbSize, err := dbSize(ctx, client)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read DBSIZE for target file count: %w", err)
+		}
+		// Every source's keys count toward the target
+		for i := 1; i < len(sources); i++ {
+			n, err := sources[i].client.DBSize(ctx).Result()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read DBSIZE of %s for target file count: %w", sources[i].name, err)
+			}
+			dbSize += n
+		}
+		storageConfig.MaxRecords = recordsPerFileForTarget(dbSize, opts.TargetFileCount)
+		opts.Verbosity.infof("Target of %d files for %d keys: %d records per file\n",
+			opts.TargetFileCount, dbSize, storageConfig.MaxRecords)
 	}
 	fileManager := NewFileManager(storageConfig)
 
-	return &RedisExporter{
+	// A resumed export writes next to the files of the run it continues
+	var completedKeys *completedKeysLog
+	if opts.Resume && opts.CompletedKeysLog == "" {
+		return nil, errors.New("RESUME requires COMPLETED_KEYS_LOG")
+	}
+	if opts.PollInterval > 0 && opts.CompletedKeysLog != "" {
+		return nil, errors.New("POLL_INTERVAL cannot be combined with COMPLETED_KEYS_LOG")
+	}
+	if opts.CompletedKeysLog != "" {
+		completedKeys, err = openCompletedKeysLog(opts.CompletedKeysLog, opts.Resume, opts.ResumeBloomFPRate)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Resume && !streaming {
+			if err := fileManager.ContinuePartitionIDs(); err != nil {
+				_ = completedKeys.close(0)
+				return nil, err
+			}
+		}
+	}
+
+	re := &RedisExporter{
 		client:        client,
 		fileManager:   fileManager,
 		ctx:           ctx,
 		batchSize:     opts.BatchSize,
 		flushInterval: 1000,
-	}, nil
+		verbosity:     opts.Verbosity,
+
+		writeQueueSize: opts.WriteQueueSize,
+		batchTimeout:   opts.BatchTimeout,
+		assumeType:     assumeType,
+		keyTypes:       keyTypes,
+		typeCache:      newTypeCache(opts.TypeCacheSize),
+		ignorePatterns: ignorePatterns,
+
+		keyRegex:        keyRegex,
+		keyExcludeRegex: keyExcludeRegex,
+
+		keysFile:  opts.KeysFile,
+		ttlWindow: ttlWindow,
+
+		sequentialElementScans: opts.SequentialElementScans,
+		skipTTL:                opts.SkipTTL,
+
+		autoScanCount:     opts.AutoScanCount,
+		scanLatencyTarget: opts.ScanLatencyTarget,
+		throttle:          newThrottle(opts.ThrottleLatency, opts.ThrottleInterval),
+
+		listChunkSize:  opts.ListChunkSize,
+		listChunkBytes: opts.ListChunkBytes,
+
+		zsetWithRank:    opts.ZSetWithRank,
+		zsetScores:      zsetScores,
+		hashFields:      parseHashFields(opts.HashFields),
+		maxElements:     opts.MaxElementsPerKey,
+		maxElementsFrom: maxElementsFrom,
+		zsetRankMaxSize: opts.ZSetRankMaxSize,
+
+		streamSince:         streamSince,
+		watchRotateInterval: opts.WatchRotateInterval,
+		pollInterval:        opts.PollInterval,
+		pollSeenLimit:       opts.PollSeenLimit,
+		minSizeBytes:        opts.MinSizeBytes,
+		runTimestamp:        runTimestamp,
+		estimateSample:      opts.EstimateSample,
+
+		completedKeys: completedKeys,
+
+		readFromReplica: opts.ReadFromReplica,
+		credentials:     credentials,
+		tunnel:          tunnel,
+		sources:         sources,
+
+		scanRetries:       opts.ScanRetries,
+		scanRetryInterval: opts.ScanRetryInterval,
+
+		rdbFile: opts.RDBFile,
+		db:      opt.DB,
+
+		namespaceDepth: opts.NamespaceDepth,
+		namespaceWidth: opts.NamespaceWidth,
+
+		bigKeysTop: opts.BigKeysTop,
+		bigKeysBy:  bigKeysBy,
+
+		sample: sample,
+
+		dualMode: opts.DualMode,
+
+		bitmapKeys:          opts.BitmapKeys,
+		bitmapSampleOffsets: opts.BitmapSampleOffsets,
+		bitmapChunkBytes:    opts.BitmapChunkBytes,
+	}
+	if re.writeQueueSize <= 0 {
+		re.writeQueueSize = defaultWriteQueueSize
+	}
+	if re.scanLatencyTarget <= 0 {
+		re.scanLatencyTarget = defaultScanLatencyTarget
+	}
+	if re.listChunkSize <= 0 {
+		re.listChunkSize = defaultListChunkSize
+	}
+	if re.zsetRankMaxSize <= 0 {
+		re.zsetRankMaxSize = defaultZSetRankMaxSize
+	}
+
+	if re.skipTTL {
+		fileManager.SetTTLsSkipped()
+	}
+
+	// Provenance is best effort: some managed services restrict INFO
+	if len(re.sources) > 0 {
+		for i, source := range re.sources {
+			info := SourceInfo{Name: source.name}
+			if server, err := re.captureServerInfo(source.client); err != nil {
+				fmt.Printf("Warning: %v; export metadata will not identify source %s\n", err, source.name)
+			} else {
+				info.Server = server
+				re.sources[i].server = server
+			}
+			fileManager.AddSource(info)
+		}
+		fileManager.SetSource(re.sources[0].name)
+		re.applyServerSupport(re.sources[0].server)
+	} else if re.rdbFile == "" {
+		if server, err := re.captureServerInfo(re.client); err != nil {
+			fmt.Printf("Warning: %v; export metadata will not identify the server\n", err)
+		} else {
+			fileManager.SetServerInfo(server)
+			re.verbosity.infof("Server: %s %s run_id=%s\n", server.Flavor, serverVersion(server), server.RunID)
+			re.applyServerSupport(server)
+		}
+	}
+
+	if opts.IncludeACL {
+		fileManager.SetACL(re.captureACL())
+	}
+
+	// Record a best-effort consistency anchor before any keys are read
+	if opts.SnapshotWait {
+		snapshot, err := re.captureReplicationSnapshot(opts.SnapshotWaitReplicas, opts.SnapshotWaitTimeout)
+		if err != nil {
+			return nil, err
+		}
+		fileManager.SetReplicationSnapshot(snapshot)
+		re.verbosity.infof("Replication snapshot: role=%s offset=%d\n", snapshot.Role, snapshot.MasterReplOffset)
+	}
+
+	return re, nil
+}
+
+// recordsPerFileForTarget spreads keys over about target files, rounding up
+// so the target is not exceeded, with a floor of minTargetRecordsPerFile
+func recordsPerFileForTarget(keys, target int64) int64 {
+	perFile := (keys + target - 1) / target
+	if perFile < minTargetRecordsPerFile {
+		return minTargetRecordsPerFile
+	}
+	return perFile
+}
+
+// overrideCredentials replaces the credentials parsed from a URL with those
+// set separately; each is only replaced when set
+func overrideCredentials(opt *redis.Options, username, password string) {
+	if username != "" {
+		opt.Username = username
+	}
+	if password != "" {
+		opt.Password = password
+	}
+}
+
+// pingWithRetry pings Redis, retrying with exponential backoff up to retries times
+func pingWithRetry(ctx context.Context, client redis.UniversalClient, retries int, interval time.Duration) error {
+	delay := interval
+	for attempt := 0; ; attempt++ {
+		err := client.Ping(ctx).Err()
+		if err == nil || attempt >= retries {
+			return err
+		}
+
+		fmt.Printf("Redis not ready (attempt %d/%d): %v - retrying in %s\n", attempt+1, retries+1, err, delay)
+		time.Sleep(delay)
+
+		delay *= 2
+		if delay > maxConnectRetryInterval {
+			delay = maxConnectRetryInterval
+		}
+	}
 }
 
 func (re *RedisExporter) Close() error {
-	if err := re.fileManager.Close(); err != nil {
-		log.Printf("Error closing file manager: %v", err)
+	closeErr := re.fileManager.Close()
+	if closeErr != nil {
+		log.Printf("Error closing file manager: %v", closeErr)
+	}
+	// Closing finished every partition that could be, so log all durable keys
+	if err := re.completedKeys.close(re.fileManager.DurableRecords()); err != nil {
+		log.Printf("Error closing completed keys log: %v", err)
+	}
+	re.credentials.stop()
+	for i, source := range re.sources {
+		if i != re.currentSource {
+			_ = source.client.Close()
+		}
+	}
+	if err := re.client.Close(); err != nil {
+		return err
+	}
+	if err := re.tunnel.close(); err != nil {
+		log.Printf("Error closing SSH tunnel: %v", err)
+	}
+	// An export that ran out of space must fail even if it happened on close
+	if errors.Is(closeErr, ErrOutputFull) {
+		return closeErr
+	}
+	return nil
+}
+
+// closeExport closes the exporter when an export returns, replacing a nil
+// result with the out-of-space error if finishing the files ran out of space
+func (re *RedisExporter) closeExport(result *error) {
+	if err := re.Close(); *result == nil && errors.Is(err, ErrOutputFull) {
+		*result = err
 	}
-	return re.client.Close()
 }
 
 // ExportKeysOnly - Memory-efficient export of just key metadata
-func (re *RedisExporter) ExportKeysOnly() error {
-	defer func() {
-		_ = re.Close()
-	}()
-
-	var cursor uint64
-	var keys []string
-	var err error
+func (re *RedisExporter) ExportKeysOnly() (err error) {
+	if re.rdbFile != "" {
+		return re.exportRDB("*", true)
+	}
+
+	defer re.closeExport(&err)
+
 	count := 0
 
-	fmt.Println("Starting Redis key metadata export (keys only)...")
+	re.verbosity.infof("Starting Redis key metadata export (keys only)...\n")
 
-	for {
-		// Use smaller scan batches for memory efficiency
-		keys, cursor, err = re.client.Scan(re.ctx, cursor, "*", int64(re.batchSize)).Result()
-		if err != nil {
-			return fmt.Errorf("failed to scan keys: %w", err)
+	// Use smaller scan batches for memory efficiency
+	err = re.scanBatches("*", func(keys []string) error {
+		keys, small := re.filterBySize(keys)
+		re.fileManager.AddSmallKeys(int64(small))
+
+		exported := re.exportKeyMetadataBatch(keys)
+		if err := re.fileManager.OutOfSpace(); err != nil {
+			count += exported
+			return err
 		}
 
-		// Process keys in a batch with a pipeline for efficiency
-		pipe := re.client.Pipeline()
-		keyTypes := make(map[string]*redis.StatusCmd)
-		keyTTLs := make(map[string]*redis.DurationCmd)
+		// Flush periodically
+		if (count+exported)/re.flushInterval > count/re.flushInterval {
+			re.verbosity.infof("Exported %d keys...\n", count+exported)
+			re.flushAll()
+			re.writeCheckpoint(count + exported)
+		}
+		count += exported
+		return nil
+	})
+	if err != nil {
+		// Close still rotates the open partitions, so record what was exported
+		re.fileManager.SetMetadata("*", int64(count))
+		re.fileManager.MarkPartial(err)
+		return err
+	}
 
-		// Build pipeline commands
-		for _, key := range keys {
-			keyTypes[key] = pipe.Type(re.ctx, key)
+	fmt.Printf("Key export completed! Total keys exported: %d\n", count)
+	re.reportSmallKeys()
+	return nil
+}
+
+// exportKeyMetadataBatch pipelines TYPE and TTL (unless skipped) for a batch of
+// keys and writes a metadata record for each, returning the number of records
+// written
+func (re *RedisExporter) exportKeyMetadataBatch(keys []string) int {
+	// Process keys in a batch with a pipeline for efficiency
+	pipe := re.client.Pipeline()
+	keyTypes := make(map[string]*redis.StatusCmd, len(keys))
+	keyTTLs := make(map[string]*redis.DurationCmd, len(keys))
+
+	// Build pipeline commands, skipping TYPE for cached keys
+	cachedTypes := make(map[string]string)
+	for _, key := range keys {
+		if re.assumeType == "" {
+			if keyType, ok := re.knownType(key); ok {
+				cachedTypes[key] = keyType
+			} else {
+				keyTypes[key] = pipe.Type(re.ctx, key)
+			}
+		}
+		if !re.skipTTL {
 			keyTTLs[key] = pipe.TTL(re.ctx, key)
 		}
+	}
 
-		// Execute pipeline
-		_, err = pipe.Exec(re.ctx)
-		if err != nil {
-			log.Printf("Pipeline error: %v", err)
-			continue
-		}
+	// Execute pipeline
+	ctx, cancel := re.batchContext(re.ctx)
+	defer cancel()
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Pipeline error: %v", err)
+		return 0
+	}
 
-		// Process results
-		timestamp := time.Now().UTC().Format(time.RFC3339)
-		for _, key := range keys {
-			keyType, err := keyTypes[key].Result()
-			if err != nil {
+	idle := re.keyIdleSeconds(keys)
+
+	// Types are resolved first since the cardinality commands depend on them
+	types := make(map[string]string, len(keys))
+	for _, key := range keys {
+		keyType := re.assumeType
+		if cached, ok := cachedTypes[key]; ok {
+			keyType = cached
+		} else if keyType == "" {
+			var err error
+			if keyType, err = keyTypes[key].Result(); err != nil {
 				log.Printf("Error getting type for key %s: %v", key, err)
 				continue
 			}
+			re.cacheType(key, keyType)
+		}
+		types[key] = keyType
+	}
+
+	var cardinalities map[string]int64
+	if re.fileManager.config.IncludeCardinality {
+		cardinalities = re.keyCardinalities(types)
+	}
+	var encodings map[string]string
+	if re.fileManager.config.IncludeEncoding {
+		encodings = re.keyEncodings(keys)
+	}
+	expiries := re.keyExpiryTimes(keys)
+
+	// Process results
+	count := 0
+	timestamp := re.exportedAt()
+	for _, key := range keys {
+		keyType, ok := types[key]
+		if !ok {
+			continue
+		}
 
-			ttl, err := keyTTLs[key].Result()
+		ttl := NoExpiry()
+		if !re.skipTTL {
+			reply, err := keyTTLs[key].Result()
+			if err == nil {
+				ttl, err = ttlFromDuration(reply)
+			}
 			if err != nil {
 				log.Printf("Error getting TTL for key %s: %v", key, err)
 				continue
 			}
+		}
 
-			ttlSeconds := int64(-1)
-			if ttl > 0 {
-				ttlSeconds = int64(ttl.Seconds())
-			}
-
-			// Estimate size without fetching data
-			sizeEstimate := re.estimateKeySize(key, keyType)
+		// Estimate size without fetching data
+		sizeEstimate := re.estimateKeySize(key, keyType)
 
-			record := &RedisRecord{
-				Key:        key,
-				Type:       keyType,
-				Value:      fmt.Sprintf("size_estimate=%d", sizeEstimate),
-				TTLSeconds: ttlSeconds,
-				ExportedAt: timestamp,
-			}
+		record := &RedisRecord{
+			Key:   key,
+			Type:  keyType,
+			Value: fmt.Sprintf("size_estimate=%d", sizeEstimate),
 
-			if err := re.fileManager.WriteRecord(record); err != nil {
-				log.Printf("Error writing key %s: %v", key, err)
-				continue
-			}
+			SizeEstimate: sizeEstimate,
+			SizeLabel:    true,
+			TTLSeconds:   ttl.Value(),
+			ExportedAt:   timestamp,
+			Slot:         keySlot(key),
 
-			count++
+			IdleSeconds: idle[key],
+			Cardinality: noCardinality,
+			Encoding:    encodings[key],
+			ExpiresAt:   expiries[key],
 		}
-
-		// Flush periodically
-		if count%re.flushInterval == 0 {
-			fmt.Printf("Exported %d keys...\n", count)
-			re.flushAll()
+		if n, ok := cardinalities[key]; ok {
+			record.Cardinality = n
 		}
 
-		// Break when the cursor returns to 0
-		if cursor == 0 {
-			break
+		if err := re.fileManager.WriteRecord(record); err != nil {
+			log.Printf("Error writing key %s: %v", key, err)
+			if errors.Is(err, ErrOutputFull) {
+				break
+			}
+			continue
 		}
+
+		count++
 	}
 
-	fmt.Printf("Key export completed! Total keys exported: %d\n", count)
-	return nil
+	return count
 }
 
 // estimateKeySize provides rough size estimates without fetching data
@@ -233,140 +1295,144 @@ func (re *RedisExporter) estimateKeySize(key, keyType string) int64 {
 }
 
 // ExportKeysOnlyByPattern - Memory-efficient export with pattern matching
-func (re *RedisExporter) ExportKeysOnlyByPattern(pattern string) error {
-	defer func() {
-		_ = re.Close()
-	}()
-
-	var cursor uint64
-	var keys []string
-	var err error
-	count := 0
-
-	fmt.Printf("Starting Redis key metadata export with pattern: %s\n", pattern)
-
-	for {
-		keys, cursor, err = re.client.Scan(re.ctx, cursor, pattern, int64(re.batchSize)).Result()
-		if err != nil {
-			return fmt.Errorf("failed to scan keys: %w", err)
-		}
-
-		// Use pipeline for efficiency
-		pipe := re.client.Pipeline()
-		keyTypes := make(map[string]*redis.StatusCmd)
-		keyTTLs := make(map[string]*redis.DurationCmd)
-
-		for _, key := range keys {
-			keyTypes[key] = pipe.Type(re.ctx, key)
-			keyTTLs[key] = pipe.TTL(re.ctx, key)
-		}
-
-		_, err = pipe.Exec(re.ctx)
-		if err != nil {
-			log.Printf("Pipeline error: %v", err)
-			continue
-		}
-
-		timestamp := time.Now().UTC().Format(time.RFC3339)
-		for _, key := range keys {
-			keyType, err := keyTypes[key].Result()
-			if err != nil {
-				continue
-			}
+func (re *RedisExporter) ExportKeysOnlyByPattern(pattern string) (err error) {
+	if re.rdbFile != "" {
+		return re.exportRDB(pattern, true)
+	}
 
-			ttl, err := keyTTLs[key].Result()
-			if err != nil {
-				continue
-			}
+	defer re.closeExport(&err)
 
-			ttlSeconds := int64(-1)
-			if ttl > 0 {
-				ttlSeconds = int64(ttl.Seconds())
-			}
+	count := 0
 
-			sizeEstimate := re.estimateKeySize(key, keyType)
+	re.verbosity.infof("Starting Redis key metadata export with pattern: %s\n", pattern)
 
-			record := &RedisRecord{
-				Key:        key,
-				Type:       keyType,
-				Value:      fmt.Sprintf("size_estimate=%d", sizeEstimate),
-				TTLSeconds: ttlSeconds,
-				ExportedAt: timestamp,
-			}
+	err = re.scanBatches(pattern, func(keys []string) error {
+		keys, small := re.filterBySize(keys)
+		re.fileManager.AddSmallKeys(int64(small))
 
-			_ = re.fileManager.WriteRecord(record)
-			count++
+		exported := re.exportKeyMetadataBatch(keys)
+		if err := re.fileManager.OutOfSpace(); err != nil {
+			count += exported
+			return err
 		}
 
-		if count%re.flushInterval == 0 {
-			fmt.Printf("Exported %d keys...\n", count)
+		if (count+exported)/re.flushInterval > count/re.flushInterval {
+			re.verbosity.infof("Exported %d keys...\n", count+exported)
 			re.flushAll()
+			re.writeCheckpoint(count + exported)
 		}
-
-		if cursor == 0 {
-			break
-		}
+		count += exported
+		return nil
+	})
+	if err != nil {
+		// Close still rotates the open partitions, so record what was exported
+		re.fileManager.SetMetadata(pattern, int64(count))
+		re.fileManager.MarkPartial(err)
+		return err
 	}
 
 	fmt.Printf("Export completed! Total keys exported: %d\n", count)
+	re.reportSmallKeys()
 	return nil
 }
 
 // ExportByPattern - Export full data for all keys matching pattern
-func (re *RedisExporter) ExportByPattern(pattern string) error {
-	defer func() {
-		_ = re.Close()
-	}()
-
-	var cursor uint64
-	var keys []string
-	var err error
+func (re *RedisExporter) ExportByPattern(pattern string) (err error) {
+	if re.rdbFile != "" {
+		return re.exportRDB(pattern, false)
+	}
+
+	defer re.closeExport(&err)
+
+	if err := re.requireValueColumn(); err != nil {
+		return err
+	}
+
 	count := 0
+	skipped := 0
 
 	// Update metadata with pattern
 	re.fileManager.SetMetadata(pattern, 0)
 
-	fmt.Printf("Starting full data export with pattern: %s\n", pattern)
+	re.verbosity.infof("Starting full data export with pattern: %s\n", pattern)
 
 	// Export full data for all keys matching pattern
-	for {
-		keys, cursor, err = re.client.Scan(re.ctx, cursor, pattern, int64(re.batchSize)).Result()
-		if err != nil {
-			return fmt.Errorf("failed to scan keys: %w", err)
-		}
+	err = re.scanBatches(pattern, func(keys []string) error {
+		keys = re.sampler.filter(keys)
+
+		keys, resumed := re.completedKeys.filter(keys)
+		re.fileManager.AddResumedKeys(int64(resumed))
+
+		keys, small := re.filterBySize(keys)
+		re.fileManager.AddSmallKeys(int64(small))
+
+		// Idle times must be read before exporting values resets them
+		idle := re.keyIdleSeconds(keys)
 
 		// Export full data for each key in batch
 		for _, key := range keys {
-			if err := re.exportKey(key); err != nil {
+			if err := re.exportKey(key, idle[key]); err != nil {
+				if errors.Is(err, ErrOutputFull) {
+					return err
+				}
+				if errors.Is(err, errAssumedTypeMismatch) {
+					skipped++
+				}
 				log.Printf("Error exporting key %s: %v", key, err)
 				continue
 			}
 			count++
+			re.completedKeys.add(key, re.fileManager.recordCount)
 
 			if count%100 == 0 {
-				fmt.Printf("Exported %d keys...\n", count)
+				re.verbosity.infof("Exported %d keys...\n", count)
 				re.flushAll()
+				re.writeCheckpoint(count)
+				if err := re.completedKeys.commit(re.fileManager.DurableRecords()); err != nil {
+					return err
+				}
 			}
 		}
-
-		if cursor == 0 {
-			break
-		}
+		return nil
+	})
+	if err != nil {
+		// Close still rotates the open partitions, so record what was exported
+		re.fileManager.SetMetadata(pattern, int64(count))
+		re.fileManager.MarkPartial(err)
+		return err
 	}
 
 	// Update final metadata
 	re.fileManager.SetMetadata(pattern, int64(count))
 
 	fmt.Printf("Export completed! Total keys exported with full data: %d\n", count)
-	fmt.Printf("Files created with %s format\n", re.fileManager.config.Format)
-	fmt.Println("Using Hive-style partitioning for optimal DuckDB querying")
+	if resumed := re.fileManager.metadata.ResumedKeys; resumed > 0 {
+		fmt.Printf("Skipped %d keys completed by a previous run\n", resumed)
+	}
+	re.reportSmallKeys()
+	re.reportTruncatedKeys()
+	if skipped > 0 {
+		fmt.Printf("Skipped %d keys that were not of assumed type %s\n", skipped, re.assumeType)
+	}
+	if re.fileManager.config.Stream {
+		fmt.Printf("Records streamed to FIFO %s\n", re.fileManager.config.OutputDir)
+		return nil
+	}
+	re.verbosity.infof("Files created with %s format\n", re.fileManager.config.Format)
+	if !re.fileManager.queryable() {
+		return nil
+	}
+	re.verbosity.infof("Using Hive-style partitioning for optimal DuckDB querying\n")
 
 	// Print DuckDB query example
-	queryPath := re.fileManager.GetQueryPath()
-	fmt.Printf("DuckDB query: SELECT * FROM read_%s('%s');\n",
-		string(re.fileManager.config.Format), queryPath)
-	fmt.Printf("Example filter: SELECT * FROM read_%s('%s') WHERE type = 'string';\n",
-		string(re.fileManager.config.Format), queryPath)
+	for _, statement := range re.fileManager.querySetup() {
+		re.verbosity.infof("DuckDB setup: %s\n", statement)
+	}
+	querySource := re.fileManager.QuerySource()
+	re.verbosity.infof("DuckDB query: SELECT * FROM %s;\n", querySource)
+	re.verbosity.infof("Example filter: SELECT * FROM %s WHERE type = 'string';\n", querySource)
+	re.verbosity.infof("Or load a redis_data view with: duckdb -init %s\n",
+		filepath.Join(re.fileManager.config.OutputDir, loadSQLFileName))
 	return nil
 }
 
@@ -374,95 +1440,240 @@ func (re *RedisExporter) flushAll() {
 	re.fileManager.FlushAll()
 }
 
-func (re *RedisExporter) exportKey(key string) error {
-	// Get key type
-	keyType, err := re.client.Type(re.ctx, key).Result()
-	if err != nil {
-		return fmt.Errorf("failed to get type for key %s: %w", key, err)
+// flushDue flushes on a FlushEvery tick. A failure is logged and a full
+// disk refuses the next record.
+func (re *RedisExporter) flushDue() {
+	if err := re.fileManager.FlushDue(); err != nil {
+		log.Printf("Error flushing records: %v", err)
 	}
+}
 
-	// Get TTL
-	ttl, err := re.client.TTL(re.ctx, key).Result()
+// exportKey writes a key's elements and metadata record. idleSeconds routes
+// every record of the key to the same age partition.
+func (re *RedisExporter) exportKey(key string, idleSeconds int64) error {
+	re.verbosity.debugf("Exporting key %s (idle=%ds)\n", key, idleSeconds)
+
+	// Get key type
+	keyType, cached, err := re.keyType(key)
 	if err != nil {
-		return fmt.Errorf("failed to get TTL for key %s: %w", key, err)
+		return err
 	}
 
-	ttlSeconds := int64(-1)
-	if ttl > 0 {
-		ttlSeconds = int64(ttl.Seconds())
+	// Get TTL
+	ttl := NoExpiry()
+	if !re.skipTTL {
+		reply, err := re.client.TTL(re.ctx, key).Result()
+		if err == nil {
+			ttl, err = ttlFromDuration(reply)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get TTL for key %s: %w", key, err)
+		}
 	}
 
 	// Get size and export detailed data
-	size, err := re.exportKeyData(key, keyType)
+	re.truncatedLength = 0
+	size, err := re.exportKeyData(key, keyType, idleSeconds)
+	if cached && isWrongTypeError(err) {
+		// The key was recreated with another type since it was typed
+		re.forgetType(key)
+		return re.exportKey(key, idleSeconds)
+	}
 	if err != nil {
+		if re.assumeType != "" && isWrongTypeError(err) {
+			return fmt.Errorf("failed to export key %s as %s: %w", key, keyType, errAssumedTypeMismatch)
+		}
 		return fmt.Errorf("failed to export data for key %s: %w", key, err)
 	}
 
 	// Write key metadata
-	timestamp := time.Now().UTC().Format(time.RFC3339)
+	timestamp := re.exportedAt()
 	keyRecord := &RedisRecord{
 		Key:        key,
 		Type:       keyType,
 		Value:      fmt.Sprintf("size=%d", size),
-		TTLSeconds: ttlSeconds,
+		TTLSeconds: ttl.Value(),
 		ExportedAt: timestamp,
+		Slot:       keySlot(key),
+
+		SizeEstimate: size,
+		SizeLabel:    true,
+		IdleSeconds:  idleSeconds,
+		ExpiresAt:    re.keyExpiryTimes([]string{key})[key],
+	}
+	if re.truncatedLength > 0 {
+		re.markTruncated(keyRecord, re.truncatedLength)
+	}
+
+	// Attach a RESTORE-compatible payload alongside the readable record
+	if re.dualMode {
+		payload, err := re.client.Dump(re.ctx, key).Result()
+		if err != nil && err != redis.Nil {
+			return fmt.Errorf("failed to dump key %s: %w", key, err)
+		}
+		keyRecord.RawDump = base64.StdEncoding.EncodeToString([]byte(payload))
 	}
 
 	return re.fileManager.WriteRecord(keyRecord)
 }
 
-func (re *RedisExporter) exportKeyData(key, keyType string) (int64, error) {
-	timestamp := time.Now().UTC().Format(time.RFC3339)
+// keyType returns the type of key: the assumed type, the type already known
+// from the scan or cache, or the result of TYPE. cached reports whether it was
+// already known.
+func (re *RedisExporter) keyType(key string) (keyType string, cached bool, err error) {
+	if re.assumeType != "" {
+		return re.assumeType, false, nil
+	}
+	if keyType, ok := re.knownType(key); ok {
+		return keyType, true, nil
+	}
+
+	keyType, err = re.client.Type(re.ctx, key).Result()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get type for key %s: %w", key, err)
+	}
+	re.cacheType(key, keyType)
+	return keyType, false, nil
+}
+
+// cacheType remembers the type of an existing key
+func (re *RedisExporter) cacheType(key, keyType string) {
+	if keyType != "none" {
+		re.typeCache.put(key, keyType)
+	}
+}
+
+// requireValueColumn rejects data exports when the value column is dropped
+// or a keys-only column is added
+func (re *RedisExporter) requireValueColumn() error {
+	if re.fileManager.config.OmitValue {
+		return errors.New("DROP_VALUE_COLUMN only applies to keys-only exports")
+	}
+	if re.fileManager.config.IncludeCardinality {
+		return errors.New("INCLUDE_CARDINALITY only applies to keys-only exports")
+	}
+	if re.fileManager.config.IncludeEncoding {
+		return errors.New("INCLUDE_ENCODING only applies to keys-only exports")
+	}
+	if re.fileManager.config.IncludeIdleTime {
+		return errors.New("INCLUDE_IDLE_TIME only applies to keys-only exports")
+	}
+	return nil
+}
+
+// exportedAt returns the exported_at value for records written now: the run
+// start time with RUN_TIMESTAMP=fixed, otherwise the current time
+func (re *RedisExporter) exportedAt() string {
+	if re.runTimestamp != "" {
+		return re.runTimestamp
+	}
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// isWrongTypeError reports whether Redis rejected a command with WRONGTYPE
+func isWrongTypeError(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "WRONGTYPE")
+}
+
+// nextListChunkSize adapts the LRANGE window to the bytes returned by the previous
+// chunk, shrinking it below the byte budget and growing back towards maxChunk
+func nextListChunkSize(current, maxChunk, chunkBytes, budget int64) int64 {
+	if budget <= 0 || chunkBytes <= 0 {
+		return current
+	}
+
+	if chunkBytes > budget {
+		next := current * budget / chunkBytes
+		if next < 1 {
+			next = 1
+		}
+		return next
+	}
+
+	if chunkBytes < budget/2 && current < maxChunk {
+		next := current * 2
+		if next > maxChunk {
+			next = maxChunk
+		}
+		return next
+	}
+
+	return current
+}
+
+func (re *RedisExporter) exportKeyData(key, keyType string, idleSeconds int64) (int64, error) {
+	timestamp := re.exportedAt()
+	// Element rows carry the slot of the key they belong to
+	slot := keySlot(key)
 
 	switch keyType {
 	case "string":
+		// Huge strings are not read just to be measured
+		if re.fileManager.config.MaxValueBytes > 0 && !re.isBitmapKey(key) {
+			return re.client.StrLen(re.ctx, key).Result()
+		}
+
 		val, err := re.client.Get(re.ctx, key).Result()
 		if err != nil {
 			return 0, err
 		}
+
+		// Bitmaps are strings; matching keys also get bit-level statistics
+		if re.isBitmapKey(key) {
+			if err := re.exportBitmap(key, val, slot, idleSeconds, timestamp); err != nil {
+				return 0, err
+			}
+		}
 		return int64(len(val)), nil
 
 	case "set":
 		// Use SSCAN for memory efficiency on large sets
-		var cursor uint64
 		totalSize := int64(0)
-
-		for {
-			members, nextCursor, err := re.client.SScan(re.ctx, key, cursor, "*", 1000).Result()
-			if err != nil {
-				return 0, err
-			}
-
+		err := re.scanElements(func(cursor uint64) ([]string, uint64, error) {
+			return re.client.SScan(re.ctx, key, cursor, "*", 1000).Result()
+		}, func(members []string) error {
 			for _, member := range members {
 				record := &RedisRecord{
 					Key:        fmt.Sprintf("%s:member:%s", key, member),
 					Type:       "set_member",
 					Value:      member,
-					TTLSeconds: -1,
+					TTLSeconds: ttlNoExpiry,
 					ExportedAt: timestamp,
+					Slot:       slot,
+
+					IdleSeconds: idleSeconds,
+					ParentKey:   key,
 				}
 				if err := re.fileManager.WriteRecord(record); err != nil {
-					return 0, err
+					return err
 				}
 				totalSize += int64(len(member))
 			}
-
-			cursor = nextCursor
-			if cursor == 0 {
-				break
-			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
 		}
 		return totalSize, nil
 
 	case "hash":
+		if len(re.hashFields) > 0 {
+			return re.exportHashFields(key, slot, idleSeconds, timestamp)
+		}
+
 		// Use HSCAN for memory efficiency on large hashes
-		var cursor uint64
 		totalSize := int64(0)
-
-		for {
-			fields, nextCursor, err := re.client.HScan(re.ctx, key, cursor, "*", 1000).Result()
+		err := re.scanElements(func(cursor uint64) ([]string, uint64, error) {
+			return re.client.HScan(re.ctx, key, cursor, "*", 1000).Result()
+		}, func(fields []string) error {
+			// Field TTLs (Redis 7.4+) are fetched for the whole HSCAN batch at once
+			names := make([]string, 0, len(fields)/2)
+			for i := 0; i+1 < len(fields); i += 2 {
+				names = append(names, fields[i])
+			}
+			fieldTTLs, err := re.hashFieldTTLs(key, names)
 			if err != nil {
-				return 0, err
+				return err
 			}
 
 			// HScan returns field-value pairs in alternating positions
@@ -470,39 +1681,55 @@ func (re *RedisExporter) exportKeyData(key, keyType string) (int64, error) {
 				if i+1 < len(fields) {
 					field := fields[i]
 					value := fields[i+1]
+					ttlSeconds := ttlNoExpiry
+					if fieldTTLs != nil {
+						ttlSeconds = fieldTTLs[i/2]
+					}
 					record := &RedisRecord{
 						Key:        fmt.Sprintf("%s:field:%s", key, field),
 						Type:       "hash_field",
 						Value:      value,
-						TTLSeconds: -1,
+						TTLSeconds: ttlSeconds,
 						ExportedAt: timestamp,
+						Slot:       slot,
+
+						IdleSeconds: idleSeconds,
+						ParentKey:   key,
 					}
 					if err := re.fileManager.WriteRecord(record); err != nil {
-						return 0, err
+						return err
 					}
 					totalSize += int64(len(field) + len(value))
 				}
 			}
-
-			cursor = nextCursor
-			if cursor == 0 {
-				break
-			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
 		}
 		return totalSize, nil
 
 	case "zset":
-		// Use ZSCAN for memory efficiency
-		var cursor uint64
-		totalSize := int64(0)
-		rank := 0
+		if re.zsetScores != nil {
+			return re.exportZSetRange(key, slot, idleSeconds, timestamp)
+		}
 
-		for {
-			members, nextCursor, err := re.client.ZScan(re.ctx, key, cursor, "*", 1000).Result()
+		// True ranks need score order, which ZSCAN does not provide
+		if re.zsetWithRank {
+			card, err := re.client.ZCard(re.ctx, key).Result()
 			if err != nil {
 				return 0, err
 			}
+			if card <= re.zsetRankMaxSize {
+				return re.exportZSetRanked(key, card, slot, idleSeconds, timestamp)
+			}
+		}
 
+		// Use ZSCAN for memory efficiency
+		totalSize := int64(0)
+		err := re.scanElements(func(cursor uint64) ([]string, uint64, error) {
+			return re.client.ZScan(re.ctx, key, cursor, "*", 1000).Result()
+		}, func(members []string) error {
 			// ZSCAN returns member-score pairs in alternating positions
 			for i := 0; i < len(members); i += 2 {
 				if i+1 < len(members) {
@@ -511,25 +1738,33 @@ func (re *RedisExporter) exportKeyData(key, keyType string) (int64, error) {
 					record := &RedisRecord{
 						Key:        fmt.Sprintf("%s:member:%s", key, member),
 						Type:       "zset_member",
-						Value:      fmt.Sprintf("score=%s,rank=%d", scoreStr, rank),
-						TTLSeconds: -1,
+						Value:      fmt.Sprintf("score=%s", scoreStr),
+						TTLSeconds: ttlNoExpiry,
 						ExportedAt: timestamp,
+						Slot:       slot,
+
+						IdleSeconds: idleSeconds,
+						ParentKey:   key,
 					}
 					if err := re.fileManager.WriteRecord(record); err != nil {
-						return 0, err
+						return err
 					}
 					totalSize += int64(len(member))
-					rank++
 				}
 			}
-
-			cursor = nextCursor
-			if cursor == 0 {
-				break
-			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
 		}
 		return totalSize, nil
 
+	case "stream":
+		return re.exportStream(key, slot, idleSeconds, timestamp)
+
+	case bloomFilterType, cuckooFilterType:
+		return re.exportFilterInfo(key, keyType, slot, idleSeconds, timestamp)
+
 	case "list":
 		// For lists, we need to be careful with very large lists
 		length, err := re.client.LLen(re.ctx, key).Result()
@@ -537,34 +1772,53 @@ func (re *RedisExporter) exportKeyData(key, keyType string) (int64, error) {
 			return 0, err
 		}
 
-		// Process in chunks to avoid memory issues
-		const chunkSize = 1000
+		// MAX_ELEMENTS_PER_KEY keeps one end of long lists; indexes stay positions in the whole list
+		first, last, truncated := re.listWindow(length)
+		if truncated {
+			re.truncatedLength = length
+		}
+
+		// Process in chunks to avoid memory issues, adapting the window to element size
+		chunkSize := re.listChunkSize
 		totalSize := int64(0)
 
-		for start := int64(0); start < length; start += chunkSize {
+		for start := first; start < last; {
 			end := start + chunkSize - 1
-			if end >= length {
-				end = length - 1
+			if end >= last {
+				end = last - 1
 			}
 
 			values, err := re.client.LRange(re.ctx, key, start, end).Result()
 			if err != nil {
 				return 0, err
 			}
+			if len(values) == 0 {
+				// List was trimmed while exporting
+				break
+			}
 
+			chunkBytes := int64(0)
 			for i, value := range values {
 				record := &RedisRecord{
 					Key:        fmt.Sprintf("%s:index:%d", key, start+int64(i)),
 					Type:       "list_item",
 					Value:      value,
-					TTLSeconds: -1,
+					TTLSeconds: ttlNoExpiry,
 					ExportedAt: timestamp,
+					Slot:       slot,
+
+					IdleSeconds: idleSeconds,
+					ParentKey:   key,
 				}
 				if err := re.fileManager.WriteRecord(record); err != nil {
 					return 0, err
 				}
 				totalSize += int64(len(value))
+				chunkBytes += int64(len(value))
 			}
+
+			start += int64(len(values))
+			chunkSize = nextListChunkSize(chunkSize, re.listChunkSize, chunkBytes, re.listChunkBytes)
 		}
 		return totalSize, nil
 
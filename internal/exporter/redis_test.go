@@ -0,0 +1,909 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestExporter starts an in-memory Redis and returns an exporter writing CSV to a temp dir
+func newTestExporter(t testing.TB, opts RedisExporterOptions) (*RedisExporter, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+
+	opts.RedisURL = "redis://" + mr.Addr() + "/0"
+	if opts.OutputDir == "" {
+		opts.OutputDir = t.TempDir()
+	}
+	if opts.OutputFormat == "" {
+		opts.OutputFormat = "csv"
+	}
+	if opts.BatchSize == 0 {
+		opts.BatchSize = 10
+	}
+	if opts.MaxRecordsPerFile == 0 {
+		opts.MaxRecordsPerFile = 1000
+	}
+
+	exp, err := NewRedisExporter(opts)
+	if err != nil {
+		t.Fatalf("Failed to create exporter: %v", err)
+	}
+
+	return exp.(*RedisExporter), mr
+}
+
+// findDataFiles walks the output directory for files with the given extension
+func findDataFiles(t *testing.T, dir, ext string) []string {
+	t.Helper()
+
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if filepath.Ext(path) == ext {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error walking directory: %v", err)
+	}
+
+	return files
+}
+
+func TestExportKeysOnlyByPatternNoMatches(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{BatchSize: 2})
+
+	for _, key := range []string{"user:1", "user:2", "user:3", "config:a", "config:b"} {
+		if err := mr.Set(key, "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- exp.ExportKeysOnlyByPattern("missing:*")
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Expected export to succeed, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Export with no matching keys did not terminate")
+	}
+
+	// Metadata is still written and no data files are created
+	if _, err := os.Stat(filepath.Join(exp.fileManager.config.OutputDir, "export_metadata.json")); err != nil {
+		t.Errorf("Expected metadata file, got %v", err)
+	}
+
+	if files := findDataFiles(t, exp.fileManager.config.OutputDir, ".csv"); len(files) != 0 {
+		t.Errorf("Expected no data files, got %v", files)
+	}
+}
+
+func TestExportKeysOnlyByPattern(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{BatchSize: 2, IncludeSlot: true})
+
+	for _, key := range []string{"user:1", "user:2", "user:3", "config:a"} {
+		if err := mr.Set(key, "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := exp.ExportKeysOnlyByPattern("user:*"); err != nil {
+		t.Fatalf("Expected export to succeed, got %v", err)
+	}
+
+	partitions := exp.fileManager.metadata.Partitions
+	if len(partitions) != 1 || partitions[0].RecordCount != 3 {
+		t.Errorf("Expected a single partition with 3 records, got %+v", partitions)
+	}
+
+	rows := readCSVRows(t, exp.fileManager.config.OutputDir)
+	if rows[0][6] != "slot" {
+		t.Fatalf("Expected slot column, got %v", rows[0])
+	}
+	for _, row := range rows[1:] {
+		if row[6] != strconv.Itoa(keySlot(row[0])) {
+			t.Errorf("Expected slot %d for %s, got %s", keySlot(row[0]), row[0], row[6])
+		}
+	}
+}
+
+func TestNextListChunkSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		current    int64
+		maxChunk   int64
+		chunkBytes int64
+		budget     int64
+		expected   int64
+	}{
+		{name: "budget disabled", current: 1000, maxChunk: 1000, chunkBytes: 1 << 30, budget: 0, expected: 1000},
+		{name: "within budget", current: 1000, maxChunk: 1000, chunkBytes: 800, budget: 1000, expected: 1000},
+		{name: "shrinks proportionally", current: 1000, maxChunk: 1000, chunkBytes: 100_000_000, budget: 1_000_000, expected: 10},
+		{name: "never below one", current: 1, maxChunk: 1000, chunkBytes: 100_000_000, budget: 1_000_000, expected: 1},
+		{name: "grows back", current: 10, maxChunk: 1000, chunkBytes: 100, budget: 1_000_000, expected: 20},
+		{name: "grows up to max", current: 800, maxChunk: 1000, chunkBytes: 100, budget: 1_000_000, expected: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := nextListChunkSize(tt.current, tt.maxChunk, tt.chunkBytes, tt.budget)
+			if actual != tt.expected {
+				t.Errorf("Expected chunk size %d, got %d", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestConnectRetries(t *testing.T) {
+	// Reserve an address, then release it so Redis can start there later
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	if err := listener.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := RedisExporterOptions{
+		RedisURL:          "redis://" + addr + "/0",
+		OutputDir:         t.TempDir(),
+		OutputFormat:      "csv",
+		BatchSize:         10,
+		MaxRecordsPerFile: 1000,
+	}
+
+	t.Run("fails without retries", func(t *testing.T) {
+		if _, err := NewRedisExporter(opts); err == nil {
+			t.Fatal("Expected connection error without retries")
+		}
+	})
+
+	t.Run("succeeds once Redis starts", func(t *testing.T) {
+		mr := miniredis.NewMiniRedis()
+		defer mr.Close()
+
+		go func() {
+			time.Sleep(200 * time.Millisecond)
+			if err := mr.StartAddr(addr); err != nil {
+				t.Errorf("Failed to start Redis: %v", err)
+			}
+		}()
+
+		opts.ConnectRetries = 5
+		opts.ConnectRetryInterval = 50 * time.Millisecond
+
+		exp, err := NewRedisExporter(opts)
+		if err != nil {
+			t.Fatalf("Expected connection to succeed after retries, got %v", err)
+		}
+		_ = exp.Close()
+	})
+}
+
+func TestExportBitmapKeys(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{
+		BitmapKeys:          "flags:*",
+		BitmapSampleOffsets: []int64{0, 3},
+	})
+
+	for _, offset := range []int{3, 5, 9} {
+		if err := exp.client.SetBit(exp.ctx, "flags:a", int64(offset), 1).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mr.Set("plain", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("Expected export to succeed, got %v", err)
+	}
+
+	var bitmaps []string
+	for _, row := range readCSVRows(t, exp.fileManager.config.OutputDir)[1:] {
+		if row[1] == "bitmap" {
+			bitmaps = append(bitmaps, row[0]+"="+row[2])
+		}
+	}
+
+	expected := "flags:a=bitcount=3,first_set=3,first_clear=0,bit_0=0,bit_3=1"
+	if len(bitmaps) != 1 || bitmaps[0] != expected {
+		t.Errorf("Expected [%s], got %v", expected, bitmaps)
+	}
+}
+
+func TestExportBitmapChunks(t *testing.T) {
+	exp, _ := newTestExporter(t, RedisExporterOptions{BitmapKeys: "flags:*", BitmapChunkBytes: 2})
+
+	// Bytes 0x14 0x40 0x00 0x00 0x00 0x80: the middle chunk has no bit set
+	for _, offset := range []int{3, 5, 9, 40} {
+		if err := exp.client.SetBit(exp.ctx, "flags:a", int64(offset), 1).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("Expected export to succeed, got %v", err)
+	}
+
+	var chunks []string
+	for _, row := range readCSVRows(t, exp.fileManager.config.OutputDir)[1:] {
+		if row[1] == "bitmap_chunk" {
+			chunks = append(chunks, row[0]+"="+row[2])
+		}
+	}
+	sort.Strings(chunks)
+
+	expected := []string{
+		"flags:a:chunk:0=offset=0,bitcount=3,data=1440",
+		"flags:a:chunk:2=offset=32,bitcount=1,data=0080",
+	}
+	if strings.Join(chunks, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected %v, got %v", expected, chunks)
+	}
+}
+
+func TestAssumeTypeSkipsWrongType(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{AssumeType: "hash"})
+
+	mr.HSet("session:1", "user", "alice")
+	mr.HSet("session:2", "user", "bob")
+	mr.Set("session:3", "not-a-hash")
+
+	outputDir := exp.fileManager.config.OutputDir
+	if err := exp.ExportByPattern("session:*"); err != nil {
+		t.Fatalf("ExportByPattern failed: %v", err)
+	}
+
+	keys := make(map[string]string)
+	for _, row := range readCSVRows(t, outputDir)[1:] {
+		keys[row[0]] = row[1]
+	}
+
+	for _, key := range []string{"session:1", "session:2"} {
+		if keys[key] != "hash" {
+			t.Errorf("Expected %s exported as hash, got %q", key, keys[key])
+		}
+	}
+	if _, ok := keys["session:3"]; ok {
+		t.Error("Expected key of another type to be skipped")
+	}
+}
+
+// passHook passes everything through; test hooks embed it and override
+// the stages they intercept
+type passHook struct{}
+
+func (passHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (passHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return next
+}
+
+func (passHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// commandCountHook counts the commands named name, pipelined or not
+type commandCountHook struct {
+	passHook
+	name  string
+	count *int
+}
+
+func (h commandCountHook) observe(cmd redis.Cmder) {
+	if cmd.Name() == h.name {
+		*h.count++
+	}
+}
+
+func (h commandCountHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.observe(cmd)
+		return next(ctx, cmd)
+	}
+}
+
+func (h commandCountHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			h.observe(cmd)
+		}
+		return next(ctx, cmds)
+	}
+}
+
+func TestSkipTTL(t *testing.T) {
+	for _, keysOnly := range []bool{true, false} {
+		t.Run(fmt.Sprintf("keysOnly=%t", keysOnly), func(t *testing.T) {
+			exp, mr := newTestExporter(t, RedisExporterOptions{SkipTTL: true})
+			if err := mr.Set("session", "abc"); err != nil {
+				t.Fatal(err)
+			}
+			mr.SetTTL("session", time.Hour)
+			mr.HSet("user:1", "name", "alice")
+
+			// Neither TTL nor HTTL may be sent
+			ttlCalls := 0
+			exp.client.AddHook(commandCountHook{name: "ttl", count: &ttlCalls})
+			err := mr.Server().Register("HTTL", func(c *server.Peer, cmd string, args []string) {
+				t.Error("Expected no HTTL call")
+				c.WriteError("ERR unexpected HTTL")
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			outputDir := exp.fileManager.config.OutputDir
+			if keysOnly {
+				err = exp.ExportKeysOnlyByPattern("*")
+			} else {
+				err = exp.ExportByPattern("*")
+			}
+			if err != nil {
+				t.Fatalf("Export failed: %v", err)
+			}
+			if ttlCalls != 0 {
+				t.Errorf("Expected no TTL calls, got %d", ttlCalls)
+			}
+
+			// The column stays in the schema, holding -1
+			rows := readCSVRows(t, outputDir)
+			if rows[0][3] != "ttl_seconds" {
+				t.Fatalf("Expected ttl_seconds column, got headers %v", rows[0])
+			}
+			for _, row := range rows[1:] {
+				if row[3] != "-1" {
+					t.Errorf("Expected TTL -1 for %s, got %s", row[0], row[3])
+				}
+			}
+
+			data, err := os.ReadFile(filepath.Join(outputDir, metadataFileName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var metadata ExportMetadata
+			if err := json.Unmarshal(data, &metadata); err != nil {
+				t.Fatal(err)
+			}
+			if !metadata.TTLsSkipped {
+				t.Error("Expected metadata to record that TTLs were skipped")
+			}
+		})
+	}
+}
+
+func TestTypeBytes(t *testing.T) {
+	tests := []struct {
+		keysOnly bool
+		expected map[string]int64
+	}{
+		// Key records count the data size, element rows their value
+		{expected: map[string]int64{"string": 100, "hash": 15, "hash_field": 7}},
+		// Keys-only records count the size estimate
+		{keysOnly: true, expected: map[string]int64{"string": 7, "hash": 40}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("keysOnly=%t", tt.keysOnly), func(t *testing.T) {
+			exp, mr := newTestExporter(t, RedisExporterOptions{})
+			if err := mr.Set("session", strings.Repeat("a", 100)); err != nil {
+				t.Fatal(err)
+			}
+			mr.HSet("user", "f1", "hello", "field2", "wo")
+
+			outputDir := exp.fileManager.config.OutputDir
+			var err error
+			if tt.keysOnly {
+				err = exp.ExportKeysOnlyByPattern("*")
+			} else {
+				err = exp.ExportByPattern("*")
+			}
+			if err != nil {
+				t.Fatalf("Export failed: %v", err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(outputDir, metadataFileName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var metadata ExportMetadata
+			if err := json.Unmarshal(data, &metadata); err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(metadata.TypeBytes, tt.expected) {
+				t.Errorf("Expected type bytes %v, got %v", tt.expected, metadata.TypeBytes)
+			}
+		})
+	}
+}
+
+func TestZSetRank(t *testing.T) {
+	tests := []struct {
+		name     string
+		withRank bool
+		expected map[string]string
+	}{
+		{
+			name:     "unranked by default",
+			expected: map[string]string{"c": "score=1", "a": "score=2", "b": "score=3.5"},
+		},
+		{
+			name:     "true ranks in score order",
+			withRank: true,
+			expected: map[string]string{"c": "score=1,rank=0", "a": "score=2,rank=1", "b": "score=3.5,rank=2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A one-member chunk exercises the chunked ZRANGE path
+			exp, mr := newTestExporter(t, RedisExporterOptions{ZSetWithRank: tt.withRank, ListChunkSize: 1})
+
+			mr.ZAdd("board", 2, "a")
+			mr.ZAdd("board", 3.5, "b")
+			mr.ZAdd("board", 1, "c")
+
+			outputDir := exp.fileManager.config.OutputDir
+			if err := exp.ExportByPattern("board"); err != nil {
+				t.Fatalf("ExportByPattern failed: %v", err)
+			}
+
+			values := make(map[string]string)
+			for _, row := range readCSVRows(t, outputDir)[1:] {
+				if row[1] == "zset_member" {
+					values[strings.TrimPrefix(row[0], "board:member:")] = row[2]
+				}
+			}
+
+			for member, expected := range tt.expected {
+				if values[member] != expected {
+					t.Errorf("Expected %s value %q, got %q", member, expected, values[member])
+				}
+			}
+		})
+	}
+}
+
+func TestParentKeyColumn(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{IncludeParentKey: true})
+
+	mr.HSet("user:1", "name", "alice")
+	mr.SAdd("tags", "go")
+	mr.Push("queue", "job")
+
+	outputDir := exp.fileManager.config.OutputDir
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("ExportByPattern failed: %v", err)
+	}
+
+	rows := readCSVRows(t, outputDir)
+	if rows[0][6] != "parent_key" {
+		t.Fatalf("Expected parent_key column, got headers %v", rows[0])
+	}
+
+	expected := map[string]string{
+		"user:1":            "",
+		"user:1:field:name": "user:1",
+		"tags:member:go":    "tags",
+		"queue:index:0":     "queue",
+	}
+	for _, row := range rows[1:] {
+		if parent, ok := expected[row[0]]; ok && row[6] != parent {
+			t.Errorf("Expected parent_key %q for %s, got %q", parent, row[0], row[6])
+		}
+	}
+}
+
+func TestMinSizeBytes(t *testing.T) {
+	for _, keysOnly := range []bool{true, false} {
+		t.Run(fmt.Sprintf("keysOnly=%v", keysOnly), func(t *testing.T) {
+			exp, mr := newTestExporter(t, RedisExporterOptions{MinSizeBytes: 1024})
+
+			if err := mr.Set("small", "x"); err != nil {
+				t.Fatal(err)
+			}
+			if err := mr.Set("large", strings.Repeat("x", 4096)); err != nil {
+				t.Fatal(err)
+			}
+
+			var err error
+			if keysOnly {
+				err = exp.ExportKeysOnlyByPattern("*")
+			} else {
+				err = exp.ExportByPattern("*")
+			}
+			if err != nil {
+				t.Fatalf("Export failed: %v", err)
+			}
+
+			keys := make(map[string]bool)
+			for _, row := range readCSVRows(t, exp.fileManager.config.OutputDir)[1:] {
+				keys[row[0]] = true
+			}
+			if !keys["large"] || keys["small"] {
+				t.Errorf("Expected only the large key, got %v", keys)
+			}
+			if exp.fileManager.metadata.SmallKeys != 1 {
+				t.Errorf("Expected 1 small key skipped, got %d", exp.fileManager.metadata.SmallKeys)
+			}
+		})
+	}
+}
+
+func TestFixedRunTimestamp(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{RunTimestamp: "fixed", BatchSize: 2})
+
+	mr.HSet("user:1", "name", "alice", "email", "alice@example.com")
+	mr.Push("queue", "a", "b", "c")
+	if err := mr.Set("config", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	outputDir := exp.fileManager.config.OutputDir
+	runTimestamp := exp.runTimestamp
+	if runTimestamp == "" {
+		t.Fatal("Expected a fixed run timestamp")
+	}
+
+	// Let the clock move past the run start
+	time.Sleep(1100 * time.Millisecond)
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("ExportByPattern failed: %v", err)
+	}
+
+	rows := readCSVRows(t, outputDir)
+	for _, row := range rows[1:] {
+		if row[4] != runTimestamp {
+			t.Errorf("Expected exported_at %s for %s, got %s", runTimestamp, row[0], row[4])
+		}
+	}
+
+	if _, err := NewRedisExporter(RedisExporterOptions{
+		RedisURL:     "redis://" + mr.Addr() + "/0",
+		OutputDir:    t.TempDir(),
+		RunTimestamp: "hourly",
+	}); err == nil {
+		t.Error("Expected error for unsupported run timestamp mode")
+	}
+}
+
+func TestHashFieldTTLs(t *testing.T) {
+	t.Run("unsupported", func(t *testing.T) {
+		exp, mr := newTestExporter(t, RedisExporterOptions{})
+		mr.HSet("user:1", "name", "alice")
+
+		outputDir := exp.fileManager.config.OutputDir
+		if err := exp.ExportByPattern("*"); err != nil {
+			t.Fatalf("ExportByPattern failed: %v", err)
+		}
+		if !exp.httlUnsupported {
+			t.Error("Expected HTTL to be detected as unsupported")
+		}
+		for _, row := range readCSVRows(t, outputDir)[1:] {
+			if row[0] == "user:1:field:name" && row[3] != "-1" {
+				t.Errorf("Expected no field TTL, got %s", row[3])
+			}
+		}
+	})
+
+	t.Run("supported", func(t *testing.T) {
+		exp, mr := newTestExporter(t, RedisExporterOptions{})
+		mr.HSet("user:1", "name", "alice", "session", "abc")
+
+		// miniredis lacks HTTL, so serve it from a fixed table
+		fieldTTLs := map[string]int{"session": 300}
+		err := mr.Server().Register("HTTL", func(c *server.Peer, cmd string, args []string) {
+			fields := args[3:]
+			c.WriteLen(len(fields))
+			for _, field := range fields {
+				if ttl, ok := fieldTTLs[field]; ok {
+					c.WriteInt(ttl)
+				} else {
+					c.WriteInt(-1)
+				}
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		outputDir := exp.fileManager.config.OutputDir
+		if err := exp.ExportByPattern("*"); err != nil {
+			t.Fatalf("ExportByPattern failed: %v", err)
+		}
+
+		ttls := make(map[string]string)
+		for _, row := range readCSVRows(t, outputDir)[1:] {
+			ttls[row[0]] = row[3]
+		}
+		if ttls["user:1:field:session"] != "300" || ttls["user:1:field:name"] != "-1" {
+			t.Errorf("Expected field TTLs 300 and -1, got %v", ttls)
+		}
+	})
+}
+
+func TestDropValueColumn(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{DropValueColumn: true})
+	mr.HSet("user:1", "name", "alice")
+	if err := mr.Set("config", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	outputDir := exp.fileManager.config.OutputDir
+	if err := exp.ExportKeysOnlyByPattern("*"); err != nil {
+		t.Fatalf("ExportKeysOnlyByPattern failed: %v", err)
+	}
+
+	rows := readCSVRows(t, outputDir)
+	expected := []string{"key", "type", "size_estimate", "ttl_seconds", "exported_at", "partition_id"}
+	if strings.Join(rows[0], ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected headers %v, got %v", expected, rows[0])
+	}
+	for _, row := range rows[1:] {
+		if _, err := strconv.ParseInt(row[2], 10, 64); err != nil {
+			t.Errorf("Expected numeric size_estimate for %s, got %q", row[0], row[2])
+		}
+	}
+
+	// Full exports need the value column
+	full, _ := newTestExporter(t, RedisExporterOptions{DropValueColumn: true})
+	if err := full.ExportByPattern("*"); err == nil {
+		t.Error("Expected full export to reject DROP_VALUE_COLUMN")
+	}
+}
+
+func TestRecordsPerFileForTarget(t *testing.T) {
+	tests := []struct {
+		keys, target, expected int64
+	}{
+		{keys: 10000000, target: 100, expected: 100000},
+		{keys: 10000001, target: 100, expected: 100001},
+		{keys: 5000, target: 100, expected: minTargetRecordsPerFile},
+		{keys: 0, target: 100, expected: minTargetRecordsPerFile},
+	}
+
+	for _, tt := range tests {
+		if got := recordsPerFileForTarget(tt.keys, tt.target); got != tt.expected {
+			t.Errorf("recordsPerFileForTarget(%d, %d) = %d, expected %d", tt.keys, tt.target, got, tt.expected)
+		}
+	}
+}
+
+func TestTargetFileCount(t *testing.T) {
+	mr := miniredis.RunT(t)
+	for i := 0; i < 3000; i++ {
+		if err := mr.Set(fmt.Sprintf("key:%d", i), "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exp, err := NewRedisExporter(RedisExporterOptions{
+		RedisURL:          "redis://" + mr.Addr() + "/0",
+		OutputDir:         t.TempDir(),
+		OutputFormat:      "csv",
+		BatchSize:         100,
+		MaxRecordsPerFile: 100,
+		TargetFileCount:   2,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create exporter: %v", err)
+	}
+	re := exp.(*RedisExporter)
+
+	if re.fileManager.config.MaxRecords != 1500 {
+		t.Fatalf("Expected 1500 records per file, got %d", re.fileManager.config.MaxRecords)
+	}
+
+	if err := re.ExportKeysOnly(); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if files := findDataFiles(t, re.fileManager.config.OutputDir, ".csv"); len(files) != 2 {
+		t.Errorf("Expected 2 files, got %d", len(files))
+	}
+}
+
+func TestProtocolVersion(t *testing.T) {
+	// RESP3 replies hashes and scores as maps and doubles; the rows must not change
+	var outputs [][][]string
+	for _, version := range []int{2, 3} {
+		exp, mr := newTestExporter(t, RedisExporterOptions{ProtocolVersion: version})
+		mr.HSet("user:1", "name", "alice")
+		if _, err := mr.ZAdd("scores", 1.5, "amy"); err != nil {
+			t.Fatal(err)
+		}
+		if err := mr.Set("greeting", "hello"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := mr.RPush("queue", "a", "b"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := mr.SAdd("tags", "red", "blue"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := exp.ExportByPattern("*"); err != nil {
+			t.Fatalf("RESP%d export failed: %v", version, err)
+		}
+		var rows [][]string
+		for _, row := range readCSVRows(t, exp.fileManager.config.OutputDir) {
+			// Drop exported_at, which differs between runs
+			rows = append(rows, row[:4])
+		}
+		outputs = append(outputs, rows)
+	}
+	if fmt.Sprint(outputs[0]) != fmt.Sprint(outputs[1]) {
+		t.Errorf("Expected identical rows, got RESP2 %v and RESP3 %v", outputs[0], outputs[1])
+	}
+
+	mr := miniredis.RunT(t)
+	_, err := NewRedisExporter(RedisExporterOptions{
+		RedisURL:        "redis://" + mr.Addr() + "/0",
+		OutputDir:       t.TempDir(),
+		ProtocolVersion: 4,
+	})
+	if err == nil || !strings.Contains(err.Error(), "protocol version") {
+		t.Errorf("Expected protocol version 4 to be rejected, got %v", err)
+	}
+}
+
+// seedEveryType writes one key of each type, with fixed stream IDs and a
+// consumer group so repeated exports match
+func seedEveryType(t *testing.T, exp *RedisExporter) {
+	t.Helper()
+
+	ctx := exp.ctx
+	client := exp.client
+	steps := []error{
+		client.Set(ctx, "greeting", "hello", 0).Err(),
+		client.SetBit(ctx, "flags:1", 9, 1).Err(),
+		client.SAdd(ctx, "tags", "a", "b").Err(),
+		client.ZAdd(ctx, "scores", redis.Z{Score: 1, Member: "amy"}, redis.Z{Score: 2.5, Member: "bob"}).Err(),
+		client.HSet(ctx, "user:1", "name", "alice", "email", "a@example.com").Err(),
+		client.RPush(ctx, "queue", "1", "2").Err(),
+		client.XAdd(ctx, &redis.XAddArgs{Stream: "events", ID: "1-0", Values: []string{"action", "login"}}).Err(),
+		client.XAdd(ctx, &redis.XAddArgs{Stream: "events", ID: "2-0", Values: []string{"action", "logout"}}).Err(),
+		client.XGroupCreate(ctx, "events", "billing", "0").Err(),
+		client.XReadGroup(ctx, &redis.XReadGroupArgs{Group: "billing", Consumer: "worker", Streams: []string{"events", ">"}, Count: 1}).Err(),
+	}
+	for _, err := range steps {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestProtocolParity(t *testing.T) {
+	// RESP3 replies maps, sets and doubles where RESP2 replies arrays and
+	// strings; every type and option must write the same records on both
+	tests := []struct {
+		name     string
+		opts     RedisExporterOptions
+		keysOnly bool
+	}{
+		{"full", RedisExporterOptions{BitmapKeys: "flags:*"}, false},
+		{"metadata", RedisExporterOptions{ZSetWithRank: true, IncludeParentKey: true, IncludeExpiresAt: true}, false},
+		{"filters", RedisExporterOptions{HashFields: []string{"name"}, ZSetMinScore: "2", MaxElementsPerKey: 1}, false},
+		{"keys only", RedisExporterOptions{IncludeCardinality: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outputs []string
+			for _, version := range []int{2, 3} {
+				opts := tt.opts
+				opts.ProtocolVersion = version
+				exp, _ := newTestExporter(t, opts)
+				seedEveryType(t, exp)
+
+				export := func() error { return exp.ExportByPattern("*") }
+				if tt.keysOnly {
+					export = exp.ExportKeysOnly
+				}
+				if err := export(); err != nil {
+					t.Fatalf("RESP%d export failed: %v", version, err)
+				}
+
+				rows := readCSVRows(t, exp.fileManager.config.OutputDir)
+				exportedAt := -1
+				for i, name := range rows[0] {
+					if name == "exported_at" {
+						exportedAt = i
+					}
+				}
+				var lines []string
+				for _, row := range rows {
+					// exported_at differs between runs
+					row = append(append([]string{}, row[:exportedAt]...), row[exportedAt+1:]...)
+					lines = append(lines, strings.Join(row, ","))
+				}
+				sort.Strings(lines)
+				outputs = append(outputs, strings.Join(lines, "\n"))
+			}
+			if !strings.Contains(outputs[0], "billing") && !tt.keysOnly {
+				t.Errorf("Expected consumer group records, got:\n%s", outputs[0])
+			}
+			if outputs[0] != outputs[1] {
+				t.Errorf("Expected identical records, got RESP2:\n%s\nRESP3:\n%s", outputs[0], outputs[1])
+			}
+		})
+	}
+}
+
+func TestClientNameIncludesExportID(t *testing.T) {
+	exp, _ := newTestExporter(t, RedisExporterOptions{ClientName: "redis-dumper/v1.2.3"})
+
+	name, err := exp.client.ClientGetName(context.Background()).Result()
+	if err != nil {
+		t.Fatalf("CLIENT GETNAME failed: %v", err)
+	}
+	if expected := "redis-dumper/v1.2.3/" + exp.fileManager.metadata.ExportID; name != expected {
+		t.Errorf("Expected client name %s, got %s", expected, name)
+	}
+}
+
+func TestCredentialOverrides(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.RequireUserAuth("exporter", "p@ss/word")
+
+	tests := []struct {
+		name     string
+		url      string
+		username string
+		password string
+		ok       bool
+	}{
+		{"both replace the URL", "redis://someone:stale@" + mr.Addr() + "/0", "exporter", "p@ss/word", true},
+		{"password only keeps the URL username", "redis://exporter:stale@" + mr.Addr() + "/0", "", "p@ss/word", true},
+		{"URL credentials without overrides", "redis://exporter:stale@" + mr.Addr() + "/0", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp, err := NewRedisExporter(RedisExporterOptions{
+				RedisURL:      tt.url,
+				RedisUsername: tt.username,
+				RedisPassword: tt.password,
+				OutputDir:     t.TempDir(),
+				OutputFormat:  "csv",
+			})
+			if !tt.ok {
+				if err == nil {
+					exp.Close()
+					t.Fatal("Expected the stale URL credentials to be refused")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewRedisExporter failed: %v", err)
+			}
+			exp.Close()
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// requireReplicaRole fails when READ_FROM_REPLICA was asked of a plain
+// connection that reached a master. Managed services may restrict INFO, so
+// only a reply naming the master role is an error.
+func requireReplicaRole(ctx context.Context, client redis.UniversalClient) error {
+	info, err := client.Info(ctx, "replication").Result()
+	if err != nil {
+		fmt.Printf("Warning: could not confirm REDIS_URL is a replica: %v\n", err)
+		return nil
+	}
+	if parseInfo(info)["role"] == "master" {
+		return errors.New("READ_FROM_REPLICA is set but REDIS_URL points at a master; use a replica's URL")
+	}
+	return nil
+}
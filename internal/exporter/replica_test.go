@@ -0,0 +1,92 @@
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// infoHook replaces INFO replies with a fixed role, which miniredis cannot report
+type infoHook struct {
+	passHook
+	role string
+}
+
+func (h infoHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		if info, ok := cmd.(*redis.StringCmd); ok && cmd.Name() == "info" {
+			info.SetErr(nil)
+			info.SetVal("# Replication\r\nrole:" + h.role + "\r\n")
+			return nil
+		}
+		return err
+	}
+}
+
+func TestReplicaScanAddrs(t *testing.T) {
+	slots := []redis.ClusterSlot{
+		{Start: 0, End: 5460, Nodes: []redis.ClusterNode{{Addr: "10.0.0.1:7000"}, {Addr: "10.0.0.4:7000"}, {Addr: "10.0.0.5:7000"}}},
+		{Start: 5461, End: 10922, Nodes: []redis.ClusterNode{{Addr: "10.0.0.2:7000"}}},
+		// A second range of the first master must not pick another replica
+		{Start: 10923, End: 12000, Nodes: []redis.ClusterNode{{Addr: "10.0.0.1:7000"}, {Addr: "10.0.0.5:7000"}}},
+		{Start: 12001, End: 16383, Nodes: []redis.ClusterNode{{Addr: "10.0.0.3:7000"}, {Addr: "10.0.0.6:7000"}}},
+	}
+
+	addrs, alone := replicaScanAddrs(slots)
+	expected := []string{"10.0.0.4:7000", "10.0.0.2:7000", "10.0.0.6:7000"}
+	if len(addrs) != len(expected) {
+		t.Errorf("Expected %v, got %v", expected, addrs)
+	}
+	for _, addr := range expected {
+		if !addrs[addr] {
+			t.Errorf("Expected %s to be scanned, got %v", addr, addrs)
+		}
+	}
+	if len(alone) != 1 || alone[0] != "10.0.0.2:7000" {
+		t.Errorf("Expected only 10.0.0.2:7000 without a replica, got %v", alone)
+	}
+}
+
+func TestRequireReplicaRole(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer func() {
+		_ = client.Close()
+	}()
+	ctx := context.Background()
+
+	// An unreadable role is only a warning
+	if err := requireReplicaRole(ctx, client); err != nil {
+		t.Errorf("Expected a failed INFO to be tolerated, got %v", err)
+	}
+
+	client.AddHook(infoHook{role: "slave"})
+	if err := requireReplicaRole(ctx, client); err != nil {
+		t.Errorf("Expected a replica to be accepted, got %v", err)
+	}
+
+	master := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer func() {
+		_ = master.Close()
+	}()
+	master.AddHook(infoHook{role: "master"})
+	if err := requireReplicaRole(ctx, master); err == nil || !strings.Contains(err.Error(), "READ_FROM_REPLICA") {
+		t.Errorf("Expected a master to be rejected, got %v", err)
+	}
+}
+
+func TestReadFromReplicaExport(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{ReadFromReplica: true})
+	mr.Set("user:1", "alice")
+
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if rows := readCSVRows(t, exp.fileManager.config.OutputDir); len(rows) != 2 {
+		t.Errorf("Expected a header and 1 record, got %v", rows)
+	}
+}
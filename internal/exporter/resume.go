@@ -0,0 +1,254 @@
+package exporter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"math"
+	"os"
+	"strconv"
+)
+
+// keySet answers whether a key was completed by a previous run
+type keySet interface {
+	contains(key string) bool
+}
+
+// exactKeySet holds every completed key
+type exactKeySet map[string]struct{}
+
+func (s exactKeySet) contains(key string) bool {
+	_, ok := s[key]
+	return ok
+}
+
+// bloomFilter trades a false positive rate for a fixed size of about
+// -ln(p)/ln(2)^2 bits per key. Its hashes are derived from two seeded hashes.
+type bloomFilter struct {
+	bits   []uint64
+	size   uint64
+	hashes int
+	seed1  maphash.Seed
+	seed2  maphash.Seed
+}
+
+func newBloomFilter(keys int64, falsePositiveRate float64) *bloomFilter {
+	if keys < 1 {
+		keys = 1
+	}
+	size := uint64(math.Ceil(-float64(keys) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	if size < 64 {
+		size = 64
+	}
+	hashes := int(math.Round(float64(size) / float64(keys) * math.Ln2))
+	if hashes < 1 {
+		hashes = 1
+	}
+
+	return &bloomFilter{
+		bits:   make([]uint64, (size+63)/64),
+		size:   size,
+		hashes: hashes,
+		seed1:  maphash.MakeSeed(),
+		seed2:  maphash.MakeSeed(),
+	}
+}
+
+func (b *bloomFilter) positions(key string, visit func(bit uint64) bool) bool {
+	h1 := maphash.String(b.seed1, key)
+	h2 := maphash.String(b.seed2, key) | 1
+	for i := 0; i < b.hashes; i++ {
+		if !visit((h1 + uint64(i)*h2) % b.size) {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *bloomFilter) add(key string) {
+	b.positions(key, func(bit uint64) bool {
+		b.bits[bit/64] |= 1 << (bit % 64)
+		return true
+	})
+}
+
+func (b *bloomFilter) contains(key string) bool {
+	return b.positions(key, func(bit uint64) bool {
+		return b.bits[bit/64]&(1<<(bit%64)) != 0
+	})
+}
+
+// completedKey is an exported key waiting for its records to become durable
+type completedKey struct {
+	key string
+	// records is how many records had been written once the key was done
+	records int64
+}
+
+// completedKeysLog appends each finished top-level key of a full-data export
+// to a sidecar file, one Go-quoted key per line. A key is only appended once
+// all its records are in finished files, so a crash never logs a key whose
+// data was lost; keys exported but not yet logged are exported again.
+type completedKeysLog struct {
+	path    string
+	file    *os.File
+	writer  *bufio.Writer
+	skip    keySet
+	pending []completedKey
+}
+
+// openCompletedKeysLog opens the log for appending. With resume, the keys
+// already logged are loaded into the skip set, exactly or, when
+// bloomFalsePositiveRate is set, into a bloom filter. Without resume an
+// existing non-empty log is refused rather than silently discarded.
+func openCompletedKeysLog(path string, resume bool, bloomFalsePositiveRate float64) (*completedKeysLog, error) {
+	if bloomFalsePositiveRate < 0 || bloomFalsePositiveRate >= 1 {
+		return nil, fmt.Errorf("invalid resume bloom false positive rate: %v (expected 0 for exact, or between 0 and 1)", bloomFalsePositiveRate)
+	}
+
+	l := &completedKeysLog{path: path}
+
+	if info, err := os.Stat(path); err == nil && info.Size() > 0 && !resume {
+		return nil, fmt.Errorf("completed keys log %s already exists: set RESUME=true to continue that export or remove the log", path)
+	}
+
+	if resume {
+		skip, err := loadCompletedKeys(path, bloomFalsePositiveRate)
+		if err != nil {
+			return nil, err
+		}
+		l.skip = skip
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open completed keys log: %w", err)
+	}
+	l.file = file
+	l.writer = bufio.NewWriter(file)
+	return l, nil
+}
+
+// loadCompletedKeys reads a completed keys log into a skip set. A missing log
+// resumes nothing. A torn last line from a crash is ignored. The bloom filter
+// is sized by a first pass that only counts the keys, and filled by a second,
+// so the keys themselves are never held in memory.
+func loadCompletedKeys(path string, bloomFalsePositiveRate float64) (keySet, error) {
+	if bloomFalsePositiveRate == 0 {
+		exact := make(exactKeySet)
+		err := readCompletedKeys(path, func(key string) {
+			exact[key] = struct{}{}
+		})
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		return exact, nil
+	}
+
+	var count int64
+	err := readCompletedKeys(path, func(string) {
+		count++
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return exactKeySet{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	filter := newBloomFilter(count, bloomFalsePositiveRate)
+	if err := readCompletedKeys(path, filter.add); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+func readCompletedKeys(path string, visit func(key string)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1<<30)
+	for scanner.Scan() {
+		key, err := strconv.Unquote(scanner.Text())
+		if err != nil {
+			continue
+		}
+		visit(key)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read completed keys log: %w", err)
+	}
+	return nil
+}
+
+// filter drops keys a previous run completed, returning the rest and the
+// number dropped
+func (l *completedKeysLog) filter(keys []string) ([]string, int) {
+	if l == nil || l.skip == nil {
+		return keys, 0
+	}
+
+	kept := keys[:0]
+	for _, key := range keys {
+		if !l.skip.contains(key) {
+			kept = append(kept, key)
+		}
+	}
+	return kept, len(keys) - len(kept)
+}
+
+// add records that key finished once records records had been written
+func (l *completedKeysLog) add(key string, records int64) {
+	if l == nil {
+		return
+	}
+	l.pending = append(l.pending, completedKey{key: key, records: records})
+}
+
+// commit appends the pending keys whose records are within the first
+// durable records written
+func (l *completedKeysLog) commit(durable int64) error {
+	if l == nil {
+		return nil
+	}
+
+	done := 0
+	for _, completed := range l.pending {
+		if completed.records > durable {
+			break
+		}
+		if _, err := l.writer.WriteString(strconv.Quote(completed.key) + "\n"); err != nil {
+			return fmt.Errorf("failed to write completed keys log: %w", err)
+		}
+		done++
+	}
+	l.pending = l.pending[done:]
+
+	if err := l.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write completed keys log: %w", err)
+	}
+	return nil
+}
+
+// close commits the durable keys and closes the log
+func (l *completedKeysLog) close(durable int64) error {
+	if l == nil {
+		return nil
+	}
+
+	err := l.commit(durable)
+	if syncErr := l.file.Sync(); err == nil && syncErr != nil {
+		err = fmt.Errorf("failed to sync completed keys log: %w", syncErr)
+	}
+	if closeErr := l.file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close completed keys log: %w", closeErr)
+	}
+	return err
+}
@@ -0,0 +1,199 @@
+package exporter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCompletedKeysLogResume(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "completed.log")
+	exp, mr := newTestExporter(t, RedisExporterOptions{CompletedKeysLog: logPath})
+	outputDir := exp.fileManager.config.OutputDir
+
+	for i := 0; i < 5; i++ {
+		if err := mr.Set(fmt.Sprintf("key:%d", i), "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mr.Set("multi\nline", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	logged := make(map[string]bool)
+	if err := readCompletedKeys(logPath, func(key string) { logged[key] = true }); err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) != 6 || !logged["multi\nline"] {
+		t.Fatalf("Expected 6 logged keys, got %v", logged)
+	}
+
+	// A restart without RESUME must not discard the log
+	opts := RedisExporterOptions{
+		RedisURL:          "redis://" + mr.Addr() + "/0",
+		OutputDir:         outputDir,
+		OutputFormat:      "csv",
+		BatchSize:         10,
+		MaxRecordsPerFile: 1000,
+		CompletedKeysLog:  logPath,
+	}
+	if _, err := NewRedisExporter(opts); err == nil || !strings.Contains(err.Error(), "RESUME") {
+		t.Fatalf("Expected an existing log to require RESUME, got %v", err)
+	}
+
+	if err := mr.Set("key:new", "v"); err != nil {
+		t.Fatal(err)
+	}
+	opts.Resume = true
+	resumed, err := NewRedisExporter(opts)
+	if err != nil {
+		t.Fatalf("Failed to create exporter: %v", err)
+	}
+	resumedExp := resumed.(*RedisExporter)
+	if err := resumedExp.ExportByPattern("*"); err != nil {
+		t.Fatalf("Resumed export failed: %v", err)
+	}
+
+	if resumedExp.fileManager.metadata.ResumedKeys != 6 {
+		t.Errorf("Expected 6 resumed keys, got %d", resumedExp.fileManager.metadata.ResumedKeys)
+	}
+
+	// Both runs' files survive and together hold every key once
+	files := findDataFiles(t, outputDir, ".csv")
+	if len(files) != 2 {
+		t.Fatalf("Expected the resumed run to add a second file, got %v", files)
+	}
+	seen := make(map[string]int)
+	for _, row := range readCSVRows(t, outputDir) {
+		if row[0] != "key" {
+			seen[row[0]]++
+		}
+	}
+	if len(seen) != 7 {
+		t.Errorf("Expected 7 distinct keys across runs, got %v", seen)
+	}
+	for key, n := range seen {
+		if n != 1 {
+			t.Errorf("Expected %q once, got %d", key, n)
+		}
+	}
+}
+
+func TestResumeRequiresLog(t *testing.T) {
+	_, err := NewRedisExporter(RedisExporterOptions{
+		RedisURL:     "redis://localhost:6379/0",
+		OutputDir:    t.TempDir(),
+		OutputFormat: "csv",
+		Resume:       true,
+		RDBFile:      os.DevNull,
+	})
+	if err == nil || !strings.Contains(err.Error(), "COMPLETED_KEYS_LOG") {
+		t.Errorf("Expected RESUME without a log to be rejected, got %v", err)
+	}
+}
+
+func TestDurableRecords(t *testing.T) {
+	tests := []struct {
+		name   string
+		config StorageConfig
+		// durableAfterFlush is the durable count after FlushAll
+		durableAfterFlush int64
+	}{
+		{"csv", StorageConfig{Format: FormatCSV}, 3},
+		{"gzip csv", StorageConfig{Format: FormatCSV, Compression: CompressionGzip}, 0},
+		{"reproducible csv", StorageConfig{Format: FormatCSV, Reproducible: true}, 0},
+		{"parquet", StorageConfig{Format: FormatParquet}, 0},
+		{"background parquet", StorageConfig{Format: FormatParquet, MaxConcurrentCopies: 2}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.OutputDir = t.TempDir()
+			config.MaxRecords = 1000
+			fm := NewFileManager(config)
+
+			for i := 0; i < 3; i++ {
+				if err := fm.WriteRecord(&RedisRecord{Key: fmt.Sprintf("key:%d", i), Type: "string", TTLSeconds: -1}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := fm.DurableRecords(); got != 0 {
+				t.Errorf("Expected 0 durable records before flushing, got %d", got)
+			}
+
+			fm.FlushAll()
+			if got := fm.DurableRecords(); got != tt.durableAfterFlush {
+				t.Errorf("Expected %d durable records after FlushAll, got %d", tt.durableAfterFlush, got)
+			}
+
+			if err := fm.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if got := fm.DurableRecords(); got != 3 {
+				t.Errorf("Expected 3 durable records after Close, got %d", got)
+			}
+		})
+	}
+}
+
+func TestBloomFilter(t *testing.T) {
+	const keys = 10000
+	filter := newBloomFilter(keys, 0.01)
+	for i := 0; i < keys; i++ {
+		filter.add(fmt.Sprintf("key:%d", i))
+	}
+
+	for i := 0; i < keys; i++ {
+		if !filter.contains(fmt.Sprintf("key:%d", i)) {
+			t.Fatalf("Expected no false negatives, key:%d missing", i)
+		}
+	}
+
+	falsePositives := 0
+	for i := 0; i < keys; i++ {
+		if filter.contains(fmt.Sprintf("other:%d", i)) {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / keys; rate > 0.03 {
+		t.Errorf("Expected a false positive rate near 1%%, got %.2f%%", rate*100)
+	}
+}
+
+func TestLoadCompletedKeysBloom(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "completed.log")
+	var log strings.Builder
+	for i := 0; i < 1000; i++ {
+		log.WriteString(strconv.Quote(fmt.Sprintf("key:%d", i)) + "\n")
+	}
+	// A torn last line from a crash
+	log.WriteString(`"key:torn`)
+	if err := os.WriteFile(path, []byte(log.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	skip, err := loadCompletedKeys(path, 0.01)
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	filter, ok := skip.(*bloomFilter)
+	if !ok {
+		t.Fatalf("Expected a bloom filter, got %T", skip)
+	}
+	// Sized for the 1000 complete lines
+	if expected := newBloomFilter(1000, 0.01); filter.size != expected.size || filter.hashes != expected.hashes {
+		t.Errorf("Expected a filter of %d bits and %d hashes, got %d and %d", expected.size, expected.hashes, filter.size, filter.hashes)
+	}
+	for i := 0; i < 1000; i++ {
+		if !filter.contains(fmt.Sprintf("key:%d", i)) {
+			t.Fatalf("Expected key:%d to be skipped", i)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package exporter
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+const (
+	// SampleRandom keeps keys whose name hashes below the sample rate
+	SampleRandom = "random"
+	// SampleNth keeps every Nth scanned key, N being 1/SampleRate
+	SampleNth = "nth"
+
+	defaultSampleRate = 0.01
+)
+
+// keySampler picks the subset of keys a sample export writes. A nil sampler
+// keeps every key.
+type keySampler struct {
+	mode  string
+	rate  float64
+	every int64
+	// seen and kept count keys offered and selected
+	seen int64
+	kept int64
+}
+
+// newKeySampler validates the sample mode and rate, 0 meaning the default rate
+func newKeySampler(mode string, rate float64) (*keySampler, error) {
+	if rate == 0 {
+		rate = defaultSampleRate
+	}
+	if rate < 0 || rate > 1 {
+		return nil, fmt.Errorf("SAMPLE_RATE must be between 0 and 1, got %g", rate)
+	}
+
+	switch mode {
+	case SampleRandom, "":
+		return &keySampler{mode: SampleRandom, rate: rate}, nil
+	case SampleNth:
+		return &keySampler{mode: SampleNth, rate: rate, every: int64(math.Round(1 / rate))}, nil
+	default:
+		return nil, fmt.Errorf("unsupported SAMPLE_MODE: %s", mode)
+	}
+}
+
+// keep reports whether key is in the sample. Random sampling hashes the key
+// name, so the same keys are picked on every run.
+func (s *keySampler) keep(key string) bool {
+	if s == nil {
+		return true
+	}
+
+	s.seen++
+	var selected bool
+	if s.every > 0 {
+		selected = (s.seen-1)%s.every == 0
+	} else {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key))
+		selected = float64(mixHash(h.Sum64())) < s.rate*math.MaxUint64
+	}
+	if selected {
+		s.kept++
+	}
+	return selected
+}
+
+// filter drops the keys outside the sample from a batch in place
+func (s *keySampler) filter(keys []string) []string {
+	if s == nil {
+		return keys
+	}
+
+	kept := keys[:0]
+	for _, key := range keys {
+		if s.keep(key) {
+			kept = append(kept, key)
+		}
+	}
+	return kept
+}
+
+// ExportSample exports the full data of a SAMPLE_RATE subset of the keys
+// matching pattern, to check the output before a full export
+func (re *RedisExporter) ExportSample(pattern string) error {
+	re.sampler = re.sample
+
+	re.verbosity.infof("Sampling %g of the keys matching %s (%s)\n", re.sample.rate, pattern, re.sample.mode)
+	if err := re.ExportByPattern(pattern); err != nil {
+		return err
+	}
+
+	fmt.Printf("Sampled %d of %d keys\n", re.sample.kept, re.sample.seen)
+	return nil
+}
+
+// mixHash spreads FNV's weak high bits, which barely vary across keys that
+// share a prefix, over the whole range (the splitmix64 finalizer)
+func mixHash(h uint64) uint64 {
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
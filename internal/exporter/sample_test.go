@@ -0,0 +1,62 @@
+package exporter
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestKeySampler(t *testing.T) {
+	nth, err := newKeySampler(SampleNth, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	random, err := newKeySampler(SampleRandom, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key:%d", i)
+	}
+	if kept := nth.filter(append([]string(nil), keys...)); len(kept) != 100 || kept[1] != "key:10" {
+		t.Errorf("Expected every 10th key, got %d starting %v", len(kept), kept[:2])
+	}
+
+	first := random.filter(append([]string(nil), keys...))
+	if len(first) < 50 || len(first) > 150 {
+		t.Errorf("Expected about 100 random keys, got %d", len(first))
+	}
+	// The same keys are picked again
+	again, _ := newKeySampler(SampleRandom, 0.1)
+	if second := again.filter(append([]string(nil), keys...)); fmt.Sprint(second) != fmt.Sprint(first) {
+		t.Error("Expected random sampling to pick the same keys on every run")
+	}
+
+	for _, tt := range []struct {
+		mode string
+		rate float64
+	}{{SampleRandom, 1.5}, {SampleRandom, -0.1}, {"every", 0.1}} {
+		if _, err := newKeySampler(tt.mode, tt.rate); err == nil {
+			t.Errorf("Expected SAMPLE_MODE=%s SAMPLE_RATE=%g to be rejected", tt.mode, tt.rate)
+		}
+	}
+}
+
+func TestExportSample(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{SampleRate: 0.25, SampleMode: SampleNth})
+	for i := 0; i < 40; i++ {
+		mr.Set(fmt.Sprintf("key:%d", i), "value")
+	}
+
+	if err := exp.ExportSample("*"); err != nil {
+		t.Fatalf("ExportSample failed: %v", err)
+	}
+
+	if rows := readCSVRows(t, exp.fileManager.config.OutputDir); len(rows)-1 != 10 {
+		t.Errorf("Expected 10 of 40 keys, got %d", len(rows)-1)
+	}
+	if exp.sample.seen != 40 || exp.sample.kept != 10 {
+		t.Errorf("Expected 10 of 40 keys counted, got %d of %d", exp.sample.kept, exp.sample.seen)
+	}
+}
@@ -0,0 +1,373 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultWriteQueueSize = 4
+	// saturationLogInterval rate-limits the "writer is the bottleneck" warning
+	saturationLogInterval = 30 * time.Second
+
+	// Bounds for SCAN COUNT when auto-tuning
+	minAutoScanCount = 10
+	maxAutoScanCount = 100000
+	// defaultScanLatencyTarget is used when auto-tuning without an explicit target
+	defaultScanLatencyTarget = 10 * time.Millisecond
+)
+
+// queueStats tracks how often the scanner had to wait on the writer
+type queueStats struct {
+	saturations atomic.Int64
+	blockedNs   atomic.Int64
+}
+
+// batchContext bounds a single SCAN or pipeline round trip by the batch
+// timeout, so a hung connection fails the batch instead of stalling the export
+func (re *RedisExporter) batchContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if re.batchTimeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, re.batchTimeout)
+}
+
+// nextScanCount adapts SCAN COUNT to the latency of the previous call: it
+// doubles while calls finish well under target and halves once they exceed it
+func nextScanCount(current int64, latency, target time.Duration) int64 {
+	if target <= 0 {
+		return current
+	}
+
+	if latency > target {
+		next := current / 2
+		if next < minAutoScanCount {
+			next = minAutoScanCount
+		}
+		return next
+	}
+
+	if latency < target/2 && current < maxAutoScanCount {
+		next := current * 2
+		if next > maxAutoScanCount {
+			next = maxAutoScanCount
+		}
+		return next
+	}
+
+	return current
+}
+
+// scanStep runs one SCAN call and reports its latency. A call that fails
+// because the connection dropped or the server is failing over is retried at
+// the same cursor, which stays valid across reconnects and failovers, after
+// scanRetryInterval doubled after each failure.
+func (re *RedisExporter) scanStep(ctx context.Context, node redis.Cmdable, cursor uint64, pattern string, count int64) ([]string, uint64, time.Duration, error) {
+	delay := re.scanRetryInterval
+	for attempt := 0; ; attempt++ {
+		batchCtx, batchCancel := re.batchContext(ctx)
+		startedAt := time.Now()
+		var scan *redis.ScanCmd
+		if keyType := re.scanTypeOption(); keyType != "" {
+			scan = node.ScanType(batchCtx, cursor, pattern, count, keyType)
+		} else {
+			scan = node.Scan(batchCtx, cursor, pattern, count)
+		}
+		keys, next, err := scan.Result()
+		latency := time.Since(startedAt)
+		batchCancel()
+		if err == nil {
+			return keys, next, latency, nil
+		}
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			err = fmt.Errorf("scan batch exceeded timeout of %s: %w", re.batchTimeout, err)
+		}
+		if attempt >= re.scanRetries || ctx.Err() != nil || !retryableScanError(err) {
+			return nil, 0, 0, err
+		}
+
+		log.Printf("SCAN at cursor %d failed (attempt %d/%d): %v - retrying in %s", cursor, attempt+1, re.scanRetries+1, err, delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, 0, 0, ctx.Err()
+		}
+		delay *= 2
+		if delay > maxConnectRetryInterval {
+			delay = maxConnectRetryInterval
+		}
+	}
+}
+
+// retryableScanError reports whether a SCAN error may clear on its own:
+// network failures and timeouts, or a server that is loading, read-only or
+// without a master during a failover. Other server errors are final.
+func retryableScanError(err error) bool {
+	var serverErr redis.Error
+	if !errors.As(err, &serverErr) {
+		return true
+	}
+	for _, prefix := range []string{"LOADING", "READONLY", "MASTERDOWN", "CLUSTERDOWN", "TRYAGAIN"} {
+		if redis.HasErrorPrefix(err, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanBatch is a batch of keys and the index of the source they came from
+type scanBatch struct {
+	keys   []string
+	source int
+	// types are the key types KEY_TYPES resolved while scanning, nil when
+	// the keys were not typed
+	types map[string]string
+}
+
+// scanBatches runs SCAN in a producer goroutine and hands every non-empty
+// batch of keys to handle on the calling goroutine. Batches pass through a
+// bounded queue of writeQueueSize entries, so when the writer falls behind
+// the scanner blocks instead of buffering the keyspace in memory.
+func (re *RedisExporter) scanBatches(pattern string, handle func(keys []string) error) error {
+	ctx, cancel := context.WithCancel(re.ctx)
+	defer cancel()
+
+	batches := make(chan scanBatch, re.writeQueueSize)
+	scanErr := make(chan error, 1)
+	stats := &queueStats{}
+	var ignored atomic.Int64
+	var outsideTTL atomic.Int64
+
+	go func() {
+		defer close(batches)
+
+		// A cluster is scanned one master at a time, and sources one after
+		// another
+		targets, err := re.scanTargets(ctx)
+		if err != nil {
+			scanErr <- err
+			return
+		}
+
+		// KEYS_FILE replaces SCAN with the listed keys, read through the client
+		var keysFile *keysFileReader
+		if re.keysFile != "" {
+			if keysFile, err = openKeysFile(re.keysFile, re.fileManager.config.KeyEncoding); err != nil {
+				scanErr <- err
+				return
+			}
+			defer func() {
+				_ = keysFile.Close()
+				if keysFile.missing > 0 {
+					fmt.Printf("Skipped %d of %d listed keys that no longer exist\n", keysFile.missing, keysFile.listed)
+				}
+			}()
+			targets = []scanTarget{{node: re.client}}
+		}
+
+		var lastWarning time.Time
+		count := int64(re.batchSize)
+		if re.autoScanCount {
+			count = minAutoScanCount
+		}
+		for _, target := range targets {
+			var cursor uint64
+			for {
+				re.sampleLatency(ctx, target.node)
+				re.throttle.pause(ctx)
+				var keys []string
+				var next uint64
+				var latency time.Duration
+				if keysFile != nil {
+					keys, next, err = re.keysFileStep(ctx, keysFile, pattern, count)
+				} else {
+					keys, next, latency, err = re.scanStep(ctx, target.node, cursor, pattern, count)
+				}
+				if err != nil {
+					scanErr <- fmt.Errorf("failed to scan keys: %w", err)
+					return
+				}
+
+				// Ignored keys are dropped before anything else can fetch them
+				keys, dropped := re.filterIgnored(keys)
+				ignored.Add(int64(dropped))
+				re.verbosity.debugf("SCAN cursor %d returned %d keys (%d ignored) in %s with COUNT %d\n",
+					cursor, len(keys), dropped, latency.Round(time.Microsecond), count)
+
+				// Regexes run client-side before the pipelined TYPE filter
+				keys, unmatched := re.filterKeyRegex(keys)
+				if unmatched > 0 {
+					re.verbosity.debugf("Key regexes dropped %d keys at cursor %d\n", unmatched, cursor)
+				}
+
+				keys, types, unselected, err := re.filterKeyTypes(ctx, target.node, keys)
+				if err != nil {
+					scanErr <- err
+					return
+				}
+				if unselected > 0 {
+					re.verbosity.debugf("KEY_TYPES dropped %d keys at cursor %d\n", unselected, cursor)
+				}
+
+				keys, outside, err := re.filterByTTL(ctx, target.node, keys)
+				if err != nil {
+					scanErr <- err
+					return
+				}
+				outsideTTL.Add(int64(outside))
+
+				// Selective patterns often yield empty batches - nothing to hand over
+				if len(keys) > 0 {
+					batch := scanBatch{keys: keys, source: target.source, types: types}
+					select {
+					case batches <- batch:
+					default:
+						// Queue is full: the writer is the bottleneck, so block until it catches up
+						stats.saturations.Add(1)
+						if time.Since(lastWarning) >= saturationLogInterval {
+							re.verbosity.infof("Write queue saturated (%d batches) - writer is the bottleneck\n", cap(batches))
+							lastWarning = time.Now()
+						}
+
+						blockedAt := time.Now()
+						select {
+						case batches <- batch:
+						case <-ctx.Done():
+							return
+						}
+						stats.blockedNs.Add(int64(time.Since(blockedAt)))
+					}
+				}
+
+				if re.autoScanCount {
+					count = nextScanCount(count, latency, re.scanLatencyTarget)
+				}
+
+				cursor = next
+				re.scanCursor.Store(cursor)
+				if cursor == 0 {
+					break
+				}
+			}
+		}
+	}()
+
+	// Account for ignored keys however the scan ends
+	defer func() {
+		if len(re.ignorePatterns) > 0 {
+			dropped := ignored.Load()
+			re.fileManager.AddIgnoredKeys(dropped)
+			fmt.Printf("Ignore rules dropped %d keys\n", dropped)
+		}
+		if re.ttlWindow != nil {
+			dropped := outsideTTL.Load()
+			re.fileManager.AddTTLFilteredKeys(dropped)
+			fmt.Printf("TTL filters dropped %d keys\n", dropped)
+		}
+	}()
+
+	flushTicks := re.fileManager.FlushTicks()
+consume:
+	for {
+		select {
+		case batch, ok := <-batches:
+			if !ok {
+				break consume
+			}
+			re.useSource(batch.source)
+			re.throttle.pause(ctx)
+			// The types are only read on this goroutine, while the batch is handled
+			re.scannedTypes = batch.types
+			err := handle(batch.keys)
+			re.scannedTypes = nil
+			if err != nil {
+				// Stop the scanner and let it exit before returning
+				cancel()
+				for range batches {
+				}
+				return err
+			}
+		case <-flushTicks:
+			// A selective pattern can leave records buffered between sparse batches
+			re.flushDue()
+		}
+	}
+
+	if saturations := stats.saturations.Load(); saturations > 0 {
+		re.verbosity.infof("Scanner waited on the writer %d times (%s total)\n",
+			saturations, time.Duration(stats.blockedNs.Load()).Round(time.Millisecond))
+	}
+	if t := re.throttle; t != nil && t.paused.Load() > 0 {
+		re.verbosity.infof("Throttling paused the export for %s (longest pause %s)\n",
+			time.Duration(t.paused.Load()).Round(time.Millisecond), time.Duration(t.peak.Load()))
+	}
+
+	select {
+	case err := <-scanErr:
+		return err
+	default:
+		return nil
+	}
+}
+
+// elementScanResult is one step of an SSCAN, HSCAN or ZSCAN cursor loop
+type elementScanResult struct {
+	batch []string
+	next  uint64
+	err   error
+}
+
+// scanElements runs an element cursor loop, handing each batch to handle.
+// Cursor steps depend on the previous reply, so they cannot be pipelined;
+// with prefetching the next step is fetched while handle writes the current
+// batch, overlapping the round trip with the writing.
+func (re *RedisExporter) scanElements(scan func(cursor uint64) ([]string, uint64, error), handle func(batch []string) error) error {
+	if re.sequentialElementScans {
+		var cursor uint64
+		for {
+			batch, next, err := scan(cursor)
+			if err != nil {
+				return err
+			}
+			if err := handle(batch); err != nil {
+				return err
+			}
+			if cursor = next; cursor == 0 {
+				return nil
+			}
+		}
+	}
+
+	// The channel is buffered, so an abandoned fetch never blocks
+	fetch := func(cursor uint64) <-chan elementScanResult {
+		result := make(chan elementScanResult, 1)
+		go func() {
+			batch, next, err := scan(cursor)
+			result <- elementScanResult{batch: batch, next: next, err: err}
+		}()
+		return result
+	}
+
+	pending := fetch(0)
+	for {
+		step := <-pending
+		if step.err != nil {
+			return step.err
+		}
+		if step.next != 0 {
+			pending = fetch(step.next)
+		}
+		if err := handle(step.batch); err != nil {
+			return err
+		}
+		if step.next == 0 {
+			return nil
+		}
+	}
+}
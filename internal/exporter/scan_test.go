@@ -0,0 +1,540 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2/server"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestNextScanCount(t *testing.T) {
+	target := 10 * time.Millisecond
+	tests := []struct {
+		name     string
+		current  int64
+		latency  time.Duration
+		expected int64
+	}{
+		{"fast call grows", 100, time.Millisecond, 200},
+		{"growth capped", 80000, time.Millisecond, maxAutoScanCount},
+		{"slow call backs off", 1000, 20 * time.Millisecond, 500},
+		{"backoff floored", 15, 20 * time.Millisecond, minAutoScanCount},
+		{"near target holds", 1000, 8 * time.Millisecond, 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextScanCount(tt.current, tt.latency, target); got != tt.expected {
+				t.Errorf("nextScanCount(%d, %s) = %d, expected %d", tt.current, tt.latency, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScanBatchesSlowWriter(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{BatchSize: 5, WriteQueueSize: 1})
+	defer func() {
+		_ = exp.Close()
+	}()
+
+	for i := 0; i < 100; i++ {
+		mr.Set("key:"+strconv.Itoa(i), "value")
+	}
+
+	// A slow handler forces the scanner to block on the bounded queue
+	seen := make(map[string]bool)
+	err := exp.scanBatches("key:*", func(keys []string) error {
+		time.Sleep(time.Millisecond)
+		for _, key := range keys {
+			seen[key] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("scanBatches failed: %v", err)
+	}
+
+	if len(seen) != 100 {
+		t.Errorf("Expected 100 keys, got %d", len(seen))
+	}
+}
+
+func TestScanBatchesFlushEvery(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{BatchSize: 1, FlushEvery: time.Millisecond})
+	defer func() {
+		_ = exp.Close()
+	}()
+	mr.Set("a", "v")
+	mr.Set("b", "v")
+
+	// The second SCAN waits for the first batch's record to reach disk,
+	// which only the timer can do while the scanner is stalled
+	var flushed atomic.Bool
+	scans := 0
+	exp.client.AddHook(scanHook{commandCountHook: commandCountHook{count: &scans}, onScan: func(n int) {
+		if n != 2 {
+			return
+		}
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			if csvHasData(exp.fileManager.config.OutputDir) {
+				flushed.Store(true)
+				return
+			}
+		}
+	}})
+
+	err := exp.scanBatches("*", func(keys []string) error {
+		idle := exp.keyIdleSeconds(keys)
+		for _, key := range keys {
+			if err := exp.exportKey(key, idle[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("scanBatches failed: %v", err)
+	}
+	if !flushed.Load() {
+		t.Error("Expected FLUSH_EVERY to write the buffered record while the scan stalled")
+	}
+}
+
+// csvHasData reports whether any CSV file under dir holds more than a header
+func csvHasData(dir string) bool {
+	found := false
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || filepath.Ext(path) != ".csv" {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err == nil && strings.Count(string(data), "\n") > 1 {
+			found = true
+		}
+		return nil
+	})
+	return found
+}
+
+func TestScanBatchesHandlerError(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{BatchSize: 5, WriteQueueSize: 1})
+	defer func() {
+		_ = exp.Close()
+	}()
+
+	for i := 0; i < 100; i++ {
+		mr.Set("key:"+strconv.Itoa(i), "value")
+	}
+
+	errStop := errors.New("stop")
+	calls := 0
+	err := exp.scanBatches("*", func(keys []string) error {
+		calls++
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("Expected handler error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected handler to stop after 1 call, got %d", calls)
+	}
+}
+
+// slowScanHook delays SCAN until the command's context is done or delay passes
+type slowScanHook struct {
+	passHook
+	delay time.Duration
+}
+
+func (h slowScanHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() != "scan" {
+			return next(ctx, cmd)
+		}
+
+		select {
+		case <-time.After(h.delay):
+			return next(ctx, cmd)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func TestScanBatchesTimeout(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{BatchTimeout: 50 * time.Millisecond})
+	defer func() {
+		_ = exp.Close()
+	}()
+
+	mr.Set("key:1", "value")
+	exp.client.AddHook(slowScanHook{delay: 5 * time.Second})
+
+	start := time.Now()
+	err := exp.scanBatches("*", func(keys []string) error {
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected scan to fail fast, took %s", elapsed)
+	}
+}
+
+// failingScanHook fails every SCAN after the first succeed calls
+type failingScanHook struct {
+	passHook
+	succeed int
+	calls   *atomic.Int64
+}
+
+func (h failingScanHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() == "scan" && h.calls.Add(1) > int64(h.succeed) {
+			return errors.New("connection reset by peer")
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func TestScanErrorWritesPartialExport(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{BatchSize: 2})
+
+	for i := 0; i < 20; i++ {
+		mr.Set(fmt.Sprintf("key:%02d", i), "value")
+	}
+	exp.client.AddHook(failingScanHook{succeed: 3, calls: &atomic.Int64{}})
+
+	outputDir := exp.fileManager.config.OutputDir
+	err := exp.ExportKeysOnlyByPattern("key:*")
+	if err == nil || !strings.Contains(err.Error(), "connection reset by peer") {
+		t.Fatalf("Expected scan error, got %v", err)
+	}
+
+	// Keys exported before the failure are on disk
+	rows := readCSVRows(t, outputDir)
+	if len(rows) < 2 {
+		t.Fatal("Expected rows written before the scan failed")
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "export_metadata.json"))
+	if err != nil {
+		t.Fatalf("Expected metadata after a failed scan: %v", err)
+	}
+	var metadata ExportMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Status != exportStatusPartial || !strings.Contains(metadata.Error, "failed to scan keys") {
+		t.Errorf("Expected partial status with the scan error, got %q / %q", metadata.Status, metadata.Error)
+	}
+	if metadata.TotalKeys != int64(len(rows)-1) {
+		t.Errorf("Expected total_keys %d, got %d", len(rows)-1, metadata.TotalKeys)
+	}
+	if len(metadata.Partitions) != 1 || metadata.Partitions[0].RecordCount != metadata.TotalKeys {
+		t.Errorf("Expected partition metadata for the written rows, got %+v", metadata.Partitions)
+	}
+}
+
+// serverError is an error reply from Redis
+type serverError string
+
+func (e serverError) Error() string { return string(e) }
+func (serverError) RedisError()     {}
+
+// flakyScanHook fails SCAN calls with err while failures is positive
+type flakyScanHook struct {
+	passHook
+	err      error
+	failures *atomic.Int64
+	calls    *atomic.Int64
+}
+
+func (h flakyScanHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() == "scan" {
+			h.calls.Add(1)
+			if h.failures.Add(-1) >= 0 {
+				return h.err
+			}
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func TestScanRetriesAtCursor(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{
+		BatchSize:         2,
+		ScanRetries:       3,
+		ScanRetryInterval: time.Millisecond,
+	})
+	for i := 0; i < 20; i++ {
+		mr.Set(fmt.Sprintf("key:%02d", i), "value")
+	}
+
+	// Two drops in a row are ridden out and no key is lost
+	failures, calls := &atomic.Int64{}, &atomic.Int64{}
+	failures.Store(2)
+	exp.client.AddHook(flakyScanHook{err: errors.New("connection reset by peer"), failures: failures, calls: calls})
+
+	if err := exp.ExportKeysOnlyByPattern("key:*"); err != nil {
+		t.Fatalf("Expected the scan to recover, got %v", err)
+	}
+	if rows := readCSVRows(t, exp.fileManager.config.OutputDir); len(rows)-1 != 20 {
+		t.Errorf("Expected 20 keys after retrying, got %d", len(rows)-1)
+	}
+}
+
+func TestScanRetriesGiveUp(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int64
+	}{
+		// Retries run out during a long outage
+		{"network", errors.New("connection reset by peer"), 3},
+		// A failover in progress is retried too
+		{"loading", serverError("LOADING Redis is loading the dataset in memory"), 3},
+		// Errors that will not clear fail at once
+		{"server", serverError("ERR invalid cursor"), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp, mr := newTestExporter(t, RedisExporterOptions{ScanRetries: 2, ScanRetryInterval: time.Millisecond})
+			mr.Set("key", "value")
+
+			failures, calls := &atomic.Int64{}, &atomic.Int64{}
+			failures.Store(100)
+			exp.client.AddHook(flakyScanHook{err: tt.err, failures: failures, calls: calls})
+
+			if err := exp.ExportKeysOnlyByPattern("*"); err == nil {
+				t.Fatal("Expected the scan to fail")
+			}
+			if calls.Load() != tt.expected {
+				t.Errorf("Expected %d SCAN calls, got %d", tt.expected, calls.Load())
+			}
+		})
+	}
+}
+
+// pagedScan serves pages of a fixed element list the way SSCAN does, one
+// page per cursor step
+func pagedScan(elements []string, pageSize int) func(cursor uint64) ([]string, uint64, error) {
+	return func(cursor uint64) ([]string, uint64, error) {
+		end := min(int(cursor)+pageSize, len(elements))
+		next := uint64(end)
+		if end == len(elements) {
+			next = 0
+		}
+		return elements[cursor:end], next, nil
+	}
+}
+
+func TestScanElements(t *testing.T) {
+	elements := make([]string, 25)
+	for i := range elements {
+		elements[i] = "member:" + strconv.Itoa(i)
+	}
+
+	for _, sequential := range []bool{false, true} {
+		t.Run(fmt.Sprintf("sequential=%t", sequential), func(t *testing.T) {
+			exp := &RedisExporter{sequentialElementScans: sequential}
+
+			var seen []string
+			err := exp.scanElements(pagedScan(elements, 10), func(batch []string) error {
+				seen = append(seen, batch...)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("scanElements failed: %v", err)
+			}
+			if strings.Join(seen, ",") != strings.Join(elements, ",") {
+				t.Errorf("Expected elements in order, got %v", seen)
+			}
+		})
+	}
+}
+
+func TestScanElementsPrefetch(t *testing.T) {
+	exp := &RedisExporter{}
+	elements := []string{"a", "b", "c", "d"}
+	scan := pagedScan(elements, 2)
+
+	// The first batch is only written once the second is being fetched
+	fetching := make(chan uint64, 2)
+	err := exp.scanElements(func(cursor uint64) ([]string, uint64, error) {
+		fetching <- cursor
+		return scan(cursor)
+	}, func(batch []string) error {
+		if batch[0] != "a" {
+			return nil
+		}
+		<-fetching
+		select {
+		case cursor := <-fetching:
+			if cursor != 2 {
+				t.Errorf("Expected prefetch of cursor 2, got %d", cursor)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("Expected the next batch to be fetched while writing the first")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("scanElements failed: %v", err)
+	}
+}
+
+func TestScanElementsErrors(t *testing.T) {
+	elements := []string{"a", "b", "c", "d", "e", "f"}
+	errScan := errors.New("scan failed")
+	errWrite := errors.New("write failed")
+
+	for _, sequential := range []bool{false, true} {
+		t.Run(fmt.Sprintf("sequential=%t", sequential), func(t *testing.T) {
+			exp := &RedisExporter{sequentialElementScans: sequential}
+
+			// A failed step ends the loop after the batches before it
+			batches := 0
+			err := exp.scanElements(func(cursor uint64) ([]string, uint64, error) {
+				if cursor >= 2 {
+					return nil, 0, errScan
+				}
+				return pagedScan(elements, 2)(cursor)
+			}, func(batch []string) error {
+				batches++
+				return nil
+			})
+			if !errors.Is(err, errScan) {
+				t.Errorf("Expected scan error, got %v", err)
+			}
+			if batches != 1 {
+				t.Errorf("Expected 1 batch before the failed step, got %d", batches)
+			}
+
+			// A failed write stops the loop
+			batches = 0
+			err = exp.scanElements(pagedScan(elements, 2), func(batch []string) error {
+				batches++
+				return errWrite
+			})
+			if !errors.Is(err, errWrite) {
+				t.Errorf("Expected write error, got %v", err)
+			}
+			if batches != 1 {
+				t.Errorf("Expected handler to stop after 1 batch, got %d", batches)
+			}
+		})
+	}
+}
+
+// newPagedHashClient returns a client for a server whose HSCAN pages through
+// fields synthetic hash fields 1000 at a time after latency, like a real
+// server across a network. miniredis replies to HSCAN with the whole hash.
+func newPagedHashClient(tb testing.TB, fields int, latency time.Duration) *redis.Client {
+	tb.Helper()
+
+	srv, err := server.NewServer("127.0.0.1:0")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(srv.Close)
+
+	err = srv.Register("HSCAN", func(c *server.Peer, cmd string, args []string) {
+		time.Sleep(latency)
+		cursor, _ := strconv.Atoi(args[1])
+		end := min(cursor+1000, fields)
+		next := end
+		if end == fields {
+			next = 0
+		}
+
+		c.WriteLen(2)
+		c.WriteBulk(strconv.Itoa(next))
+		c.WriteLen(2 * (end - cursor))
+		for i := cursor; i < end; i++ {
+			c.WriteBulk("field:" + strconv.Itoa(i))
+			c.WriteBulk("value:" + strconv.Itoa(i))
+		}
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr().String()})
+	tb.Cleanup(func() {
+		_ = client.Close()
+	})
+	return client
+}
+
+func TestExportPagedHash(t *testing.T) {
+	const fields = 2500
+
+	for _, sequential := range []bool{false, true} {
+		t.Run(fmt.Sprintf("sequential=%t", sequential), func(t *testing.T) {
+			exp, _ := newTestExporter(t, RedisExporterOptions{SequentialElementScans: sequential, MaxRecordsPerFile: 10000})
+			exp.client = newPagedHashClient(t, fields, 0)
+			exp.httlUnsupported = true
+
+			size, err := exp.exportKeyData("big", "hash", 0)
+			if err != nil {
+				t.Fatalf("exportKeyData failed: %v", err)
+			}
+			if err := exp.fileManager.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			rows := readCSVRows(t, exp.fileManager.config.OutputDir)[1:]
+			if len(rows) != fields {
+				t.Fatalf("Expected %d field records, got %d", fields, len(rows))
+			}
+			expectedSize := int64(0)
+			for i, row := range rows {
+				if want := "big:field:field:" + strconv.Itoa(i); row[0] != want {
+					t.Fatalf("Record %d: expected key %s, got %s", i, want, row[0])
+				}
+				expectedSize += int64(len(row[0]) - len("big:field:") + len(row[2]))
+			}
+			if size != expectedSize {
+				t.Errorf("Expected size %d, got %d", expectedSize, size)
+			}
+		})
+	}
+}
+
+// BenchmarkExportLargeHash exports a 1M field hash over a link with 1ms of
+// latency per HSCAN step, with and without prefetching the next step
+func BenchmarkExportLargeHash(b *testing.B) {
+	for _, sequential := range []bool{true, false} {
+		b.Run(fmt.Sprintf("sequential=%t", sequential), func(b *testing.B) {
+			exp, _ := newTestExporter(b, RedisExporterOptions{SequentialElementScans: sequential, MaxRecordsPerFile: 1000000})
+			exp.client = newPagedHashClient(b, 1000000, time.Millisecond)
+			exp.httlUnsupported = true
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := exp.exportKeyData("big", "hash", 0); err != nil {
+					b.Fatalf("exportKeyData failed: %v", err)
+				}
+			}
+			b.StopTimer()
+
+			if err := exp.fileManager.Close(); err != nil {
+				b.Fatal(err)
+			}
+		})
+	}
+}
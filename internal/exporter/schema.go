@@ -0,0 +1,190 @@
+package exporter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// column describes a single output column shared by every write path so the
+// CSV headers, DuckDB table and inserts always agree on the schema
+type column struct {
+	Name    string
+	SQLType string
+	value   func(w *partitionWriter, record *RedisRecord) interface{}
+}
+
+// columns returns the active schema for this file manager's configuration
+func (fm *FileManager) columns() []column {
+	cols := []column{
+		{Name: "key", SQLType: "VARCHAR", value: func(_ *partitionWriter, r *RedisRecord) interface{} { return encodeKey(fm.config.KeyEncoding, r.Key) }},
+		{Name: "type", SQLType: "VARCHAR", value: func(_ *partitionWriter, r *RedisRecord) interface{} { return r.Type }},
+	}
+
+	// Metadata-only exports carry the size estimate as a number instead of a value
+	if fm.config.OmitValue {
+		cols = append(cols, column{Name: "size_estimate", SQLType: "BIGINT", value: func(_ *partitionWriter, r *RedisRecord) interface{} { return r.SizeEstimate }})
+	} else {
+		cols = append(cols, column{Name: "value", SQLType: "VARCHAR", value: func(_ *partitionWriter, r *RedisRecord) interface{} { return r.Value }})
+		if fm.config.MaxValueBytes > 0 {
+			cols = append(cols, column{Name: "value_truncated", SQLType: "BOOLEAN", value: func(_ *partitionWriter, r *RedisRecord) interface{} { return r.ValueTruncated }})
+		}
+		// full_length is NULL on rows that were not cut
+		if fm.config.TruncateElements {
+			cols = append(cols,
+				column{Name: "elements_truncated", SQLType: "BOOLEAN", value: func(_ *partitionWriter, r *RedisRecord) interface{} { return r.FullLength > 0 }},
+				column{Name: "full_length", SQLType: "BIGINT", value: func(_ *partitionWriter, r *RedisRecord) interface{} {
+					if r.FullLength == 0 {
+						return nil
+					}
+					return r.FullLength
+				}},
+			)
+		}
+	}
+
+	// NULL for strings and other types without elements
+	if fm.config.IncludeCardinality {
+		cols = append(cols, column{Name: "cardinality", SQLType: "BIGINT", value: func(_ *partitionWriter, r *RedisRecord) interface{} {
+			if r.Cardinality == noCardinality {
+				return nil
+			}
+			return r.Cardinality
+		}})
+	}
+
+	// NULL when the encoding could not be read
+	if fm.config.IncludeEncoding {
+		cols = append(cols, column{Name: "encoding", SQLType: "VARCHAR", value: func(_ *partitionWriter, r *RedisRecord) interface{} {
+			if r.Encoding == "" {
+				return nil
+			}
+			return r.Encoding
+		}})
+	}
+
+	// NULL when the idle time could not be read
+	if fm.config.IncludeIdleTime {
+		cols = append(cols, column{Name: "idle_seconds", SQLType: "BIGINT", value: func(_ *partitionWriter, r *RedisRecord) interface{} {
+			if r.IdleSeconds < 0 {
+				return nil
+			}
+			return r.IdleSeconds
+		}})
+	}
+
+	cols = append(cols, column{Name: "ttl_seconds", SQLType: "BIGINT", value: func(_ *partitionWriter, r *RedisRecord) interface{} { return r.TTLSeconds }})
+
+	// NULL without an expiry
+	if fm.config.IncludeExpiresAt {
+		cols = append(cols, column{Name: "expires_at", SQLType: "VARCHAR", value: func(_ *partitionWriter, r *RedisRecord) interface{} {
+			if r.ExpiresAt <= 0 || r.TTLSeconds == ttlNoExpiry || r.TTLSeconds == ttlMissing {
+				return nil
+			}
+			return time.UnixMilli(r.ExpiresAt).UTC().Format(time.RFC3339)
+		}})
+	}
+
+	cols = append(cols, column{Name: "exported_at", SQLType: "VARCHAR", value: func(_ *partitionWriter, r *RedisRecord) interface{} { return r.ExportedAt }})
+
+	if !fm.config.OmitPartitionID {
+		cols = append(cols, column{Name: "partition_id", SQLType: "INTEGER", value: func(w *partitionWriter, _ *RedisRecord) interface{} { return w.partitionID }})
+	}
+
+	if fm.config.IncludeSlot {
+		cols = append(cols, column{Name: "slot", SQLType: "INTEGER", value: func(_ *partitionWriter, r *RedisRecord) interface{} { return r.Slot }})
+	}
+
+	// Same values as the year=/month=/day=/hour= directories the record lands in
+	if fm.config.MaterializePartitionCols {
+		for _, part := range []struct{ name, layout string }{
+			{"year", "2006"}, {"month", "01"}, {"day", "02"}, {"hour", "15"},
+		} {
+			layout := part.layout
+			cols = append(cols, column{Name: part.name, SQLType: "VARCHAR", value: func(w *partitionWriter, _ *RedisRecord) interface{} {
+				return w.createdAt.Format(layout)
+			}})
+		}
+	}
+
+	if fm.config.IncludeParentKey {
+		cols = append(cols, column{Name: "parent_key", SQLType: "VARCHAR", value: func(_ *partitionWriter, r *RedisRecord) interface{} {
+			return encodeKey(fm.config.KeyEncoding, r.ParentKey)
+		}})
+	}
+
+	if fm.config.IncludeSource {
+		cols = append(cols, column{Name: "source", SQLType: "VARCHAR", value: func(_ *partitionWriter, _ *RedisRecord) interface{} { return fm.source }})
+	}
+
+	if fm.config.IncludeRawDump {
+		cols = append(cols, column{Name: "raw_dump", SQLType: "VARCHAR", value: func(_ *partitionWriter, r *RedisRecord) interface{} { return r.RawDump }})
+	}
+
+	return cols
+}
+
+// columnNames returns the names of the active columns in order
+func (fm *FileManager) columnNames() []string {
+	cols := fm.columns()
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// createTableSQL builds the DuckDB CREATE TABLE statement for the active schema
+func (fm *FileManager) createTableSQL() string {
+	cols := fm.columns()
+	defs := make([]string, len(cols))
+	for i, col := range cols {
+		defs[i] = fmt.Sprintf("%s %s", col.Name, col.SQLType)
+	}
+	return fmt.Sprintf("CREATE TABLE %s (%s)", fm.tableName, strings.Join(defs, ", "))
+}
+
+// insertSQL builds the parameterized DuckDB INSERT statement for the active schema
+func (fm *FileManager) insertSQL() string {
+	names := fm.columnNames()
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ")
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", fm.tableName, strings.Join(names, ", "), placeholders)
+}
+
+// recordValues returns the record's values in column order
+func (fm *FileManager) recordValues(w *partitionWriter, record *RedisRecord) []interface{} {
+	cols := fm.columns()
+	values := make([]interface{}, len(cols))
+	for i, col := range cols {
+		values[i] = col.value(w, record)
+	}
+	return values
+}
+
+// csvRow returns the record's values in column order formatted for CSV
+func (fm *FileManager) csvRow(w *partitionWriter, record *RedisRecord) []string {
+	values := fm.recordValues(w, record)
+	row := make([]string, len(values))
+	for i, value := range values {
+		row[i] = formatCSVValue(value)
+	}
+	return row
+}
+
+func formatCSVValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
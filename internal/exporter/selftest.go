@@ -0,0 +1,245 @@
+package exporter
+
+import (
+	"bufio"
+	"compress/gzip"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cameronnewman/redis-dumper/recordpb"
+	"google.golang.org/protobuf/encoding/protodelim"
+)
+
+const (
+	// selfTestRecords is written per case, enough for several rotations
+	selfTestRecords    = 250
+	selfTestMaxRecords = 100
+)
+
+// selfTestValues exercise CSV quoting and non-ASCII text
+var selfTestValues = []string{"plain", "with,comma", "with \"quotes\"", "multi\nline", "ünïcødé"}
+
+// SelfTest writes synthetic records through a FileManager in every format,
+// plus the configured format and compression, rotating several times, and
+// reads the output back. It needs no Redis and checks that DuckDB and the
+// filesystem under outputDir work. Each case prints PASS or FAIL.
+func SelfTest(outputDir, format, compression string) error {
+	// Scratch files live next to the real output so its filesystem is tested
+	parent := outputDir
+	if isFIFO(outputDir) {
+		parent = os.TempDir()
+	} else if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	scratch, err := os.MkdirTemp(parent, ".redis_dumper_selftest")
+	if err != nil {
+		return fmt.Errorf("failed to create self-test directory: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(scratch); err != nil {
+			fmt.Printf("Warning: failed to remove self-test directory: %v\n", err)
+		}
+	}()
+
+	configured := OutputFormat(format)
+	if configured == "" {
+		configured = FormatCSV
+	}
+	cases := []StorageConfig{
+		{Format: FormatCSV},
+		{Format: FormatParquet},
+	}
+	if compression != "" || configured == FormatProto {
+		cases = append(cases, StorageConfig{Format: configured, Compression: Compression(compression)})
+	}
+
+	failed := 0
+	for i, config := range cases {
+		config.OutputDir = filepath.Join(scratch, fmt.Sprintf("case_%d", i))
+		config.MaxRecords = selfTestMaxRecords
+
+		name := string(config.Format)
+		if config.Compression != CompressionDefault {
+			name += "+" + string(config.Compression)
+		}
+
+		if err := runSelfTestCase(config); err != nil {
+			fmt.Printf("FAIL %s: %v\n", name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("PASS %s: wrote and read back %d records\n", name, selfTestRecords)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d self-test cases failed", failed, len(cases))
+	}
+	return nil
+}
+
+// runSelfTestCase writes, rotates and reads back one output configuration
+func runSelfTestCase(config StorageConfig) error {
+	if err := validateStorageConfig(config); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(config.OutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	fm := NewFileManager(config)
+	for i := 0; i < selfTestRecords; i++ {
+		record := &RedisRecord{
+			Key:        fmt.Sprintf("selftest:%d", i),
+			Type:       "string",
+			Value:      selfTestValues[i%len(selfTestValues)],
+			TTLSeconds: ttlNoExpiry,
+			ExportedAt: "2024-01-15T14:30:00Z",
+			Slot:       keySlot(fmt.Sprintf("selftest:%d", i)),
+		}
+		if err := fm.WriteRecord(record); err != nil {
+			_ = fm.Close()
+			return fmt.Errorf("failed to write record: %w", err)
+		}
+	}
+	if err := fm.Close(); err != nil {
+		return fmt.Errorf("failed to finish files: %w", err)
+	}
+
+	metadata, err := os.ReadFile(filepath.Join(config.OutputDir, "export_metadata.json"))
+	if err != nil {
+		return fmt.Errorf("failed to read export metadata: %w", err)
+	}
+	var parsed ExportMetadata
+	if err := json.Unmarshal(metadata, &parsed); err != nil {
+		return fmt.Errorf("failed to parse export metadata: %w", err)
+	}
+	expectedFiles := (selfTestRecords + selfTestMaxRecords - 1) / selfTestMaxRecords
+	if len(parsed.Partitions) != expectedFiles {
+		return fmt.Errorf("expected %d partitions after rotation, metadata lists %d", expectedFiles, len(parsed.Partitions))
+	}
+
+	var count int64
+	switch config.Format {
+	case FormatCSV:
+		count, err = countSelfTestCSV(config.OutputDir)
+	case FormatParquet:
+		count, err = countSelfTestParquet(config.OutputDir)
+	case FormatProto:
+		count, err = countSelfTestProto(config.OutputDir)
+	}
+	if err != nil {
+		return err
+	}
+	if count != selfTestRecords {
+		return fmt.Errorf("wrote %d records but read back %d", selfTestRecords, count)
+	}
+	return nil
+}
+
+// countSelfTestCSV parses every CSV file under dir and counts the data rows
+func countSelfTestCSV(dir string) (int64, error) {
+	var count int64
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.Contains(d.Name(), ".csv") {
+			return err
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+
+		var reader io.Reader = file
+		if strings.HasSuffix(path, ".gz") {
+			gz, err := gzip.NewReader(file)
+			if err != nil {
+				return fmt.Errorf("failed to open %s: %w", path, err)
+			}
+			reader = gz
+		}
+
+		rows, err := csv.NewReader(reader).ReadAll()
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if len(rows) == 0 {
+			return fmt.Errorf("%s has no header", path)
+		}
+		count += int64(len(rows) - 1)
+		return nil
+	})
+	return count, err
+}
+
+// countSelfTestProto decodes every protobuf file under dir and counts the messages
+func countSelfTestProto(dir string) (int64, error) {
+	var count int64
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.Contains(d.Name(), ".pb") {
+			return err
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+
+		var reader io.Reader = file
+		if strings.HasSuffix(path, ".gz") {
+			gz, err := gzip.NewReader(file)
+			if err != nil {
+				return fmt.Errorf("failed to open %s: %w", path, err)
+			}
+			reader = gz
+		}
+
+		buffered := bufio.NewReader(reader)
+		for {
+			var msg recordpb.RedisRecord
+			if err := protodelim.UnmarshalFrom(buffered, &msg); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return fmt.Errorf("failed to decode %s: %w", path, err)
+			}
+			count++
+		}
+	})
+	return count, err
+}
+
+// countSelfTestParquet counts the rows of every Parquet file under dir with DuckDB
+func countSelfTestParquet(dir string) (int64, error) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		return 0, fmt.Errorf("failed to open DuckDB: %w", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var count int64
+	query := fmt.Sprintf("SELECT COUNT(*) FROM read_parquet('%s', hive_partitioning=false)",
+		filepath.Join(dir, "**", "*.parquet"))
+	if err := db.QueryRow(query).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to read Parquet files: %w", err)
+	}
+	if count == 0 {
+		return 0, errors.New("no Parquet rows found")
+	}
+	return count, nil
+}
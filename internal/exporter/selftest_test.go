@@ -0,0 +1,30 @@
+package exporter
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	outputDir := t.TempDir()
+	if err := SelfTest(outputDir, "csv", "gzip"); err != nil {
+		t.Fatalf("Self-test failed: %v", err)
+	}
+
+	// The scratch directory is removed afterwards
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected an empty output directory, got %d entries", len(entries))
+	}
+}
+
+func TestSelfTestReportsFailures(t *testing.T) {
+	err := SelfTest(t.TempDir(), "csv", "zstd")
+	if err == nil || !strings.Contains(err.Error(), "1 of 3") {
+		t.Errorf("Expected the unsupported compression case to fail, got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package exporter
+
+import (
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Sentinel promotes a replica only after the master has been down for
+	// down-after-milliseconds, so commands retry long enough to reach the new one
+	sentinelMaxRetries      = 20
+	sentinelMaxRetryBackoff = 3 * time.Second
+)
+
+// failoverOptions builds options for a client that asks the sentinels at
+// addrs for the current address of masterName and reconnects after a
+// failover. Credentials, the database and TLS are taken from opt.
+func failoverOptions(opt *redis.Options, masterName string, addrs []string, sentinelPassword string) (*redis.FailoverOptions, error) {
+	if masterName == "" || len(addrs) == 0 {
+		return nil, errors.New("SENTINEL_MASTER_NAME and SENTINEL_ADDRS must be set together")
+	}
+
+	return &redis.FailoverOptions{
+		MasterName:       masterName,
+		SentinelAddrs:    addrs,
+		SentinelPassword: sentinelPassword,
+
+		Username:     opt.Username,
+		Password:     opt.Password,
+		DB:           opt.DB,
+		TLSConfig:    opt.TLSConfig,
+		OnConnect:    opt.OnConnect,
+		PoolSize:     opt.PoolSize,
+		MinIdleConns: opt.MinIdleConns,
+		DialTimeout:  opt.DialTimeout,
+		ReadTimeout:  opt.ReadTimeout,
+		WriteTimeout: opt.WriteTimeout,
+
+		Protocol:              opt.Protocol,
+		ContextTimeoutEnabled: opt.ContextTimeoutEnabled,
+
+		CredentialsProviderContext: opt.CredentialsProviderContext,
+		ConnMaxLifetime:            opt.ConnMaxLifetime,
+
+		StreamingCredentialsProvider: opt.StreamingCredentialsProvider,
+		Dialer:                       opt.Dialer,
+
+		MaxRetries:      sentinelMaxRetries,
+		MaxRetryBackoff: sentinelMaxRetryBackoff,
+	}, nil
+}
@@ -0,0 +1,62 @@
+package exporter
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// filterBySize drops keys whose MEMORY USAGE is below minSizeBytes, returning
+// the remaining keys and the number dropped. Keys whose size cannot be read
+// are kept so a failing MEMORY USAGE never silently shrinks the export.
+func (re *RedisExporter) filterBySize(keys []string) ([]string, int) {
+	if re.minSizeBytes <= 0 || re.memoryUsageUnsupported {
+		return keys, 0
+	}
+
+	pipe := re.client.Pipeline()
+	usages := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		usages[i] = pipe.MemoryUsage(re.ctx, key)
+	}
+
+	ctx, cancel := re.batchContext(re.ctx)
+	defer cancel()
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		log.Printf("Pipeline error: %v", err)
+	}
+
+	kept := keys[:0]
+	dropped := 0
+	for i, key := range keys {
+		size, err := usages[i].Result()
+		switch {
+		case err == redis.Nil:
+			// Deleted since SCAN returned it
+			dropped++
+		case isUnknownCommandError(err):
+			re.memoryUsageUnsupported = true
+			kept = append(kept, key)
+		case err != nil:
+			re.verbosity.debugf("MEMORY USAGE failed for %s, keeping it: %v\n", key, err)
+			kept = append(kept, key)
+		case size < re.minSizeBytes:
+			dropped++
+		default:
+			kept = append(kept, key)
+		}
+	}
+
+	if re.memoryUsageUnsupported {
+		fmt.Printf("Warning: MEMORY USAGE is not supported by the server; MIN_SIZE_BYTES keeps every remaining key\n")
+	}
+	return kept, dropped
+}
+
+// reportSmallKeys prints how many keys MinSizeBytes skipped
+func (re *RedisExporter) reportSmallKeys() {
+	if re.minSizeBytes > 0 {
+		fmt.Printf("Skipped %d keys smaller than %d bytes\n", re.fileManager.metadata.SmallKeys, re.minSizeBytes)
+	}
+}
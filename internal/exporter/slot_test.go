@@ -0,0 +1,38 @@
+package exporter
+
+import "testing"
+
+func TestKeySlot(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected int
+	}{
+		// Values verified against CLUSTER KEYSLOT
+		{key: "123456789", expected: 12739},
+		{key: "foo", expected: 12182},
+		{key: "bar", expected: 5061},
+		{key: "{user1000}.following", expected: 3443},
+		{key: "{user1000}.followers", expected: 3443},
+		{key: "user1000", expected: 3443},
+		// Empty hash tags are ignored and the whole key is hashed
+		{key: "foo{}{bar}", expected: int(crc16("foo{}{bar}") % clusterSlots)},
+		// Only the first '{' and the next '}' delimit the tag
+		{key: "foo{{bar}}zap", expected: int(crc16("{bar") % clusterSlots)},
+		{key: "", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if actual := keySlot(tt.key); actual != tt.expected {
+				t.Errorf("Expected slot %d for %q, got %d", tt.expected, tt.key, actual)
+			}
+		})
+	}
+}
+
+func TestCRC16(t *testing.T) {
+	// Reference check value for CRC16-XMODEM
+	if actual := crc16("123456789"); actual != 0x31C3 {
+		t.Errorf("Expected 0x31C3, got 0x%04X", actual)
+	}
+}
@@ -0,0 +1,136 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// exportSource is one of several independent servers exported in turn into
+// the same output
+type exportSource struct {
+	name   string
+	client redis.UniversalClient
+	db     int
+	// server is nil when INFO was refused
+	server *ServerInfo
+}
+
+// SourceInfo identifies a server of a multi-source export
+type SourceInfo struct {
+	Name   string      `json:"name"`
+	Server *ServerInfo `json:"server,omitempty"`
+}
+
+// sourceName identifies a server in the source column, without credentials
+func sourceName(opt *redis.Options) string {
+	return opt.Addr + "/" + strconv.Itoa(opt.DB)
+}
+
+// sourceOptions derives the options of another source from those of the
+// first: only the address, credentials and database come from rawURL, so
+// pool, timeout, TLS and authentication settings are shared
+func sourceOptions(first *redis.Options, rawURL string, dial dialFunc) (*redis.Options, error) {
+	parsed, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+	}
+
+	opt := *first
+	opt.Addr = parsed.Addr
+	opt.Username = parsed.Username
+	opt.Password = parsed.Password
+	opt.DB = parsed.DB
+	if opt.TLSConfig != nil {
+		opt.TLSConfig = opt.TLSConfig.Clone()
+		if host, _, err := net.SplitHostPort(opt.Addr); err == nil {
+			opt.TLSConfig.ServerName = host
+		}
+	}
+	if dial != nil {
+		opt.Dialer = dial
+		if opt.TLSConfig != nil {
+			opt.Dialer = dialTLS(dial, opt.TLSConfig)
+		}
+	}
+	return &opt, nil
+}
+
+// scanTarget is a node SCAN visits and the index of the source its keys are
+// read from
+type scanTarget struct {
+	node   redis.Cmdable
+	source int
+}
+
+// scanTargets lists the nodes of every source in export order
+func (re *RedisExporter) scanTargets(ctx context.Context) ([]scanTarget, error) {
+	if len(re.sources) == 0 {
+		nodes, err := re.scanNodes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		targets := make([]scanTarget, len(nodes))
+		for i, node := range nodes {
+			targets[i] = scanTarget{node: node}
+		}
+		return targets, nil
+	}
+
+	targets := make([]scanTarget, len(re.sources))
+	for i, source := range re.sources {
+		targets[i] = scanTarget{node: source.client, source: i}
+	}
+	return targets, nil
+}
+
+// useSource switches key reads to source i and stamps its name on the
+// records that follow. State learned about keys or the server of the
+// previous source is dropped.
+func (re *RedisExporter) useSource(i int) {
+	if len(re.sources) == 0 || i == re.currentSource {
+		return
+	}
+	source := re.sources[i]
+	re.currentSource = i
+	re.client = source.client
+	re.db = source.db
+	re.typeCache.clear()
+	re.applyServerSupport(source.server)
+	re.fileManager.SetSource(source.name)
+	re.verbosity.infof("Exporting source %s (%d of %d)\n", source.name, i+1, len(re.sources))
+}
+
+// requireSingleSource rejects commands that read a single server
+func (re *RedisExporter) requireSingleSource(command string) error {
+	if len(re.sources) > 0 {
+		return fmt.Errorf("%s is not supported with several REDIS_URL sources", command)
+	}
+	return nil
+}
+
+// validateSourceOptions rejects settings that need a single server, or a
+// single keyspace, alongside several sources
+func validateSourceOptions(opts RedisExporterOptions) error {
+	if len(opts.RedisSourceURLs) == 0 {
+		return nil
+	}
+	unsupported := map[string]bool{
+		"REDIS_CLUSTER_URLS":   len(opts.RedisClusterURLs) > 0,
+		"SENTINEL_MASTER_NAME": opts.SentinelMasterName != "" || len(opts.SentinelAddrs) > 0,
+		"RDB_FILE":             opts.RDBFile != "",
+		"COMPLETED_KEYS_LOG":   opts.CompletedKeysLog != "",
+		"SNAPSHOT_WAIT":        opts.SnapshotWait,
+		"INCLUDE_ACL":          opts.IncludeACL,
+		"KEYS_FILE":            opts.KeysFile != "",
+	}
+	for _, name := range []string{"REDIS_CLUSTER_URLS", "SENTINEL_MASTER_NAME", "RDB_FILE", "COMPLETED_KEYS_LOG", "SNAPSHOT_WAIT", "INCLUDE_ACL", "KEYS_FILE"} {
+		if unsupported[name] {
+			return fmt.Errorf("%s cannot be combined with several REDIS_URL sources", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package exporter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestExportSeveralSources(t *testing.T) {
+	first := miniredis.RunT(t)
+	second := miniredis.RunT(t)
+	first.Set("shared", "one")
+	first.Set("only:first", "a")
+	second.Set("shared", "three")
+	second.HSet("only:second", "field", "b")
+
+	outputDir := t.TempDir()
+	exp, err := NewRedisExporter(RedisExporterOptions{
+		RedisURL:     "redis://" + first.Addr() + "/0",
+		OutputDir:    outputDir,
+		OutputFormat: "csv",
+		RedisSourceURLs: []string{
+			"redis://" + first.Addr() + "/0",
+			"redis://" + second.Addr() + "/0",
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewRedisExporter failed: %v", err)
+	}
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	rows := readCSVRows(t, outputDir)
+	header := rows[0]
+	keyCol, valueCol, sourceCol := -1, -1, -1
+	for i, name := range header {
+		switch name {
+		case "key":
+			keyCol = i
+		case "value":
+			valueCol = i
+		case "source":
+			sourceCol = i
+		}
+	}
+	if sourceCol < 0 {
+		t.Fatalf("Expected a source column, got %v", header)
+	}
+
+	// The same key on both servers is kept once per source; strings are
+	// told apart by their size
+	got := map[string]string{}
+	for _, row := range rows[1:] {
+		if row[0] == "key" {
+			continue
+		}
+		got[row[keyCol]+"@"+row[sourceCol]] = row[valueCol]
+	}
+	firstName := first.Addr() + "/0"
+	secondName := second.Addr() + "/0"
+	for key, value := range map[string]string{
+		"shared@" + firstName:     "size=3",
+		"shared@" + secondName:    "size=5",
+		"only:first@" + firstName: "size=1",
+	} {
+		if got[key] != value {
+			t.Errorf("Expected %s = %q, got %q", key, value, got[key])
+		}
+	}
+	if _, ok := got["only:second:field:field@"+secondName]; !ok {
+		t.Errorf("Expected the hash field of the second source, got %v", got)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "export_metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var metadata ExportMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		t.Fatal(err)
+	}
+	if len(metadata.Sources) != 2 || metadata.Sources[0].Name != firstName || metadata.Sources[1].Name != secondName {
+		t.Errorf("Expected both sources in the metadata, got %+v", metadata.Sources)
+	}
+}
+
+func TestSingleSourceOmitsSourceColumn(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{})
+	mr.Set("greeting", "hello")
+
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	for _, name := range readCSVRows(t, exp.fileManager.config.OutputDir)[0] {
+		if name == "source" {
+			t.Error("Expected no source column for a single REDIS_URL")
+		}
+	}
+}
+
+func TestSeveralSourcesRejections(t *testing.T) {
+	mr := miniredis.RunT(t)
+	url := "redis://" + mr.Addr() + "/0"
+	base := RedisExporterOptions{
+		RedisURL:        url,
+		OutputDir:       t.TempDir(),
+		OutputFormat:    "csv",
+		RedisSourceURLs: []string{url, url},
+	}
+
+	options := base
+	options.SnapshotWait = true
+	if _, err := NewRedisExporter(options); err == nil || !strings.Contains(err.Error(), "SNAPSHOT_WAIT") {
+		t.Errorf("Expected SNAPSHOT_WAIT to be rejected, got %v", err)
+	}
+
+	exp, err := NewRedisExporter(base)
+	if err != nil {
+		t.Fatalf("NewRedisExporter failed: %v", err)
+	}
+	defer exp.Close()
+	if _, err := exp.Estimate("*"); err == nil || !strings.Contains(err.Error(), "estimate") {
+		t.Errorf("Expected estimate to be rejected, got %v", err)
+	}
+}
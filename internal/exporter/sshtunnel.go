@@ -0,0 +1,188 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshDialTimeout bounds connecting and authenticating to the SSH host
+const sshDialTimeout = 10 * time.Second
+
+// sshTunnel dials Redis through an SSH connection to a bastion host, which
+// forwards each connection with direct-tcpip like ssh -L does
+type sshTunnel struct {
+	addr   string
+	config *ssh.ClientConfig
+	// bastionDial reaches the SSH host itself, through a proxy if one is set
+	bastionDial dialFunc
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+// newSSHTunnel connects to host, "host" or "host:port", as user with the
+// private key in keyFile. The host key is checked against knownHostsFile, or
+// ~/.ssh/known_hosts when that exists, unless skipHostKey is set. A nil dial
+// connects directly.
+func newSSHTunnel(host, user, keyFile, knownHostsFile string, skipHostKey bool, dial dialFunc) (*sshTunnel, error) {
+	if user == "" || keyFile == "" {
+		return nil, errors.New("SSH_USER and SSH_KEY are required with SSH_HOST")
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "22")
+	}
+
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH key: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH key (passphrase-protected keys are not supported): %w", err)
+	}
+
+	hostKeyCallback, err := sshHostKeyCallback(knownHostsFile, skipHostKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if dial == nil {
+		dial = directDialer.DialContext
+	}
+	t := &sshTunnel{
+		addr: host,
+		config: &ssh.ClientConfig{
+			User:            user,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: hostKeyCallback,
+		},
+		bastionDial: dial,
+	}
+	if t.client, err = t.connect(); err != nil {
+		return nil, fmt.Errorf("failed to connect to SSH host %s: %w", t.addr, err)
+	}
+	return t, nil
+}
+
+// connect dials the SSH host and authenticates within sshDialTimeout
+func (t *sshTunnel) connect() (*ssh.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sshDialTimeout)
+	defer cancel()
+
+	conn, err := t.bastionDial(ctx, "tcp", t.addr)
+	if err != nil {
+		return nil, err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	sshConn, channels, requests, err := ssh.NewClientConn(conn, t.addr, t.config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return ssh.NewClient(sshConn, channels, requests), nil
+}
+
+// sshHostKeyCallback verifies host keys with a known_hosts file, failing when
+// there is none. The tunnel carries credentials and data, so any host key is
+// only accepted when skip is set explicitly.
+func sshHostKeyCallback(knownHostsFile string, skip bool) (ssh.HostKeyCallback, error) {
+	if skip {
+		fmt.Println("Warning: SSH_INSECURE_SKIP_HOST_KEY is set; the SSH host key will not be verified")
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	if knownHostsFile == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			defaultFile := filepath.Join(home, ".ssh", "known_hosts")
+			if _, err := os.Stat(defaultFile); err == nil {
+				knownHostsFile = defaultFile
+			}
+		}
+	}
+	if knownHostsFile == "" {
+		return nil, errors.New("no known_hosts file to verify the SSH host key; set SSH_KNOWN_HOSTS, or SSH_INSECURE_SKIP_HOST_KEY=true to skip verification")
+	}
+
+	callback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SSH known hosts: %w", err)
+	}
+	return callback, nil
+}
+
+// dial opens a forwarded connection to addr as seen from the SSH host. A
+// dropped SSH connection is re-established once, so a bastion restart does
+// not end a long export.
+func (t *sshTunnel) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	t.mu.Lock()
+	client := t.client
+	t.mu.Unlock()
+
+	conn, err := client.DialContext(ctx, network, addr)
+	// The bastion refusing the forward means the SSH connection itself is up
+	var refused *ssh.OpenChannelError
+	if err != nil && ctx.Err() == nil && !errors.As(err, &refused) {
+		client, reconnectErr := t.reconnect(client)
+		if reconnectErr != nil {
+			return nil, fmt.Errorf("failed to dial %s through SSH (%v): %w", addr, err, reconnectErr)
+		}
+		conn, err = client.DialContext(ctx, network, addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pipeConn(conn), nil
+}
+
+// pipeConn fronts an SSH channel, which has no deadlines, with a pipe that
+// does, so read and write timeouts still apply to tunneled connections
+func pipeConn(channel net.Conn) net.Conn {
+	local, remote := net.Pipe()
+	go func() {
+		io.Copy(channel, remote)
+		channel.Close()
+	}()
+	go func() {
+		io.Copy(remote, channel)
+		remote.Close()
+	}()
+	return local
+}
+
+// reconnect replaces the failed SSH connection, unless another dial already has
+func (t *sshTunnel) reconnect(failed *ssh.Client) (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client != failed {
+		return t.client, nil
+	}
+	client, err := t.connect()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconnect to SSH host %s: %w", t.addr, err)
+	}
+	failed.Close()
+	t.client = client
+	return client, nil
+}
+
+// close ends the SSH connection and every connection forwarded through it
+func (t *sshTunnel) close() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.client.Close()
+}
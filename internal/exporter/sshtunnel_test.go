@@ -0,0 +1,238 @@
+package exporter
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testSSHServer is a bastion that accepts one client key and forwards
+// direct-tcpip channels
+type testSSHServer struct {
+	addr      string
+	hostKey   ssh.PublicKey
+	forwarded atomic.Int64
+}
+
+// startTestSSHServer returns the bastion and the file of the key it accepts
+func startTestSSHServer(t *testing.T) (*testSSHServer, string) {
+	t.Helper()
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientPub, clientPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorized, err := ssh.NewPublicKey(clientPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == "tunnel" && string(key.Marshal()) == string(authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, io.EOF
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &testSSHServer{addr: listener.Addr().String(), hostKey: hostSigner.PublicKey()}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn, config)
+		}
+	}()
+	return server, keyFile
+}
+
+func (s *testSSHServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		var target struct {
+			Host       string
+			Port       uint32
+			OriginHost string
+			OriginPort uint32
+		}
+		if newChannel.ChannelType() != "direct-tcpip" || ssh.Unmarshal(newChannel.ExtraData(), &target) != nil {
+			newChannel.Reject(ssh.UnknownChannelType, "only direct-tcpip")
+			continue
+		}
+		upstream, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			upstream.Close()
+			continue
+		}
+		s.forwarded.Add(1)
+		go ssh.DiscardRequests(channelRequests)
+		go func() {
+			io.Copy(upstream, channel)
+			upstream.Close()
+		}()
+		go func() {
+			io.Copy(channel, upstream)
+			channel.Close()
+		}()
+	}
+}
+
+func TestSSHTunnelExport(t *testing.T) {
+	server, keyFile := startTestSSHServer(t)
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(server.addr)}, server.hostKey)
+	if err := os.WriteFile(knownHosts, []byte(line+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mr := miniredis.RunT(t)
+	mr.Set("greeting", "hello")
+
+	outputDir := t.TempDir()
+	exp, err := NewRedisExporter(RedisExporterOptions{
+		RedisURL:      "redis://" + mr.Addr() + "/0",
+		OutputDir:     outputDir,
+		OutputFormat:  "csv",
+		SSHHost:       server.addr,
+		SSHUser:       "tunnel",
+		SSHKey:        keyFile,
+		SSHKnownHosts: knownHosts,
+	})
+	if err != nil {
+		t.Fatalf("NewRedisExporter failed: %v", err)
+	}
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	if server.forwarded.Load() == 0 {
+		t.Error("Expected Redis connections to go through the SSH tunnel")
+	}
+	if files := findDataFiles(t, outputDir, ".csv"); len(files) == 0 {
+		t.Error("Expected the tunneled export to write data")
+	}
+}
+
+func TestSSHTunnelRejectsUnknownHostKey(t *testing.T) {
+	server, keyFile := startTestSSHServer(t)
+	other, _ := startTestSSHServer(t)
+
+	// known_hosts lists another key for this address
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(server.addr)}, other.hostKey)
+	if err := os.WriteFile(knownHosts, []byte(line+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newSSHTunnel(server.addr, "tunnel", keyFile, knownHosts, false, nil); err == nil {
+		t.Error("Expected a mismatched host key to be rejected")
+	}
+	if _, err := newSSHTunnel(server.addr, "", keyFile, knownHosts, false, nil); err == nil || !strings.Contains(err.Error(), "SSH_USER") {
+		t.Errorf("Expected a missing user to be rejected, got %v", err)
+	}
+}
+
+func TestSSHTunnelRequiresKnownHosts(t *testing.T) {
+	server, keyFile := startTestSSHServer(t)
+	// No SSH_KNOWN_HOSTS and no ~/.ssh/known_hosts
+	t.Setenv("HOME", t.TempDir())
+
+	if _, err := newSSHTunnel(server.addr, "tunnel", keyFile, "", false, nil); err == nil || !strings.Contains(err.Error(), "SSH_INSECURE_SKIP_HOST_KEY") {
+		t.Errorf("Expected an unverifiable host key to be rejected, got %v", err)
+	}
+
+	tunnel, err := newSSHTunnel(server.addr, "tunnel", keyFile, "", true, nil)
+	if err != nil {
+		t.Fatalf("Expected SSH_INSECURE_SKIP_HOST_KEY to accept the host key, got %v", err)
+	}
+	if err := tunnel.close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+}
+
+func TestSSHTunnelTLS(t *testing.T) {
+	server, keyFile := startTestSSHServer(t)
+	caPEM, serverCert := testPKI(t)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, caPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mr := miniredis.NewMiniRedis()
+	if err := mr.StartTLS(&tls.Config{Certificates: []tls.Certificate{serverCert}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mr.Close)
+
+	// TLS is negotiated with Redis itself, through the tunnel
+	exp, err := NewRedisExporter(RedisExporterOptions{
+		RedisURL:     "redis://" + mr.Addr() + "/0",
+		OutputDir:    t.TempDir(),
+		OutputFormat: "csv",
+		EnableTLS:    true,
+		TLSCAFile:    caFile,
+		SSHHost:      server.addr,
+		SSHUser:      "tunnel",
+		SSHKey:       keyFile,
+
+		SSHInsecureSkipHostKey: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected a TLS connection through the tunnel, got %v", err)
+	}
+	if err := exp.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if server.forwarded.Load() == 0 {
+		t.Error("Expected Redis connections to go through the SSH tunnel")
+	}
+}
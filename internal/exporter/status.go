@@ -0,0 +1,153 @@
+package exporter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	checkpointFileName = "checkpoint.json"
+	metadataFileName   = "export_metadata.json"
+	// staleCheckpointAge is how long a checkpoint can go without updates
+	// before status warns that the export may have stopped
+	staleCheckpointAge = 5 * time.Minute
+)
+
+// Checkpoint is the progress of a running export. It is rewritten to
+// checkpoint.json at every periodic flush and removed once
+// export_metadata.json is written, so a leftover checkpoint means the export
+// is still running or stopped without closing.
+type Checkpoint struct {
+	ExportID       string    `json:"export_id"`
+	Pattern        string    `json:"pattern"`
+	StartTime      time.Time `json:"start_time"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	KeysExported   int64     `json:"keys_exported"`
+	RecordsWritten int64     `json:"records_written"`
+	Partitions     int       `json:"partitions"`
+	// ScanCursor is the SCAN position of the scanner, which may be ahead of
+	// the keys written by up to WRITE_QUEUE_SIZE batches; 0 for RDB files
+	ScanCursor uint64 `json:"scan_cursor"`
+}
+
+// WriteCheckpoint records the progress of a running export. FIFO output has
+// no directory to write it to.
+func (fm *FileManager) WriteCheckpoint(keys int64, cursor uint64) error {
+	if fm.config.Stream {
+		return nil
+	}
+
+	fm.copyMu.Lock()
+	partitions := len(fm.metadata.Partitions)
+	fm.copyMu.Unlock()
+
+	data, err := json.MarshalIndent(&Checkpoint{
+		ExportID:       fm.metadata.ExportID,
+		Pattern:        fm.metadata.Pattern,
+		StartTime:      fm.metadata.StartTime,
+		UpdatedAt:      time.Now(),
+		KeysExported:   keys,
+		RecordsWritten: fm.recordCount,
+		Partitions:     partitions,
+		ScanCursor:     cursor,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	if err := writeFileAtomically(filepath.Join(fm.config.OutputDir, checkpointFileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// removeCheckpoint deletes checkpoint.json once the final metadata exists
+func (fm *FileManager) removeCheckpoint() {
+	err := os.Remove(filepath.Join(fm.config.OutputDir, checkpointFileName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("Warning: failed to remove checkpoint: %v\n", err)
+	}
+}
+
+// writeCheckpoint records progress at a periodic flush. A failed write only
+// loses monitoring, so it is not fatal.
+func (re *RedisExporter) writeCheckpoint(keys int) {
+	if err := re.fileManager.WriteCheckpoint(int64(keys), re.scanCursor.Load()); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// Status prints the progress of the export in dir: the checkpoint of an
+// export that is running or stopped without closing, otherwise the final
+// export_metadata.json
+func Status(dir string, now time.Time) error {
+	data, err := os.ReadFile(filepath.Join(dir, checkpointFileName))
+	if err == nil {
+		var checkpoint Checkpoint
+		if err := json.Unmarshal(data, &checkpoint); err != nil {
+			return fmt.Errorf("failed to parse checkpoint: %w", err)
+		}
+		printCheckpoint(&checkpoint, now)
+		return nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, metadataFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no export found in %s: neither %s nor %s exists yet", dir, checkpointFileName, metadataFileName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read export metadata: %w", err)
+	}
+
+	var metadata ExportMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return fmt.Errorf("failed to parse export metadata: %w", err)
+	}
+	printMetadataStatus(&metadata)
+	return nil
+}
+
+func printCheckpoint(c *Checkpoint, now time.Time) {
+	elapsed := c.UpdatedAt.Sub(c.StartTime)
+	fmt.Printf("Export %s: running, pattern %s\n", c.ExportID, c.Pattern)
+	fmt.Printf("Started:    %s (%s ago)\n", c.StartTime.Format(time.RFC3339), now.Sub(c.StartTime).Round(time.Second))
+	fmt.Printf("Updated:    %s (%s ago)\n", c.UpdatedAt.Format(time.RFC3339), now.Sub(c.UpdatedAt).Round(time.Second))
+	fmt.Printf("Keys:       %d (%.0f keys/sec)\n", c.KeysExported, perSecond(c.KeysExported, elapsed))
+	fmt.Printf("Records:    %d (%.0f records/sec)\n", c.RecordsWritten, perSecond(c.RecordsWritten, elapsed))
+	fmt.Printf("Files:      %d finished\n", c.Partitions)
+	fmt.Printf("Cursor:     %d\n", c.ScanCursor)
+	if now.Sub(c.UpdatedAt) > staleCheckpointAge {
+		fmt.Printf("Warning: no progress recorded for %s - the export may have stopped\n", now.Sub(c.UpdatedAt).Round(time.Second))
+	}
+}
+
+func printMetadataStatus(m *ExportMetadata) {
+	var records int64
+	for _, partition := range m.Partitions {
+		records += partition.RecordCount
+	}
+	elapsed := m.EndTime.Sub(m.StartTime)
+
+	fmt.Printf("Export %s: %s, pattern %s\n", m.ExportID, m.Status, m.Pattern)
+	fmt.Printf("Finished:   %s after %s\n", m.EndTime.Format(time.RFC3339), elapsed.Round(time.Second))
+	fmt.Printf("Keys:       %d (%.0f keys/sec)\n", m.TotalKeys, perSecond(m.TotalKeys, elapsed))
+	fmt.Printf("Records:    %d (%.0f records/sec)\n", records, perSecond(records, elapsed))
+	fmt.Printf("Files:      %d\n", len(m.Partitions))
+	if m.Error != "" {
+		fmt.Printf("Error:      %s\n", m.Error)
+	}
+}
+
+func perSecond(n int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(n) / elapsed.Seconds()
+}
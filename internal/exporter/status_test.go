@@ -0,0 +1,65 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckpointDuringExport(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{})
+	for i := 0; i < 250; i++ {
+		if err := mr.Set(fmt.Sprintf("key:%03d", i), "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := exp.fileManager.config.OutputDir
+
+	// A checkpoint written mid-export describes the progress so far
+	exp.fileManager.SetMetadata("key:*", 0)
+	for i := 0; i < 3; i++ {
+		if err := exp.fileManager.WriteRecord(&RedisRecord{Key: fmt.Sprintf("key:%03d", i), Type: "string", TTLSeconds: -1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exp.scanCursor.Store(42)
+	exp.writeCheckpoint(3)
+
+	data, err := os.ReadFile(filepath.Join(dir, checkpointFileName))
+	if err != nil {
+		t.Fatalf("Expected a checkpoint: %v", err)
+	}
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint.KeysExported != 3 || checkpoint.RecordsWritten != 3 || checkpoint.ScanCursor != 42 ||
+		checkpoint.Pattern != "key:*" || checkpoint.ExportID != exp.fileManager.metadata.ExportID {
+		t.Errorf("Unexpected checkpoint: %+v", checkpoint)
+	}
+	if err := Status(dir, time.Now()); err != nil {
+		t.Errorf("Status failed for a running export: %v", err)
+	}
+
+	// Closing replaces the checkpoint with the final metadata
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, checkpointFileName)); !os.IsNotExist(err) {
+		t.Errorf("Expected the checkpoint to be removed on close, got %v", err)
+	}
+	if err := Status(dir, time.Now()); err != nil {
+		t.Errorf("Status failed for a finished export: %v", err)
+	}
+}
+
+func TestStatusWithoutExport(t *testing.T) {
+	err := Status(t.TempDir(), time.Now())
+	if err == nil || !strings.Contains(err.Error(), "no export found") {
+		t.Errorf("Expected no export to be found, got %v", err)
+	}
+}
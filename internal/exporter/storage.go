@@ -1,13 +1,21 @@
 package exporter
 
 import (
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
-	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"strconv"
+	"sort"
+	"sync"
+	"text/template"
 	"time"
 
 	_ "github.com/marcboeker/go-duckdb"
@@ -19,6 +27,20 @@ type OutputFormat string
 const (
 	FormatCSV     OutputFormat = "csv"
 	FormatParquet OutputFormat = "parquet"
+	// FormatProto writes length-delimited recordpb.RedisRecord messages
+	FormatProto OutputFormat = "proto"
+)
+
+// Export statuses recorded in export_metadata.json
+const (
+	exportStatusComplete = "complete"
+	exportStatusPartial  = "partial"
+)
+
+// Settings pinned in reproducible mode
+const (
+	reproducibleTimestamp    = "1970-01-01T00:00:00Z"
+	reproducibleRowGroupSize = 122880
 )
 
 // RedisRecord represents the unified schema for all Redis data
@@ -28,6 +50,35 @@ type RedisRecord struct {
 	Value      string
 	TTLSeconds int64
 	ExportedAt string
+	// Slot is the cluster hash slot of the top-level key the record belongs to
+	Slot int
+	// RawDump is the base64 DUMP payload, only written when IncludeRawDump is set
+	RawDump string
+	// IdleSeconds is the OBJECT IDLETIME of the top-level key, -1 when unknown.
+	// Only populated when partitioning by age.
+	IdleSeconds int64
+	// ParentKey is the top-level key of an element record, empty for keys
+	ParentKey string
+	// SizeEstimate is the byte size of a top-level key's data (an estimate for
+	// keys-only exports), written in place of Value when the value column is
+	// omitted
+	SizeEstimate int64
+	// SizeLabel is set on top-level key records whose Value only labels
+	// SizeEstimate, so TypeBytes counts the data size instead of the label
+	SizeLabel bool
+	// Cardinality is the element count of a keys-only collection record,
+	// noCardinality for other types
+	Cardinality int64
+	// Encoding is the OBJECT ENCODING of a keys-only record, empty when unknown
+	Encoding string
+	// ExpiresAt is the absolute expiry in Unix milliseconds when read from the
+	// server or an RDB file, 0 to derive it from TTLSeconds when written
+	ExpiresAt int64
+	// ValueTruncated is set when Value was cut at MaxValueBytes
+	ValueTruncated bool
+	// FullLength is the length of a list record cut short by
+	// MaxElementsPerKey, 0 when nothing was cut
+	FullLength int64
 }
 
 // HivePartition represents a Hive-style partition structure
@@ -46,34 +97,193 @@ type StorageConfig struct {
 	OutputDir  string
 	Format     OutputFormat
 	MaxRecords int64
+	// ExportID names the run; empty generates one
+	ExportID string
+	// Compression wraps CSV files in gzip or selects the Parquet page codec
+	Compression Compression
+	// IncludeRawDump adds a raw_dump column carrying RESTORE-compatible payloads
+	IncludeRawDump bool
+	// OmitPartitionID drops the partition_id column from every output format
+	OmitPartitionID bool
+	// OmitValue replaces the value column with a numeric size_estimate column
+	// for metadata-only exports
+	OmitValue bool
+	// IncludeParentKey adds a parent_key column linking elements to their key
+	IncludeParentKey bool
+	// IncludeSlot adds a slot column with the cluster hash slot of each key
+	IncludeSlot bool
+	// IncludeCardinality adds a keys-only cardinality column with the
+	// element count of each collection
+	IncludeCardinality bool
+	// IncludeEncoding adds a keys-only encoding column with the OBJECT
+	// ENCODING of each key
+	IncludeEncoding bool
+	// IncludeIdleTime adds a keys-only idle_seconds column with the OBJECT
+	// IDLETIME of each key
+	IncludeIdleTime bool
+	// IncludeExpiresAt adds an expires_at column with the absolute expiry
+	IncludeExpiresAt bool
+	// MaxValueBytes cuts longer element values and adds a value_truncated
+	// column, 0 for no limit
+	MaxValueBytes int
+	// TruncateElements adds elements_truncated and full_length columns
+	// marking lists cut short by MaxElementsPerKey
+	TruncateElements bool
+	// IncludeSource adds a source column naming the server set by SetSource
+	IncludeSource bool
+	// CSVDelimiter and CSVQuote select the CSV dialect, comma and double quote
+	// when zero. OmitCSVHeader drops the header row from every CSV file.
+	CSVDelimiter  rune
+	CSVQuote      rune
+	OmitCSVHeader bool
+	// KeyEncoding rewrites keys that are not valid UTF-8 in the key and
+	// parent_key columns. Keys never influence file or directory names.
+	KeyEncoding KeyEncoding
+	// MaterializePartitionCols adds year, month, day and hour columns holding
+	// the values of the record's Hive partition directory
+	MaterializePartitionCols bool
+	// Reproducible sorts records by key within each partition, normalizes
+	// exported_at and idle_seconds and pins compression so unchanged data
+	// yields identical files
+	Reproducible bool
+	// PartitionBy selects the directory layout records are routed into
+	PartitionBy PartitionScheme
+	// PartitionTemplate is the Go template rendered per record into its
+	// partition directory when partitioning by template
+	PartitionTemplate string
+	// QueryURI is where the output directory is published (e.g. s3://bucket/prefix),
+	// used for query hints and load.sql instead of the local path
+	QueryURI string
+	// QueryRegion is emitted as s3_region in load.sql for S3 query URIs
+	QueryRegion string
+	// ParquetSummaryFiles writes _metadata and _common_metadata on Close
+	ParquetSummaryFiles bool
+	// IcebergMetadata registers the Parquet files as an Iceberg table on Close
+	IcebergMetadata bool
+	// MaxConcurrentCopies runs up to this many Parquet COPYs in the background
+	// while writing continues (0 copies synchronously on rotation)
+	MaxConcurrentCopies int
+	// IntermediateFlush rewrites the open Parquet file every this many records
+	// so a crash loses at most that many (0 writes only at rotation)
+	IntermediateFlush int64
+	// FlushEvery ticks FlushTicks this often so buffered records, including
+	// Parquet ones, reach disk while few arrive (0 flushes on counts only)
+	FlushEvery time.Duration
+	// ChecksumManifest writes SHA256SUMS covering every data and metadata file
+	// on Close
+	ChecksumManifest bool
+	// Bundle packs the finished export into a single archive in OutputDir,
+	// deleting the loose files as they are archived with BundleDeleteFiles
+	Bundle            Bundle
+	BundleDeleteFiles bool
+	// Stream writes a single CSV stream to OutputDir, which is a FIFO, with no
+	// rotation, partitioning or metadata file
+	Stream bool
+}
+
+// partitionWriter holds the open output for a single partition directory
+type partitionWriter struct {
+	route       string
+	partitionID int
+	recordCount int64
+	// unflushed counts Parquet records not yet written by an intermediate flush
+	unflushed int64
+	// pendingFrom is the index of the writer's first record not yet in a
+	// finished or flushed file, -1 when every record it holds is
+	pendingFrom int64
+	path        string
+	// createdAt is the export time that named the writer's Hive directory
+	createdAt time.Time
+	db        *sql.DB
+	csvWriter rowWriter
+	csvFile   *os.File
+	// gzipWriter sits between csvWriter and csvFile when compressing CSV
+	gzipWriter *gzip.Writer
+	// csvHash digests the bytes written to csvFile for the checksum manifest
+	csvHash   hash.Hash
+	csvBuffer [][]string
 }
 
-// FileManager handles all file operations for the exporter using DuckDB
+// FileManager handles all file operations for the exporter using DuckDB.
+// Records are routed to one open writer per partition directory.
 type FileManager struct {
-	config               StorageConfig
-	db                   *sql.DB
-	tableName            string
-	recordCount          int64
-	partitionID          int
-	metadata             *ExportMetadata
-	currentPartitionPath string
-	csvWriter            *csv.Writer
-	csvFile              *os.File
+	config      StorageConfig
+	tableName   string
+	recordCount int64
+	partitionID int
+	metadata    *ExportMetadata
+	writers     map[string]*partitionWriter
+
+	// copySlots bounds background Parquet COPYs; nil copies synchronously
+	copySlots chan struct{}
+	copies    sync.WaitGroup
+	// copyMu guards metadata.Partitions, copyErr and copying against background COPYs
+	copyMu  sync.Mutex
+	copyErr error
+	// copying maps partition IDs being copied to their first pending record
+	// index. Failed COPYs stay so their records are never reported durable.
+	copying map[int]int64
+	// outOfSpace is set by the first ENOSPC; later records are refused
+	outOfSpace error
+	// partitionTemplate is the parsed PartitionTemplate
+	partitionTemplate *template.Template
+	// acl is written to acl.json when IncludeACL captured it
+	acl *ACLReport
+	// closed is set by Close, which finishes the export only once
+	closed bool
+	// flushTicker drives FlushTicks when FlushEvery is set
+	flushTicker *time.Ticker
+	// source is written to the source column of every following record
+	source string
 }
 
 // NewFileManager creates a new file manager instance
 func NewFileManager(config StorageConfig) *FileManager {
-	return &FileManager{
+	exportID := config.ExportID
+	if exportID == "" {
+		exportID = newExportID(time.Now())
+	}
+	fm := &FileManager{
 		config:      config,
 		tableName:   "redis_data",
 		recordCount: 0,
 		partitionID: 0,
 		metadata: &ExportMetadata{
-			ExportID:   fmt.Sprintf("export_%d", time.Now().Unix()),
+			ExportID:   exportID,
 			StartTime:  time.Now(),
 			Partitions: make([]PartitionInfo, 0),
+			TypeCounts: make(map[string]int64),
+			TypeBytes:  make(map[string]int64),
 		},
+		writers: make(map[string]*partitionWriter),
+		copying: make(map[int]int64),
+	}
+	if config.MaxConcurrentCopies > 0 {
+		fm.copySlots = make(chan struct{}, config.MaxConcurrentCopies)
+	}
+	if config.FlushEvery > 0 {
+		fm.flushTicker = time.NewTicker(config.FlushEvery)
 	}
+	return fm
+}
+
+// FlushTicks returns a channel that ticks every FlushEvery, or nil, which
+// never delivers, when it is unset. The writer goroutine selects on it and
+// calls FlushDue, so flushes never race with writes.
+func (fm *FileManager) FlushTicks() <-chan time.Time {
+	if fm.flushTicker == nil {
+		return nil
+	}
+	return fm.flushTicker.C
+}
+
+// newExportID returns a run ID that sorts by start time, with a random
+// suffix so exports started in the same second do not collide
+func newExportID(now time.Time) string {
+	var suffix [4]byte
+	// crypto/rand.Read never fails on supported platforms
+	_, _ = rand.Read(suffix[:])
+	return fmt.Sprintf("export_%d_%s", now.Unix(), hex.EncodeToString(suffix[:]))
 }
 
 // CreateHivePartitionPath creates a Hive-style partition path
@@ -92,214 +302,523 @@ func (fm *FileManager) CreateHivePartitionPath(timestamp time.Time) string {
 	)
 }
 
-// initializeWriter initializes the appropriate writer based on format
-func (fm *FileManager) initializeWriter() error {
+// partitionPath returns the directory for a route. Time partitioning uses
+// the export clock; other schemes name the directory after the route.
+func (fm *FileManager) partitionPath(route string, now time.Time) string {
+	if route == "" {
+		return fm.CreateHivePartitionPath(now)
+	}
+	return filepath.Join(fm.config.OutputDir, route)
+}
+
+// routeFor returns the partition route a record belongs to
+func (fm *FileManager) routeFor(record *RedisRecord) (string, error) {
+	if fm.config.Stream {
+		return "", nil
+	}
+
+	switch fm.config.PartitionBy {
+	case PartitionByAge:
+		return ageBucket(record.IdleSeconds), nil
+	case PartitionByTemplate:
+		return fm.templateRoute(record)
+	default:
+		return "", nil
+	}
+}
+
+// initializeWriter opens a new writer for the route based on format
+func (fm *FileManager) initializeWriter(route string) (*partitionWriter, error) {
 	now := time.Now()
 	fm.partitionID++
 
+	w := &partitionWriter{
+		route:       route,
+		partitionID: fm.partitionID,
+		createdAt:   now,
+		pendingFrom: -1,
+	}
+
+	if fm.config.Stream {
+		if err := fm.initializeStreamWriter(w); err != nil {
+			return nil, err
+		}
+		fm.writers[route] = w
+		return w, nil
+	}
+
 	// Create partition path
-	partitionPath := fm.CreateHivePartitionPath(now)
+	partitionPath := fm.partitionPath(route, now)
 	if err := os.MkdirAll(partitionPath, 0755); err != nil {
-		return fmt.Errorf("failed to create partition directory: %w", err)
+		return nil, fmt.Errorf("failed to create partition directory: %w", err)
 	}
 
-	fm.currentPartitionPath = partitionPath
+	w.path = partitionPath
 
+	var err error
 	switch fm.config.Format {
-	case FormatCSV:
-		return fm.initializeCSVWriter(partitionPath)
+	case FormatCSV, FormatProto:
+		err = fm.initializeCSVWriter(w)
 	case FormatParquet:
-		return fm.initializeDuckDBWriter(partitionPath)
+		err = fm.initializeDuckDBWriter(w)
 	default:
-		return fmt.Errorf("unsupported format: %s", fm.config.Format)
+		err = fmt.Errorf("unsupported format: %s", fm.config.Format)
+	}
+	if err != nil {
+		return nil, err
 	}
+
+	fm.writers[route] = w
+	return w, nil
 }
 
 // initializeCSVWriter sets up CSV writing
-func (fm *FileManager) initializeCSVWriter(partitionPath string) error {
-	fileName := fmt.Sprintf("redis_data_part_%04d.csv", fm.partitionID)
-	filePath := filepath.Join(partitionPath, fileName)
+func (fm *FileManager) initializeCSVWriter(w *partitionWriter) error {
+	fileName := fmt.Sprintf("redis_data_part_%04d.%s", w.partitionID, fm.fileExtension())
+	filePath := filepath.Join(w.path, fileName)
 
 	file, err := os.Create(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to create CSV file: %w", err)
 	}
 
-	fm.csvFile = file
-	fm.csvWriter = csv.NewWriter(file)
+	w.csvFile = file
 
-	// Write headers
-	headers := []string{"key", "type", "value", "ttl_seconds", "exported_at", "partition_id"}
-	if err := fm.csvWriter.Write(headers); err != nil {
-		return fmt.Errorf("failed to write CSV headers: %w", err)
+	// The checksum is taken as the file is written, so it is never read back
+	var out io.Writer = file
+	if fm.config.ChecksumManifest {
+		w.csvHash = sha256.New()
+		out = io.MultiWriter(file, w.csvHash)
+	}
+
+	if fm.config.Compression == CompressionGzip {
+		w.gzipWriter = gzip.NewWriter(out)
+		out = w.gzipWriter
+	}
+
+	// Protobuf files are written like CSV, one message per row and no header
+	if fm.config.Format == FormatProto {
+		if w.csvWriter, err = newProtoRowWriter(out, fm.columnNames()); err != nil {
+			_ = file.Close()
+			return err
+		}
+		return nil
+	}
+
+	w.csvWriter = fm.newRowWriter(out)
+	return fm.writeCSVHeader(w)
+}
+
+// initializeStreamWriter opens the FIFO for writing. This blocks until a
+// reader opens the other end.
+func (fm *FileManager) initializeStreamWriter(w *partitionWriter) error {
+	file, err := os.OpenFile(fm.config.OutputDir, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open FIFO: %w", err)
 	}
 
+	w.path = fm.config.OutputDir
+	w.csvFile = file
+	w.csvWriter = fm.newRowWriter(file)
+
+	return fm.writeCSVHeader(w)
+}
+
+// writeCSVHeader writes the column names unless the header is omitted
+func (fm *FileManager) writeCSVHeader(w *partitionWriter) error {
+	if fm.config.OmitCSVHeader {
+		return nil
+	}
+	if err := w.csvWriter.Write(fm.columnNames()); err != nil {
+		return fmt.Errorf("failed to write CSV headers: %w", err)
+	}
 	return nil
 }
 
 // initializeDuckDBWriter sets up DuckDB for Parquet writing
-func (fm *FileManager) initializeDuckDBWriter(partitionPath string) error {
+func (fm *FileManager) initializeDuckDBWriter(w *partitionWriter) error {
 	// Create DuckDB connection
 	db, err := sql.Open("duckdb", "")
 	if err != nil {
 		return fmt.Errorf("failed to open DuckDB connection: %w", err)
 	}
 
-	fm.db = db
+	// A single thread keeps row group layout independent of scheduling
+	if fm.config.Reproducible {
+		if _, err := db.Exec("SET threads TO 1"); err != nil {
+			_ = db.Close()
+			return fmt.Errorf("failed to configure DuckDB threads: %w", err)
+		}
+	}
 
 	// Create table for this partition
-	createTableSQL := fmt.Sprintf(`
-		CREATE TABLE %s (
-			key VARCHAR,
-			type VARCHAR,
-			value VARCHAR,
-			ttl_seconds BIGINT,
-			exported_at VARCHAR,
-			partition_id INTEGER
-		)`, fm.tableName)
-
-	if _, err := fm.db.Exec(createTableSQL); err != nil {
+	createTableSQL := fm.createTableSQL()
+
+	if _, err := db.Exec(createTableSQL); err != nil {
+		_ = db.Close()
 		return fmt.Errorf("failed to create table: %w", err)
 	}
 
+	w.db = db
 	return nil
 }
 
-// WriteRecord writes a RedisRecord to the writer
+// WriteRecord routes a RedisRecord to the writer for its partition
 func (fm *FileManager) WriteRecord(record *RedisRecord) error {
+	if fm.outOfSpace != nil {
+		return fm.outOfSpace
+	}
+	return fm.checkSpace(fm.writeRecord(record))
+}
+
+func (fm *FileManager) writeRecord(record *RedisRecord) error {
+	route, err := fm.routeFor(record)
+	if err != nil {
+		return err
+	}
+
 	// Initialize writer if not already done
-	if fm.csvWriter == nil && fm.db == nil {
-		if err := fm.initializeWriter(); err != nil {
+	w, ok := fm.writers[route]
+	if !ok {
+		if w, err = fm.initializeWriter(route); err != nil {
 			return err
 		}
 	}
 
-	// Check if we need to rotate
-	if fm.recordCount >= fm.config.MaxRecords {
-		if err := fm.RotateWriter(); err != nil {
+	// Only element values are cut; key records hold short summaries
+	if fm.config.MaxValueBytes > 0 && record.ParentKey != "" && len(record.Value) > fm.config.MaxValueBytes {
+		truncated := *record
+		truncated.Value = truncateValue(record.Value, fm.config.MaxValueBytes)
+		truncated.ValueTruncated = true
+		record = &truncated
+	}
+
+	// A TTL without a known expiry counts from the moment it is written
+	if fm.config.IncludeExpiresAt && record.ExpiresAt == 0 && record.TTLSeconds >= 0 {
+		withExpiry := *record
+		withExpiry.ExpiresAt = time.Now().Add(time.Duration(record.TTLSeconds) * time.Second).UnixMilli()
+		record = &withExpiry
+	}
+
+	// Volatile timestamps would make otherwise identical exports differ; idle
+	// times grow between runs, so they are written as unknown
+	if fm.config.Reproducible {
+		normalized := *record
+		normalized.ExportedAt = reproducibleTimestamp
+		normalized.IdleSeconds = -1
+		record = &normalized
+	}
+
+	// Check if we need to rotate - a stream is never split
+	if !fm.config.Stream && w.recordCount >= fm.config.MaxRecords {
+		if err := fm.rotateWriter(w); err != nil {
 			return err
 		}
 		// After rotation, reinitialize writer
-		if err := fm.initializeWriter(); err != nil {
+		if w, err = fm.initializeWriter(route); err != nil {
 			return err
 		}
 	}
 
 	switch fm.config.Format {
-	case FormatCSV:
-		return fm.writeCSVRecord(record)
+	case FormatCSV, FormatProto:
+		err = fm.writeCSVRecord(w, record)
 	case FormatParquet:
-		return fm.writeDuckDBRecord(record)
+		err = fm.writeDuckDBRecord(w, record)
 	default:
-		return fmt.Errorf("unsupported format: %s", fm.config.Format)
+		err = fmt.Errorf("unsupported format: %s", fm.config.Format)
 	}
+	if err != nil {
+		return err
+	}
+	if w.pendingFrom < 0 {
+		w.pendingFrom = fm.recordCount - 1
+	}
+
+	fm.metadata.TypeCounts[record.Type]++
+	if record.SizeLabel {
+		fm.metadata.TypeBytes[record.Type] += record.SizeEstimate
+	} else {
+		fm.metadata.TypeBytes[record.Type] += int64(len(record.Value))
+	}
+	return nil
 }
 
 // writeCSVRecord writes to CSV
-func (fm *FileManager) writeCSVRecord(record *RedisRecord) error {
-	row := []string{
-		record.Key,
-		record.Type,
-		record.Value,
-		strconv.FormatInt(record.TTLSeconds, 10),
-		record.ExportedAt,
-		strconv.Itoa(fm.partitionID),
+func (fm *FileManager) writeCSVRecord(w *partitionWriter, record *RedisRecord) error {
+	row := fm.csvRow(w, record)
+
+	// Reproducible partitions are buffered and written sorted at rotation
+	if fm.config.Reproducible {
+		w.csvBuffer = append(w.csvBuffer, row)
+		w.recordCount++
+		fm.recordCount++
+		return nil
 	}
 
-	if err := fm.csvWriter.Write(row); err != nil {
+	if err := w.csvWriter.Write(row); err != nil {
 		return fmt.Errorf("failed to write CSV record: %w", err)
 	}
 
+	w.recordCount++
 	fm.recordCount++
 	return nil
 }
 
 // writeDuckDBRecord writes to DuckDB table
-func (fm *FileManager) writeDuckDBRecord(record *RedisRecord) error {
-	insertSQL := fmt.Sprintf(`
-		INSERT INTO %s (key, type, value, ttl_seconds, exported_at, partition_id)
-		VALUES (?, ?, ?, ?, ?, ?)`, fm.tableName)
-
-	_, err := fm.db.Exec(insertSQL,
-		record.Key,
-		record.Type,
-		record.Value,
-		record.TTLSeconds,
-		record.ExportedAt,
-		fm.partitionID)
+func (fm *FileManager) writeDuckDBRecord(w *partitionWriter, record *RedisRecord) error {
+	_, err := w.db.Exec(fm.insertSQL(), fm.recordValues(w, record)...)
 
 	if err != nil {
 		return fmt.Errorf("failed to insert record: %w", err)
 	}
 
+	w.recordCount++
 	fm.recordCount++
+	w.unflushed++
+
+	if fm.config.IntermediateFlush > 0 && w.unflushed >= fm.config.IntermediateFlush {
+		return fm.flushDuckDBWriter(w)
+	}
 	return nil
 }
 
-// RotateWriter closes current writer and creates a new partition
+// RotateWriter closes every open writer so the next record starts a new partition
 func (fm *FileManager) RotateWriter() error {
-	if fm.recordCount == 0 {
+	// Rotate in route order so partition metadata is deterministic
+	routes := make([]string, 0, len(fm.writers))
+	for route := range fm.writers {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+
+	// Keep finishing the other partitions after a failure, e.g. once space
+	// was freed by discarding an incomplete file
+	var firstErr error
+	for _, route := range routes {
+		if err := fm.rotateWriter(fm.writers[route]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return fm.checkSpace(firstErr)
+}
+
+// rotateWriter finalizes a single partition writer
+func (fm *FileManager) rotateWriter(w *partitionWriter) error {
+	if w.recordCount == 0 {
 		return nil // Nothing to rotate
 	}
 
+	var err error
 	switch fm.config.Format {
-	case FormatCSV:
-		return fm.rotateCSVWriter()
+	case FormatCSV, FormatProto:
+		err = fm.rotateCSVWriter(w)
 	case FormatParquet:
-		return fm.rotateDuckDBWriter()
+		err = fm.rotateDuckDBWriter(w)
 	default:
-		return fmt.Errorf("unsupported format: %s", fm.config.Format)
+		err = fmt.Errorf("unsupported format: %s", fm.config.Format)
 	}
+	if err != nil {
+		return err
+	}
+
+	delete(fm.writers, w.route)
+	return nil
 }
 
 // rotateCSVWriter handles CSV rotation
-func (fm *FileManager) rotateCSVWriter() error {
-	if fm.csvWriter != nil {
-		fm.csvWriter.Flush()
+func (fm *FileManager) rotateCSVWriter(w *partitionWriter) error {
+	if w.csvWriter != nil && len(w.csvBuffer) > 0 {
+		sortRows(w.csvBuffer)
+		if err := w.csvWriter.WriteAll(w.csvBuffer); err != nil {
+			delete(fm.writers, w.route)
+			return fm.discardCSVFile(w, fmt.Errorf("failed to write CSV records: %w", err))
+		}
+		w.csvBuffer = nil
 	}
 
-	if fm.csvFile != nil {
-		stat, err := fm.csvFile.Stat()
+	if w.csvWriter != nil {
+		w.csvWriter.Flush()
+		if err := w.csvWriter.Error(); err != nil {
+			delete(fm.writers, w.route)
+			return fm.discardCSVFile(w, fmt.Errorf("failed to write CSV records: %w", err))
+		}
+	}
+
+	// The gzip trailer must be written before the file size is taken
+	if w.gzipWriter != nil {
+		if err := w.gzipWriter.Close(); err != nil {
+			delete(fm.writers, w.route)
+			return fm.discardCSVFile(w, fmt.Errorf("failed to finish gzip stream: %w", err))
+		}
+		w.gzipWriter = nil
+	}
+
+	if w.csvFile != nil {
+		stat, err := w.csvFile.Stat()
 		if err != nil {
 			return err
 		}
 
 		// Add partition info
 		partitionInfo := PartitionInfo{
-			PartitionID:   fm.partitionID,
+			PartitionID:   w.partitionID,
 			DataType:      "redis_data",
-			FileName:      filepath.Base(fm.csvFile.Name()),
-			RecordCount:   fm.recordCount,
+			Partition:     w.route,
+			Path:          fm.relativePath(w.csvFile.Name()),
+			FileName:      filepath.Base(w.csvFile.Name()),
+			RecordCount:   w.recordCount,
 			FileSizeBytes: stat.Size(),
-			StartTime:     time.Now().Add(-time.Hour), // Approximate
+			SHA256:        hashSum(w.csvHash),
+			StartTime:     w.createdAt,
 			EndTime:       time.Now(),
 		}
 		fm.metadata.Partitions = append(fm.metadata.Partitions, partitionInfo)
 
-		if err := fm.csvFile.Close(); err != nil {
+		if err := w.csvFile.Close(); err != nil {
 			return fmt.Errorf("failed to close CSV file: %w", err)
 		}
-		fm.csvFile = nil
-		fm.csvWriter = nil
+		w.csvFile = nil
+		w.csvWriter = nil
 	}
 
-	fm.recordCount = 0
+	w.recordCount = 0
 	return nil
 }
 
 // rotateDuckDBWriter handles DuckDB rotation by exporting to Parquet
-func (fm *FileManager) rotateDuckDBWriter() error {
-	if fm.db == nil {
+func (fm *FileManager) rotateDuckDBWriter(w *partitionWriter) error {
+	if w.db == nil {
 		return nil
 	}
 
+	// An empty or missing path would send COPY to a relative or garbage location
+	if err := validatePartitionDir(w.path); err != nil {
+		if closeErr := w.db.Close(); closeErr != nil {
+			fmt.Printf("Warning: failed to close database connection: %v\n", closeErr)
+		}
+		w.db = nil
+		delete(fm.writers, w.route)
+		fm.trackCopy(w.partitionID, w.pendingFrom)
+		return fmt.Errorf("failed to export to Parquet: %d records of partition %d lost: %w",
+			w.recordCount, w.partitionID, err)
+	}
+
+	// Errors from earlier background COPYs surface on the next rotation
+	if err := fm.takeCopyError(); err != nil {
+		return err
+	}
+
+	// Detach the table so the writer can move on while it is copied
+	job := parquetCopy{
+		db:          w.db,
+		path:        w.path,
+		route:       w.route,
+		partitionID: w.partitionID,
+		recordCount: w.recordCount,
+		createdAt:   w.createdAt,
+	}
+	fm.trackCopy(w.partitionID, w.pendingFrom)
+	w.db = nil
+	w.recordCount = 0
+	w.pendingFrom = -1
+
+	if fm.copySlots == nil {
+		if err := fm.finishParquet(job); err != nil {
+			delete(fm.writers, w.route)
+			fm.dropRecords(job.recordCount)
+			return fmt.Errorf("%d records of partition %d lost: %w", job.recordCount, job.partitionID, err)
+		}
+		fm.untrackCopy(job.partitionID)
+		return nil
+	}
+
+	// Blocks while MaxConcurrentCopies COPYs are already in flight
+	fm.copySlots <- struct{}{}
+	fm.copies.Add(1)
+	go func() {
+		defer fm.copies.Done()
+		defer func() { <-fm.copySlots }()
+
+		if err := fm.finishParquet(job); err != nil {
+			fm.dropRecords(job.recordCount)
+			fm.copyMu.Lock()
+			if fm.copyErr == nil {
+				fm.copyErr = fmt.Errorf("%d records of partition %d lost: %w", job.recordCount, job.partitionID, err)
+			}
+			fm.copyMu.Unlock()
+			return
+		}
+		fm.untrackCopy(job.partitionID)
+	}()
+	return nil
+}
+
+// trackCopy records that a partition's records from pendingFrom on are only
+// durable once its COPY succeeds
+func (fm *FileManager) trackCopy(partitionID int, pendingFrom int64) {
+	if pendingFrom < 0 {
+		return
+	}
+	fm.copyMu.Lock()
+	fm.copying[partitionID] = pendingFrom
+	fm.copyMu.Unlock()
+}
+
+func (fm *FileManager) untrackCopy(partitionID int) {
+	fm.copyMu.Lock()
+	delete(fm.copying, partitionID)
+	fm.copyMu.Unlock()
+}
+
+// DurableRecords returns how many records, counted from the first one
+// written, are in finished or flushed files. Later records could still be
+// lost by a crash or a failed COPY.
+func (fm *FileManager) DurableRecords() int64 {
+	durable := fm.recordCount
+	for _, w := range fm.writers {
+		if w.pendingFrom >= 0 && w.pendingFrom < durable {
+			durable = w.pendingFrom
+		}
+	}
+
+	fm.copyMu.Lock()
+	defer fm.copyMu.Unlock()
+	for _, pendingFrom := range fm.copying {
+		if pendingFrom < durable {
+			durable = pendingFrom
+		}
+	}
+	return durable
+}
+
+// parquetCopy is a partition table detached from its writer, ready for COPY
+type parquetCopy struct {
+	db          *sql.DB
+	path        string
+	route       string
+	partitionID int
+	recordCount int64
+	// createdAt is when the partition's writer was opened
+	createdAt time.Time
+}
+
+// finishParquet COPYs a detached partition table to its Parquet file, records
+// the partition and closes the table's connection
+func (fm *FileManager) finishParquet(job parquetCopy) error {
+	defer func() {
+		if err := job.db.Close(); err != nil {
+			fmt.Printf("Warning: failed to close database connection: %v\n", err)
+		}
+	}()
+
 	// Export table to Parquet file
-	fileName := fmt.Sprintf("redis_data_part_%04d.parquet", fm.partitionID)
-	filePath := filepath.Join(fm.currentPartitionPath, fileName)
+	fileName := fmt.Sprintf("redis_data_part_%04d.parquet", job.partitionID)
+	filePath := filepath.Join(job.path, fileName)
 
-	exportSQL := fmt.Sprintf("COPY %s TO '%s' (FORMAT 'parquet')", fm.tableName, filePath)
-	if _, err := fm.db.Exec(exportSQL); err != nil {
-		return fmt.Errorf("failed to export to Parquet: %w", err)
+	if err := fm.copyParquet(job.db, filePath); err != nil {
+		return err
 	}
 
 	// Get file info
@@ -308,87 +827,454 @@ func (fm *FileManager) rotateDuckDBWriter() error {
 		return fmt.Errorf("failed to stat Parquet file: %w", err)
 	}
 
+	// DuckDB writes the file, so it is hashed straight after the COPY
+	var sum string
+	if fm.config.ChecksumManifest {
+		if sum, err = hashFile(filePath); err != nil {
+			return err
+		}
+	}
+
 	// Add partition info
 	partitionInfo := PartitionInfo{
-		PartitionID:   fm.partitionID,
+		PartitionID:   job.partitionID,
 		DataType:      "redis_data",
+		Partition:     job.route,
+		Path:          fm.relativePath(filePath),
 		FileName:      fileName,
-		RecordCount:   fm.recordCount,
+		RecordCount:   job.recordCount,
 		FileSizeBytes: stat.Size(),
-		StartTime:     time.Now().Add(-time.Hour), // Approximate
+		SHA256:        sum,
+		StartTime:     job.createdAt,
 		EndTime:       time.Now(),
 	}
+	fm.copyMu.Lock()
 	fm.metadata.Partitions = append(fm.metadata.Partitions, partitionInfo)
+	fm.copyMu.Unlock()
 
-	// Drop the table and close connection
-	if _, err := fm.db.Exec(fmt.Sprintf("DROP TABLE %s", fm.tableName)); err != nil {
+	// Drop the table before the connection is closed
+	if _, err := job.db.Exec(fmt.Sprintf("DROP TABLE %s", fm.tableName)); err != nil {
 		// Log error but continue - table might not exist
 		fmt.Printf("Warning: failed to drop table: %v\n", err)
 	}
-	if err := fm.db.Close(); err != nil {
-		return fmt.Errorf("failed to close database connection: %w", err)
+
+	return nil
+}
+
+// takeCopyError returns and clears the first error from a background COPY
+func (fm *FileManager) takeCopyError() error {
+	fm.copyMu.Lock()
+	defer fm.copyMu.Unlock()
+
+	err := fm.copyErr
+	fm.copyErr = nil
+	if err != nil {
+		return fmt.Errorf("background Parquet COPY failed: %w", err)
+	}
+	return nil
+}
+
+// waitCopies blocks until every background COPY has finished and returns
+// the first error among them. Partitions are re-sorted by ID because
+// background COPYs complete out of order.
+func (fm *FileManager) waitCopies() error {
+	if fm.copySlots == nil {
+		return nil
+	}
+
+	fm.copies.Wait()
+	sort.SliceStable(fm.metadata.Partitions, func(i, j int) bool {
+		return fm.metadata.Partitions[i].PartitionID < fm.metadata.Partitions[j].PartitionID
+	})
+	return fm.takeCopyError()
+}
+
+// copyParquet COPYs the partition table to a temporary file and renames it over
+// filePath, so readers never observe a partially written file
+func (fm *FileManager) copyParquet(db *sql.DB, filePath string) error {
+	tmpPath := filePath + ".tmp"
+	if _, err := db.Exec(fm.copySQL(tmpPath)); err != nil {
+		// A partial file only takes space, e.g. after ENOSPC
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to export to Parquet: %w", err)
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return fmt.Errorf("failed to move Parquet file into place: %w", err)
 	}
-	fm.db = nil
 
-	fm.recordCount = 0
 	return nil
 }
 
+// flushDuckDBWriter writes the records accumulated so far to the partition's
+// Parquet file without rotating, bounding what a crash can lose
+func (fm *FileManager) flushDuckDBWriter(w *partitionWriter) error {
+	if err := validatePartitionDir(w.path); err != nil {
+		return fmt.Errorf("failed to flush Parquet: %w", err)
+	}
+
+	fileName := fmt.Sprintf("redis_data_part_%04d.parquet", w.partitionID)
+	if err := fm.copyParquet(w.db, filepath.Join(w.path, fileName)); err != nil {
+		return err
+	}
+
+	w.unflushed = 0
+	w.pendingFrom = -1
+	return nil
+}
+
+// relativePath returns path relative to the output directory, using '/'
+func (fm *FileManager) relativePath(path string) string {
+	rel, err := filepath.Rel(fm.config.OutputDir, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// validatePartitionDir checks that path names an existing directory
+func validatePartitionDir(path string) error {
+	if path == "" {
+		return fmt.Errorf("partition directory is not initialized")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("partition directory %s is unavailable: %w", path, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("partition path %s is not a directory", path)
+	}
+
+	return nil
+}
+
+// copySQL builds the COPY statement that writes the partition table to Parquet
+func (fm *FileManager) copySQL(filePath string) string {
+	if !fm.config.Reproducible {
+		if fm.config.Compression == CompressionDefault {
+			return fmt.Sprintf("COPY %s TO '%s' (FORMAT 'parquet')", fm.tableName, filePath)
+		}
+		return fmt.Sprintf("COPY %s TO '%s' (FORMAT 'parquet', COMPRESSION '%s')",
+			fm.tableName, filePath, fm.parquetCodec())
+	}
+
+	// Sort on every column so ties on key still have a total order
+	return fmt.Sprintf(
+		"COPY (SELECT * FROM %s ORDER BY ALL) TO '%s' (FORMAT 'parquet', COMPRESSION '%s', ROW_GROUP_SIZE %d)",
+		fm.tableName, filePath, fm.parquetCodec(), reproducibleRowGroupSize)
+}
+
+// sortRows orders rows lexicographically, column by column
+func sortRows(rows [][]string) {
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+}
+
 // FlushAll flushes all active writers
 func (fm *FileManager) FlushAll() {
 	switch fm.config.Format {
-	case FormatCSV:
-		if fm.csvWriter != nil {
-			fm.csvWriter.Flush()
+	case FormatCSV, FormatProto:
+		for _, w := range fm.writers {
+			if w.csvWriter != nil {
+				w.csvWriter.Flush()
+				// A gzip flush ends the deflate block so tailing readers can
+				// decompress the rows, but the file stays unfinished until
+				// rotation writes the trailer
+				if w.gzipWriter != nil {
+					if err := w.gzipWriter.Flush(); err != nil {
+						fmt.Printf("Error flushing gzip stream: %v\n", err)
+					}
+				}
+				// Gzip and reproducible rows only count as durable at rotation
+				if w.csvWriter.Error() == nil && w.gzipWriter == nil && len(w.csvBuffer) == 0 {
+					w.pendingFrom = -1
+				}
+			}
 		}
 	case FormatParquet:
 		// DuckDB handles flushing automatically
 	}
 }
 
+// FlushDue runs on every FlushTicks tick: it flushes CSV buffers like
+// FlushAll and writes Parquet records added since the last flush, rewriting
+// the open file as IntermediateFlush does
+func (fm *FileManager) FlushDue() error {
+	fm.FlushAll()
+	if fm.config.Format != FormatParquet {
+		return nil
+	}
+	for _, w := range fm.writers {
+		if w.db != nil && w.unflushed > 0 {
+			if err := fm.flushDuckDBWriter(w); err != nil {
+				return fm.checkSpace(err)
+			}
+		}
+	}
+	return nil
+}
+
 // SetMetadata updates the export metadata
 func (fm *FileManager) SetMetadata(pattern string, totalKeys int64) {
 	fm.metadata.Pattern = pattern
 	fm.metadata.TotalKeys = totalKeys
 }
 
+// MarkPartial records that the export stopped early because of err
+func (fm *FileManager) MarkPartial(err error) {
+	fm.metadata.Status = exportStatusPartial
+	fm.metadata.Error = err.Error()
+}
+
+// AddIgnoredKeys counts keys dropped by ignore rules
+func (fm *FileManager) AddIgnoredKeys(n int64) {
+	fm.metadata.IgnoredKeys += n
+}
+
+// AddTTLFilteredKeys counts keys dropped by the TTL filters
+func (fm *FileManager) AddTTLFilteredKeys(n int64) {
+	fm.metadata.TTLFilteredKeys += n
+}
+
+// AddTruncatedKeys counts lists cut short by MaxElementsPerKey
+func (fm *FileManager) AddTruncatedKeys(n int64) {
+	fm.metadata.TruncatedKeys += n
+}
+
+// AddResumedKeys counts keys skipped because a previous run completed them
+func (fm *FileManager) AddResumedKeys(n int64) {
+	fm.metadata.ResumedKeys += n
+}
+
+// ContinuePartitionIDs numbers new partitions after the highest
+// redis_data_part_NNNN file already in the output directory, so a resumed
+// export never overwrites the files of the run it continues
+func (fm *FileManager) ContinuePartitionIDs() error {
+	err := filepath.WalkDir(fm.config.OutputDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		var id int
+		if _, scanErr := fmt.Sscanf(d.Name(), "redis_data_part_%d.", &id); scanErr == nil && id > fm.partitionID {
+			fm.partitionID = id
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan existing partitions: %w", err)
+	}
+	return nil
+}
+
+// AddSmallKeys adds to the count of keys skipped by MinSizeBytes
+func (fm *FileManager) AddSmallKeys(n int64) {
+	fm.metadata.SmallKeys += n
+}
+
+// SetRDBSource records the RDB file an offline export was read from
+func (fm *FileManager) SetRDBSource(source *RDBSource) {
+	fm.metadata.RDB = source
+}
+
+// SetServerInfo records the server instance the export was read from
+func (fm *FileManager) SetServerInfo(server *ServerInfo) {
+	fm.metadata.Server = server
+}
+
+// SetSource names the server the following records are read from
+func (fm *FileManager) SetSource(name string) {
+	fm.source = name
+}
+
+// AddSource records a server of a multi-source export in the metadata
+func (fm *FileManager) AddSource(source SourceInfo) {
+	fm.metadata.Sources = append(fm.metadata.Sources, source)
+}
+
+// SetTTLsSkipped records that TTLs were not looked up and read -1
+func (fm *FileManager) SetTTLsSkipped() {
+	fm.metadata.TTLsSkipped = true
+}
+
+// SetReplicationSnapshot records the replication state captured at export start
+func (fm *FileManager) SetReplicationSnapshot(snapshot *ReplicationSnapshot) {
+	fm.metadata.Replication = snapshot
+}
+
 // Close finalizes all writers and creates metadata file
 func (fm *FileManager) Close() error {
-	// Rotate final partition
-	if fm.recordCount > 0 {
-		if err := fm.RotateWriter(); err != nil {
-			fmt.Printf("Error rotating final writer: %v\n", err)
+	// A bundled export's files are gone, so a second Close must not rewrite them
+	if fm.closed {
+		return fm.outOfSpace
+	}
+	fm.closed = true
+	if fm.flushTicker != nil {
+		fm.flushTicker.Stop()
+	}
+
+	// A FIFO cannot be stat'ed for partition info, so only finish the stream
+	if fm.config.Stream {
+		return fm.closeStream()
+	}
+
+	// Rotate final partitions. Records lost here, or a sidecar that cannot be
+	// written, make the export partial; the metadata is still written and the
+	// checkpoint is kept.
+	var failed error
+	if err := fm.checkSpace(fm.RotateWriter()); err != nil {
+		fmt.Printf("Error rotating final writer: %v\n", err)
+		failed = err
+	}
+	if err := fm.checkSpace(fm.waitCopies()); err != nil {
+		fmt.Printf("Error finishing Parquet copies: %v\n", err)
+		if failed == nil {
+			failed = err
 		}
 	}
 
+	// Whatever was finished is still described, marked as incomplete
+	if fm.outOfSpace != nil && fm.metadata.Status == "" {
+		fm.MarkPartial(fm.outOfSpace)
+	}
+
+	if fm.config.ParquetSummaryFiles {
+		if err := fm.checkSpace(fm.writeParquetSummaryFiles()); err != nil {
+			fmt.Printf("Error writing Parquet summary files: %v\n", err)
+			if failed == nil {
+				failed = err
+			}
+		}
+	}
+
+	if fm.config.IcebergMetadata {
+		if err := fm.checkSpace(fm.writeIcebergMetadata()); err != nil {
+			fmt.Printf("Error writing Iceberg metadata: %v\n", err)
+			if failed == nil {
+				failed = err
+			}
+		}
+	}
+	if failed != nil && fm.metadata.Status == "" {
+		fm.MarkPartial(failed)
+	}
+
 	// Write metadata file
 	fm.metadata.EndTime = time.Now()
-	metadataPath := filepath.Join(fm.config.OutputDir, "export_metadata.json")
-	metadataFile, err := os.Create(metadataPath)
-	if err != nil {
-		return fmt.Errorf("failed to create metadata file: %w", err)
+	if fm.metadata.Status == "" {
+		fm.metadata.Status = exportStatusComplete
 	}
-	defer func() {
-		if err := metadataFile.Close(); err != nil {
-			fmt.Printf("Warning: failed to close metadata file: %v\n", err)
+	if err := fm.writeMetadataFile(); err != nil {
+		return fm.checkSpace(err)
+	}
+	if failed == nil {
+		fm.removeCheckpoint()
+	}
+
+	if fm.queryable() {
+		if err := fm.writeLoadSQL(); err != nil {
+			return fm.checkSpace(err)
 		}
-	}()
+	}
+	if fm.acl != nil {
+		if err := fm.writeACLFile(); err != nil {
+			return fm.checkSpace(err)
+		}
+	}
+	if fm.config.ChecksumManifest {
+		if err := fm.writeChecksumManifest(); err != nil {
+			return fm.checkSpace(err)
+		}
+	}
+	if fm.config.Bundle != BundleNone {
+		if err := fm.writeBundle(); err != nil {
+			return fm.checkSpace(err)
+		}
+	}
+	if failed != nil {
+		return failed
+	}
+	return fm.outOfSpace
+}
+
+// writeMetadataFile writes export_metadata.json through a temporary file, so
+// a full disk never leaves truncated metadata behind
+func (fm *FileManager) writeMetadataFile() error {
+	data, err := json.MarshalIndent(fm.metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
 
-	encoder := json.NewEncoder(metadataFile)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(fm.metadata); err != nil {
+	if err := writeFileAtomically(filepath.Join(fm.config.OutputDir, metadataFileName), append(data, '\n'), 0644); err != nil {
 		return fmt.Errorf("failed to write metadata: %w", err)
 	}
+	return nil
+}
+
+// writeFileAtomically writes data to a temporary file renamed over path, so
+// readers never see a partial file and a failed write leaves none behind
+func writeFileAtomically(path string, data []byte, perm os.FileMode) error {
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, perm); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// closeStream writes any buffered rows and closes the FIFO writer
+func (fm *FileManager) closeStream() error {
+	w, ok := fm.writers[""]
+	if !ok {
+		return nil
+	}
+	delete(fm.writers, "")
+
+	if len(w.csvBuffer) > 0 {
+		sortRows(w.csvBuffer)
+		if err := w.csvWriter.WriteAll(w.csvBuffer); err != nil {
+			return fmt.Errorf("failed to write CSV records: %w", err)
+		}
+		w.csvBuffer = nil
+	}
+
+	w.csvWriter.Flush()
+	if err := w.csvWriter.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV stream: %w", err)
+	}
+
+	if err := w.csvFile.Close(); err != nil {
+		return fmt.Errorf("failed to close FIFO: %w", err)
+	}
 
 	return nil
 }
 
+// isFIFO reports whether path exists and is a named pipe
+func isFIFO(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeNamedPipe != 0
+}
+
 // GetQueryPath returns the DuckDB query path for all data
 func (fm *FileManager) GetQueryPath() string {
 	pattern := filepath.Join(
 		fm.config.OutputDir,
 		"**",
-		fmt.Sprintf("*.%s", string(fm.config.Format)),
+		fmt.Sprintf("*.%s", fm.fileExtension()),
 	)
 	return pattern
 }
@@ -1,9 +1,15 @@
 package exporter
 
 import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -38,6 +44,22 @@ func TestNewFileManager(t *testing.T) {
 	}
 }
 
+func TestExportIDUnique(t *testing.T) {
+	config := StorageConfig{OutputDir: "/tmp/test", Format: FormatCSV, MaxRecords: 1000}
+
+	first := NewFileManager(config).metadata.ExportID
+	second := NewFileManager(config).metadata.ExportID
+	if first == second {
+		t.Errorf("Expected distinct export IDs, got %s twice", first)
+	}
+
+	// IDs still sort by start time
+	now := time.Unix(1705329000, 0)
+	if id := newExportID(now); !strings.HasPrefix(id, "export_1705329000_") || len(id) != len("export_1705329000_")+8 {
+		t.Errorf("Unexpected export ID format: %s", id)
+	}
+}
+
 func TestCreateHivePartitionPath(t *testing.T) {
 	config := StorageConfig{
 		OutputDir:  "/tmp/test",
@@ -141,6 +163,18 @@ func TestCSVWriting(t *testing.T) {
 		t.Error("No partitions found in metadata")
 	}
 
+	// Verify the per-type profile
+	expectedCounts := map[string]int64{"string": 1, "hash": 1, "set": 1}
+	expectedBytes := map[string]int64{"string": 6, "hash": 40, "set": 7}
+	for recordType, count := range expectedCounts {
+		if metadata.TypeCounts[recordType] != count {
+			t.Errorf("Expected %d %s records, got %d", count, recordType, metadata.TypeCounts[recordType])
+		}
+		if metadata.TypeBytes[recordType] != expectedBytes[recordType] {
+			t.Errorf("Expected %d %s bytes, got %d", expectedBytes[recordType], recordType, metadata.TypeBytes[recordType])
+		}
+	}
+
 	// Verify CSV files exist in Hive structure
 	found := false
 	err = filepath.Walk(tempDir, func(path string, info os.FileInfo, err error) error {
@@ -377,6 +411,285 @@ func TestFileRotation(t *testing.T) {
 	}
 }
 
+func TestSinglePartition(t *testing.T) {
+	// Fewer records than MaxRecords are only finished by Close
+	for _, format := range []OutputFormat{FormatCSV, FormatParquet} {
+		t.Run(string(format), func(t *testing.T) {
+			tempDir := t.TempDir()
+			fm := NewFileManager(StorageConfig{
+				OutputDir:  tempDir,
+				Format:     format,
+				MaxRecords: 1000,
+			})
+
+			for i := 0; i < 5; i++ {
+				record := &RedisRecord{
+					Key:        fmt.Sprintf("key%d", i),
+					Type:       "string",
+					Value:      "value",
+					TTLSeconds: -1,
+					ExportedAt: "2024-01-15T14:30:00Z",
+				}
+				if err := fm.WriteRecord(record); err != nil {
+					t.Fatalf("Failed to write record: %v", err)
+				}
+			}
+			if len(fm.metadata.Partitions) != 0 {
+				t.Fatalf("Expected no finished partition before Close, got %d", len(fm.metadata.Partitions))
+			}
+			if err := fm.Close(); err != nil {
+				t.Fatalf("Failed to close file manager: %v", err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(tempDir, metadataFileName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var metadata ExportMetadata
+			if err := json.Unmarshal(data, &metadata); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(metadata.Partitions) != 1 {
+				t.Fatalf("Expected exactly 1 partition, got %d", len(metadata.Partitions))
+			}
+			partition := metadata.Partitions[0]
+			if partition.RecordCount != 5 {
+				t.Errorf("Expected 5 records, got %d", partition.RecordCount)
+			}
+			if partition.PartitionID != 1 {
+				t.Errorf("Expected partition ID 1, got %d", partition.PartitionID)
+			}
+			expectedName := "redis_data_part_0001." + fm.fileExtension()
+			if partition.FileName != expectedName {
+				t.Errorf("Expected file %s, got %s", expectedName, partition.FileName)
+			}
+
+			// The partition spans the export, never more
+			if partition.StartTime.Before(metadata.StartTime) || partition.EndTime.After(metadata.EndTime) ||
+				partition.EndTime.Before(partition.StartTime) {
+				t.Errorf("Expected partition times %s - %s within export %s - %s",
+					partition.StartTime, partition.EndTime, metadata.StartTime, metadata.EndTime)
+			}
+
+			files := findDataFiles(t, tempDir, "."+fm.fileExtension())
+			if len(files) != 1 {
+				t.Fatalf("Expected 1 data file, got %d", len(files))
+			}
+			if files[0] != filepath.Join(tempDir, partition.Path) {
+				t.Errorf("Expected data file at %s, got %s", partition.Path, files[0])
+			}
+			stat, err := os.Stat(files[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stat.Size() == 0 || stat.Size() != partition.FileSizeBytes {
+				t.Errorf("Expected a non-empty file of %d bytes, got %d", partition.FileSizeBytes, stat.Size())
+			}
+		})
+	}
+}
+
+func TestRawDumpColumn(t *testing.T) {
+	tests := []struct {
+		name             string
+		includeRawDump   bool
+		omitPartitionID  bool
+		includeParentKey bool
+		expected         []string
+	}{
+		{
+			name:     "default schema",
+			expected: []string{"key", "type", "value", "ttl_seconds", "exported_at", "partition_id"},
+		},
+		{
+			name:           "dual mode schema",
+			includeRawDump: true,
+			expected:       []string{"key", "type", "value", "ttl_seconds", "exported_at", "partition_id", "raw_dump"},
+		},
+		{
+			name:            "without partition id",
+			omitPartitionID: true,
+			expected:        []string{"key", "type", "value", "ttl_seconds", "exported_at"},
+		},
+		{
+			name:             "with parent key",
+			includeRawDump:   true,
+			includeParentKey: true,
+			expected:         []string{"key", "type", "value", "ttl_seconds", "exported_at", "partition_id", "parent_key", "raw_dump"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "redis_dumper_schema_test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				if err := os.RemoveAll(tempDir); err != nil {
+					t.Logf("Warning: failed to remove temp dir: %v", err)
+				}
+			}()
+
+			fm := NewFileManager(StorageConfig{
+				OutputDir:      tempDir,
+				Format:         FormatCSV,
+				MaxRecords:     1000,
+				IncludeRawDump: tt.includeRawDump,
+
+				OmitPartitionID:  tt.omitPartitionID,
+				IncludeParentKey: tt.includeParentKey,
+			})
+
+			record := &RedisRecord{
+				Key:        "test:key",
+				Type:       "string",
+				Value:      "size=5",
+				TTLSeconds: -1,
+				ExportedAt: "2024-01-15T14:30:00Z",
+				RawDump:    "AAVoZWxsbwsA",
+			}
+			if err := fm.WriteRecord(record); err != nil {
+				t.Fatalf("Failed to write record: %v", err)
+			}
+			if err := fm.Close(); err != nil {
+				t.Fatalf("Failed to close file manager: %v", err)
+			}
+
+			rows := readCSVRows(t, tempDir)
+			if len(rows) != 2 {
+				t.Fatalf("Expected header and 1 row, got %d rows", len(rows))
+			}
+
+			if strings.Join(rows[0], ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected headers %v, got %v", tt.expected, rows[0])
+			}
+			if len(rows[1]) != len(tt.expected) {
+				t.Errorf("Expected %d values, got %d", len(tt.expected), len(rows[1]))
+			}
+			if tt.includeRawDump && rows[1][len(rows[1])-1] != record.RawDump {
+				t.Errorf("Expected raw_dump %s, got %s", record.RawDump, rows[1][len(rows[1])-1])
+			}
+		})
+	}
+}
+
+func TestReproducibleOutput(t *testing.T) {
+	records := []*RedisRecord{
+		{Key: "key3", Type: "string", Value: "value3", TTLSeconds: -1, ExportedAt: "2024-01-15T14:30:00Z"},
+		{Key: "key1", Type: "string", Value: "value1", TTLSeconds: 3600, ExportedAt: "2024-01-15T14:30:01Z"},
+		{Key: "key2", Type: "string", Value: "value2", TTLSeconds: -1, ExportedAt: "2024-01-15T14:30:02Z"},
+	}
+
+	for _, format := range []OutputFormat{FormatCSV, FormatParquet} {
+		t.Run(string(format), func(t *testing.T) {
+			var hashes []string
+
+			for run := 0; run < 2; run++ {
+				tempDir, err := os.MkdirTemp("", "redis_dumper_reproducible_test")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer func() {
+					if err := os.RemoveAll(tempDir); err != nil {
+						t.Logf("Warning: failed to remove temp dir: %v", err)
+					}
+				}()
+
+				fm := NewFileManager(StorageConfig{
+					OutputDir:    tempDir,
+					Format:       format,
+					MaxRecords:   1000,
+					Reproducible: true,
+
+					IncludeIdleTime: true,
+				})
+
+				// Write in a different order and with different timestamps each run
+				for i := range records {
+					record := *records[(i+run)%len(records)]
+					record.ExportedAt = time.Now().Format(time.RFC3339Nano)
+					record.IdleSeconds = int64(run*10 + i)
+					if err := fm.WriteRecord(&record); err != nil {
+						t.Fatalf("Failed to write record: %v", err)
+					}
+				}
+				if err := fm.Close(); err != nil {
+					t.Fatalf("Failed to close file manager: %v", err)
+				}
+
+				var files []string
+				err = filepath.Walk(tempDir, func(path string, info os.FileInfo, err error) error {
+					if err == nil && filepath.Ext(path) == "."+string(format) {
+						files = append(files, path)
+					}
+					return err
+				})
+				if err != nil || len(files) != 1 {
+					t.Fatalf("Expected a single data file, got %v (%v)", files, err)
+				}
+
+				content, err := os.ReadFile(files[0])
+				if err != nil {
+					t.Fatal(err)
+				}
+				sum := sha256.Sum256(content)
+				hashes = append(hashes, hex.EncodeToString(sum[:]))
+
+				if format == FormatCSV {
+					rows := readCSVRows(t, tempDir)
+					if rows[1][0] != "key1" || rows[3][0] != "key3" {
+						t.Errorf("Expected rows sorted by key, got %v", rows)
+					}
+					if rows[1][3] != "" {
+						t.Errorf("Expected idle_seconds written as unknown, got %s", rows[1][3])
+					}
+					if rows[1][5] != reproducibleTimestamp {
+						t.Errorf("Expected normalized exported_at, got %s", rows[1][5])
+					}
+				}
+			}
+
+			if hashes[0] != hashes[1] {
+				t.Errorf("Expected identical files across runs, got %s and %s", hashes[0], hashes[1])
+			}
+		})
+	}
+}
+
+// readCSVRows reads every row from all CSV files under dir
+func readCSVRows(t *testing.T, dir string) [][]string {
+	t.Helper()
+
+	var rows [][]string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || filepath.Ext(path) != ".csv" {
+			return err
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+
+		fileRows, err := csv.NewReader(file).ReadAll()
+		if err != nil {
+			return err
+		}
+		rows = append(rows, fileRows...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error reading CSV files: %v", err)
+	}
+
+	return rows
+}
+
 func TestGetQueryPath(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -584,3 +897,466 @@ func BenchmarkParquetWriting(b *testing.B) {
 		b.Errorf("Failed to close file manager: %v", err)
 	}
 }
+
+func TestRotateAfterFailedInit(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "redis_dumper_rotate_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Logf("Warning: failed to remove temp dir: %v", err)
+		}
+	}()
+
+	// A file where the output directory should be makes initialization fail
+	outputDir := filepath.Join(tempDir, "output")
+	if err := os.WriteFile(outputDir, []byte("not a directory"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fm := NewFileManager(StorageConfig{
+		OutputDir:  outputDir,
+		Format:     FormatParquet,
+		MaxRecords: 1000,
+	})
+
+	record := &RedisRecord{
+		Key:        "test:key",
+		Type:       "string",
+		Value:      "value",
+		TTLSeconds: -1,
+		ExportedAt: "2024-01-15T14:30:00Z",
+	}
+	if err := fm.WriteRecord(record); err == nil {
+		t.Fatal("Expected error initializing writer under a file")
+	}
+
+	// Rotating after the failed init must not COPY anywhere
+	if err := fm.RotateWriter(); err != nil {
+		t.Errorf("Expected no-op rotation after failed init, got %v", err)
+	}
+	if len(fm.metadata.Partitions) != 0 {
+		t.Errorf("Expected no partitions, got %d", len(fm.metadata.Partitions))
+	}
+}
+
+func TestRotateMissingPartitionDir(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "redis_dumper_rotate_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Logf("Warning: failed to remove temp dir: %v", err)
+		}
+	}()
+
+	fm := NewFileManager(StorageConfig{
+		OutputDir:  tempDir,
+		Format:     FormatParquet,
+		MaxRecords: 1000,
+	})
+
+	record := &RedisRecord{
+		Key:        "test:key",
+		Type:       "string",
+		Value:      "value",
+		TTLSeconds: -1,
+		ExportedAt: "2024-01-15T14:30:00Z",
+	}
+	if err := fm.WriteRecord(record); err != nil {
+		t.Fatalf("Failed to write record: %v", err)
+	}
+
+	// Lose the partition directory before rotation
+	w := fm.writers[""]
+	if err := os.RemoveAll(w.path); err != nil {
+		t.Fatal(err)
+	}
+
+	err = fm.RotateWriter()
+	if err == nil || !strings.Contains(err.Error(), "partition directory") {
+		t.Fatalf("Expected partition directory error, got %v", err)
+	}
+	if len(fm.writers) != 0 {
+		t.Errorf("Expected failed writer to be released, got %d writers", len(fm.writers))
+	}
+
+	// An uninitialized path is rejected the same way
+	if err := validatePartitionDir(""); err == nil {
+		t.Error("Expected error for empty partition directory")
+	}
+}
+
+func TestIntermediateFlush(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "redis_dumper_flush_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Logf("Warning: failed to remove temp dir: %v", err)
+		}
+	}()
+
+	fm := NewFileManager(StorageConfig{
+		OutputDir:         tempDir,
+		Format:            FormatParquet,
+		MaxRecords:        1000,
+		IntermediateFlush: 2,
+	})
+
+	for i := 0; i < 5; i++ {
+		record := &RedisRecord{
+			Key:        fmt.Sprintf("test:key%d", i),
+			Type:       "string",
+			Value:      "value",
+			TTLSeconds: -1,
+			ExportedAt: "2024-01-15T14:30:00Z",
+		}
+		if err := fm.WriteRecord(record); err != nil {
+			t.Fatalf("Failed to write record: %v", err)
+		}
+	}
+
+	// Before rotation the last flush at 4 records is already on disk
+	files := findDataFiles(t, tempDir, ".parquet")
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 flushed Parquet file, got %d", len(files))
+	}
+	if rows := countParquetRows(t, files[0]); rows != 4 {
+		t.Errorf("Expected 4 flushed rows, got %d", rows)
+	}
+
+	if err := fm.Close(); err != nil {
+		t.Fatalf("Failed to close file manager: %v", err)
+	}
+	if rows := countParquetRows(t, files[0]); rows != 5 {
+		t.Errorf("Expected 5 rows after close, got %d", rows)
+	}
+	if tmp := findDataFiles(t, tempDir, ".tmp"); len(tmp) != 0 {
+		t.Errorf("Expected no temporary files, got %v", tmp)
+	}
+}
+
+func TestFlushDueParquet(t *testing.T) {
+	tempDir := t.TempDir()
+	fm := NewFileManager(StorageConfig{
+		OutputDir:  tempDir,
+		Format:     FormatParquet,
+		MaxRecords: 1000,
+	})
+	if fm.FlushTicks() != nil {
+		t.Error("Expected no flush ticks without FLUSH_EVERY")
+	}
+
+	// Nothing is buffered yet, so there is nothing to write
+	if err := fm.FlushDue(); err != nil {
+		t.Fatalf("FlushDue failed: %v", err)
+	}
+	if files := findDataFiles(t, tempDir, ".parquet"); len(files) != 0 {
+		t.Fatalf("Expected no Parquet files before any record, got %v", files)
+	}
+
+	if err := fm.WriteRecord(&RedisRecord{Key: "k", Type: "string", Value: "v", TTLSeconds: ttlNoExpiry, ExportedAt: "2024-01-15T14:30:00Z"}); err != nil {
+		t.Fatalf("Failed to write record: %v", err)
+	}
+	if err := fm.FlushDue(); err != nil {
+		t.Fatalf("FlushDue failed: %v", err)
+	}
+	files := findDataFiles(t, tempDir, ".parquet")
+	if len(files) != 1 || countParquetRows(t, files[0]) != 1 {
+		t.Fatalf("Expected the open partition written with 1 row, got %v", files)
+	}
+
+	if err := fm.Close(); err != nil {
+		t.Fatalf("Failed to close file manager: %v", err)
+	}
+}
+
+func TestFlushTicksStopOnClose(t *testing.T) {
+	fm := NewFileManager(StorageConfig{
+		OutputDir:  t.TempDir(),
+		Format:     FormatCSV,
+		MaxRecords: 1000,
+		FlushEvery: time.Millisecond,
+	})
+	ticks := fm.FlushTicks()
+	if ticks == nil {
+		t.Fatal("Expected flush ticks with FLUSH_EVERY")
+	}
+	<-ticks
+
+	if err := fm.Close(); err != nil {
+		t.Fatalf("Failed to close file manager: %v", err)
+	}
+	// Drain a tick sent before Close, then expect silence
+	select {
+	case <-ticks:
+	default:
+	}
+	select {
+	case <-ticks:
+		t.Error("Expected no ticks after Close")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+// countParquetRows counts the rows in a Parquet file using DuckDB
+func countParquetRows(t *testing.T, path string) int {
+	t.Helper()
+
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var count int
+	if err := db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM read_parquet('%s')", path)).Scan(&count); err != nil {
+		t.Fatalf("Failed to read Parquet file: %v", err)
+	}
+	return count
+}
+
+func TestConcurrentCopies(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "redis_dumper_copies_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Logf("Warning: failed to remove temp dir: %v", err)
+		}
+	}()
+
+	fm := NewFileManager(StorageConfig{
+		OutputDir:           tempDir,
+		Format:              FormatParquet,
+		MaxRecords:          2,
+		MaxConcurrentCopies: 2,
+	})
+
+	for i := 0; i < 9; i++ {
+		record := &RedisRecord{
+			Key:        fmt.Sprintf("test:key%d", i),
+			Type:       "string",
+			Value:      "value",
+			TTLSeconds: -1,
+			ExportedAt: "2024-01-15T14:30:00Z",
+		}
+		if err := fm.WriteRecord(record); err != nil {
+			t.Fatalf("Failed to write record: %v", err)
+		}
+	}
+	if err := fm.Close(); err != nil {
+		t.Fatalf("Failed to close file manager: %v", err)
+	}
+
+	// Background copies finish out of order but metadata is sorted
+	partitions := fm.metadata.Partitions
+	if len(partitions) != 5 {
+		t.Fatalf("Expected 5 partitions, got %d", len(partitions))
+	}
+	rows := 0
+	for i, partition := range partitions {
+		if partition.PartitionID != i+1 {
+			t.Errorf("Expected partition %d at index %d, got %d", i+1, i, partition.PartitionID)
+		}
+		rows += countParquetRows(t, filepath.Join(tempDir, partition.Path))
+	}
+	if rows != 9 {
+		t.Errorf("Expected 9 rows across partitions, got %d", rows)
+	}
+}
+
+func TestFailedCopyMarksExportPartial(t *testing.T) {
+	tests := []struct {
+		name   string
+		damage func(t *testing.T, path string)
+	}{
+		// The partition directory disappears before the final rotation
+		{"directory removed", func(t *testing.T, path string) {
+			if err := os.RemoveAll(filepath.Dir(path)); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		// The background COPY cannot create its temporary file
+		{"copy fails", func(t *testing.T, path string) {
+			blocker := filepath.Join(path, "redis_data_part_0001.parquet.tmp", "blocker")
+			if err := os.MkdirAll(blocker, 0755); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			fm := NewFileManager(StorageConfig{
+				OutputDir:           tempDir,
+				Format:              FormatParquet,
+				MaxRecords:          1000,
+				MaxConcurrentCopies: 1,
+			})
+
+			record := &RedisRecord{Key: "test:key", Type: "string", Value: "value", TTLSeconds: -1, ExportedAt: "2024-01-15T14:30:00Z"}
+			if err := fm.WriteRecord(record); err != nil {
+				t.Fatalf("Failed to write record: %v", err)
+			}
+			if err := fm.WriteCheckpoint(1, 0); err != nil {
+				t.Fatal(err)
+			}
+			for _, w := range fm.writers {
+				tt.damage(t, w.path)
+			}
+
+			if err := fm.Close(); err == nil {
+				t.Fatal("Expected Close to report the lost partition")
+			}
+
+			data, err := os.ReadFile(filepath.Join(tempDir, metadataFileName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var metadata ExportMetadata
+			if err := json.Unmarshal(data, &metadata); err != nil {
+				t.Fatal(err)
+			}
+			if metadata.Status != exportStatusPartial || metadata.Error == "" {
+				t.Errorf("Expected a partial export with an error, got status=%q error=%q", metadata.Status, metadata.Error)
+			}
+			if _, err := os.Stat(filepath.Join(tempDir, checkpointFileName)); err != nil {
+				t.Errorf("Expected the checkpoint to be kept, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMaterializePartitionCols(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "redis_dumper_partition_cols_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Logf("Warning: failed to remove temp dir: %v", err)
+		}
+	}()
+
+	fm := NewFileManager(StorageConfig{
+		OutputDir:                tempDir,
+		Format:                   FormatParquet,
+		MaxRecords:               1000,
+		MaterializePartitionCols: true,
+	})
+
+	record := &RedisRecord{
+		Key:        "test:key",
+		Type:       "string",
+		Value:      "value",
+		TTLSeconds: -1,
+		ExportedAt: "2024-01-15T14:30:00Z",
+	}
+	if err := fm.WriteRecord(record); err != nil {
+		t.Fatalf("Failed to write record: %v", err)
+	}
+	if err := fm.Close(); err != nil {
+		t.Fatalf("Failed to close file manager: %v", err)
+	}
+
+	files := findDataFiles(t, tempDir, ".parquet")
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 Parquet file, got %d", len(files))
+	}
+
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var year, month, day, hour string
+	query := fmt.Sprintf("SELECT year, month, day, hour FROM read_parquet('%s', hive_partitioning=false)", files[0])
+	if err := db.QueryRow(query).Scan(&year, &month, &day, &hour); err != nil {
+		t.Fatalf("Failed to read partition columns: %v", err)
+	}
+
+	// Columns hold exactly the directory values
+	dir := fmt.Sprintf("year=%s/month=%s/day=%s/hour=%s", year, month, day, hour)
+	if !strings.Contains(filepath.ToSlash(files[0]), dir) {
+		t.Errorf("Expected file under %s, got %s", dir, files[0])
+	}
+}
+
+func TestOmitValueParquet(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "redis_dumper_omit_value_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Logf("Warning: failed to remove temp dir: %v", err)
+		}
+	}()
+
+	fm := NewFileManager(StorageConfig{
+		OutputDir:  tempDir,
+		Format:     FormatParquet,
+		MaxRecords: 1000,
+		OmitValue:  true,
+	})
+
+	record := &RedisRecord{
+		Key:          "test:key",
+		Type:         "hash",
+		Value:        "size_estimate=80",
+		TTLSeconds:   -1,
+		ExportedAt:   "2024-01-15T14:30:00Z",
+		SizeEstimate: 80,
+	}
+	if err := fm.WriteRecord(record); err != nil {
+		t.Fatalf("Failed to write record: %v", err)
+	}
+	if err := fm.Close(); err != nil {
+		t.Fatalf("Failed to close file manager: %v", err)
+	}
+
+	files := findDataFiles(t, tempDir, ".parquet")
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 Parquet file, got %d", len(files))
+	}
+
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	rows, err := db.Query(fmt.Sprintf("SELECT * FROM read_parquet('%s', hive_partitioning=false)", files[0]))
+	if err != nil {
+		t.Fatalf("Failed to read Parquet file: %v", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	columns, err := rows.Columns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(columns, ",") != strings.Join(fm.columnNames(), ",") || strings.Contains(strings.Join(columns, ","), "value") {
+		t.Errorf("Expected columns %v without value, got %v", fm.columnNames(), columns)
+	}
+
+	var size int64
+	if err := db.QueryRow(fmt.Sprintf("SELECT size_estimate FROM read_parquet('%s')", files[0])).Scan(&size); err != nil || size != 80 {
+		t.Errorf("Expected size_estimate 80, got %d (%v)", size, err)
+	}
+}
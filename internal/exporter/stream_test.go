@@ -0,0 +1,121 @@
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestParseStreamSince(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	tests := []struct {
+		since    string
+		expected string
+		wantErr  bool
+	}{
+		{"", "-", false},
+		{"1699999999000", "1699999999000", false},
+		{"-5m", "1699999700000", false},
+		{"-bogus", "", true},
+		{"yesterday", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseStreamSince(tt.since, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseStreamSince(%q) error = %v, wantErr %v", tt.since, err, tt.wantErr)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("parseStreamSince(%q) = %s, expected %s", tt.since, got, tt.expected)
+		}
+	}
+}
+
+func TestExportStreamSince(t *testing.T) {
+	// A one-entry chunk exercises resuming XRANGE after the last ID
+	exp, mr := newTestExporter(t, RedisExporterOptions{StreamSince: "2000", ListChunkSize: 1})
+
+	for _, id := range []string{"1000-0", "2000-0", "2000-1", "3000-0"} {
+		if _, err := mr.XAdd("events", id, []string{"user", "42", "action", "login"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outputDir := exp.fileManager.config.OutputDir
+	if err := exp.ExportByPattern("events"); err != nil {
+		t.Fatalf("ExportByPattern failed: %v", err)
+	}
+
+	entries := make(map[string]string)
+	var info []string
+	for _, row := range readCSVRows(t, outputDir)[1:] {
+		switch row[1] {
+		case "stream_entry":
+			entries[row[0]] = row[2]
+		case "stream_info":
+			info = append(info, row[0]+" "+row[2])
+		}
+	}
+
+	// The info record covers the whole stream, not just the exported entries
+	if len(info) != 1 || info[0] != "events length=4,first_id=1000-0,last_id=3000-0" {
+		t.Errorf("Expected one stream_info record for the whole stream, got %v", info)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries since 2000, got %v", entries)
+	}
+	if _, ok := entries["events:entry:1000-0"]; ok {
+		t.Error("Expected entry before STREAM_SINCE to be skipped")
+	}
+	if value := entries["events:entry:3000-0"]; value != `{"action":"login","user":"42"}` {
+		t.Errorf("Unexpected entry value: %s", value)
+	}
+}
+
+func TestExportStreamGroups(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{})
+	for _, id := range []string{"1000-0", "2000-0", "3000-0"} {
+		if _, err := mr.XAdd("events", id, []string{"user", "42"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// One consumer has read, but not acknowledged, the first two entries
+	ctx := context.Background()
+	if err := exp.client.XGroupCreate(ctx, "events", "billing", "0").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := exp.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group: "billing", Consumer: "worker-1", Streams: []string{"events", ">"}, Count: 2,
+	}).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := exp.ExportByPattern("events"); err != nil {
+		t.Fatalf("ExportByPattern failed: %v", err)
+	}
+
+	records := make(map[string]string)
+	for _, row := range readCSVRows(t, exp.fileManager.config.OutputDir)[1:] {
+		if row[1] == "stream_group" || row[1] == "stream_consumer" {
+			records[row[0]] = row[2]
+		}
+	}
+
+	group := records["events:group:billing"]
+	if !strings.HasPrefix(group, "consumers=1,pending=2,last_delivered_id=2000-0,") {
+		t.Errorf("Unexpected group record: %q", group)
+	}
+	consumer := records["events:group:billing:consumer:worker-1"]
+	if !strings.HasPrefix(consumer, "pending=2,idle_ms=") {
+		t.Errorf("Unexpected consumer record: %q", consumer)
+	}
+	if len(records) != 2 {
+		t.Errorf("Expected a group and a consumer record, got %v", records)
+	}
+}
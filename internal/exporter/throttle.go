@@ -0,0 +1,122 @@
+package exporter
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// defaultThrottleInterval is how often the server latency is sampled
+	defaultThrottleInterval = time.Second
+	// Bounds for the pause inserted before each SCAN call and batch read
+	minThrottleDelay = 10 * time.Millisecond
+	maxThrottleDelay = 5 * time.Second
+)
+
+// throttle slows the export down while the server is under pressure. The
+// scanner PINGs the node it scans every interval; while the round trip
+// exceeds target, the pause taken before each SCAN call and each batch read
+// doubles, and it halves again once the latency falls under half the target.
+type throttle struct {
+	target   time.Duration
+	interval time.Duration
+
+	// sampledAt is only touched by the scanner goroutine
+	sampledAt time.Time
+	delay     atomic.Int64
+	paused    atomic.Int64
+	peak      atomic.Int64
+}
+
+// newThrottle returns nil, which never pauses, when target is not positive
+func newThrottle(target, interval time.Duration) *throttle {
+	if target <= 0 {
+		return nil
+	}
+	if interval <= 0 {
+		interval = defaultThrottleInterval
+	}
+	return &throttle{target: target, interval: interval}
+}
+
+// nextThrottleDelay adapts the pause to the latest latency sample
+func nextThrottleDelay(current, latency, target time.Duration) time.Duration {
+	if latency > target {
+		next := current * 2
+		if next < minThrottleDelay {
+			next = minThrottleDelay
+		}
+		if next > maxThrottleDelay {
+			next = maxThrottleDelay
+		}
+		return next
+	}
+
+	if latency < target/2 {
+		next := current / 2
+		if next < minThrottleDelay {
+			next = 0
+		}
+		return next
+	}
+
+	return current
+}
+
+// sampleLatency PINGs node when the interval has elapsed and adapts the
+// pause. A failed PING is left to the SCAN retries and changes nothing.
+func (re *RedisExporter) sampleLatency(ctx context.Context, node redis.Cmdable) {
+	t := re.throttle
+	if t == nil || time.Since(t.sampledAt) < t.interval {
+		return
+	}
+	t.sampledAt = time.Now()
+
+	batchCtx, cancel := re.batchContext(ctx)
+	defer cancel()
+	startedAt := time.Now()
+	if err := node.Ping(batchCtx).Err(); err != nil {
+		re.verbosity.debugf("Latency sample failed: %v\n", err)
+		return
+	}
+	latency := time.Since(startedAt)
+
+	current := time.Duration(t.delay.Load())
+	next := nextThrottleDelay(current, latency, t.target)
+	if next == current {
+		return
+	}
+	t.delay.Store(int64(next))
+	if next > time.Duration(t.peak.Load()) {
+		t.peak.Store(int64(next))
+	}
+	switch {
+	case next == 0:
+		re.verbosity.infof("Server latency %s is back under %s - no longer throttling\n", latency.Round(time.Microsecond), t.target)
+	case next > current:
+		re.verbosity.infof("Server latency %s exceeds %s - pausing %s before each batch\n", latency.Round(time.Microsecond), t.target, next)
+	default:
+		re.verbosity.debugf("Server latency %s - easing the pause to %s\n", latency.Round(time.Microsecond), next)
+	}
+}
+
+// pause waits for the current throttle delay, or until ctx is done
+func (t *throttle) pause(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	delay := time.Duration(t.delay.Load())
+	if delay <= 0 {
+		return
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		t.paused.Add(int64(delay))
+	case <-ctx.Done():
+	}
+}
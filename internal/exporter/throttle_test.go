@@ -0,0 +1,80 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestNextThrottleDelay(t *testing.T) {
+	target := 10 * time.Millisecond
+	tests := []struct {
+		name     string
+		current  time.Duration
+		latency  time.Duration
+		expected time.Duration
+	}{
+		{"starts pausing", 0, 20 * time.Millisecond, minThrottleDelay},
+		{"doubles", 40 * time.Millisecond, 20 * time.Millisecond, 80 * time.Millisecond},
+		{"capped", maxThrottleDelay, time.Second, maxThrottleDelay},
+		{"holds near target", 40 * time.Millisecond, 8 * time.Millisecond, 40 * time.Millisecond},
+		{"halves", 40 * time.Millisecond, time.Millisecond, 20 * time.Millisecond},
+		{"stops", minThrottleDelay, time.Millisecond, 0},
+		{"idle", 0, time.Millisecond, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextThrottleDelay(tt.current, tt.latency, target); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// slowPingHook delays PING replies, as a loaded server would
+type slowPingHook struct {
+	passHook
+	delay time.Duration
+}
+
+func (h slowPingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() == "ping" {
+			time.Sleep(h.delay)
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func TestThrottlePausesSlowServer(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{
+		BatchSize:        2,
+		ThrottleLatency:  time.Millisecond,
+		ThrottleInterval: time.Nanosecond,
+	})
+	for i := 0; i < 10; i++ {
+		mr.Set(fmt.Sprintf("key:%02d", i), "value")
+	}
+	exp.client.AddHook(slowPingHook{delay: 5 * time.Millisecond})
+
+	if err := exp.ExportKeysOnlyByPattern("key:*"); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if rows := readCSVRows(t, exp.fileManager.config.OutputDir); len(rows)-1 != 10 {
+		t.Errorf("Expected 10 keys, got %d", len(rows)-1)
+	}
+	if exp.throttle.paused.Load() == 0 {
+		t.Error("Expected the export to pause while PING was slow")
+	}
+}
+
+func TestThrottleDisabled(t *testing.T) {
+	exp, _ := newTestExporter(t, RedisExporterOptions{})
+	if exp.throttle != nil {
+		t.Error("Expected no throttle without a latency threshold")
+	}
+}
@@ -0,0 +1,52 @@
+package exporter
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// loadCAPool reads PEM certificates from a file, or from every .pem, .crt and
+// .cer file directly inside a directory, into a pool for verifying the server
+func loadCAPool(path string) (*x509.CertPool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open TLS CA file: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS CA directory: %w", err)
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			switch strings.ToLower(filepath.Ext(entry.Name())) {
+			case ".pem", ".crt", ".cer":
+				if !entry.IsDir() {
+					files = append(files, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+	}
+
+	pool := x509.NewCertPool()
+	loaded := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS CA file: %w", err)
+		}
+		if pool.AppendCertsFromPEM(data) {
+			loaded++
+		}
+	}
+	// An empty pool would reject every server with a confusing error
+	if loaded == 0 {
+		return nil, fmt.Errorf("no PEM certificates found in TLS CA path %s", path)
+	}
+	return pool, nil
+}
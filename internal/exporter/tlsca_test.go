@@ -0,0 +1,140 @@
+package exporter
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// testPKI returns a PEM CA certificate and a server certificate it signed
+// for 127.0.0.1
+func testPKI(t *testing.T) ([]byte, tls.Certificate) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "redis"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	serverDER, err := x509.CreateCertificate(rand.Reader, serverTemplate, caCert, &serverKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	return caPEM, tls.Certificate{Certificate: [][]byte{serverDER}, PrivateKey: serverKey}
+}
+
+func TestLoadCAPool(t *testing.T) {
+	caPEM, _ := testPKI(t)
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, caPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not a certificate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{caFile, dir} {
+		if _, err := loadCAPool(path); err != nil {
+			t.Errorf("loadCAPool(%s) failed: %v", path, err)
+		}
+	}
+
+	if _, err := loadCAPool(filepath.Join(dir, "README")); err == nil {
+		t.Error("Expected a file without certificates to be rejected")
+	}
+	if _, err := loadCAPool(t.TempDir()); err == nil {
+		t.Error("Expected an empty directory to be rejected")
+	}
+	if _, err := loadCAPool(filepath.Join(dir, "missing.pem")); err == nil {
+		t.Error("Expected a missing file to be rejected")
+	}
+}
+
+func TestTLSCAFileVerifiesServer(t *testing.T) {
+	caPEM, serverCert := testPKI(t)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, caPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mr := miniredis.NewMiniRedis()
+	if err := mr.StartTLS(&tls.Config{Certificates: []tls.Certificate{serverCert}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mr.Close)
+
+	opts := RedisExporterOptions{
+		RedisURL:     "redis://" + mr.Addr() + "/0",
+		OutputDir:    t.TempDir(),
+		OutputFormat: "csv",
+		EnableTLS:    true,
+	}
+
+	// Without the CA the internal certificate cannot be verified
+	if _, err := NewRedisExporter(opts); err == nil {
+		t.Fatal("Expected verification against the system roots to fail")
+	}
+
+	opts.TLSCAFile = caFile
+	// The CA turns verification on even though SKIP_TLS_VERIFY is set
+	opts.SkipTLSVerify = true
+	exp, err := NewRedisExporter(opts)
+	if err != nil {
+		t.Fatalf("Expected the CA to verify the server, got %v", err)
+	}
+	if err := exp.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+
+	opts.EnableTLS = false
+	if _, err := NewRedisExporter(opts); err == nil || !strings.Contains(err.Error(), "TLS_CA_FILE") {
+		t.Errorf("Expected TLS_CA_FILE without TLS to be rejected, got %v", err)
+	}
+}
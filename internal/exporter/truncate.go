@@ -0,0 +1,52 @@
+package exporter
+
+import "fmt"
+
+const (
+	// TruncateHead keeps the first MAX_ELEMENTS_PER_KEY elements of a list
+	TruncateHead = "head"
+	// TruncateTail keeps the last MAX_ELEMENTS_PER_KEY elements of a list
+	TruncateTail = "tail"
+)
+
+// validateTruncation checks MAX_ELEMENTS_PER_KEY and MAX_ELEMENTS_FROM,
+// returning the side to keep
+func validateTruncation(maxElements int64, from string) (string, error) {
+	if maxElements < 0 {
+		return "", fmt.Errorf("MAX_ELEMENTS_PER_KEY must not be negative, got %d", maxElements)
+	}
+	switch from {
+	case TruncateHead, "":
+		return TruncateHead, nil
+	case TruncateTail:
+		return TruncateTail, nil
+	default:
+		return "", fmt.Errorf("unsupported MAX_ELEMENTS_FROM: %s", from)
+	}
+}
+
+// listWindow returns the indexes [first, last) of a list of length to export,
+// and whether MAX_ELEMENTS_PER_KEY cut it short
+func (re *RedisExporter) listWindow(length int64) (first, last int64, truncated bool) {
+	if re.maxElements <= 0 || length <= re.maxElements {
+		return 0, length, false
+	}
+	if re.maxElementsFrom == TruncateTail {
+		return length - re.maxElements, length, true
+	}
+	return 0, re.maxElements, true
+}
+
+// markTruncated records the full length of a list cut short on its key
+// record and counts the key
+func (re *RedisExporter) markTruncated(record *RedisRecord, length int64) {
+	re.fileManager.AddTruncatedKeys(1)
+	record.FullLength = length
+}
+
+// reportTruncatedKeys prints how many lists MAX_ELEMENTS_PER_KEY cut short
+func (re *RedisExporter) reportTruncatedKeys() {
+	if n := re.fileManager.metadata.TruncatedKeys; n > 0 {
+		fmt.Printf("Truncated %d lists to their %s %d elements\n", n, re.maxElementsFrom, re.maxElements)
+	}
+}
@@ -0,0 +1,129 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/cameronnewman/redis-dumper/recordpb"
+)
+
+// listRecords exports everything and returns the value, elements_truncated
+// and full_length columns of each record, separated by spaces
+func listRecords(t *testing.T, exp *RedisExporter) map[string]string {
+	t.Helper()
+
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	rows := readCSVRows(t, exp.fileManager.config.OutputDir)
+	if headers := strings.Join(rows[0][2:5], ","); headers != "value,elements_truncated,full_length" {
+		t.Fatalf("Expected truncation columns after value, got headers %v", rows[0])
+	}
+	values := make(map[string]string)
+	for _, row := range rows[1:] {
+		values[row[0]] = strings.Join(row[2:5], " ")
+	}
+	return values
+}
+
+func TestMaxElementsPerKey(t *testing.T) {
+	tests := []struct {
+		from     string
+		expected map[string]string
+	}{
+		{TruncateHead, map[string]string{
+			"queue:index:0": "a false ",
+			"queue:index:1": "bb false ",
+			"queue":         "size=3 true 5",
+			"short:index:0": "x false ",
+			"short":         "size=1 false ",
+		}},
+		{TruncateTail, map[string]string{
+			"queue:index:3": "dddd false ",
+			"queue:index:4": "eeeee false ",
+			"queue":         "size=9 true 5",
+			"short:index:0": "x false ",
+			"short":         "size=1 false ",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from, func(t *testing.T) {
+			// A one-element chunk checks the window spans several LRANGE calls
+			exp, mr := newTestExporter(t, RedisExporterOptions{MaxElementsPerKey: 2, MaxElementsFrom: tt.from, ListChunkSize: 1})
+			mr.RPush("queue", "a", "bb", "ccc", "dddd", "eeeee")
+			mr.RPush("short", "x")
+
+			values := listRecords(t, exp)
+			if len(values) != len(tt.expected) {
+				t.Errorf("Expected %d records, got %v", len(tt.expected), values)
+			}
+			for key, want := range tt.expected {
+				if values[key] != want {
+					t.Errorf("Expected %s = %q, got %q", key, want, values[key])
+				}
+			}
+			if n := exp.fileManager.metadata.TruncatedKeys; n != 1 {
+				t.Errorf("Expected 1 truncated key, got %d", n)
+			}
+		})
+	}
+}
+
+func TestMaxElementsPerKeyRDB(t *testing.T) {
+	rdbFile := filepath.Join(t.TempDir(), "dump.rdb")
+	if err := os.WriteFile(rdbFile, testRDB(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	exp, _ := newTestExporter(t, RedisExporterOptions{RDBFile: rdbFile, MaxElementsPerKey: 1, MaxElementsFrom: TruncateTail})
+	values := listRecords(t, exp)
+	if values["queue:index:2"] != "plain false " || values["queue"] != "size=5 true 3" {
+		t.Errorf("Expected only the last queue element, got queue=%q queue:index:2=%q", values["queue"], values["queue:index:2"])
+	}
+	if _, ok := values["queue:index:0"]; ok {
+		t.Error("Expected queue:index:0 to be truncated")
+	}
+}
+
+func TestMaxElementsPerKeyProto(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{OutputFormat: "proto", MaxElementsPerKey: 1})
+	mr.RPush("queue", "a", "b", "c")
+	mr.RPush("short", "x")
+
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	records := make(map[string]*recordpb.RedisRecord)
+	for _, file := range findDataFiles(t, exp.fileManager.config.OutputDir, ".pb") {
+		for _, record := range readProtoRecords(t, file) {
+			records[string(record.Key)] = record
+		}
+	}
+	if queue := records["queue"]; queue == nil || !queue.ElementsTruncated || queue.FullLength == nil || *queue.FullLength != 3 {
+		t.Errorf("Expected queue to be flagged with its full length, got %v", queue)
+	}
+	for _, key := range []string{"short", "queue:index:0"} {
+		if record := records[key]; record == nil || record.ElementsTruncated || record.FullLength != nil {
+			t.Errorf("Expected %s not to be flagged, got %v", key, record)
+		}
+	}
+}
+
+func TestMaxElementsValidation(t *testing.T) {
+	mr := miniredis.RunT(t)
+	for _, opts := range []RedisExporterOptions{
+		{MaxElementsPerKey: -1},
+		{MaxElementsPerKey: 10, MaxElementsFrom: "middle"},
+	} {
+		opts.RedisURL = "redis://" + mr.Addr()
+		opts.OutputDir = t.TempDir()
+		if _, err := NewRedisExporter(opts); err == nil {
+			t.Errorf("Expected MAX_ELEMENTS_PER_KEY=%d MAX_ELEMENTS_FROM=%q to be rejected", opts.MaxElementsPerKey, opts.MaxElementsFrom)
+		}
+	}
+}
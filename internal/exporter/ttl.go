@@ -0,0 +1,134 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Sentinels Redis uses in TTL replies and the exporter writes to ttl_seconds
+const (
+	ttlNoExpiry int64 = -1
+	ttlMissing  int64 = -2
+)
+
+// TTLState says what a TTL value means
+type TTLState int
+
+const (
+	// TTLNoExpiry is a key or field without an expiry, written as -1
+	TTLNoExpiry TTLState = iota
+	// TTLMissing is a key or field that was gone when its TTL was read, written as -2
+	TTLMissing
+	// TTLSeconds is a remaining lifetime in whole seconds, written as is. Zero
+	// means it expires in under a second, not that it never does.
+	TTLSeconds
+)
+
+// TTL is the interpreted value of a ttl_seconds column or a TTL reply
+type TTL struct {
+	state   TTLState
+	seconds int64
+}
+
+// NoExpiry returns the TTL of a key or field without an expiry
+func NoExpiry() TTL {
+	return TTL{state: TTLNoExpiry}
+}
+
+// MissingTTL returns the TTL of a key or field that no longer exists
+func MissingTTL() TTL {
+	return TTL{state: TTLMissing}
+}
+
+// ExpiresIn returns the TTL of a key or field with seconds left to live.
+// A negative lifetime has already run out and counts as missing.
+func ExpiresIn(seconds int64) TTL {
+	if seconds < 0 {
+		return MissingTTL()
+	}
+	return TTL{state: TTLSeconds, seconds: seconds}
+}
+
+// ParseTTL interprets a ttl_seconds value or an integer TTL/HTTL reply. Only
+// -1 and -2 are sentinels; any other negative value is an error rather than
+// being mistaken for "no expiry".
+func ParseTTL(value int64) (TTL, error) {
+	switch {
+	case value == ttlNoExpiry:
+		return NoExpiry(), nil
+	case value == ttlMissing:
+		return MissingTTL(), nil
+	case value >= 0:
+		return ExpiresIn(value), nil
+	default:
+		return TTL{}, fmt.Errorf("invalid TTL %d: expected -1, -2 or seconds >= 0", value)
+	}
+}
+
+// ttlFromDuration interprets a go-redis TTL reply, which keeps -1 and -2 as
+// raw nanosecond durations and scales everything else to seconds
+func ttlFromDuration(d time.Duration) (TTL, error) {
+	switch {
+	case d == time.Duration(ttlNoExpiry):
+		return NoExpiry(), nil
+	case d == time.Duration(ttlMissing):
+		return MissingTTL(), nil
+	case d >= 0:
+		return ExpiresIn(int64(d / time.Second)), nil
+	default:
+		return TTL{}, fmt.Errorf("invalid TTL %v", d)
+	}
+}
+
+// State reports whether the TTL is no expiry, missing or a number of seconds
+func (t TTL) State() TTLState {
+	return t.state
+}
+
+// Remaining returns the seconds left and true, or false without an expiry
+// or for a missing key
+func (t TTL) Remaining() (int64, bool) {
+	return t.seconds, t.state == TTLSeconds
+}
+
+// Value returns the TTL as written to ttl_seconds
+func (t TTL) Value() int64 {
+	switch t.state {
+	case TTLSeconds:
+		return t.seconds
+	case TTLMissing:
+		return ttlMissing
+	default:
+		return ttlNoExpiry
+	}
+}
+
+// RestoreMillis returns the ttl argument for RESTORE, where 0 means no
+// expiry. A key due to expire within the second gets 1ms rather than 0 so it
+// is not restored as persistent, and a missing key cannot be restored.
+func (t TTL) RestoreMillis() (int64, error) {
+	switch t.state {
+	case TTLSeconds:
+		if t.seconds == 0 {
+			return 1, nil
+		}
+		return t.seconds * 1000, nil
+	case TTLMissing:
+		return 0, errors.New("cannot restore a key that was missing when exported")
+	default:
+		return 0, nil
+	}
+}
+
+// String formats the TTL for messages
+func (t TTL) String() string {
+	switch t.state {
+	case TTLSeconds:
+		return fmt.Sprintf("%ds", t.seconds)
+	case TTLMissing:
+		return "missing"
+	default:
+		return "no expiry"
+	}
+}
@@ -0,0 +1,149 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTTL(t *testing.T) {
+	tests := []struct {
+		value     int64
+		state     TTLState
+		remaining int64
+		restore   int64
+		wantErr   bool
+	}{
+		{-1, TTLNoExpiry, 0, 0, false},
+		{-2, TTLMissing, 0, 0, false},
+		{0, TTLSeconds, 0, 1, false},
+		{1, TTLSeconds, 1, 1000, false},
+		{3600, TTLSeconds, 3600, 3600000, false},
+		{-3, 0, 0, 0, true},
+		{-3600, 0, 0, 0, true},
+	}
+	for _, tt := range tests {
+		ttl, err := ParseTTL(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseTTL(%d): expected an error, got %v", tt.value, ttl)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseTTL(%d): %v", tt.value, err)
+			continue
+		}
+		if ttl.State() != tt.state {
+			t.Errorf("ParseTTL(%d): expected state %d, got %d", tt.value, tt.state, ttl.State())
+		}
+		if ttl.Value() != tt.value {
+			t.Errorf("ParseTTL(%d): expected it to round-trip, got %d", tt.value, ttl.Value())
+		}
+		remaining, ok := ttl.Remaining()
+		if ok != (tt.state == TTLSeconds) || remaining != tt.remaining {
+			t.Errorf("ParseTTL(%d): expected remaining %d, got %d (%v)", tt.value, tt.remaining, remaining, ok)
+		}
+
+		restore, err := ttl.RestoreMillis()
+		if tt.state == TTLMissing {
+			if err == nil {
+				t.Errorf("ParseTTL(%d): expected RestoreMillis to fail", tt.value)
+			}
+		} else if err != nil || restore != tt.restore {
+			t.Errorf("ParseTTL(%d): expected RESTORE ttl %d, got %d (%v)", tt.value, tt.restore, restore, err)
+		}
+	}
+}
+
+func TestTTLConstructors(t *testing.T) {
+	tests := []struct {
+		name  string
+		ttl   TTL
+		value int64
+		str   string
+	}{
+		{"zero value", TTL{}, -1, "no expiry"},
+		{"no expiry", NoExpiry(), -1, "no expiry"},
+		{"missing", MissingTTL(), -2, "missing"},
+		{"expiring", ExpiresIn(90), 90, "90s"},
+		{"under a second", ExpiresIn(0), 0, "0s"},
+		{"already expired", ExpiresIn(-5), -2, "missing"},
+	}
+	for _, tt := range tests {
+		if got := tt.ttl.Value(); got != tt.value {
+			t.Errorf("%s: expected value %d, got %d", tt.name, tt.value, got)
+		}
+		if got := tt.ttl.String(); got != tt.str {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.str, got)
+		}
+	}
+}
+
+func TestTTLFromDuration(t *testing.T) {
+	// go-redis v8 keeps the sentinels unscaled and multiplies the rest by a second
+	tests := []struct {
+		reply   time.Duration
+		value   int64
+		wantErr bool
+	}{
+		{-1, -1, false},
+		{-2, -2, false},
+		{0, 0, false},
+		{90 * time.Second, 90, false},
+		{-3, 0, true},
+		{-5 * time.Second, 0, true},
+	}
+	for _, tt := range tests {
+		ttl, err := ttlFromDuration(tt.reply)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ttlFromDuration(%v): expected an error, got %v", tt.reply, ttl)
+			}
+			continue
+		}
+		if err != nil || ttl.Value() != tt.value {
+			t.Errorf("ttlFromDuration(%v): expected %d, got %v (%v)", tt.reply, tt.value, ttl, err)
+		}
+	}
+}
+
+func TestRDBTTL(t *testing.T) {
+	snapshot := int64(1_700_000_000_000)
+	tests := []struct {
+		name     string
+		expireAt int64
+		value    int64
+	}{
+		{"no expiry", 0, -1},
+		{"expired", snapshot - 1000, -2},
+		{"expiring at the snapshot", snapshot, -2},
+		{"under half a second", snapshot + 400, 0},
+		{"rounded up", snapshot + 1500, 2},
+		{"a minute", snapshot + 60_000, 60},
+	}
+	for _, tt := range tests {
+		if got := rdbTTL(tt.expireAt, snapshot).Value(); got != tt.value {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.value, got)
+		}
+	}
+}
+
+func TestExportSubSecondTTL(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{})
+	mr.Set("soon", "v")
+	mr.SetTTL("soon", 500*time.Millisecond)
+	mr.Set("forever", "v")
+
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	ttls := make(map[string]string)
+	for _, row := range readCSVRows(t, exp.fileManager.config.OutputDir) {
+		ttls[row[0]] = row[3]
+	}
+	// A key expiring within the second must not be written as "no expiry"
+	if ttls["soon"] != "0" || ttls["forever"] != "-1" {
+		t.Errorf("Expected TTLs 0 and -1, got %v", ttls)
+	}
+}
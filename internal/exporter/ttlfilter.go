@@ -0,0 +1,103 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// TTLFilterPersistent keeps only keys without an expiry
+	TTLFilterPersistent = "persistent"
+	// TTLFilterExpiring keeps only keys with an expiry
+	TTLFilterExpiring = "expiring"
+)
+
+// ttlWindow selects keys by their remaining time to live
+type ttlWindow struct {
+	filter string
+	min    time.Duration
+	max    time.Duration
+}
+
+// newTTLWindow validates the TTL filters, returning nil when none is set
+func newTTLWindow(filter string, min, max time.Duration) (*ttlWindow, error) {
+	switch filter {
+	case "", TTLFilterPersistent, TTLFilterExpiring:
+	default:
+		return nil, fmt.Errorf("unsupported TTL_FILTER: %s", filter)
+	}
+	if min < 0 || max < 0 {
+		return nil, fmt.Errorf("MIN_TTL and MAX_TTL cannot be negative")
+	}
+	if max > 0 && min > max {
+		return nil, fmt.Errorf("MIN_TTL %s is above MAX_TTL %s", min, max)
+	}
+	if filter == TTLFilterPersistent && (min > 0 || max > 0) {
+		return nil, fmt.Errorf("TTL_FILTER=persistent cannot be combined with MIN_TTL or MAX_TTL")
+	}
+	if filter == "" && min == 0 && max == 0 {
+		return nil, nil
+	}
+	return &ttlWindow{filter: filter, min: min, max: max}, nil
+}
+
+// keep reports whether a key with TTL ttl is in the window. MIN_TTL and
+// MAX_TTL only admit expiring keys.
+func (w *ttlWindow) keep(ttl TTL) bool {
+	switch ttl.State() {
+	case TTLMissing:
+		// Deleted since SCAN
+		return false
+	case TTLNoExpiry:
+		return w.filter != TTLFilterExpiring && w.min == 0 && w.max == 0
+	}
+
+	seconds, _ := ttl.Remaining()
+	remaining := time.Duration(seconds) * time.Second
+	switch {
+	case w.filter == TTLFilterPersistent:
+		return false
+	case w.min > 0 && remaining < w.min:
+		return false
+	case w.max > 0 && remaining > w.max:
+		return false
+	default:
+		return true
+	}
+}
+
+// filterByTTL drops keys outside the TTL window from a scanned batch in
+// place, pipelining PTTL on the scanned node, and returns the remaining keys
+// and the number dropped
+func (re *RedisExporter) filterByTTL(ctx context.Context, node redis.Cmdable, keys []string) ([]string, int, error) {
+	if re.ttlWindow == nil || len(keys) == 0 {
+		return keys, 0, nil
+	}
+
+	pipe := node.Pipeline()
+	cmds := make([]*redis.DurationCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.PTTL(ctx, key)
+	}
+
+	batchCtx, cancel := re.batchContext(ctx)
+	defer cancel()
+	if _, err := pipe.Exec(batchCtx); err != nil {
+		return nil, 0, fmt.Errorf("failed to read TTLs for the TTL filters: %w", err)
+	}
+
+	kept := keys[:0]
+	for i, key := range keys {
+		ttl, err := ttlFromDuration(cmds[i].Val())
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read TTL of key %s for the TTL filters: %w", key, err)
+		}
+		if re.ttlWindow.keep(ttl) {
+			kept = append(kept, key)
+		}
+	}
+	return kept, len(keys) - len(kept), nil
+}
@@ -0,0 +1,85 @@
+package exporter
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTTLWindowKeep(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   string
+		min, max time.Duration
+		ttl      TTL
+		expected bool
+	}{
+		{"persistent key, persistent only", TTLFilterPersistent, 0, 0, NoExpiry(), true},
+		{"expiring key, persistent only", TTLFilterPersistent, 0, 0, ExpiresIn(3600), false},
+		{"persistent key, expiring only", TTLFilterExpiring, 0, 0, NoExpiry(), false},
+		{"expiring key, expiring only", TTLFilterExpiring, 0, 0, ExpiresIn(3600), true},
+		{"persistent key, max", "", 0, 24 * time.Hour, NoExpiry(), false},
+		{"inside max", "", 0, 24 * time.Hour, ExpiresIn(3600), true},
+		{"beyond max", "", 0, 24 * time.Hour, ExpiresIn(48 * 3600), false},
+		{"below min", "", time.Hour, 0, ExpiresIn(60), false},
+		{"expiring within the second", TTLFilterExpiring, 0, 0, ExpiresIn(0), true},
+		{"missing key", TTLFilterExpiring, 0, 0, MissingTTL(), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := newTTLWindow(tt.filter, tt.min, tt.max)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := w.keep(tt.ttl); got != tt.expected {
+				t.Errorf("keep(%s) = %v, expected %v", tt.ttl, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewTTLWindowValidation(t *testing.T) {
+	if w, err := newTTLWindow("", 0, 0); w != nil || err != nil {
+		t.Errorf("Expected no window without filters, got %v, %v", w, err)
+	}
+	for _, tt := range []struct {
+		filter   string
+		min, max time.Duration
+	}{
+		{"soon", 0, 0},
+		{"", -time.Hour, 0},
+		{"", 2 * time.Hour, time.Hour},
+		{TTLFilterPersistent, 0, time.Hour},
+	} {
+		if _, err := newTTLWindow(tt.filter, tt.min, tt.max); err == nil {
+			t.Errorf("Expected TTL_FILTER=%q MIN_TTL=%s MAX_TTL=%s to be rejected", tt.filter, tt.min, tt.max)
+		}
+	}
+}
+
+func TestMaxTTLExport(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{MaxTTL: 24 * time.Hour})
+	mr.Set("persistent", "value")
+	mr.Set("soon", "value")
+	mr.SetTTL("soon", time.Hour)
+	mr.Set("later", "value")
+	mr.SetTTL("later", 72*time.Hour)
+
+	if err := exp.ExportKeysOnly(); err != nil {
+		t.Fatalf("ExportKeysOnly failed: %v", err)
+	}
+
+	var keys []string
+	for _, row := range readCSVRows(t, exp.fileManager.config.OutputDir)[1:] {
+		keys = append(keys, row[0])
+	}
+	sort.Strings(keys)
+	if got := strings.Join(keys, ","); got != "soon" {
+		t.Errorf("Expected only soon, got %s", got)
+	}
+	if exp.fileManager.metadata.TTLFilteredKeys != 2 {
+		t.Errorf("Expected 2 TTL-filtered keys, got %d", exp.fileManager.metadata.TTLFilteredKeys)
+	}
+}
@@ -0,0 +1,83 @@
+package exporter
+
+import "container/list"
+
+// typeCache remembers the TYPE of recently seen keys, evicting the least
+// recently used beyond its size, so a key typed once is not typed again. A
+// nil cache is disabled.
+type typeCache struct {
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type typeCacheEntry struct {
+	key     string
+	keyType string
+}
+
+// newTypeCache returns a cache of up to size keys, or nil when size is 0
+func newTypeCache(size int) *typeCache {
+	if size <= 0 {
+		return nil
+	}
+	return &typeCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+func (c *typeCache) get(key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+
+	element, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*typeCacheEntry).keyType, true
+}
+
+func (c *typeCache) put(key, keyType string) {
+	if c == nil {
+		return
+	}
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*typeCacheEntry).keyType = keyType
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&typeCacheEntry{key: key, keyType: keyType})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*typeCacheEntry).key)
+	}
+}
+
+// remove forgets key, e.g. after it was deleted or its cached type was stale
+func (c *typeCache) remove(key string) {
+	if c == nil {
+		return
+	}
+
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+// clear forgets every key, e.g. when keys start coming from another server
+func (c *typeCache) clear() {
+	if c == nil {
+		return
+	}
+
+	c.order.Init()
+	clear(c.entries)
+}
@@ -0,0 +1,101 @@
+package exporter
+
+import "testing"
+
+func TestTypeCache(t *testing.T) {
+	cache := newTypeCache(2)
+	cache.put("a", "string")
+	cache.put("b", "hash")
+
+	// Reading a makes b the least recently used
+	if keyType, ok := cache.get("a"); !ok || keyType != "string" {
+		t.Errorf("Expected a to be cached as string, got %q", keyType)
+	}
+	cache.put("c", "list")
+
+	if _, ok := cache.get("b"); ok {
+		t.Error("Expected b to be evicted")
+	}
+	for key, expected := range map[string]string{"a": "string", "c": "list"} {
+		if keyType, ok := cache.get(key); !ok || keyType != expected {
+			t.Errorf("Expected %s to be cached as %s, got %q", key, expected, keyType)
+		}
+	}
+
+	cache.remove("a")
+	if _, ok := cache.get("a"); ok {
+		t.Error("Expected a to be removed")
+	}
+
+	// A disabled cache never holds anything
+	disabled := newTypeCache(0)
+	disabled.put("a", "string")
+	if _, ok := disabled.get("a"); ok {
+		t.Error("Expected a disabled cache to miss")
+	}
+}
+
+func TestTypeCacheSkipsTypeRoundTrip(t *testing.T) {
+	commands := func(cacheSize int) int {
+		exp, mr := newTestExporter(t, RedisExporterOptions{TypeCacheSize: cacheSize})
+		defer func() {
+			_ = exp.client.Close()
+		}()
+		for _, key := range []string{"k1", "k2", "k3"} {
+			if err := mr.Set(key, "value"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		keys := []string{"k1", "k2", "k3"}
+		exp.exportKeyMetadataBatch(keys)
+		before := mr.CommandCount()
+		for _, key := range keys {
+			if err := exp.exportKey(key, 0); err != nil {
+				t.Fatalf("Failed to export %s: %v", key, err)
+			}
+		}
+		return mr.CommandCount() - before
+	}
+
+	uncached, cached := commands(0), commands(100)
+	if uncached-cached != 3 {
+		t.Errorf("Expected the cache to save 3 TYPE calls, got %d uncached and %d cached commands", uncached, cached)
+	}
+}
+
+func TestTypeCacheStaleType(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{TypeCacheSize: 100})
+
+	if _, err := mr.Push("key", "a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := exp.exportKey("key", 0); err != nil {
+		t.Fatalf("Failed to export list: %v", err)
+	}
+
+	// Recreate the key as a string behind the cache's back
+	mr.Del("key")
+	if err := mr.Set("key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := exp.exportKey("key", 0); err != nil {
+		t.Fatalf("Failed to export recreated key: %v", err)
+	}
+	if keyType, _ := exp.typeCache.get("key"); keyType != "string" {
+		t.Errorf("Expected the cache to be refreshed to string, got %q", keyType)
+	}
+
+	if err := exp.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, row := range readCSVRows(t, exp.fileManager.config.OutputDir) {
+		if row[0] == "key" && row[1] != "type" {
+			types = append(types, row[1])
+		}
+	}
+	if len(types) != 2 || types[0] != "list" || types[1] != "string" {
+		t.Errorf("Expected list then string key records, got %v", types)
+	}
+}
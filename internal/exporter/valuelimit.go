@@ -0,0 +1,21 @@
+package exporter
+
+import "unicode/utf8"
+
+// truncateValue cuts value to at most limit bytes, backing off to the start
+// of a UTF-8 sequence so text is not left with a broken character
+func truncateValue(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+	cut := limit
+	for cut > 0 && cut > limit-utf8.UTFMax && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	if cut == limit-utf8.UTFMax || !utf8.RuneStart(value[cut]) {
+		// Not UTF-8 text; cut at the limit
+		cut = limit
+	}
+	// A limit inside the first character leaves nothing
+	return value[:cut]
+}
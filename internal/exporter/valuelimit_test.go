@@ -0,0 +1,98 @@
+package exporter
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTruncateValue(t *testing.T) {
+	tests := []struct {
+		name, value string
+		limit       int
+		expected    string
+	}{
+		{"short", "abc", 5, "abc"},
+		{"ascii", "abcdefgh", 5, "abcde"},
+		// é is two bytes; cutting inside it backs off before it
+		{"utf8", "abcdé", 5, "abcd"},
+		// A limit inside the first character leaves nothing rather than half of it
+		{"first rune", "é", 1, ""},
+		{"first emoji", "😀abc", 3, ""},
+		{"binary", "\xff\xfe\xfd\xfc\xfb\xfa", 5, "\xff\xfe\xfd\xfc\xfb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateValue(tt.value, tt.limit); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMaxValueBytes(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{MaxValueBytes: 8})
+	mr.HSet("user:1", "bio", strings.Repeat("x", 100), "name", "alice")
+	mr.Set("blob", strings.Repeat("y", 1000))
+
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	rows := readCSVRows(t, exp.fileManager.config.OutputDir)
+	if len(rows) == 0 || rows[0][2] != "value" || rows[0][3] != "value_truncated" {
+		t.Fatalf("Expected value_truncated after value, got headers %v", rows)
+	}
+	got := make(map[string][]string)
+	for _, row := range rows[1:] {
+		got[row[0]] = row[2:4]
+	}
+	expected := map[string][2]string{
+		"user:1:field:bio":  {"xxxxxxxx", "true"},
+		"user:1:field:name": {"alice", "false"},
+		// Strings are measured with STRLEN
+		"blob": {"size=1000", "false"},
+	}
+	for key, want := range expected {
+		if row := got[key]; row == nil || row[0] != want[0] || row[1] != want[1] {
+			t.Errorf("Expected %s = %v, got %v", key, want, row)
+		}
+	}
+}
+
+func TestMaxValueBytesParquet(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{OutputFormat: "parquet", MaxValueBytes: 4})
+	mr.RPush("queue", "short", "ok")
+
+	if err := exp.ExportByPattern("*"); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	source := fmt.Sprintf("read_parquet('%s')", filepath.Join(exp.fileManager.config.OutputDir, "**", "*.parquet"))
+	var value, columnType string
+	query := fmt.Sprintf("SELECT value, typeof(value_truncated) FROM %s WHERE value_truncated", source)
+	if err := db.QueryRow(query).Scan(&value, &columnType); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if value != "shor" || columnType != "BOOLEAN" {
+		t.Errorf("Expected shor with a BOOLEAN value_truncated, got %s (%s)", value, columnType)
+	}
+}
+
+func TestMaxValueBytesRejectsDump(t *testing.T) {
+	exp, _ := newTestExporter(t, RedisExporterOptions{MaxValueBytes: 8})
+	err := exp.ExportDump("*")
+	if err == nil || !strings.Contains(err.Error(), "MAX_VALUE_BYTES") {
+		t.Errorf("Expected dump with MAX_VALUE_BYTES to fail, got %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package exporter
+
+import "fmt"
+
+// Verbosity controls how much progress output the exporter prints. Errors,
+// warnings and end-of-run summaries are printed at every level.
+type Verbosity int
+
+const (
+	// VerbosityQuiet prints only errors and the final summary
+	VerbosityQuiet Verbosity = iota - 1
+	// VerbosityNormal adds startup and periodic progress messages
+	VerbosityNormal
+	// VerbosityVerbose adds per-batch and per-key detail
+	VerbosityVerbose
+)
+
+// infof prints progress output unless running quietly
+func (v Verbosity) infof(format string, args ...interface{}) {
+	if v >= VerbosityNormal {
+		fmt.Printf(format, args...)
+	}
+}
+
+// debugf prints per-batch and per-key detail in verbose mode
+func (v Verbosity) debugf(format string, args ...interface{}) {
+	if v >= VerbosityVerbose {
+		fmt.Printf(format, args...)
+	}
+}
@@ -0,0 +1,221 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultWatchRotateInterval bounds how long changes wait in an open
+	// partition before they are visible to readers
+	defaultWatchRotateInterval = time.Minute
+	// watchNotifyFlags are the notify-keyspace-events classes watch needs:
+	// keyevent channels for generic, string, list, set, hash, zset, stream,
+	// expired and evicted events
+	watchNotifyFlags = "Eg$lshzxte"
+	// deletedRecordType marks a tombstone for a key that no longer exists
+	deletedRecordType = "deleted"
+)
+
+// deleteEvents are keyevent names after which the key is gone
+var deleteEvents = map[string]bool{
+	"del":     true,
+	"expired": true,
+	"evicted": true,
+}
+
+// parseKeyEvent extracts the event name from a __keyevent@<db>__:<event> channel
+func parseKeyEvent(channel string) (string, bool) {
+	if !strings.HasPrefix(channel, "__keyevent@") {
+		return "", false
+	}
+	i := strings.Index(channel, "__:")
+	if i < 0 {
+		return "", false
+	}
+	return channel[i+len("__:"):], true
+}
+
+// mergeNotifyFlags adds the flags watch needs to the server's current
+// notify-keyspace-events setting, keeping any the server already has
+func mergeNotifyFlags(current string) string {
+	merged := current
+	for _, flag := range watchNotifyFlags {
+		// A enables every class except key-miss and new-key events
+		if strings.ContainsRune(merged, flag) || (flag != 'E' && strings.ContainsRune(merged, 'A')) {
+			continue
+		}
+		merged += string(flag)
+	}
+	return merged
+}
+
+// enableKeyspaceNotifications turns on keyevent notifications. Managed Redis
+// often disables CONFIG, so failures are reported and the watch continues
+// in case notifications are already configured.
+func (re *RedisExporter) enableKeyspaceNotifications() {
+	current, err := re.client.ConfigGet(re.ctx, "notify-keyspace-events").Result()
+	existing, ok := current["notify-keyspace-events"]
+	if err != nil || !ok {
+		fmt.Printf("Warning: failed to read notify-keyspace-events, ensure it includes %s: %v\n", watchNotifyFlags, err)
+		return
+	}
+
+	merged := mergeNotifyFlags(existing)
+	if merged == existing {
+		return
+	}
+
+	if err := re.client.ConfigSet(re.ctx, "notify-keyspace-events", merged).Err(); err != nil {
+		fmt.Printf("Warning: failed to set notify-keyspace-events to %s: %v\n", merged, err)
+		return
+	}
+	re.verbosity.infof("Set notify-keyspace-events to %s\n", merged)
+}
+
+// Watch subscribes to keyevent notifications and exports every changed key
+// matching pattern until ctx is cancelled, then flushes open partitions.
+// Notifications are fire-and-forget, so changes made while the watcher is
+// disconnected or falling behind are not replayed.
+func (re *RedisExporter) Watch(ctx context.Context, pattern string) (err error) {
+	defer re.closeExport(&err)
+
+	if err := re.requireLiveServer("watch"); err != nil {
+		return err
+	}
+	if err := re.requireSingleSource("watch"); err != nil {
+		return err
+	}
+	// Keyspace notifications are only published by the node holding the key
+	if err := re.requireSingleNode("watch"); err != nil {
+		return err
+	}
+
+	if err := re.requireValueColumn(); err != nil {
+		return err
+	}
+
+	re.fileManager.SetMetadata(pattern, 0)
+	re.enableKeyspaceNotifications()
+
+	channel := fmt.Sprintf("__keyevent@%d__:*", re.db)
+	pubsub := re.client.PSubscribe(ctx, channel)
+	defer func() {
+		_ = pubsub.Close()
+	}()
+
+	// Wait for the subscription so no change between here and the loop is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", channel, err)
+	}
+	re.verbosity.infof("Watching %s for keys matching: %s\n", channel, pattern)
+
+	rotateInterval := re.watchRotateInterval
+	if rotateInterval <= 0 {
+		rotateInterval = defaultWatchRotateInterval
+	}
+	ticker := time.NewTicker(rotateInterval)
+	defer ticker.Stop()
+
+	messages := pubsub.Channel()
+	count := 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			re.fileManager.SetMetadata(pattern, int64(count))
+			fmt.Printf("Watch stopped! Total changes exported: %d\n", count)
+			return nil
+
+		case <-re.fileManager.FlushTicks():
+			re.flushDue()
+
+		case <-ticker.C:
+			// Close the open partitions so readers see recent changes
+			if re.fileManager.config.Stream {
+				re.flushAll()
+			} else if err := re.fileManager.RotateWriter(); err != nil {
+				err = fmt.Errorf("failed to rotate partitions: %w", err)
+				re.fileManager.SetMetadata(pattern, int64(count))
+				re.fileManager.MarkPartial(err)
+				return err
+			}
+
+		case msg, ok := <-messages:
+			if !ok {
+				err := errors.New("keyspace notification subscription closed")
+				re.fileManager.SetMetadata(pattern, int64(count))
+				re.fileManager.MarkPartial(err)
+				return err
+			}
+			exported, err := re.handleKeyEvent(msg.Channel, msg.Payload, pattern)
+			if errors.Is(err, ErrOutputFull) {
+				re.fileManager.SetMetadata(pattern, int64(count))
+				re.fileManager.MarkPartial(err)
+				return err
+			}
+			if err != nil {
+				log.Printf("Error exporting key %s: %v", msg.Payload, err)
+				continue
+			}
+			if !exported {
+				continue
+			}
+
+			count++
+			if count%100 == 0 {
+				re.verbosity.infof("Exported %d changes...\n", count)
+				re.flushAll()
+			}
+		}
+	}
+}
+
+// handleKeyEvent exports the key named by a keyevent notification. Deleted
+// keys are written as tombstones. It reports whether a record was written.
+func (re *RedisExporter) handleKeyEvent(channel, key, pattern string) (bool, error) {
+	event, ok := parseKeyEvent(channel)
+	if !ok || !matchGlob(pattern, key) || re.isIgnored(key) || !re.keyRegexSelected(key) {
+		return false, nil
+	}
+
+	re.verbosity.debugf("Key event %s on %s\n", event, key)
+
+	if deleteEvents[event] {
+		return true, re.writeTombstone(key, event)
+	}
+
+	// The key may be gone again by the time it is read
+	exists, err := re.client.Exists(re.ctx, key).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check key %s: %w", key, err)
+	}
+	if exists == 0 {
+		return true, re.writeTombstone(key, event)
+	}
+
+	idle := re.keyIdleSeconds([]string{key})
+	if err := re.exportKey(key, idle[key]); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// writeTombstone records that key no longer exists
+func (re *RedisExporter) writeTombstone(key, event string) error {
+	re.typeCache.remove(key)
+	return re.fileManager.WriteRecord(&RedisRecord{
+		Key:        key,
+		Type:       deletedRecordType,
+		Value:      fmt.Sprintf("event=%s", event),
+		TTLSeconds: ttlMissing,
+		ExportedAt: re.exportedAt(),
+		Slot:       keySlot(key),
+
+		IdleSeconds: -1,
+	})
+}
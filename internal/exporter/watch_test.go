@@ -0,0 +1,111 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseKeyEvent(t *testing.T) {
+	tests := []struct {
+		channel string
+		event   string
+		ok      bool
+	}{
+		{channel: "__keyevent@0__:set", event: "set", ok: true},
+		{channel: "__keyevent@12__:expired", event: "expired", ok: true},
+		{channel: "__keyspace@0__:user:1", ok: false},
+		{channel: "orders", ok: false},
+	}
+
+	for _, tt := range tests {
+		event, ok := parseKeyEvent(tt.channel)
+		if event != tt.event || ok != tt.ok {
+			t.Errorf("parseKeyEvent(%q) = %q, %v, expected %q, %v", tt.channel, event, ok, tt.event, tt.ok)
+		}
+	}
+}
+
+func TestMergeNotifyFlags(t *testing.T) {
+	tests := []struct {
+		current  string
+		expected string
+	}{
+		{current: "", expected: "Eg$lshzxte"},
+		{current: "Kx", expected: "KxEg$lshzte"},
+		{current: "KEA", expected: "KEA"},
+		{current: "AE", expected: "AE"},
+	}
+
+	for _, tt := range tests {
+		if got := mergeNotifyFlags(tt.current); got != tt.expected {
+			t.Errorf("mergeNotifyFlags(%q) = %q, expected %q", tt.current, got, tt.expected)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{})
+
+	if err := mr.Set("user:1", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	mr.HSet("user:2", "name", "bob")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- exp.Watch(ctx, "user:*")
+	}()
+
+	// miniredis has no keyspace notifications, so publish them by hand
+	deadline := time.Now().Add(5 * time.Second)
+	for mr.PubSubNumPat() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Watch did not subscribe")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mr.Publish("__keyevent@0__:set", "user:1")
+	mr.Publish("__keyevent@0__:hset", "user:2")
+	mr.Publish("__keyevent@0__:set", "config:a")
+	mr.Publish("__keyevent@0__:del", "user:3")
+
+	// Give the watcher time to drain the notifications before stopping it
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Expected watch to stop cleanly, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Watch did not stop after cancel")
+	}
+
+	types := make(map[string]string)
+	for _, row := range readCSVRows(t, exp.fileManager.config.OutputDir)[1:] {
+		types[row[0]] = row[1]
+	}
+
+	expected := map[string]string{
+		"user:1":            "string",
+		"user:2":            "hash",
+		"user:2:field:name": "hash_field",
+		"user:3":            deletedRecordType,
+	}
+	for key, recordType := range expected {
+		if types[key] != recordType {
+			t.Errorf("Expected %s record for %s, got %q", recordType, key, types[key])
+		}
+	}
+	if _, ok := types["config:a"]; ok {
+		t.Error("Expected config:a to be filtered by the pattern")
+	}
+	if exp.fileManager.metadata.TotalKeys != 3 {
+		t.Errorf("Expected 3 exported changes, got %d", exp.fileManager.metadata.TotalKeys)
+	}
+}
@@ -0,0 +1,63 @@
+package exporter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// defaultZSetRankMaxSize caps the sorted sets exported with true ranks
+const defaultZSetRankMaxSize = 1000000
+
+// exportZSetRanked exports a sorted set in score order with ZRANGE WITHSCORES,
+// chunked by the list chunk size, so each member's rank is its real position.
+// Ranks are only consistent if the set is not modified while it is read.
+func (re *RedisExporter) exportZSetRanked(key string, card int64, slot int, idleSeconds int64, timestamp string) (int64, error) {
+	totalSize := int64(0)
+
+	for start := int64(0); start < card; {
+		members, err := re.client.ZRangeWithScores(re.ctx, key, start, start+re.listChunkSize-1).Result()
+		if err != nil {
+			return 0, err
+		}
+		if len(members) == 0 {
+			// Set shrank while exporting
+			break
+		}
+
+		for i, z := range members {
+			member := fmt.Sprint(z.Member)
+			record := &RedisRecord{
+				Key:        fmt.Sprintf("%s:member:%s", key, member),
+				Type:       "zset_member",
+				Value:      fmt.Sprintf("score=%s,rank=%d", formatScore(z.Score), start+int64(i)),
+				TTLSeconds: ttlNoExpiry,
+				ExportedAt: timestamp,
+				Slot:       slot,
+
+				IdleSeconds: idleSeconds,
+				ParentKey:   key,
+			}
+			if err := re.fileManager.WriteRecord(record); err != nil {
+				return 0, err
+			}
+			totalSize += int64(len(member))
+		}
+
+		start += int64(len(members))
+	}
+
+	return totalSize, nil
+}
+
+// formatScore renders a score the way Redis replies with it, e.g. in ZSCAN
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	default:
+		return strconv.FormatFloat(score, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,141 @@
+package exporter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// scoreBound is one end of a ZRANGEBYSCORE range
+type scoreBound struct {
+	// arg is the bound as passed to ZRANGEBYSCORE, e.g. "(5" or "-inf"
+	arg       string
+	score     float64
+	exclusive bool
+}
+
+// parseScoreBound parses a bound in ZRANGEBYSCORE syntax: a score, "-inf" or
+// "+inf", with a leading "(" to exclude it. Empty means fallback.
+func parseScoreBound(name, value, fallback string) (scoreBound, error) {
+	if value == "" {
+		value = fallback
+	}
+	bound := scoreBound{arg: value}
+
+	number := value
+	if strings.HasPrefix(number, "(") {
+		bound.exclusive = true
+		number = number[1:]
+	}
+	score, err := strconv.ParseFloat(number, 64)
+	if err != nil || math.IsNaN(score) {
+		return scoreBound{}, fmt.Errorf("invalid %s %q: expected a score, -inf or +inf, optionally prefixed with (", name, value)
+	}
+	bound.score = score
+	return bound, nil
+}
+
+// scoreRange limits sorted set exports to members scored within it. A nil
+// range keeps every member.
+type scoreRange struct {
+	min, max scoreBound
+}
+
+// newScoreRange validates ZSET_MIN_SCORE and ZSET_MAX_SCORE, returning nil
+// when neither is set
+func newScoreRange(min, max string) (*scoreRange, error) {
+	if min == "" && max == "" {
+		return nil, nil
+	}
+
+	minBound, err := parseScoreBound("ZSET_MIN_SCORE", min, "-inf")
+	if err != nil {
+		return nil, err
+	}
+	maxBound, err := parseScoreBound("ZSET_MAX_SCORE", max, "+inf")
+	if err != nil {
+		return nil, err
+	}
+	if minBound.score > maxBound.score {
+		return nil, fmt.Errorf("ZSET_MIN_SCORE %s is above ZSET_MAX_SCORE %s", minBound.arg, maxBound.arg)
+	}
+	return &scoreRange{min: minBound, max: maxBound}, nil
+}
+
+// contains reports whether score is within the range
+func (r *scoreRange) contains(score float64) bool {
+	if r == nil {
+		return true
+	}
+	if score < r.min.score || (r.min.exclusive && score == r.min.score) {
+		return false
+	}
+	if score > r.max.score || (r.max.exclusive && score == r.max.score) {
+		return false
+	}
+	return true
+}
+
+// exportZSetRange exports the members of a sorted set within the score range
+// in score order with chunked ZRANGEBYSCORE ... LIMIT. With ZSET_WITH_RANK the
+// rank of each chunk's first member is read with ZRANK, so ranks are
+// positions in the whole set rather than in the window.
+func (re *RedisExporter) exportZSetRange(key string, slot int, idleSeconds int64, timestamp string) (int64, error) {
+	totalSize := int64(0)
+
+	for offset := int64(0); ; {
+		members, err := re.client.ZRangeByScoreWithScores(re.ctx, key, &redis.ZRangeBy{
+			Min:    re.zsetScores.min.arg,
+			Max:    re.zsetScores.max.arg,
+			Offset: offset,
+			Count:  re.listChunkSize,
+		}).Result()
+		if err != nil {
+			return 0, err
+		}
+		if len(members) == 0 {
+			break
+		}
+
+		rank := int64(-1)
+		if re.zsetWithRank {
+			rank, err = re.client.ZRank(re.ctx, key, fmt.Sprint(members[0].Member)).Result()
+			if err != nil && err != redis.Nil {
+				return 0, err
+			}
+		}
+
+		for i, z := range members {
+			member := fmt.Sprint(z.Member)
+			value := fmt.Sprintf("score=%s", formatScore(z.Score))
+			if rank >= 0 {
+				value = fmt.Sprintf("%s,rank=%d", value, rank+int64(i))
+			}
+			record := &RedisRecord{
+				Key:        fmt.Sprintf("%s:member:%s", key, member),
+				Type:       "zset_member",
+				Value:      value,
+				TTLSeconds: ttlNoExpiry,
+				ExportedAt: timestamp,
+				Slot:       slot,
+
+				IdleSeconds: idleSeconds,
+				ParentKey:   key,
+			}
+			if err := re.fileManager.WriteRecord(record); err != nil {
+				return 0, err
+			}
+			totalSize += int64(len(member))
+		}
+
+		if int64(len(members)) < re.listChunkSize {
+			break
+		}
+		offset += int64(len(members))
+	}
+
+	return totalSize, nil
+}
@@ -0,0 +1,75 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestScoreRangeContains(t *testing.T) {
+	r, err := newScoreRange("(1", "3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for score, want := range map[float64]bool{0.5: false, 1: false, 2: true, 3: true, 3.5: false} {
+		if got := r.contains(score); got != want {
+			t.Errorf("contains(%g): expected %v, got %v", score, want, got)
+		}
+	}
+
+	if r, err := newScoreRange("", ""); r != nil || err != nil {
+		t.Errorf("Expected no range when unset, got %v, %v", r, err)
+	}
+}
+
+func TestScoreRangeValidation(t *testing.T) {
+	mr := miniredis.RunT(t)
+	for _, bounds := range [][2]string{{"abc", ""}, {"", "((5"}, {"5", "1"}, {"nan", ""}} {
+		_, err := NewRedisExporter(RedisExporterOptions{
+			RedisURL:     "redis://" + mr.Addr(),
+			OutputDir:    t.TempDir(),
+			ZSetMinScore: bounds[0],
+			ZSetMaxScore: bounds[1],
+		})
+		if err == nil || !strings.Contains(err.Error(), "ZSET_M") {
+			t.Errorf("Expected ZSET_MIN_SCORE=%q ZSET_MAX_SCORE=%q to be rejected, got %v", bounds[0], bounds[1], err)
+		}
+	}
+}
+
+func TestExportZSetRange(t *testing.T) {
+	exp, mr := newTestExporter(t, RedisExporterOptions{
+		ZSetWithRank: true,
+		ZSetMinScore: "(1",
+		ZSetMaxScore: "4",
+		// Chunks of two check the LIMIT offsets and per-chunk ranks
+		ListChunkSize: 2,
+	})
+	for score, member := range map[float64]string{1: "a", 2: "b", 3: "c", 4: "d", 5: "e"} {
+		mr.ZAdd("events", score, member)
+	}
+
+	if err := exp.ExportByPattern("events"); err != nil {
+		t.Fatalf("ExportByPattern failed: %v", err)
+	}
+
+	values := make(map[string]string)
+	for _, row := range readCSVRows(t, exp.fileManager.config.OutputDir)[1:] {
+		values[row[0]] = row[2]
+	}
+	expected := map[string]string{
+		"events:member:b": "score=2,rank=1",
+		"events:member:c": "score=3,rank=2",
+		"events:member:d": "score=4,rank=3",
+		"events":          "size=3",
+	}
+	if len(values) != len(expected) {
+		t.Errorf("Expected %d records, got %v", len(expected), values)
+	}
+	for key, want := range expected {
+		if values[key] != want {
+			t.Errorf("Expected %s = %q, got %q", key, want, values[key])
+		}
+	}
+}
@@ -0,0 +1,6 @@
+// Package recordpb holds the RedisRecord message written by OUTPUT_FORMAT=proto.
+// Each .pb file is a stream of varint length-delimited messages, readable with
+// protodelim.UnmarshalFrom.
+package recordpb
+
+//go:generate protoc -I.. --go_out=.. --go_opt=paths=source_relative ../recordpb/record.proto
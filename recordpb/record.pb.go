@@ -0,0 +1,350 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: recordpb/record.proto
+
+package recordpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// RedisRecord is one exported record with the same columns as the CSV and
+// Parquet output. Fields of columns that are not enabled are left unset.
+type RedisRecord struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Redis key, or <key>:<kind>:<name> for element records. Keys and values
+	// are binary-safe in Redis, so they are bytes rather than UTF-8 strings.
+	Key []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Record type, e.g. hash for a key and hash_field for its fields
+	Type string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	// Serialized value, empty when the value column is dropped
+	Value []byte `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	// TTL in seconds, -1 without one and -2 if the key was gone when read
+	TtlSeconds int64 `protobuf:"zigzag64,4,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	// When the record was read, RFC 3339
+	ExportedAt string `protobuf:"bytes,5,opt,name=exported_at,json=exportedAt,proto3" json:"exported_at,omitempty"`
+	// Partition the record was written to, 0 with INCLUDE_PARTITION_ID=false
+	PartitionId int32 `protobuf:"varint,6,opt,name=partition_id,json=partitionId,proto3" json:"partition_id,omitempty"`
+	// Cluster hash slot of the top-level key, only with INCLUDE_SLOT=true
+	Slot int32 `protobuf:"varint,7,opt,name=slot,proto3" json:"slot,omitempty"`
+	// Partition directory values, only with MATERIALIZE_PARTITION_COLS=true
+	Year  string `protobuf:"bytes,8,opt,name=year,proto3" json:"year,omitempty"`
+	Month string `protobuf:"bytes,9,opt,name=month,proto3" json:"month,omitempty"`
+	Day   string `protobuf:"bytes,10,opt,name=day,proto3" json:"day,omitempty"`
+	Hour  string `protobuf:"bytes,11,opt,name=hour,proto3" json:"hour,omitempty"`
+	// Top-level key of element records, only with INCLUDE_PARENT_KEY=true
+	ParentKey []byte `protobuf:"bytes,12,opt,name=parent_key,json=parentKey,proto3" json:"parent_key,omitempty"`
+	// Base64 DUMP payload of key records, only with DUAL_MODE=true
+	RawDump []byte `protobuf:"bytes,13,opt,name=raw_dump,json=rawDump,proto3" json:"raw_dump,omitempty"`
+	// Keys-only size estimate, only with DROP_VALUE_COLUMN=true
+	SizeEstimate int64 `protobuf:"varint,14,opt,name=size_estimate,json=sizeEstimate,proto3" json:"size_estimate,omitempty"`
+	// Element count of collections in keys-only exports, unset for other
+	// types, only with INCLUDE_CARDINALITY=true
+	Cardinality *int64 `protobuf:"varint,15,opt,name=cardinality,proto3,oneof" json:"cardinality,omitempty"`
+	// Server the record was read from, host:port/db, only when REDIS_URL lists
+	// several servers
+	Source string `protobuf:"bytes,16,opt,name=source,proto3" json:"source,omitempty"`
+	// OBJECT ENCODING of the key in keys-only exports, e.g. listpack, only
+	// with INCLUDE_ENCODING=true
+	Encoding string `protobuf:"bytes,17,opt,name=encoding,proto3" json:"encoding,omitempty"`
+	// OBJECT IDLETIME of the key in keys-only exports, unset when unknown, only
+	// with INCLUDE_IDLE_TIME=true
+	IdleSeconds *int64 `protobuf:"varint,18,opt,name=idle_seconds,json=idleSeconds,proto3,oneof" json:"idle_seconds,omitempty"`
+	// Absolute expiry, RFC 3339, empty without one, only with
+	// INCLUDE_EXPIRES_AT=true
+	ExpiresAt string `protobuf:"bytes,19,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Whether value was cut at MAX_VALUE_BYTES, only with MAX_VALUE_BYTES set
+	ValueTruncated bool `protobuf:"varint,20,opt,name=value_truncated,json=valueTruncated,proto3" json:"value_truncated,omitempty"`
+	// Whether a list was cut at MAX_ELEMENTS_PER_KEY, set on its key record,
+	// only with MAX_ELEMENTS_PER_KEY set
+	ElementsTruncated bool `protobuf:"varint,21,opt,name=elements_truncated,json=elementsTruncated,proto3" json:"elements_truncated,omitempty"`
+	// Length of a list before MAX_ELEMENTS_PER_KEY cut it, unset on other
+	// records, only with MAX_ELEMENTS_PER_KEY set
+	FullLength    *int64 `protobuf:"varint,22,opt,name=full_length,json=fullLength,proto3,oneof" json:"full_length,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RedisRecord) Reset() {
+	*x = RedisRecord{}
+	mi := &file_recordpb_record_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RedisRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RedisRecord) ProtoMessage() {}
+
+func (x *RedisRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_recordpb_record_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RedisRecord.ProtoReflect.Descriptor instead.
+func (*RedisRecord) Descriptor() ([]byte, []int) {
+	return file_recordpb_record_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RedisRecord) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *RedisRecord) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *RedisRecord) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *RedisRecord) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *RedisRecord) GetExportedAt() string {
+	if x != nil {
+		return x.ExportedAt
+	}
+	return ""
+}
+
+func (x *RedisRecord) GetPartitionId() int32 {
+	if x != nil {
+		return x.PartitionId
+	}
+	return 0
+}
+
+func (x *RedisRecord) GetSlot() int32 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *RedisRecord) GetYear() string {
+	if x != nil {
+		return x.Year
+	}
+	return ""
+}
+
+func (x *RedisRecord) GetMonth() string {
+	if x != nil {
+		return x.Month
+	}
+	return ""
+}
+
+func (x *RedisRecord) GetDay() string {
+	if x != nil {
+		return x.Day
+	}
+	return ""
+}
+
+func (x *RedisRecord) GetHour() string {
+	if x != nil {
+		return x.Hour
+	}
+	return ""
+}
+
+func (x *RedisRecord) GetParentKey() []byte {
+	if x != nil {
+		return x.ParentKey
+	}
+	return nil
+}
+
+func (x *RedisRecord) GetRawDump() []byte {
+	if x != nil {
+		return x.RawDump
+	}
+	return nil
+}
+
+func (x *RedisRecord) GetSizeEstimate() int64 {
+	if x != nil {
+		return x.SizeEstimate
+	}
+	return 0
+}
+
+func (x *RedisRecord) GetCardinality() int64 {
+	if x != nil && x.Cardinality != nil {
+		return *x.Cardinality
+	}
+	return 0
+}
+
+func (x *RedisRecord) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *RedisRecord) GetEncoding() string {
+	if x != nil {
+		return x.Encoding
+	}
+	return ""
+}
+
+func (x *RedisRecord) GetIdleSeconds() int64 {
+	if x != nil && x.IdleSeconds != nil {
+		return *x.IdleSeconds
+	}
+	return 0
+}
+
+func (x *RedisRecord) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+func (x *RedisRecord) GetValueTruncated() bool {
+	if x != nil {
+		return x.ValueTruncated
+	}
+	return false
+}
+
+func (x *RedisRecord) GetElementsTruncated() bool {
+	if x != nil {
+		return x.ElementsTruncated
+	}
+	return false
+}
+
+func (x *RedisRecord) GetFullLength() int64 {
+	if x != nil && x.FullLength != nil {
+		return *x.FullLength
+	}
+	return 0
+}
+
+var File_recordpb_record_proto protoreflect.FileDescriptor
+
+const file_recordpb_record_proto_rawDesc = "" +
+	"\n" +
+	"\x15recordpb/record.proto\x12\x0eredisdumper.v1\"\xc2\x05\n" +
+	"\vRedisRecord\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\fR\x03key\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\fR\x05value\x12\x1f\n" +
+	"\vttl_seconds\x18\x04 \x01(\x12R\n" +
+	"ttlSeconds\x12\x1f\n" +
+	"\vexported_at\x18\x05 \x01(\tR\n" +
+	"exportedAt\x12!\n" +
+	"\fpartition_id\x18\x06 \x01(\x05R\vpartitionId\x12\x12\n" +
+	"\x04slot\x18\a \x01(\x05R\x04slot\x12\x12\n" +
+	"\x04year\x18\b \x01(\tR\x04year\x12\x14\n" +
+	"\x05month\x18\t \x01(\tR\x05month\x12\x10\n" +
+	"\x03day\x18\n" +
+	" \x01(\tR\x03day\x12\x12\n" +
+	"\x04hour\x18\v \x01(\tR\x04hour\x12\x1d\n" +
+	"\n" +
+	"parent_key\x18\f \x01(\fR\tparentKey\x12\x19\n" +
+	"\braw_dump\x18\r \x01(\fR\arawDump\x12#\n" +
+	"\rsize_estimate\x18\x0e \x01(\x03R\fsizeEstimate\x12%\n" +
+	"\vcardinality\x18\x0f \x01(\x03H\x00R\vcardinality\x88\x01\x01\x12\x16\n" +
+	"\x06source\x18\x10 \x01(\tR\x06source\x12\x1a\n" +
+	"\bencoding\x18\x11 \x01(\tR\bencoding\x12&\n" +
+	"\fidle_seconds\x18\x12 \x01(\x03H\x01R\vidleSeconds\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x13 \x01(\tR\texpiresAt\x12'\n" +
+	"\x0fvalue_truncated\x18\x14 \x01(\bR\x0evalueTruncated\x12-\n" +
+	"\x12elements_truncated\x18\x15 \x01(\bR\x11elementsTruncated\x12$\n" +
+	"\vfull_length\x18\x16 \x01(\x03H\x02R\n" +
+	"fullLength\x88\x01\x01B\x0e\n" +
+	"\f_cardinalityB\x0f\n" +
+	"\r_idle_secondsB\x0e\n" +
+	"\f_full_lengthB0Z.github.com/cameronnewman/redis-dumper/recordpbb\x06proto3"
+
+var (
+	file_recordpb_record_proto_rawDescOnce sync.Once
+	file_recordpb_record_proto_rawDescData []byte
+)
+
+func file_recordpb_record_proto_rawDescGZIP() []byte {
+	file_recordpb_record_proto_rawDescOnce.Do(func() {
+		file_recordpb_record_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_recordpb_record_proto_rawDesc), len(file_recordpb_record_proto_rawDesc)))
+	})
+	return file_recordpb_record_proto_rawDescData
+}
+
+var file_recordpb_record_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_recordpb_record_proto_goTypes = []any{
+	(*RedisRecord)(nil), // 0: redisdumper.v1.RedisRecord
+}
+var file_recordpb_record_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_recordpb_record_proto_init() }
+func file_recordpb_record_proto_init() {
+	if File_recordpb_record_proto != nil {
+		return
+	}
+	file_recordpb_record_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_recordpb_record_proto_rawDesc), len(file_recordpb_record_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_recordpb_record_proto_goTypes,
+		DependencyIndexes: file_recordpb_record_proto_depIdxs,
+		MessageInfos:      file_recordpb_record_proto_msgTypes,
+	}.Build()
+	File_recordpb_record_proto = out.File
+	file_recordpb_record_proto_goTypes = nil
+	file_recordpb_record_proto_depIdxs = nil
+}